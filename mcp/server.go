@@ -1,8 +1,10 @@
 package main
 
 import (
+	"bigfoot/golf/common/handlers/health"
 	"bigfoot/golf/common/models/auth"
 	"bigfoot/golf/common/models/db"
+	"bigfoot/golf/common/models/storage"
 	"bigfoot/golf/common/models/teetimes"
 	"bigfoot/golf/common/models/weather"
 	"context"
@@ -23,11 +25,16 @@ import (
 type MCPServer struct {
 	server *server.MCPServer
 	router *mux.Router
+	// course returns the course the tools book against; it is called per
+	// request since the stores are wired when the database first connects,
+	// after the tools are registered
+	course func() *teetimes.Course
 }
 
-func NewMCPServer() *MCPServer {
+func NewMCPServer(course func() *teetimes.Course) *MCPServer {
 	return &MCPServer{
 		router: mux.NewRouter(),
+		course: course,
 	}
 }
 
@@ -40,8 +47,8 @@ func (m *MCPServer) Initialize(ctx context.Context) error {
 	time.Local = loc
 	db.TimeLocation = loc
 
-	// Initialize DB
-	db.InitDB(ctx)
+	// Initialize DB, retrying in the background until it answers
+	storage.Start(ctx)
 
 	// Create MCP server with standard configuration
 	mcpServer := server.NewMCPServer("Golf Booking MCP Server", "1.0.0")
@@ -154,7 +161,7 @@ func (m *MCPServer) handleReservations(ctx context.Context, request mcp.CallTool
 
 	switch action {
 	case "get":
-		reservations, err := teetimes.GetUserReservationsForMCP(userID)
+		reservations, err := m.course().GetUserReservationsForMCP(ctx, userID)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get reservations: %v", err)
 		}
@@ -178,7 +185,7 @@ func (m *MCPServer) handleReservations(ctx context.Context, request mcp.CallTool
 			return nil, fmt.Errorf("invalid tee time format: %v", err)
 		}
 
-		reservation, err := teetimes.CreateReservation(userID, teeTimeDate, int(players))
+		reservation, err := m.course().CreateReservation(ctx, userID, teeTimeDate, int(players))
 		if err != nil {
 			return nil, fmt.Errorf("failed to book reservation: %v", err)
 		}
@@ -196,7 +203,7 @@ func (m *MCPServer) handleReservations(ctx context.Context, request mcp.CallTool
 	case "cancel":
 		reservationID, _ := argMap["reservation_id"].(string)
 
-		err := teetimes.CancelReservation(userID, reservationID)
+		err := m.course().CancelReservation(ctx, userID, reservationID)
 		if err != nil {
 			return nil, fmt.Errorf("failed to cancel reservation: %v", err)
 		}
@@ -230,7 +237,7 @@ func (m *MCPServer) handleFindTeeTimes(ctx context.Context, request mcp.CallTool
 		return nil, fmt.Errorf("invalid date format: %v", err)
 	}
 
-	availableTimes, err := teetimes.GetAvailableTeeTimes(date, timeRange, int(players))
+	availableTimes, err := m.course().GetAvailableTeeTimes(ctx, date, timeRange, int(players))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get available tee times: %v", err)
 	}
@@ -382,6 +389,12 @@ func (m *MCPServer) handleHTTP(w http.ResponseWriter, r *http.Request) {
 			},
 		}
 
+		// The reservation tools need the database; tell the client when to retry
+		if status := storage.DBStatus(); !status.Ready && toolName != "get_conditions" {
+			health.Unavailable(w, status)
+			return
+		}
+
 		switch toolName {
 		case "manage_reservations":
 			result, err = m.handleReservations(r.Context(), toolRequest)
@@ -415,7 +428,7 @@ func StartMCPServer() {
 	ctx := context.Background()
 
 	// Create MCP server
-	mcpServer := NewMCPServer()
+	mcpServer := NewMCPServer(storage.Course)
 
 	// Initialize
 	if err := mcpServer.Initialize(ctx); err != nil {
@@ -424,6 +437,7 @@ func StartMCPServer() {
 
 	// Setup HTTP routes
 	mcpServer.router.HandleFunc("/mcp", mcpServer.handleHTTP).Methods("POST")
+	health.Register(mcpServer.router)
 	mcpServer.router.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("OK"))
@@ -0,0 +1,59 @@
+// Command migrate applies the schema migrations the database named by DB_URI
+// has not seen. With -dry-run it lists them and changes nothing.
+package main
+
+import (
+	"bigfoot/golf/common/models/db"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "list pending migrations without applying them")
+	flag.Parse()
+	os.Exit(run(context.Background(), *dryRun))
+}
+
+// run migrates the database and returns the exit code once its connection is closed
+func run(ctx context.Context, dryRun bool) int {
+	var (
+		pending []db.Migration
+		err     error
+	)
+	if path, ok := db.SQLitePath(os.Getenv("DB_URI")); ok {
+		conn, cerr := db.ConnectSQLite(ctx, path)
+		if cerr != nil {
+			log.Printf("open sqlite: %v", cerr)
+			return 1
+		}
+		defer conn.Close()
+		pending, err = db.MigrateSQLite(ctx, conn, dryRun)
+	} else {
+		db.InitDB(ctx)
+		if db.Instance.Err != nil {
+			log.Printf("connect to neo4j: %v", db.Instance.Err)
+			return 1
+		}
+		defer db.Instance.Driver.Close(ctx)
+		pending, err = db.Instance.Migrate(ctx, dryRun)
+	}
+
+	verb := "Applied"
+	if dryRun {
+		verb = "Pending"
+	}
+	if len(pending) == 0 && err == nil {
+		fmt.Println("Schema is up to date")
+	}
+	for _, m := range pending {
+		fmt.Println(verb, m.Name)
+	}
+	if err != nil {
+		log.Print(err)
+		return 1
+	}
+	return 0
+}
@@ -3,6 +3,7 @@ package controllers
 import (
 	"bigfoot/golf/common/models/anthropic"
 	"bigfoot/golf/common/models/teetimes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -18,10 +19,13 @@ type AgentController struct {
 	UserEmail    string
 	MCPClient    *anthropic.MCPClient
 	UseMCP       bool
+	// Course is the course the agent looks up and books tee times on
+	Course *teetimes.Course
 }
 
-func NewAgentController() AgentController {
+func NewAgentController(course *teetimes.Course) AgentController {
 	var _agent AgentController
+	_agent.Course = course
 
 	// Get API key from environment variable
 	apiKey := os.Getenv("ANTHROPIC_API_KEY")
@@ -39,7 +43,7 @@ func NewAgentController() AgentController {
 // SetUserID sets the user ID for the agent controller
 func (a *AgentController) SetUserID(userID string) {
 	a.UserID = userID
-	a.ToolExecutor = anthropic.NewToolExecutor(userID)
+	a.ToolExecutor = anthropic.NewToolExecutor(userID, a.Course)
 }
 
 // SetUserInfo sets the user information and optionally enables MCP
@@ -47,7 +51,7 @@ func (a *AgentController) SetUserInfo(userID, userEmail string, enableMCP bool)
 	a.UserID = userID
 	a.UserEmail = userEmail
 	a.UseMCP = enableMCP
-	a.ToolExecutor = anthropic.NewToolExecutor(userID)
+	a.ToolExecutor = anthropic.NewToolExecutor(userID, a.Course)
 
 	// Initialize MCP client if enabled
 	if enableMCP {
@@ -64,7 +68,7 @@ func (a *AgentController) SetUserInfo(userID, userEmail string, enableMCP bool)
 	return nil
 }
 
-func (a *AgentController) HandleChat(message anthropic.ChatRequest) (*anthropic.ChatResponse, error) {
+func (a *AgentController) HandleChat(ctx context.Context, message anthropic.ChatRequest) (*anthropic.ChatResponse, error) {
 	a.Request = message
 
 	// Set defaults
@@ -78,11 +82,11 @@ func (a *AgentController) HandleChat(message anthropic.ChatRequest) (*anthropic.
 
 	// Initialize tool executor if not set
 	if a.ToolExecutor == nil {
-		a.ToolExecutor = anthropic.NewToolExecutor(a.UserID)
+		a.ToolExecutor = anthropic.NewToolExecutor(a.UserID, a.Course)
 	}
 
 	// Get user's current reservations for context
-	userReservations, err := teetimes.GetUserReservations(a.UserID, false)
+	userReservations, err := a.Course.UserReservations(ctx, a.UserID, false)
 	if err != nil {
 		fmt.Printf("Warning: Could not get user reservations for context: %v\n", err)
 		userReservations = []teetimes.Reservation{}
@@ -106,7 +110,7 @@ func (a *AgentController) HandleChat(message anthropic.ChatRequest) (*anthropic.
 	}
 
 	// Add tee time context and user reservations to system message
-	teeTimeContext := anthropic.GetTeeTimeContext()
+	teeTimeContext := anthropic.GetTeeTimeContext(ctx, a.Course)
 	systemMessage := fmt.Sprintf(anthropic.SystemMessage, a.UserID, reservationsText) +
 		"\n\nCurrent Available Tee Times:\n" + teeTimeContext
 
@@ -173,7 +177,7 @@ func (a *AgentController) HandleChat(message anthropic.ChatRequest) (*anthropic.
 					toolInput = inputData
 				}
 
-				toolResult, err := a.ToolExecutor.ExecuteTool(content.Name, toolInput)
+				toolResult, err := a.ToolExecutor.ExecuteTool(ctx, content.Name, toolInput)
 				if err != nil {
 					toolResult = fmt.Sprintf("Error executing tool %s: %v", content.Name, err)
 				}
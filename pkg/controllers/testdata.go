@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"bigfoot/golf/common/models/db"
+	"context"
 	"fmt"
 
 	"log"
@@ -9,9 +10,9 @@ import (
 )
 
 // Example usage demonstrating relationship mapping
-func SetupDevEnvironment() {
+func SetupDevEnvironment(ctx context.Context) {
 
-	if isTestDataSetup() {
+	if isTestDataSetup(ctx) {
 		return
 	}
 	/*	//check if there are tee times today
@@ -27,12 +28,12 @@ func SetupDevEnvironment() {
 	fmt.Println("test")
 }
 
-func isTestDataSetup() bool {
+func isTestDataSetup(ctx context.Context) bool {
 	layout := "2006-01-02T15:04:05Z"
 	// Format the time object into a string
 	formattedTime := time.Date(time.Now().Year(), time.Now().Month(), time.Now().Day(), 0, 0, 0, 0, time.Local).Format(layout)
 	// Example 4: Query with relationships
-	dayWithRelationships, err := db.Instance.QueryNodes("ReservedDay", map[string]interface{}{
+	dayWithRelationships, err := db.Instance.QueryNodes(ctx, "ReservedDay", map[string]interface{}{
 		"day": formattedTime,
 	}) // depth of 2
 
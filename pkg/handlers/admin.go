@@ -3,14 +3,32 @@ package handlers
 import (
 	"bigfoot/golf/common/handlers/admin"
 	"bigfoot/golf/common/models/auth"
+	"bigfoot/golf/common/models/teetimes"
+	"context"
 
 	"github.com/gorilla/mux"
 )
 
-func RegisterAdminRoutes(router *mux.Router) {
+func RegisterAdminRoutes(ctx context.Context, router *mux.Router, course *teetimes.Course) {
 
-	authServer := auth.InitAuth()
-	// Authenticated routes
-	router.HandleFunc("/seasons", authServer.AuthenticateMiddleware(true, admin.GetSeasons)).Methods("POST")
+	authServer := auth.InitAuth(ctx)
+	staff := admin.NewHandlers(course)
+	// Each route requires the permission of the staff who use it, and edits
+	// to pricing and roles a step up as well
+	router.HandleFunc("/seasons", authServer.Require(auth.PermManagePricing, staff.GetSeasons)).Methods("POST")
+	router.HandleFunc("/pricegrid", authServer.Require(auth.PermManagePricing, staff.GetPriceGrid)).Methods("POST")
+	router.HandleFunc("/holidays", authServer.Require(auth.PermManagePricing, staff.GetHolidays)).Methods("POST")
+	router.HandleFunc("/holidays/save", authServer.RequireStepUp(auth.PermManagePricing, staff.SaveHoliday)).Methods("POST")
+	router.HandleFunc("/holidays/{id}", authServer.RequireStepUp(auth.PermManagePricing, staff.DeleteHoliday)).Methods("DELETE")
+	router.HandleFunc("/outings", authServer.Require(auth.PermManageOutings, staff.CreateOuting)).Methods("POST")
+	router.HandleFunc("/outings/day", authServer.Require(auth.PermViewTeeSheet, staff.GetDayOutings)).Methods("POST")
+	router.HandleFunc("/overrides", authServer.Require(auth.PermManageOverrides, staff.CreateDayOverride)).Methods("POST")
+	router.HandleFunc("/overrides/day", authServer.Require(auth.PermViewTeeSheet, staff.GetDayOverrides)).Methods("POST")
+	router.HandleFunc("/overrides/{id}", authServer.Require(auth.PermManageOverrides, staff.DeleteDayOverride)).Methods("DELETE")
+	router.HandleFunc("/seasons/{id}/open", authServer.RequireStepUp(auth.PermManagePricing, staff.SetSeasonOpen)).Methods("POST")
+	router.HandleFunc("/deals", authServer.RequireStepUp(auth.PermManagePricing, staff.SaveDailyDeal)).Methods("POST")
+	router.HandleFunc("/history/{type}/{id}", authServer.Require(auth.PermViewHistory, admin.GetHistory)).Methods("GET")
+	router.HandleFunc("/users/{id}/roles/{role}", authServer.RequireStepUp(auth.PermManageRoles, admin.GrantRole)).Methods("PUT")
+	router.HandleFunc("/users/{id}/roles/{role}", authServer.RequireStepUp(auth.PermManageRoles, admin.RevokeRole)).Methods("DELETE")
 
 }
@@ -1,15 +1,15 @@
 package admin
 
 import (
-	"bigfoot/golf/common/models/teetimes"
+	"bigfoot/golf/common/handlers/httperr"
 	"encoding/json"
 	"net/http"
 	"time"
 )
 
-func GetSeasons(w http.ResponseWriter, r *http.Request) {
+func (h *Handlers) GetSeasons(w http.ResponseWriter, r *http.Request) {
 
-	_seas, err := teetimes.GetSeasons(time.Now())
+	_seas, err := h.course.Seasons(r.Context(), time.Now())
 	if err != nil {
 		http.Error(w, "Error with Server", http.StatusBadRequest)
 		return
@@ -17,7 +17,11 @@ func GetSeasons(w http.ResponseWriter, r *http.Request) {
 
 	if len(_seas) == 0 {
 		//no seasons loaded so Init a new Season
-		_seas = teetimes.InitNewSeason(time.Now().Year())
+		_seas, err = h.course.InitNewSeason(r.Context(), time.Now().Year())
+		if err != nil {
+			httperr.ServerError(w, err, "Error with Season Config")
+			return
+		}
 	}
 
 	w.Header().Set("Content-Type", "application/json")
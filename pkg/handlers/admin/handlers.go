@@ -0,0 +1,13 @@
+package admin
+
+import "bigfoot/golf/common/models/teetimes"
+
+// Handlers serve the season, pricing, holiday, outing and override routes against the course they were built with
+type Handlers struct {
+	course *teetimes.Course
+}
+
+// NewHandlers returns the handlers working against the course
+func NewHandlers(course *teetimes.Course) *Handlers {
+	return &Handlers{course: course}
+}
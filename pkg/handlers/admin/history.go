@@ -0,0 +1,29 @@
+package admin
+
+import (
+	"bigfoot/golf/common/handlers/httperr"
+	"bigfoot/golf/common/models/audit"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// GetHistory lists the changes made to a reservation, season, block setting
+// or user's roles, oldest first, with who made each one
+func GetHistory(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	if !audit.EntityTypes[vars["type"]] {
+		http.Error(w, "Unknown entity type", http.StatusBadRequest)
+		return
+	}
+
+	events, err := audit.History(r.Context(), vars["type"], vars["id"])
+	if err != nil {
+		httperr.ServerError(w, err, "Error with Server")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(events)
+}
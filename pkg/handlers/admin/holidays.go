@@ -0,0 +1,70 @@
+package admin
+
+import (
+	"bigfoot/golf/common/handlers/httperr"
+	"bigfoot/golf/common/models/teetimes"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+type holidayRequest struct {
+	SeasonID string               `json:"seasonId"`
+	Holiday  teetimes.HolidayDate `json:"holiday"`
+}
+
+// GetHolidays lists the season's holiday calendar, federal and course holidays together
+func (h *Handlers) GetHolidays(w http.ResponseWriter, r *http.Request) {
+	var input holidayRequest
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil || input.SeasonID == "" {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	_seas, err := h.course.SeasonByID(r.Context(), input.SeasonID)
+	if err != nil {
+		httperr.ServerError(w, err, "Error with Server")
+		return
+	}
+	if _seas == nil {
+		http.Error(w, "Season not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(_seas.Holidays)
+}
+
+// SaveHoliday adds or edits a course holiday; set skip to drop a federal holiday from the calendar
+func (h *Handlers) SaveHoliday(w http.ResponseWriter, r *http.Request) {
+	var input holidayRequest
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil || input.SeasonID == "" {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	input.Holiday.Federal = false
+	if err := h.course.SaveHoliday(r.Context(), input.SeasonID, &input.Holiday); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(input.Holiday)
+}
+
+// DeleteHoliday removes a course holiday
+func (h *Handlers) DeleteHoliday(w http.ResponseWriter, r *http.Request) {
+	err := h.course.DeleteHoliday(r.Context(), mux.Vars(r)["id"])
+	if errors.Is(err, teetimes.ErrHolidayNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		httperr.ServerError(w, err, "Error with Server")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
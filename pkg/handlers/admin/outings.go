@@ -0,0 +1,55 @@
+package admin
+
+import (
+	"bigfoot/golf/common/handlers/httperr"
+	"bigfoot/golf/common/models/auth"
+	"bigfoot/golf/common/models/teetimes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// CreateOuting reserves a block of tee times or a shotgun start for an organizer
+func (h *Handlers) CreateOuting(w http.ResponseWriter, r *http.Request) {
+	var input teetimes.Outing
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if input.OrganizerID == "" {
+		input.OrganizerID, _ = auth.ActingUser(r.Context())
+	}
+
+	err := h.course.CreateOuting(r.Context(), &input)
+	if errors.Is(err, teetimes.ErrOutingConflict) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(input)
+}
+
+// GetDayOutings lists the outings on a day with their teams
+func (h *Handlers) GetDayOutings(w http.ResponseWriter, r *http.Request) {
+	var input map[string]time.Time
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	_outings, err := h.course.DayOutings(r.Context(), input["day"])
+	if err != nil {
+		httperr.ServerError(w, err, "Error with Server")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(_outings)
+}
@@ -0,0 +1,95 @@
+package admin
+
+import (
+	"bigfoot/golf/common/handlers/httperr"
+	"bigfoot/golf/common/models/auth"
+	"bigfoot/golf/common/models/teetimes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// CreateDayOverride closes the course, blocks a window or delays the day's start.
+// The response lists the golfers whose tee times moved or were cancelled.
+func (h *Handlers) CreateDayOverride(w http.ResponseWriter, r *http.Request) {
+	var input teetimes.DayOverride
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil || input.Day.IsZero() {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	input.CreatedBy, _ = auth.ActingUser(r.Context())
+
+	var result *teetimes.OverrideResult
+	var err error
+	if input.Kind == teetimes.OverrideDelay {
+		result, err = h.course.DelayDay(r.Context(), input.Day, input.DelayMinutes, input.Reason, input.CreatedBy)
+	} else {
+		result, err = h.course.CloseDay(r.Context(), input)
+	}
+	if errors.Is(err, teetimes.ErrCourseClosed) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(result)
+}
+
+// GetDayOverrides lists the closures, blocked windows and delays on a day
+func (h *Handlers) GetDayOverrides(w http.ResponseWriter, r *http.Request) {
+	var input map[string]time.Time
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	_overrides, err := h.course.DayOverrides(r.Context(), input["day"])
+	if err != nil {
+		httperr.ServerError(w, err, "Error with Server")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(_overrides)
+}
+
+// DeleteDayOverride reopens what an override took off the sheet
+func (h *Handlers) DeleteDayOverride(w http.ResponseWriter, r *http.Request) {
+	err := h.course.DeleteDayOverride(r.Context(), mux.Vars(r)["id"])
+	if errors.Is(err, teetimes.ErrOverrideNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		httperr.ServerError(w, err, "Error with Server")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SetSeasonOpen opens or closes a whole season's tee sheet
+func (h *Handlers) SetSeasonOpen(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		IsOpen bool `json:"isOpen"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.course.SetSeasonOpen(r.Context(), mux.Vars(r)["id"], input.IsOpen); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(input)
+}
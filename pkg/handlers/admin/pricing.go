@@ -0,0 +1,45 @@
+package admin
+
+import (
+	"bigfoot/golf/common/handlers/httperr"
+	"bigfoot/golf/common/models/teetimes"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// GetPriceGrid previews the price of every tee time on a day along with the rules applied
+func (h *Handlers) GetPriceGrid(w http.ResponseWriter, r *http.Request) {
+	var input map[string]time.Time
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	_grid, err := h.course.PriceGrid(r.Context(), input["day"], time.Now())
+	if err != nil {
+		httperr.ServerError(w, err, "Error with Server")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(_grid)
+}
+
+// SaveDailyDeal adds a deal price for a window of tee times
+func (h *Handlers) SaveDailyDeal(w http.ResponseWriter, r *http.Request) {
+	var input teetimes.DetailedBlockSettings
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	_seas, err := h.course.AddDailyDeal(r.Context(), input)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(_seas)
+}
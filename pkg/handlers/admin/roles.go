@@ -0,0 +1,45 @@
+package admin
+
+import (
+	"bigfoot/golf/common/handlers/httperr"
+	"bigfoot/golf/common/models/auth"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// GrantRole gives the user in the route the role and returns their roles
+func GrantRole(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	roles, err := auth.GrantRole(r.Context(), vars["id"], auth.Role(vars["role"]))
+	writeRoles(w, roles, err)
+}
+
+// RevokeRole takes the role from the user in the route and returns their roles
+func RevokeRole(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	roles, err := auth.RevokeRole(r.Context(), vars["id"], auth.Role(vars["role"]))
+	writeRoles(w, roles, err)
+}
+
+func writeRoles(w http.ResponseWriter, roles []auth.Role, err error) {
+	switch {
+	case errors.Is(err, auth.ErrUnknownRole), errors.Is(err, auth.ErrGolferRole):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, auth.ErrUserNotFound):
+		http.Error(w, "No User Found", http.StatusNotFound)
+		return
+	case errors.Is(err, auth.ErrOwnSuperAdmin):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		httperr.ServerError(w, err, "Error Saving Roles")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string][]auth.Role{"roles": roles})
+}
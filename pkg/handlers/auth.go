@@ -1,14 +1,17 @@
 package handlers
 
 import (
+	"bigfoot/golf/common/handlers/transactions"
 	"bigfoot/golf/common/models/auth"
+	"context"
 
 	"github.com/gorilla/mux"
 )
 
-func RegisterAuthRouter(router *mux.Router) {
+func RegisterAuthRouter(ctx context.Context, router *mux.Router) {
 
-	authServer := auth.InitAuth()
+	authServer := auth.InitAuth(ctx)
+	auth.SetResetMailer(transactions.MailPasswordReset)
 
 	// Auth routes
 	router.HandleFunc("/register", authServer.HandleRegister).Methods("POST")
@@ -18,5 +21,16 @@ func RegisterAuthRouter(router *mux.Router) {
 	router.HandleFunc("/apple", authServer.HandleAppleLogin).Methods("GET")
 	router.HandleFunc("/apple/callback", authServer.HandleAppleCallback).Methods("POST")
 	router.HandleFunc("/me", authServer.AuthenticateMiddleware(false, authServer.HandleMe)).Methods("GET")
+	router.HandleFunc("/forgot", authServer.HandleForgotPassword).Methods("POST")
+	router.HandleFunc("/reset", authServer.HandleResetPassword).Methods("POST")
 	router.HandleFunc("/refresh", authServer.HandleRefreshToken).Methods("POST")
+	router.HandleFunc("/logout", authServer.HandleLogout).Methods("POST")
+	router.HandleFunc("/sessions", authServer.AuthenticateMiddleware(false, authServer.HandleSessions)).Methods("GET")
+	router.HandleFunc("/sessions", authServer.AuthenticateMiddleware(false, authServer.HandleRevokeAllSessions)).Methods("DELETE")
+	router.HandleFunc("/sessions/{id}", authServer.AuthenticateMiddleware(false, authServer.HandleRevokeSession)).Methods("DELETE")
+	router.HandleFunc("/stepup", authServer.AuthenticateMiddleware(false, authServer.HandleStepUp)).Methods("POST")
+	router.HandleFunc("/totp", authServer.AuthenticateMiddleware(false, authServer.HandleTOTPStatus)).Methods("GET")
+	router.HandleFunc("/totp/enroll", authServer.AuthenticateMiddleware(false, authServer.HandleTOTPEnroll)).Methods("POST")
+	router.HandleFunc("/totp/confirm", authServer.AuthenticateMiddleware(false, authServer.HandleTOTPConfirm)).Methods("POST")
+	router.HandleFunc("/totp", authServer.RequireStepUp(auth.PermBook, authServer.HandleTOTPRemove)).Methods("DELETE")
 }
@@ -3,21 +3,43 @@ package handlers
 import (
 	"bigfoot/golf/common/handlers/transactions"
 	"bigfoot/golf/common/models/auth"
+	"bigfoot/golf/common/models/teetimes"
+	"context"
 
 	"github.com/gorilla/mux"
 )
 
-func RegisterAPIRoutes(router *mux.Router) {
+func RegisterAPIRoutes(ctx context.Context, router *mux.Router, course *teetimes.Course) {
 
-	authServer := auth.InitAuth()
+	authServer := auth.InitAuth(ctx)
+	tx := transactions.NewHandlers(course)
+	teetimes.SetWaitlistNotifier(transactions.NotifyWaitlistOffer)
+	teetimes.SetOverrideNotifier(transactions.NotifyDayOverride)
+	teetimes.SetStandingNotifier(transactions.NotifyStandingConflict)
 	// Authenticated routes
-	router.HandleFunc("/chat", authServer.AuthenticateMiddleware(false, GetChatHandler)).Methods("POST")
+	router.HandleFunc("/chat", authServer.AuthenticateMiddleware(false, GetChatHandler(course))).Methods("POST")
 	router.HandleFunc("/userupdate", authServer.AuthenticateMiddleware(false, transactions.SaveUserHandler)).Methods("POST")
 	router.HandleFunc("/verifyreq", authServer.AuthenticateMiddleware(false, transactions.SendEmailCodeHandler)).Methods("POST")
 	router.HandleFunc("/verifyemailcode", authServer.AuthenticateMiddleware(false, transactions.VerifyCodeHandler)).Methods("POST")
-	router.HandleFunc("/resetapw", authServer.AuthenticateMiddleware(false, transactions.UpdatePW)).Methods("POST")
-	router.HandleFunc("/bookTime", authServer.AuthenticateMiddleware(false, transactions.BookTime)).Methods("POST")
-	router.HandleFunc("/reservations", authServer.AuthenticateMiddleware(false, transactions.GetUserReservations)).Methods("GET", "POST")
-	router.HandleFunc("/reservations/cancel", authServer.AuthenticateMiddleware(false, transactions.CancelReservation)).Methods("POST")
+	router.HandleFunc("/resetapw", authServer.RequireStepUp(auth.PermBook, transactions.UpdatePW)).Methods("POST")
+	router.HandleFunc("/holds", authServer.AuthenticateMiddleware(false, tx.PlaceHold)).Methods("POST")
+	router.HandleFunc("/holds/{id}", authServer.AuthenticateMiddleware(false, tx.ReleaseHold)).Methods("DELETE")
+	router.HandleFunc("/waitlist", authServer.AuthenticateMiddleware(false, tx.GetWaitlist)).Methods("GET")
+	router.HandleFunc("/waitlist", authServer.AuthenticateMiddleware(false, tx.JoinWaitlist)).Methods("POST")
+	router.HandleFunc("/waitlist/{id}", authServer.AuthenticateMiddleware(false, tx.LeaveWaitlist)).Methods("DELETE")
+	router.HandleFunc("/waitlist/{id}/accept", authServer.AuthenticateMiddleware(false, tx.AcceptWaitlistOffer)).Methods("POST")
+	router.HandleFunc("/standing", authServer.AuthenticateMiddleware(false, tx.GetStanding)).Methods("GET")
+	router.HandleFunc("/standing", authServer.AuthenticateMiddleware(false, tx.CreateStanding)).Methods("POST")
+	router.HandleFunc("/standing/{id}", authServer.AuthenticateMiddleware(false, tx.DeleteStanding)).Methods("DELETE")
+	router.HandleFunc("/standing/{id}/skip", authServer.AuthenticateMiddleware(false, tx.SkipStandingDate)).Methods("POST")
+	router.HandleFunc("/outings/{id}", authServer.AuthenticateMiddleware(false, tx.GetOuting)).Methods("GET")
+	router.HandleFunc("/outings/{id}/teams", authServer.AuthenticateMiddleware(false, tx.RegisterOutingTeam)).Methods("POST")
+	router.HandleFunc("/outings/{id}/teams/{teamId}", authServer.AuthenticateMiddleware(false, tx.RemoveOutingTeam)).Methods("DELETE")
+	router.HandleFunc("/outings/{id}/pairings", authServer.AuthenticateMiddleware(false, tx.GetOutingPairings)).Methods("GET")
+	router.HandleFunc("/outings/{id}/roster.csv", authServer.AuthenticateMiddleware(false, tx.ExportOutingRoster)).Methods("GET")
+	router.HandleFunc("/quote", authServer.AuthenticateMiddleware(false, tx.QuoteTeeTime)).Methods("POST")
+	router.HandleFunc("/bookTime", authServer.AuthenticateMiddleware(false, tx.BookTime)).Methods("POST")
+	router.HandleFunc("/reservations", authServer.AuthenticateMiddleware(false, tx.GetUserReservations)).Methods("GET", "POST")
+	router.HandleFunc("/reservations/cancel", authServer.AuthenticateMiddleware(false, tx.CancelReservation)).Methods("POST")
 
 }
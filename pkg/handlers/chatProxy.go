@@ -4,43 +4,46 @@ import (
 	"bigfoot/golf/common/controllers"
 	"bigfoot/golf/common/models"
 	"bigfoot/golf/common/models/anthropic"
+	"bigfoot/golf/common/models/auth"
+	"bigfoot/golf/common/models/teetimes"
 	"encoding/json"
 	"net/http"
 )
 
-// POST /api/chat - Handle chat request with Claude
-func GetChatHandler(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-
-	var message anthropic.ChatRequest
-	if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
-		response := models.Response{
-			Success: false,
-			Error:   "Invalid JSON in chat request",
+// POST /api/chat - Handle chat request with Claude, booking on the course
+func GetChatHandler(course *teetimes.Course) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var message anthropic.ChatRequest
+		if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
+			response := models.Response{
+				Success: false,
+				Error:   "Invalid JSON in chat request",
+			}
+			sendJSONResponse(w, http.StatusBadRequest, response)
+			return
 		}
-		sendJSONResponse(w, http.StatusBadRequest, response)
-		return
-	}
 
-	_claudeClient := controllers.NewAgentController()
+		_claudeClient := controllers.NewAgentController(course)
 
-	// Get user ID from header (set by authentication middleware)
-	userID := r.Header.Get("X-User-ID")
-	if userID != "" {
-		_claudeClient.SetUserID(userID)
-	}
+		// Get user ID from the claims set by the authentication middleware
+		if userID, err := auth.ActingUser(r.Context()); err == nil {
+			_claudeClient.SetUserID(userID)
+		}
 
-	chatResponse, err := _claudeClient.HandleChat(message)
+		chatResponse, err := _claudeClient.HandleChat(r.Context(), message)
 
-	if err != nil {
-		response := models.Response{
-			Success: false,
-			Error:   "Error processing chat request: " + err.Error(),
+		if err != nil {
+			response := models.Response{
+				Success: false,
+				Error:   "Error processing chat request: " + err.Error(),
+			}
+			sendJSONResponse(w, http.StatusInternalServerError, response)
+			return
 		}
-		sendJSONResponse(w, http.StatusInternalServerError, response)
-		return
-	}
 
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(chatResponse)
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(chatResponse)
+	}
 }
@@ -0,0 +1,158 @@
+// Package health serves the liveness and readiness checks and turns requests
+// away with a 503 while the database is down.
+package health
+
+import (
+	"bigfoot/golf/common/models/storage"
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Report is the body of /healthz and /readyz
+type Report struct {
+	Status       string            `json:"status"`
+	Database     storage.Status    `json:"database"`
+	Dependencies map[string]string `json:"dependencies,omitempty"`
+}
+
+var (
+	depMu        sync.RWMutex
+	dependencies = map[string]func(ctx context.Context) error{}
+)
+
+// AddDependency reports the check's result under the name in /healthz. The
+// server stays ready when a dependency fails; only the database gates it.
+func AddDependency(name string, check func(ctx context.Context) error) {
+	depMu.Lock()
+	defer depMu.Unlock()
+	dependencies[name] = check
+}
+
+// dependencyTimeout bounds each dependency check so a slow one cannot hang the probe
+const dependencyTimeout = 2 * time.Second
+
+func report(ctx context.Context) Report {
+	r := Report{Status: "ok", Database: storage.DBStatus()}
+	if !r.Database.Ready {
+		r.Status = "degraded"
+	}
+
+	depMu.RLock()
+	checks := make(map[string]func(ctx context.Context) error, len(dependencies))
+	for name, check := range dependencies {
+		checks[name] = check
+	}
+	depMu.RUnlock()
+	for name, check := range checks {
+		if r.Dependencies == nil {
+			r.Dependencies = make(map[string]string)
+		}
+		checkCtx, cancel := context.WithTimeout(ctx, dependencyTimeout)
+		if err := check(checkCtx); err != nil {
+			r.Dependencies[name] = err.Error()
+			r.Status = "degraded"
+		} else {
+			r.Dependencies[name] = "ok"
+		}
+		cancel()
+	}
+	return r
+}
+
+// Healthz answers 200 while the process serves, reporting the database and
+// dependencies so a degraded server can be told apart from a healthy one
+func Healthz(w http.ResponseWriter, r *http.Request) {
+	writeReport(w, http.StatusOK, report(r.Context()))
+}
+
+// Readyz answers 200 once the database is up and 503 with a Retry-After
+// while it is down
+func Readyz(w http.ResponseWriter, r *http.Request) {
+	rep := report(r.Context())
+	if !rep.Database.Ready {
+		setRetryAfter(w, rep.Database)
+		writeReport(w, http.StatusServiceUnavailable, rep)
+		return
+	}
+	writeReport(w, http.StatusOK, rep)
+}
+
+// Register adds /healthz and /readyz to the router
+func Register(router *mux.Router) {
+	router.HandleFunc("/healthz", Healthz).Methods("GET")
+	router.HandleFunc("/readyz", Readyz).Methods("GET")
+}
+
+// RequireDB answers 503 with a Retry-After while the database is down, so
+// clients back off instead of seeing requests fail
+func RequireDB(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if status := storage.DBStatus(); !status.Ready {
+			Unavailable(w, status)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Unavailable answers 503 with a Retry-After for the database's next reconnect
+func Unavailable(w http.ResponseWriter, status storage.Status) {
+	setRetryAfter(w, status)
+	http.Error(w, "The tee sheet is temporarily unavailable, try again shortly", http.StatusServiceUnavailable)
+}
+
+// Server answers the health checks itself and hands every other request to
+// the app once SetApp is called, with a 503 until then. It lets the server
+// listen while the database is still coming up.
+type Server struct {
+	checks *mux.Router
+	app    atomic.Pointer[http.Handler]
+}
+
+// NewServer returns a Server with no app yet
+func NewServer() *Server {
+	s := &Server{checks: mux.NewRouter()}
+	Register(s.checks)
+	return s
+}
+
+// SetApp starts sending requests other than the health checks to the handler
+func (s *Server) SetApp(app http.Handler) {
+	s.app.Store(&app)
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var match mux.RouteMatch
+	if s.checks.Match(r, &match) {
+		s.checks.ServeHTTP(w, r)
+		return
+	}
+	if app := s.app.Load(); app != nil {
+		(*app).ServeHTTP(w, r)
+		return
+	}
+	Unavailable(w, storage.DBStatus())
+}
+
+// setRetryAfter advises waiting until the next reconnect attempt, at least a second
+func setRetryAfter(w http.ResponseWriter, status storage.Status) {
+	seconds := 1
+	if wait := time.Until(status.NextRetry); wait > time.Second {
+		seconds = int(math.Ceil(wait.Seconds()))
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+}
+
+func writeReport(w http.ResponseWriter, code int, rep Report) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(rep)
+}
@@ -0,0 +1,61 @@
+package health
+
+import (
+	"bigfoot/golf/common/models/storage"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestServerWhileDatabaseDown(t *testing.T) {
+	storage.UseMemory()
+	up := false
+	monitor := storage.NewMonitor(func(context.Context) error {
+		if !up {
+			return errors.New("connection refused")
+		}
+		return nil
+	}, func(context.Context) error { return nil })
+	storage.UseMonitor(monitor)
+	defer storage.UseMonitor(nil)
+
+	server := NewServer()
+	app := RequireDB(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	tests := []struct {
+		name      string
+		up        bool
+		setApp    bool
+		path      string
+		wantCode  int
+		wantRetry bool
+	}{
+		{"live while the database is down", false, false, "/healthz", http.StatusOK, false},
+		{"not ready while the database is down", false, false, "/readyz", http.StatusServiceUnavailable, true},
+		{"turns the app away before it is set", false, false, "/api/reservations", http.StatusServiceUnavailable, true},
+		{"turns the app away while the database is down", false, true, "/api/reservations", http.StatusServiceUnavailable, true},
+		{"ready once the database connects", true, true, "/readyz", http.StatusOK, false},
+		{"serves the app once the database connects", true, true, "/api/reservations", http.StatusTeapot, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			up = tt.up
+			monitor.Check(context.Background())
+			if tt.setApp {
+				server.SetApp(app)
+			}
+			rec := httptest.NewRecorder()
+			server.ServeHTTP(rec, httptest.NewRequest("GET", tt.path, nil))
+			if rec.Code != tt.wantCode {
+				t.Errorf("expected %d, got %d: %s", tt.wantCode, rec.Code, rec.Body.String())
+			}
+			if retry := rec.Header().Get("Retry-After"); (retry != "") != tt.wantRetry {
+				t.Errorf("expected Retry-After %v, got %q", tt.wantRetry, retry)
+			}
+		})
+	}
+}
@@ -0,0 +1,19 @@
+// Package httperr writes the error responses the API handlers share.
+package httperr
+
+import (
+	"bigfoot/golf/common/models/db"
+	"errors"
+	"net/http"
+)
+
+// ServerError reports a request that failed on the server. A database query
+// that ran past its timeout is a 504, so the client knows a retry may succeed.
+func ServerError(w http.ResponseWriter, err error, message string) {
+	var timeout *db.TimeoutError
+	if errors.As(err, &timeout) {
+		http.Error(w, "The database took too long to answer, try again", http.StatusGatewayTimeout)
+		return
+	}
+	http.Error(w, message, http.StatusInternalServerError)
+}
@@ -0,0 +1,30 @@
+package httperr
+
+import (
+	"bigfoot/golf/common/models/db"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestServerError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantCode int
+	}{
+		{"a failure is a 500", errors.New("boom"), http.StatusInternalServerError},
+		{"a query past its timeout is a 504", fmt.Errorf("load: %w", &db.TimeoutError{}), http.StatusGatewayTimeout},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			ServerError(rec, tt.err, "Issue with Search")
+			if rec.Code != tt.wantCode {
+				t.Errorf("expected %d, got %d", tt.wantCode, rec.Code)
+			}
+		})
+	}
+}
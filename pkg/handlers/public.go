@@ -2,13 +2,14 @@ package handlers
 
 import (
 	"bigfoot/golf/common/handlers/transactions"
+	"bigfoot/golf/common/models/teetimes"
 	"bigfoot/golf/common/models/weather"
 	"time"
 
 	"github.com/gorilla/mux"
 )
 
-func RegisterPublicRoutes(router *mux.Router) {
+func RegisterPublicRoutes(router *mux.Router, course *teetimes.Course) {
 
 	// Create weather handler with 15-minute cache
 	weatherHandler := weather.NewWeatherHandler(
@@ -18,5 +19,5 @@ func RegisterPublicRoutes(router *mux.Router) {
 
 	// Public routes
 	router.HandleFunc("/weather", weatherHandler.ServeHTTP).Methods("GET")
-	router.HandleFunc("/teetimes", transactions.GetTeeTimes).Methods("POST")
+	router.HandleFunc("/teetimes", transactions.NewHandlers(course).GetTeeTimes).Methods("POST")
 }
@@ -1,13 +1,15 @@
 package transactions
 
 import (
+	"bigfoot/golf/common/handlers/httperr"
 	"bigfoot/golf/common/models/teetimes"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"time"
 )
 
-func GetTeeTimes(w http.ResponseWriter, r *http.Request) {
+func (h *Handlers) GetTeeTimes(w http.ResponseWriter, r *http.Request) {
 	var input map[string]time.Time
 	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
@@ -17,11 +19,9 @@ func GetTeeTimes(w http.ResponseWriter, r *http.Request) {
 	// set start date
 	_start := input["start"]
 
-	var booking teetimes.BookingEngine
-
-	_days, err := booking.GetDayTeeTimes(_start)
+	_days, err := h.course.DayTeeTimes(r.Context(), _start)
 	if err != nil {
-		http.Error(w, "Issue with Search", http.StatusInternalServerError)
+		httperr.ServerError(w, err, "Issue with Search")
 		return
 	}
 
@@ -29,31 +29,77 @@ func GetTeeTimes(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(_days)
 }
 
-func BookTime(w http.ResponseWriter, r *http.Request) {
+// maxIdempotencyKey bounds the client's key, which is stored with the reservation
+const maxIdempotencyKey = 128
+
+// BookTime books the reservation. A retry sending the Idempotency-Key header,
+// or the idempotencyKey field, of an earlier attempt gets that attempt's
+// reservation back instead of a second booking.
+func (h *Handlers) BookTime(w http.ResponseWriter, r *http.Request) {
 	var input teetimes.Reservation
 	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
-	if input.BookingUser == nil || len(input.Players) < 1 {
+	if key := r.Header.Get("Idempotency-Key"); key != "" {
+		input.IdempotencyKey = key
+	}
+	if len(input.IdempotencyKey) > maxIdempotencyKey {
+		http.Error(w, "Idempotency key too long", http.StatusBadRequest)
+		return
+	}
+	if !bindBookingUser(w, r, &input) {
+		return
+	}
+	if len(input.Players) < 1 {
 		http.Error(w, "No User Found", http.StatusForbidden)
 		return
 	}
 
-	err := input.Save()
+	_, err := h.course.PriceReservation(r.Context(), &input, time.Now())
+	if err == nil {
+		err = h.course.BookTeeTime(r.Context(), &input)
+	}
+	if errors.Is(err, teetimes.ErrSlotUnavailable) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
 	if err != nil {
-		http.Error(w, "Error with Transaction, Try Again Later", http.StatusInternalServerError)
+		httperr.ServerError(w, err, "Error with Transaction, Try Again Later")
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(input)
 }
 
+// QuoteTeeTime prices a reservation before booking, listing the rules applied to each player
+func (h *Handlers) QuoteTeeTime(w http.ResponseWriter, r *http.Request) {
+	var input teetimes.Reservation
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if !bindBookingUser(w, r, &input) {
+		return
+	}
+
+	quote, err := h.course.PriceReservation(r.Context(), &input, time.Now())
+	if errors.Is(err, teetimes.ErrSlotUnavailable) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		httperr.ServerError(w, err, "Error pricing tee time")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(quote)
+}
+
 // GetUserReservations retrieves all reservations for the authenticated user
-func GetUserReservations(w http.ResponseWriter, r *http.Request) {
-	userID := r.Header.Get("X-User-ID") // Assuming user ID is passed in header after auth
-	if userID == "" {
-		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+func (h *Handlers) GetUserReservations(w http.ResponseWriter, r *http.Request) {
+	userID, ok := actingUser(w, r)
+	if !ok {
 		return
 	}
 
@@ -69,9 +115,9 @@ func GetUserReservations(w http.ResponseWriter, r *http.Request) {
 		includePast = r.URL.Query().Get("includePast") == "true"
 	}
 
-	reservations, err := teetimes.GetUserReservations(userID, includePast)
+	reservations, err := h.course.UserReservations(r.Context(), userID, includePast)
 	if err != nil {
-		http.Error(w, "Error retrieving reservations", http.StatusInternalServerError)
+		httperr.ServerError(w, err, "Error retrieving reservations")
 		return
 	}
 
@@ -80,10 +126,9 @@ func GetUserReservations(w http.ResponseWriter, r *http.Request) {
 }
 
 // CancelReservation cancels a specific reservation
-func CancelReservation(w http.ResponseWriter, r *http.Request) {
-	userID := r.Header.Get("X-User-ID")
-	if userID == "" {
-		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+func (h *Handlers) CancelReservation(w http.ResponseWriter, r *http.Request) {
+	userID, ok := actingUser(w, r)
+	if !ok {
 		return
 	}
 
@@ -100,9 +145,9 @@ func CancelReservation(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// First verify the reservation belongs to the user
-	reservations, err := teetimes.GetUserReservations(userID, false)
+	reservations, err := h.course.UserReservations(r.Context(), userID, false)
 	if err != nil {
-		http.Error(w, "Error verifying reservation ownership", http.StatusInternalServerError)
+		httperr.ServerError(w, err, "Error verifying reservation ownership")
 		return
 	}
 
@@ -119,9 +164,9 @@ func CancelReservation(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = targetReservation.Cancel()
+	err = h.course.Cancel(r.Context(), targetReservation)
 	if err != nil {
-		http.Error(w, "Error cancelling reservation", http.StatusInternalServerError)
+		httperr.ServerError(w, err, "Error cancelling reservation")
 		return
 	}
 
@@ -0,0 +1,242 @@
+package transactions
+
+import (
+	"bigfoot/golf/common/models/account"
+	"bigfoot/golf/common/models/auth"
+	"bigfoot/golf/common/models/db"
+	"bigfoot/golf/common/models/storage"
+	"bigfoot/golf/common/models/teetimes"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// seedSeason saves an open season around the test day with every tee time from 7:00 to 9:00
+func seedSeason(t *testing.T, stores *storage.Memory, day time.Time) {
+	ctx := context.Background()
+	t.Helper()
+	first := time.Date(day.Year(), day.Month(), day.Day(), 7, 0, 0, 0, time.UTC)
+	season := teetimes.Season{
+		Name:         "Test Season",
+		BeginDate:    day.AddDate(0, 0, -7),
+		EndDate:      day.AddDate(0, 0, 7),
+		FirstTeeTime: first,
+		LastTeeTime:  first.Add(2 * time.Hour),
+		Gap:          10 * time.Minute,
+		IsOpen:       true,
+		DefaultSettings: []teetimes.DetailedBlockSettings{
+			{Type: int(teetimes.WeekdayMorning), Name: "Weekday Morning", BeginOverride: first, EndOverride: first.Add(3 * time.Hour), Price: 50, IsAvail: true},
+			{Type: int(teetimes.WeekendMorning), Name: "Weekend Morning", BeginOverride: first, EndOverride: first.Add(3 * time.Hour), Price: 60, IsAvail: true},
+			{Type: int(teetimes.Holiday), Name: "Holiday", BeginOverride: first, EndOverride: first.Add(3 * time.Hour), Price: 70, IsAvail: true},
+		},
+	}
+	if err := stores.Seasons.Save(ctx, &season); err != nil {
+		t.Fatalf("unexpected error saving season: %v", err)
+	}
+}
+
+// seedUsers saves a verified user for each ID
+func seedUsers(t *testing.T, stores *storage.Memory, ids ...string) {
+	t.Helper()
+	for _, id := range ids {
+		user := account.User{ID: id, Email: id + "@example.com", LastName: id, Password: "hash-" + id}
+		if err := stores.Users.Save(context.Background(), &user); err != nil {
+			t.Fatalf("unexpected error saving user: %v", err)
+		}
+	}
+}
+
+// serve calls the handler signed in as the user, or signed out without one
+func serve(handler http.HandlerFunc, method, target, userID string, body any) *httptest.ResponseRecorder {
+	var claims *auth.Claims
+	if userID != "" {
+		claims = &auth.Claims{UserID: userID}
+	}
+	return serveClaims(handler, method, target, claims, body)
+}
+
+// serveClaims calls the handler with the claims AuthenticateMiddleware would verify
+func serveClaims(handler http.HandlerFunc, method, target string, claims *auth.Claims, body any) *httptest.ResponseRecorder {
+	var payload bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&payload).Encode(body)
+	}
+	req := httptest.NewRequest(method, target, &payload)
+	if claims != nil {
+		req = req.WithContext(auth.WithClaims(req.Context(), claims))
+	}
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	return rec
+}
+
+func TestBookingHandlers(t *testing.T) {
+	db.TimeLocation = time.UTC
+	stores := storage.UseMemory()
+	h := NewHandlers(storage.Course())
+	now := time.Now().UTC()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, 3)
+	seedSeason(t, stores, day)
+	seedUsers(t, stores, "golfer", "other")
+
+	golfer := account.User{ID: "golfer", LastName: "Golfer"}
+	teeTime := day.Add(7*time.Hour + 10*time.Minute)
+
+	rec := serve(h.GetTeeTimes, "POST", "/api/teetimes", "", map[string]time.Time{"start": day})
+	var days []teetimes.ReservedDay
+	if err := json.NewDecoder(rec.Body).Decode(&days); err != nil || len(days) != 1 || len(days[0].Times) != 13 {
+		t.Fatalf("expected 13 open tee times, got %d %v: %s", rec.Code, err, rec.Body.String())
+	}
+
+	rec = serve(h.BookTime, "POST", "/api/bookTime", "golfer", teetimes.Reservation{TeeTime: teeTime, Slot: 2, BookingUser: &golfer, Players: []account.User{golfer, {LastName: "Guest"}}})
+	var booked teetimes.Reservation
+	if err := json.NewDecoder(rec.Body).Decode(&booked); err != nil || booked.ID == "" || booked.Total == 0 {
+		t.Fatalf("expected a priced booking, got %d %s", rec.Code, rec.Body.String())
+	}
+	rec = serve(h.GetTeeTimes, "POST", "/api/teetimes", "", map[string]time.Time{"start": day})
+	if sheet := rec.Body.String(); !strings.Contains(sheet, booked.ID) || strings.Contains(sheet, "hash-golfer") || strings.Contains(sheet, "golfer@example.com") {
+		t.Fatalf("expected the public tee sheet to show the booking without the golfer's password or email, got %s", sheet)
+	}
+	rec = serve(h.GetUserReservations, "GET", "/api/reservations", "golfer", nil)
+	var reservations []teetimes.Reservation
+	if err := json.NewDecoder(rec.Body).Decode(&reservations); err != nil || len(reservations) != 1 || reservations[0].ID != booked.ID {
+		t.Fatalf("expected the golfer's booking listed, got %s", rec.Body.String())
+	}
+
+	tests := []struct {
+		name     string
+		handler  http.HandlerFunc
+		userID   string
+		body     any
+		wantCode int
+	}{
+		{"rejects a foursome in the booked slot", h.BookTime, "other", teetimes.Reservation{TeeTime: teeTime, Slot: 2, BookingUser: &account.User{ID: "other"}, Players: make([]account.User, 4)}, http.StatusConflict},
+		{"rejects a slot the tee sheet does not lay out", h.BookTime, "other", teetimes.Reservation{TeeTime: teeTime, Slot: 999, BookingUser: &account.User{ID: "other"}, Players: make([]account.User, 1)}, http.StatusConflict},
+		{"rejects a slot laid out at another tee time", h.BookTime, "other", teetimes.Reservation{TeeTime: teeTime, Slot: 5, BookingUser: &account.User{ID: "other"}, Players: make([]account.User, 1)}, http.StatusConflict},
+		{"rejects a booking without players", h.BookTime, "golfer", teetimes.Reservation{TeeTime: teeTime, Slot: 3, BookingUser: &golfer}, http.StatusForbidden},
+		{"hides other golfers' reservations", h.CancelReservation, "other", map[string]string{"reservationId": booked.ID}, http.StatusNotFound},
+		{"cancels the golfer's reservation", h.CancelReservation, "golfer", map[string]string{"reservationId": booked.ID}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := serve(tt.handler, "POST", "/api", tt.userID, tt.body); rec.Code != tt.wantCode {
+				t.Errorf("expected %d, got %d: %s", tt.wantCode, rec.Code, rec.Body.String())
+			}
+		})
+	}
+
+	rec = serve(h.GetUserReservations, "GET", "/api/reservations", "golfer", nil)
+	if body := bytes.TrimSpace(rec.Body.Bytes()); string(body) != "[]" {
+		t.Errorf("expected no reservations after cancelling, got %s", body)
+	}
+}
+
+func TestBookTimeIdempotencyKey(t *testing.T) {
+	db.TimeLocation = time.UTC
+	stores := storage.UseMemory()
+	h := NewHandlers(storage.Course())
+	now := time.Now().UTC()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, 3)
+	seedSeason(t, stores, day)
+	seedUsers(t, stores, "golfer")
+
+	golfer := account.User{ID: "golfer", LastName: "Golfer"}
+	// the season lays out a slot every ten minutes from 7:00
+	slotTime := func(slot int64) time.Time {
+		return day.Add(7*time.Hour + time.Duration(slot-1)*10*time.Minute)
+	}
+	book := func(slot int64, header, field string) *httptest.ResponseRecorder {
+		var payload bytes.Buffer
+		json.NewEncoder(&payload).Encode(teetimes.Reservation{
+			TeeTime: slotTime(slot), Slot: slot, BookingUser: &golfer,
+			Players: []account.User{golfer}, IdempotencyKey: field,
+		})
+		req := httptest.NewRequest("POST", "/api/bookTime", &payload)
+		req = req.WithContext(auth.WithClaims(req.Context(), &auth.Claims{UserID: golfer.ID}))
+		if header != "" {
+			req.Header.Set("Idempotency-Key", header)
+		}
+		rec := httptest.NewRecorder()
+		h.BookTime(rec, req)
+		return rec
+	}
+
+	tests := []struct {
+		name      string
+		slot      int64
+		header    string
+		field     string
+		wantCode  int
+		wantSlots int
+	}{
+		{name: "retries with the header book once", slot: 1, header: "attempt-1", wantCode: http.StatusOK, wantSlots: 1},
+		{name: "retries with the cached body book once", slot: 2, field: "attempt-2", wantCode: http.StatusOK, wantSlots: 1},
+		{name: "retries without a key book again", slot: 3, wantCode: http.StatusOK, wantSlots: 3},
+		{name: "rejects an oversized key", slot: 4, header: strings.Repeat("k", 129), wantCode: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids := make(map[string]bool)
+			for i := 0; i < 3; i++ {
+				rec := book(tt.slot, tt.header, tt.field)
+				if rec.Code != tt.wantCode {
+					t.Fatalf("expected %d, got %d: %s", tt.wantCode, rec.Code, rec.Body.String())
+				}
+				var booked teetimes.Reservation
+				if json.NewDecoder(rec.Body).Decode(&booked) == nil {
+					ids[booked.ID] = true
+				}
+			}
+			if booked := stores.Bookings.SlotReservations(slotTime(tt.slot), tt.slot); len(booked) != tt.wantSlots {
+				t.Errorf("expected %d reservations in the slot, got %d", tt.wantSlots, len(booked))
+			}
+			if tt.wantCode == http.StatusOK && len(ids) != tt.wantSlots {
+				t.Errorf("expected %d distinct reservations returned, got %d", tt.wantSlots, len(ids))
+			}
+		})
+	}
+}
+
+func TestBookTimePricesPlayersFromTheStore(t *testing.T) {
+	db.TimeLocation = time.UTC
+	stores := storage.UseMemory()
+	h := NewHandlers(storage.Course())
+	now := time.Now().UTC()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, 3)
+	seedSeason(t, stores, day)
+	seedUsers(t, stores, "golfer", "buddy")
+
+	// both players claim a junior's birthday; buddy's stored record has none
+	// and ghost is not a stored user, so neither gets the junior rate
+	junior := time.Now().AddDate(-10, 0, 0).Format(time.DateOnly)
+	golfer := account.User{ID: "golfer", LastName: "Golfer"}
+	rec := serve(h.BookTime, "POST", "/api/bookTime", "golfer", teetimes.Reservation{
+		TeeTime: day.Add(7 * time.Hour), Slot: 1, BookingUser: &golfer,
+		Players: []account.User{golfer, {ID: "buddy", DOB: junior}, {ID: "ghost", LastName: "Ghost", DOB: junior}},
+	})
+	var booked teetimes.Reservation
+	if err := json.NewDecoder(rec.Body).Decode(&booked); err != nil || booked.ID == "" {
+		t.Fatalf("expected a booking, got %d %s", rec.Code, rec.Body.String())
+	}
+	if booked.Total != booked.Price*3 {
+		t.Errorf("expected every player at the adult rate %.2f, got a total of %.2f", booked.Price, booked.Total)
+	}
+	if ghost := booked.Players[2]; ghost.ID != "" || ghost.DOB != "" || ghost.LastName != "Ghost" {
+		t.Errorf("expected the unknown player booked as a guest by name, got %+v", ghost)
+	}
+	// the stored players priced the round but only their names are kept or returned
+	stored := stores.Bookings.SlotReservations(day.Add(7*time.Hour), 1)
+	if len(stored) != 1 {
+		t.Fatalf("expected the booking stored, got %+v", stored)
+	}
+	for _, res := range []teetimes.Reservation{booked, stored[0]} {
+		if buddy := res.Players[1]; buddy.ID != "buddy" || buddy.LastName != "buddy" || buddy.Email != "" || res.BookingUser.Email != "" {
+			t.Errorf("expected players and booker with their names only, got %+v and %+v", res.Players, res.BookingUser)
+		}
+	}
+}
@@ -0,0 +1,13 @@
+package transactions
+
+import "bigfoot/golf/common/models/teetimes"
+
+// Handlers serve the tee time, hold, waitlist, standing and outing routes against the course they were built with
+type Handlers struct {
+	course *teetimes.Course
+}
+
+// NewHandlers returns the handlers working against the course
+func NewHandlers(course *teetimes.Course) *Handlers {
+	return &Handlers{course: course}
+}
@@ -0,0 +1,65 @@
+package transactions
+
+import (
+	"bigfoot/golf/common/handlers/httperr"
+	"bigfoot/golf/common/models/teetimes"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// PlaceHold reserves a slot for the authenticated user while they check out
+func (h *Handlers) PlaceHold(w http.ResponseWriter, r *http.Request) {
+	userID, ok := actingUser(w, r)
+	if !ok {
+		return
+	}
+
+	var hold teetimes.SlotHold
+	if err := json.NewDecoder(r.Body).Decode(&hold); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	hold.UserID = userID
+
+	err := h.course.PlaceHold(r.Context(), &hold)
+	if errors.Is(err, teetimes.ErrHoldPlayers) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, teetimes.ErrSlotUnavailable) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		httperr.ServerError(w, err, "Error holding tee time")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(hold)
+}
+
+// ReleaseHold gives a held slot back before the hold expires
+func (h *Handlers) ReleaseHold(w http.ResponseWriter, r *http.Request) {
+	userID, ok := actingUser(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.course.ReleaseHold(r.Context(), userID, mux.Vars(r)["id"])
+	if errors.Is(err, teetimes.ErrHoldNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		httperr.ServerError(w, err, "Error releasing hold")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "released"})
+}
@@ -0,0 +1,27 @@
+package transactions
+
+import (
+	"bigfoot/golf/common/models/account"
+	"context"
+	"fmt"
+	"net/smtp"
+	"os"
+)
+
+// sendMail sends a plain text email from the club's gmail account
+func sendMail(to, subject, body string) error {
+	from := os.Getenv("GMAIL_USER")
+	password := os.Getenv("GMAIL_PASS")
+	smtpHost := "smtp.gmail.com"
+	smtpPort := "587"
+
+	message := fmt.Sprintf("Subject: %s\n\n%s", subject, body)
+	auth := smtp.PlainAuth("", from, password, smtpHost)
+	return smtp.SendMail(smtpHost+":"+smtpPort, auth, from, []string{to}, []byte(message))
+}
+
+// MailPasswordReset emails a user the link to reset their password
+func MailPasswordReset(ctx context.Context, user account.User, link string) error {
+	body := fmt.Sprintf("Someone asked to reset the password for your Bigfoot Golf account.\n\nOpen this link within 30 minutes to choose a new one:\n%s\n\nIf it wasn't you, ignore this email and your password stays the same.", link)
+	return sendMail(user.Email, "Reset Your Password", body)
+}
@@ -0,0 +1,113 @@
+package transactions
+
+import (
+	"bigfoot/golf/common/models/auth"
+	"bigfoot/golf/common/models/teetimes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// outingError maps outing errors to the status the client should see
+func outingError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, teetimes.ErrOutingNotFound), errors.Is(err, teetimes.ErrTeamNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, teetimes.ErrNotOrganizer):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, teetimes.ErrOutingFull):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	}
+}
+
+// organizerOuting loads the outing in the route, allowing only its organizer or staff who see the tee sheet
+func (h *Handlers) organizerOuting(w http.ResponseWriter, r *http.Request) *teetimes.Outing {
+	outing, err := h.course.Outing(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		outingError(w, err)
+		return nil
+	}
+	userID, _ := auth.ActingUser(r.Context())
+	if outing.OrganizerID != userID && !auth.Allowed(r.Context(), auth.PermViewTeeSheet) {
+		outingError(w, teetimes.ErrNotOrganizer)
+		return nil
+	}
+	return outing
+}
+
+// GetOuting returns the outing with its registered teams
+func (h *Handlers) GetOuting(w http.ResponseWriter, r *http.Request) {
+	outing := h.organizerOuting(w, r)
+	if outing == nil {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(outing)
+}
+
+// RegisterOutingTeam adds a team to the outing
+func (h *Handlers) RegisterOutingTeam(w http.ResponseWriter, r *http.Request) {
+	var input teetimes.OutingTeam
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	input.OutingID = mux.Vars(r)["id"]
+
+	userID, ok := actingUser(w, r)
+	if !ok {
+		return
+	}
+	err := h.course.RegisterOutingTeam(r.Context(), userID, auth.Allowed(r.Context(), auth.PermManageOutings), &input)
+	if err != nil {
+		outingError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(input)
+}
+
+// RemoveOutingTeam drops a team from the outing
+func (h *Handlers) RemoveOutingTeam(w http.ResponseWriter, r *http.Request) {
+	userID, ok := actingUser(w, r)
+	if !ok {
+		return
+	}
+	vars := mux.Vars(r)
+	err := h.course.RemoveOutingTeam(r.Context(), userID, auth.Allowed(r.Context(), auth.PermManageOutings), vars["id"], vars["teamId"])
+	if err != nil {
+		outingError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetOutingPairings returns the starter's view of each team's starting hole or tee time
+func (h *Handlers) GetOutingPairings(w http.ResponseWriter, r *http.Request) {
+	outing := h.organizerOuting(w, r)
+	if outing == nil {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(outing.Pairings())
+}
+
+// ExportOutingRoster downloads the outing's players as CSV
+func (h *Handlers) ExportOutingRoster(w http.ResponseWriter, r *http.Request) {
+	outing := h.organizerOuting(w, r)
+	if outing == nil {
+		return
+	}
+	filename := strings.ReplaceAll(strings.ToLower(outing.Name), " ", "-") + "-roster.csv"
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	if err := outing.WriteRoster(w); err != nil {
+		http.Error(w, "Error writing roster", http.StatusInternalServerError)
+	}
+}
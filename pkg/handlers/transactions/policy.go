@@ -0,0 +1,92 @@
+package transactions
+
+import (
+	"bigfoot/golf/common/handlers/httperr"
+	"bigfoot/golf/common/models/account"
+	"bigfoot/golf/common/models/auth"
+	"bigfoot/golf/common/models/teetimes"
+	"errors"
+	"net/http"
+)
+
+// actingUser returns the signed in user, answering 401 when the request has none
+func actingUser(w http.ResponseWriter, r *http.Request) (string, bool) {
+	userID, err := auth.ActingUser(r.Context())
+	if err != nil {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return "", false
+	}
+	return userID, true
+}
+
+// actFor returns the user a request naming target acts for, answering 401
+// without a signed in user and 403 when only an admin may act for the target
+func actFor(w http.ResponseWriter, r *http.Request, target string) (string, bool) {
+	userID, err := auth.ActFor(r.Context(), target)
+	if errors.Is(err, auth.ErrForbidden) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return "", false
+	}
+	if err != nil {
+		http.Error(w, "User not authenticated", http.StatusUnauthorized)
+		return "", false
+	}
+	return userID, true
+}
+
+// storedUser loads the user's own record, answering 404 when there is none
+func storedUser(w http.ResponseWriter, r *http.Request, userID string) (*account.User, bool) {
+	user, err := account.QueryUser(r.Context(), map[string]interface{}{"id": userID})
+	if err != nil {
+		httperr.ServerError(w, err, "Error loading user")
+		return nil, false
+	}
+	if user == nil {
+		http.Error(w, "No User Found", http.StatusNotFound)
+		return nil, false
+	}
+	return user, true
+}
+
+// bindBookingUser books the reservation as the signed in user, or with an admin
+// token the user the body names, taking the booking user from the store so
+// pricing never trusts the body. Players sent with an id get their stored
+// record too, or are priced as guests by name when no such user is stored;
+// other players are guests as sent. The stored records are for pricing only,
+// booking keeps just their ids and names.
+func bindBookingUser(w http.ResponseWriter, r *http.Request, res *teetimes.Reservation) bool {
+	target := ""
+	if res.BookingUser != nil {
+		target = res.BookingUser.ID
+	}
+	userID, ok := actFor(w, r, target)
+	if !ok {
+		return false
+	}
+	user, ok := storedUser(w, r, userID)
+	if !ok {
+		return false
+	}
+	user.Password, user.TempStr = "", ""
+	res.BookingUser = user
+	for i, player := range res.Players {
+		switch {
+		case player.ID == "":
+		case player.ID == user.ID:
+			res.Players[i] = *user
+		default:
+			stored, err := account.QueryUser(r.Context(), map[string]interface{}{"id": player.ID})
+			if err != nil {
+				httperr.ServerError(w, err, "Error loading player")
+				return false
+			}
+			if stored == nil {
+				res.Players[i] = account.User{FirstName: player.FirstName, LastName: player.LastName}
+				continue
+			}
+			stored.Password, stored.TempStr = "", ""
+			res.Players[i] = *stored
+		}
+	}
+	return true
+}
@@ -0,0 +1,133 @@
+package transactions
+
+import (
+	"bigfoot/golf/common/handlers/httperr"
+	"bigfoot/golf/common/models/account"
+	"bigfoot/golf/common/models/teetimes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// CreateStanding saves a weekly standing tee time for the authenticated user
+// and books the weeks already inside its lead window
+func (h *Handlers) CreateStanding(w http.ResponseWriter, r *http.Request) {
+	userID, ok := actingUser(w, r)
+	if !ok {
+		return
+	}
+
+	var standing teetimes.StandingReservation
+	if err := json.NewDecoder(r.Body).Decode(&standing); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	standing.ID = ""
+	standing.UserID = userID
+	standing.Upcoming = nil
+
+	if err := h.course.CreateStanding(r.Context(), &standing, time.Now()); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(standing)
+}
+
+// GetStanding lists the authenticated user's standing tee times with their upcoming dates
+func (h *Handlers) GetStanding(w http.ResponseWriter, r *http.Request) {
+	userID, ok := actingUser(w, r)
+	if !ok {
+		return
+	}
+
+	standing, err := h.course.UserStanding(r.Context(), userID, time.Now())
+	if err != nil {
+		httperr.ServerError(w, err, "Error retrieving standing tee times")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(standing)
+}
+
+// SkipStandingDate stops one of the user's standing tee times booking a date
+func (h *Handlers) SkipStandingDate(w http.ResponseWriter, r *http.Request) {
+	userID, ok := actingUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Date string `json:"date"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	day, err := time.Parse(time.DateOnly, req.Date)
+	if err != nil {
+		http.Error(w, "date must be YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+
+	standing, err := h.course.SkipStandingDate(r.Context(), userID, mux.Vars(r)["id"], day)
+	if err != nil {
+		standingError(w, err, "Error skipping standing tee time")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(standing)
+}
+
+// DeleteStanding stops one of the user's standing tee times
+func (h *Handlers) DeleteStanding(w http.ResponseWriter, r *http.Request) {
+	userID, ok := actingUser(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.course.DeleteStanding(r.Context(), userID, mux.Vars(r)["id"]); err != nil {
+		standingError(w, err, "Error removing standing tee time")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "removed"})
+}
+
+func standingError(w http.ResponseWriter, err error, msg string) {
+	switch {
+	case errors.Is(err, teetimes.ErrStandingNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, teetimes.ErrNotStandingOwner):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	default:
+		httperr.ServerError(w, err, msg)
+	}
+}
+
+// NotifyStandingConflict emails the owner of a standing tee time that a week could not be booked
+func NotifyStandingConflict(ctx context.Context, standing teetimes.StandingReservation, occurrence teetimes.StandingOccurrence) {
+	user, err := account.QueryUser(ctx, map[string]interface{}{"id": standing.UserID})
+	if err != nil || user == nil {
+		log.Printf("Error finding standing tee time owner %s: %v", standing.UserID, err)
+		return
+	}
+	body := fmt.Sprintf("We could not book your standing %s tee time on %s: %s.\n\nOpen your bookings in the app to pick another time or skip the week.",
+		occurrence.TeeTime.Format("3:04 PM"),
+		occurrence.TeeTime.Format("Monday, January 2"),
+		occurrence.Reason)
+	if err := sendMail(user.Email, "Standing Tee Time Not Booked", body); err != nil {
+		log.Printf("Error emailing standing tee time conflict to %s: %v", user.Email, err)
+	}
+}
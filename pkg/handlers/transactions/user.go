@@ -1,54 +1,70 @@
 package transactions
 
 import (
+	"bigfoot/golf/common/handlers/httperr"
 	"bigfoot/golf/common/handlers/sessionmgr"
 	"bigfoot/golf/common/models/account"
+	"bigfoot/golf/common/models/auth"
 	"encoding/json"
 	"fmt"
+	"log"
 	"math/rand/v2"
 	"net/http"
-	"net/smtp"
-	"os"
 	"time"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
-// PUT /api/chat/{id} - Get user by ID
+// SaveUserHandler updates the profile of the signed in user, or with an admin
+// token the user named in the body. Only the fields auth.ProfileUpdate allows
+// are taken from the body.
 func SaveUserHandler(w http.ResponseWriter, r *http.Request) {
 
-	var user account.User
-	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
+	var input auth.ProfileEdit
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
+	userID, ok := actFor(w, r, input.ID)
+	if !ok {
+		return
+	}
+	stored, ok := storedUser(w, r, userID)
+	if !ok {
+		return
+	}
 
-	err := user.Save()
+	user := auth.ProfileUpdate(*stored, input)
+	// A new email is where password resets go, so changing it needs a step up
+	if user.Email != stored.Email && !auth.Elevated(r.Context()) {
+		auth.StepUpRequired(w)
+		return
+	}
+	err := user.Save(r.Context())
 	if err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
 
+	user.Password = ""
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(user)
 
 }
 
+// SendEmailCodeHandler mails a verification code to the signed in user's stored email
 func SendEmailCodeHandler(w http.ResponseWriter, r *http.Request) {
-
-	var user account.User
-	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	userID, ok := actingUser(w, r)
+	if !ok {
+		return
+	}
+	user, ok := storedUser(w, r, userID)
+	if !ok {
 		return
 	}
-
-	from := os.Getenv("GMAIL_USER")
-	password := os.Getenv("GMAIL_PASS")
-	smtpHost := "smtp.gmail.com"
-	smtpPort := "587"
 
 	verifyCode := randomSixDigit()
-	message := fmt.Sprintf("Subject: Verify Account\n\nPlease enter this code into your app to verify your account:\n %s", verifyCode)
+	message := fmt.Sprintf("Please enter this code into your app to verify your account:\n %s", verifyCode)
 
 	//store code in session
 	_expiresIn := time.Now().Add(time.Minute * 10)
@@ -58,8 +74,7 @@ func SendEmailCodeHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	auth := smtp.PlainAuth("", from, password, smtpHost)
-	err = smtp.SendMail(smtpHost+":"+smtpPort, auth, from, []string{user.Email}, []byte(message))
+	err = sendMail(user.Email, "Verify Account", message)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -69,9 +84,15 @@ func SendEmailCodeHandler(w http.ResponseWriter, r *http.Request) {
 
 }
 
+// VerifyCodeHandler marks the signed in user verified when the code in the
+// body matches the one mailed to them
 func VerifyCodeHandler(w http.ResponseWriter, r *http.Request) {
-	var _user account.User
-	if err := json.NewDecoder(r.Body).Decode(&_user); err != nil {
+	userID, ok := actingUser(w, r)
+	if !ok {
+		return
+	}
+	var _input account.User
+	if err := json.NewDecoder(r.Body).Decode(&_input); err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
@@ -88,14 +109,21 @@ func VerifyCodeHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Verify user ID and code
-	if _data.ID != _user.ID && _user.TempStr != _data.Code {
-		http.Error(w, _data.Message, http.StatusConflict)
+	// The code must have been sent to this user and match
+	if _data.ID != userID || _data.Code == "" || _input.TempStr != _data.Code {
+		http.Error(w, "Incorrect verification code", http.StatusConflict)
 		return
 	}
 	//user has verified email, check that
+	_user, ok := storedUser(w, r, userID)
+	if !ok {
+		return
+	}
 	_user.IsVerified = true
-	_user.Save()
+	if err := _user.Save(r.Context()); err != nil {
+		httperr.ServerError(w, err, "Error saving user")
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	//send success message
@@ -110,7 +138,14 @@ func randomSixDigit() string {
 	return fmt.Sprintf("%06d", rand.IntN(1000000))
 }
 
+// UpdatePW changes the signed in user's password. An id in the body must be
+// the user's own; no one sets another user's password here. Every other
+// session of the user is signed out, the one making the change stays.
 func UpdatePW(w http.ResponseWriter, r *http.Request) {
+	userID, ok := actingUser(w, r)
+	if !ok {
+		return
+	}
 
 	var input map[string]interface{}
 	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
@@ -118,10 +153,24 @@ func UpdatePW(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if id, ok := input["id"]; ok && id != "" && id != userID {
+		http.Error(w, auth.ErrForbidden.Error(), http.StatusForbidden)
+		return
+	}
+
 	// Hash password
 	_newPW, ok := input["password"].(string)
-	if !ok {
-		http.Error(w, "Failed to hash password", http.StatusInternalServerError)
+	if !ok || _newPW == "" {
+		http.Error(w, "Password required", http.StatusBadRequest)
+		return
+	}
+	current, err := account.QueryUser(r.Context(), map[string]interface{}{"id": userID})
+	if err != nil || current == nil {
+		http.Error(w, "No User Found", http.StatusNotFound)
+		return
+	}
+	if err := auth.ValidatePassword(_newPW, *current); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(_newPW), bcrypt.DefaultCost)
@@ -130,15 +179,23 @@ func UpdatePW(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	var user account.User
-	user.ID = input["id"].(string)
+	user.ID = userID
 	user.Password = string(hashedPassword)
 
-	err = user.UpdatePW()
+	err = user.UpdatePW(r.Context())
 	if err != nil {
 		http.Error(w, "Invalid request", http.StatusBadRequest)
 		return
 	}
+	keep := ""
+	if claims, ok := auth.ClaimsFrom(r.Context()); ok && claims.UserID == userID {
+		keep = claims.SessionID
+	}
+	if _, err := auth.RevokeOtherSessions(r.Context(), userID, keep, auth.RevokedPasswordChange); err != nil {
+		log.Printf("Error revoking sessions for %s after a password change: %v", userID, err)
+	}
 
+	user.Password = ""
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(user)
 
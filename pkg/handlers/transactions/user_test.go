@@ -0,0 +1,297 @@
+package transactions
+
+import (
+	"bigfoot/golf/common/handlers/sessionmgr"
+	"bigfoot/golf/common/models/account"
+	"bigfoot/golf/common/models/auth"
+	"bigfoot/golf/common/models/db"
+	"bigfoot/golf/common/models/storage"
+	"bigfoot/golf/common/models/teetimes"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// storedUserFor returns the user as saved, failing the test when it is missing
+func storedUserFor(t *testing.T, id string) account.User {
+	t.Helper()
+	user, err := account.QueryUser(context.Background(), map[string]interface{}{"id": id})
+	if err != nil || user == nil {
+		t.Fatalf("expected user %s to be stored, got %v", id, err)
+	}
+	return *user
+}
+
+func TestCrossUserEscalation(t *testing.T) {
+	db.TimeLocation = time.UTC
+	stores := storage.UseMemory()
+	h := NewHandlers(storage.Course())
+	now := time.Now().UTC()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, 3)
+	seedSeason(t, stores, day)
+	seedUsers(t, stores, "attacker", "victim")
+
+	attacker := &auth.Claims{UserID: "attacker"}
+	admin := &auth.Claims{UserID: "pro", Roles: []auth.Role{auth.RoleGolfer, auth.RoleProShop}}
+	victim := account.User{ID: "victim", Email: "attacker@example.com", LastName: "Owned"}
+	teeTime := day.Add(7 * time.Hour)
+
+	tests := []struct {
+		name     string
+		handler  http.HandlerFunc
+		claims   *auth.Claims
+		body     any
+		wantCode int
+	}{
+		{"rejects a signed out profile edit", SaveUserHandler, nil, victim, http.StatusUnauthorized},
+		{"rejects editing another user's profile", SaveUserHandler, attacker, victim, http.StatusForbidden},
+		{"rejects resetting another user's password", UpdatePW, attacker, map[string]string{"id": "victim", "password": "taken-over"}, http.StatusForbidden},
+		{"rejects a weak new password", UpdatePW, attacker, map[string]string{"password": "short"}, http.StatusBadRequest},
+		{"rejects booking as another user", h.BookTime, attacker, teetimes.Reservation{TeeTime: teeTime, Slot: 1, BookingUser: &victim, Players: []account.User{victim}}, http.StatusForbidden},
+		{"rejects quoting as another user", h.QuoteTeeTime, attacker, teetimes.Reservation{TeeTime: teeTime, Slot: 1, BookingUser: &victim, Players: []account.User{victim}}, http.StatusForbidden},
+		{"lets an admin book for a golfer", h.BookTime, admin, teetimes.Reservation{TeeTime: teeTime, Slot: 1, BookingUser: &victim, Players: []account.User{victim}}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := serveClaims(tt.handler, "POST", "/api", tt.claims, tt.body); rec.Code != tt.wantCode {
+				t.Errorf("expected %d, got %d: %s", tt.wantCode, rec.Code, rec.Body.String())
+			}
+		})
+	}
+
+	if stored := storedUserFor(t, "victim"); stored.LastName != "victim" || stored.Email != "victim@example.com" || stored.Password != "hash-victim" {
+		t.Errorf("expected the victim's account untouched, got %+v", stored)
+	}
+	booked := stores.Bookings.SlotReservations(teeTime, 1)
+	if len(booked) != 1 || booked[0].BookingUser.LastName != "victim" || booked[0].BookingUser.Email != "" {
+		t.Errorf("expected the admin's booking to carry the stored golfer's name only, got %+v", booked)
+	}
+}
+
+func TestUpdatePWSignsOutOtherSessions(t *testing.T) {
+	ctx := context.Background()
+	stores := storage.UseMemory()
+	seedUsers(t, stores, "golfer", "other")
+
+	now := time.Now()
+	var ids []string
+	for _, userID := range []string{"golfer", "golfer", "other"} {
+		session := auth.RefreshSession{UserID: userID, CreatedAt: now, ExpiresAt: now.Add(time.Hour)}
+		if err := stores.Sessions.Create(ctx, &session); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		ids = append(ids, session.ID)
+	}
+
+	claims := &auth.Claims{UserID: "golfer", SessionID: ids[0]}
+	if rec := serveClaims(UpdatePW, "POST", "/api/updatePW", claims, map[string]string{"password": "fresh-fairway-42"}); rec.Code != http.StatusOK {
+		t.Fatalf("expected the password changed, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	tests := []struct {
+		name   string
+		userID string
+		want   []string
+	}{
+		{"keeps the session that changed the password", "golfer", ids[:1]},
+		{"leaves other users signed in", "other", ids[2:]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			active, err := auth.UserSessions(ctx, tt.userID)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var got []string
+			for _, session := range active {
+				got = append(got, session.ID)
+			}
+			if len(got) != len(tt.want) || (len(got) > 0 && got[0] != tt.want[0]) {
+				t.Errorf("expected sessions %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestProfileFieldRules(t *testing.T) {
+	stores := storage.UseMemory()
+	seedUsers(t, stores, "golfer")
+
+	tests := []struct {
+		name    string
+		claims  *auth.Claims
+		body    account.User
+		want    func(account.User) bool
+		wantMsg string
+	}{
+		{
+			name:    "never grants admin",
+			claims:  &auth.Claims{UserID: "golfer"},
+			body:    account.User{ID: "golfer", Email: "golfer@example.com", LastName: "Golfer", IsAdmin: true},
+			want:    func(u account.User) bool { return !u.IsAdmin && u.LastName == "Golfer" },
+			wantMsg: "admin flag unchanged and the name saved",
+		},
+		{
+			name:    "never sets verified",
+			claims:  &auth.Claims{UserID: "golfer"},
+			body:    account.User{Email: "golfer@example.com", IsVerified: true},
+			want:    func(u account.User) bool { return !u.IsVerified },
+			wantMsg: "the golfer still unverified",
+		},
+		{
+			name:    "keeps the stored password",
+			claims:  &auth.Claims{UserID: "golfer"},
+			body:    account.User{Email: "golfer@example.com", Password: "plain-text"},
+			want:    func(u account.User) bool { return u.Password == "hash-golfer" },
+			wantMsg: "the password hash kept",
+		},
+		{
+			name:    "never grants roles",
+			claims:  &auth.Claims{UserID: "golfer"},
+			body:    account.User{Email: "golfer@example.com", Roles: []string{"superAdmin"}},
+			want:    func(u account.User) bool { return len(u.Roles) == 0 },
+			wantMsg: "no roles",
+		},
+		{
+			name:    "an admin cannot grant admin through the profile either",
+			claims:  &auth.Claims{UserID: "pro", Roles: []auth.Role{auth.RoleGolfer, auth.RoleProShop}},
+			body:    account.User{ID: "golfer", Email: "golfer@example.com", IsAdmin: true},
+			want:    func(u account.User) bool { return !u.IsAdmin },
+			wantMsg: "admin flag unchanged",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serveClaims(SaveUserHandler, "POST", "/api/userupdate", tt.claims, tt.body)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+			}
+			var returned account.User
+			if err := json.NewDecoder(rec.Body).Decode(&returned); err != nil || returned.Password != "" {
+				t.Errorf("expected the user back without a password, got %v %+v", err, returned)
+			}
+			if stored := storedUserFor(t, "golfer"); !tt.want(stored) {
+				t.Errorf("expected %s, got %+v", tt.wantMsg, stored)
+			}
+		})
+	}
+}
+
+func TestProfileKeepsFieldsLeftOut(t *testing.T) {
+	stores := storage.UseMemory()
+	golfer := account.User{ID: "golfer", Email: "golfer@example.com", FirstName: "Gus", Phone: "555-0100", DOB: "1980-04-02", Avatar: "gus.png"}
+	if err := stores.Users.Save(context.Background(), &golfer); err != nil {
+		t.Fatal(err)
+	}
+	claims := &auth.Claims{UserID: "golfer"}
+
+	tests := []struct {
+		name string
+		body map[string]any
+		want func(account.User) bool
+	}{
+		{"changes only the name sent", map[string]any{"first_name": "Gil"}, func(u account.User) bool {
+			return u.FirstName == "Gil" && u.Phone == "555-0100" && u.DOB == "1980-04-02" && u.Avatar == "gus.png" && u.Email == "golfer@example.com"
+		}},
+		{"clears a field sent empty", map[string]any{"phone": ""}, func(u account.User) bool {
+			return u.Phone == "" && u.FirstName == "Gil" && u.DOB == "1980-04-02"
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := serveClaims(SaveUserHandler, "POST", "/api/userupdate", claims, tt.body); rec.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+			}
+			if stored := storedUserFor(t, "golfer"); !tt.want(stored) {
+				t.Errorf("unexpected profile %+v", stored)
+			}
+		})
+	}
+}
+
+func TestEmailChangeNeedsStepUp(t *testing.T) {
+	tests := []struct {
+		name      string
+		claims    *auth.Claims
+		email     string
+		wantCode  int
+		wantEmail string
+	}{
+		{"saves the name without a step up", &auth.Claims{UserID: "golfer"}, "golfer@example.com", http.StatusOK, "golfer@example.com"},
+		{"asks for a step up to change the email", &auth.Claims{UserID: "golfer"}, "new@example.com", http.StatusForbidden, "golfer@example.com"},
+		{"changes the email after a step up", &auth.Claims{UserID: "golfer", Elev: true}, "new@example.com", http.StatusOK, "new@example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stores := storage.UseMemory()
+			seedUsers(t, stores, "golfer")
+			rec := serveClaims(SaveUserHandler, "POST", "/api/userupdate", tt.claims, account.User{Email: tt.email, LastName: "Golfer"})
+			if rec.Code != tt.wantCode {
+				t.Fatalf("expected %d, got %d: %s", tt.wantCode, rec.Code, rec.Body.String())
+			}
+			if stored := storedUserFor(t, "golfer"); stored.Email != tt.wantEmail {
+				t.Errorf("expected the email %s, got %s", tt.wantEmail, stored.Email)
+			}
+		})
+	}
+}
+
+func TestVerifyCodeIsBoundToTheSignedInUser(t *testing.T) {
+	t.Setenv("SESSION_KEY", "verify-test-session-key")
+	sessionmgr.NewSessionMgr()
+
+	// session returns the cookie SendEmailCodeHandler would have set for the code
+	session := func(t *testing.T, id, code string) *http.Cookie {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		data := sessionmgr.SeshData{ID: id, Code: code, ExpiresAt: time.Now().Add(10 * time.Minute)}
+		if err := sessionmgr.SeshStore.StoreString(data, httptest.NewRequest("POST", "/api/verifyreq", nil), rec); err != nil {
+			t.Fatalf("unexpected error storing the code: %v", err)
+		}
+		return rec.Result().Cookies()[0]
+	}
+
+	tests := []struct {
+		name         string
+		sessionFor   string
+		body         account.User
+		wantCode     int
+		wantVerified []string
+	}{
+		{"rejects a code mailed to another user", "victim", account.User{TempStr: "123456"}, http.StatusConflict, nil},
+		{"rejects the wrong code", "attacker", account.User{TempStr: "654321"}, http.StatusConflict, nil},
+		{"rejects a missing code", "attacker", account.User{ID: "attacker"}, http.StatusConflict, nil},
+		{"verifies only the signed in user", "attacker", account.User{ID: "victim", TempStr: "123456"}, http.StatusOK, []string{"attacker"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stores := storage.UseMemory()
+			seedUsers(t, stores, "attacker", "victim")
+
+			var payload bytes.Buffer
+			json.NewEncoder(&payload).Encode(tt.body)
+			req := httptest.NewRequest("POST", "/api/verifyemailcode", &payload)
+			req.AddCookie(session(t, tt.sessionFor, "123456"))
+			req = req.WithContext(auth.WithClaims(req.Context(), &auth.Claims{UserID: "attacker"}))
+			rec := httptest.NewRecorder()
+			VerifyCodeHandler(rec, req)
+			if rec.Code != tt.wantCode {
+				t.Fatalf("expected %d, got %d: %s", tt.wantCode, rec.Code, rec.Body.String())
+			}
+
+			verified := map[string]bool{}
+			for _, id := range tt.wantVerified {
+				verified[id] = true
+			}
+			for _, id := range []string{"attacker", "victim"} {
+				if got := storedUserFor(t, id).IsVerified; got != verified[id] {
+					t.Errorf("expected %s verified %v, got %v", id, verified[id], got)
+				}
+			}
+		})
+	}
+}
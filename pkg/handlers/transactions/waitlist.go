@@ -0,0 +1,148 @@
+package transactions
+
+import (
+	"bigfoot/golf/common/handlers/httperr"
+	"bigfoot/golf/common/models/account"
+	"bigfoot/golf/common/models/teetimes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// JoinWaitlist queues the authenticated user for a time window that is fully booked
+func (h *Handlers) JoinWaitlist(w http.ResponseWriter, r *http.Request) {
+	userID, ok := actingUser(w, r)
+	if !ok {
+		return
+	}
+
+	var entry teetimes.WaitlistEntry
+	if err := json.NewDecoder(r.Body).Decode(&entry); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	entry.UserID = userID
+
+	if err := h.course.JoinWaitlist(r.Context(), &entry); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(entry)
+}
+
+// GetWaitlist lists the authenticated user's waitlist entries
+func (h *Handlers) GetWaitlist(w http.ResponseWriter, r *http.Request) {
+	userID, ok := actingUser(w, r)
+	if !ok {
+		return
+	}
+
+	entries, err := h.course.UserWaitlist(r.Context(), userID)
+	if err != nil {
+		httperr.ServerError(w, err, "Error retrieving waitlist")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
+
+// LeaveWaitlist removes one of the authenticated user's waitlist entries
+func (h *Handlers) LeaveWaitlist(w http.ResponseWriter, r *http.Request) {
+	userID, ok := actingUser(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.course.LeaveWaitlist(r.Context(), userID, mux.Vars(r)["id"])
+	if errors.Is(err, teetimes.ErrWaitlistNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		httperr.ServerError(w, err, "Error leaving waitlist")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "removed"})
+}
+
+// AcceptWaitlistOffer books the spot offered to one of the user's waitlist entries
+func (h *Handlers) AcceptWaitlistOffer(w http.ResponseWriter, r *http.Request) {
+	userID, ok := actingUser(w, r)
+	if !ok {
+		return
+	}
+
+	res, err := h.course.AcceptWaitlistOffer(r.Context(), userID, mux.Vars(r)["id"])
+	switch {
+	case errors.Is(err, teetimes.ErrWaitlistNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case errors.Is(err, teetimes.ErrNoWaitlistOffer), errors.Is(err, teetimes.ErrSlotUnavailable):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil && res == nil:
+		httperr.ServerError(w, err, "Error with Transaction, Try Again Later")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
+
+// NotifyWaitlistOffer emails a waitlisted golfer that a spot is being held for them
+func NotifyWaitlistOffer(ctx context.Context, entry teetimes.WaitlistEntry) {
+	user, err := account.QueryUser(ctx, map[string]interface{}{"id": entry.UserID})
+	if err != nil || user == nil {
+		log.Printf("Error finding waitlisted user %s: %v", entry.UserID, err)
+		return
+	}
+	body := fmt.Sprintf("Good news! A tee time opened up on %s at %s for %d players.\n\nIt is being held for you until %s, open your bookings in the app to accept it.",
+		entry.OfferTeeTime.Format("Monday, January 2"),
+		entry.OfferTeeTime.Format("3:04 PM"),
+		entry.Players,
+		entry.OfferExpiresAt.Format("3:04 PM"))
+	if err := sendMail(user.Email, "Tee Time Available", body); err != nil {
+		log.Printf("Error emailing waitlist offer to %s: %v", user.Email, err)
+	}
+}
+
+// NotifyDayOverride emails each golfer whose tee time a closure or delay moved or cancelled
+func NotifyDayOverride(result teetimes.OverrideResult) {
+	reason := ""
+	if result.Override.Reason != "" {
+		reason = fmt.Sprintf(" (%s)", result.Override.Reason)
+	}
+	// the tee sheet carries only golfers' names, so their email is looked up
+	send := func(res teetimes.Reservation, subject, body string) {
+		if res.BookingUser == nil {
+			return
+		}
+		user, err := account.QueryUser(context.Background(), map[string]interface{}{"id": res.BookingUser.ID})
+		if err != nil || user == nil || user.Email == "" {
+			log.Printf("Error finding golfer %s to email a tee sheet change: %v", res.BookingUser.ID, err)
+			return
+		}
+		if err := sendMail(user.Email, subject, body); err != nil {
+			log.Printf("Error emailing tee sheet change to %s: %v", user.Email, err)
+		}
+	}
+	for _, res := range result.Moved {
+		send(res, "Tee Time Moved", fmt.Sprintf("The course is starting late on %s%s.\n\nYour tee time has moved to %s.",
+			res.TeeTime.Format("Monday, January 2"), reason, res.TeeTime.Format("3:04 PM")))
+	}
+	for _, res := range result.Cancelled {
+		send(res, "Tee Time Cancelled", fmt.Sprintf("Your %s tee time on %s has been cancelled because the course is unavailable%s.\n\nWe are sorry for the trouble, please book another time in the app.",
+			res.TeeTime.Format("3:04 PM"), res.TeeTime.Format("Monday, January 2"), reason))
+	}
+}
@@ -1,12 +1,10 @@
 package account
 
 import (
-	"bigfoot/golf/common/models/db"
+	"context"
 	"fmt"
 	"log"
 	"time"
-
-	"github.com/mitchellh/mapstructure"
 )
 
 type User struct {
@@ -25,39 +23,47 @@ type User struct {
 	Avatar     string `json:"avatar"`
 	IsVerified bool   `json:"is_verified"`
 	IsAdmin    bool   `json:"is_admin"`
-	TempStr    string
+	// Roles are the staff roles granted to the user, see auth.Role
+	Roles   []string `json:"roles,omitempty"`
+	TempStr string
+}
+
+// UserStore persists users. Save creates the user when it has no ID and
+// otherwise updates it, keeping the stored password when none is given.
+type UserStore interface {
+	Save(ctx context.Context, u *User) error
+	UpdatePassword(ctx context.Context, id, password string) error
+	// Query returns the users whose json fields equal every filter
+	Query(ctx context.Context, filters map[string]interface{}) ([]User, error)
 }
 
-func (u *User) Save() error {
+// users is the store behind User.Save and QueryUsers
+var users UserStore = neo4jUserStore{}
+
+// SetUserStore replaces the store used for users
+func SetUserStore(store UserStore) {
+	users = store
+}
+
+func (u *User) Save(ctx context.Context) error {
 
 	if u.Email == "" {
 		return fmt.Errorf("no email supplied")
 	}
 	u.UpdatedAt = time.Now()
-	userID, err := db.Instance.SaveStruct(u, "User")
-	if err != nil {
+	if err := users.Save(ctx, u); err != nil {
 		log.Printf("Error saving user with relationships: %v", err)
 		return err
 	}
-	u.ID = userID
-	fmt.Printf("Saved user with relationships, ID: %s\n", userID)
+	fmt.Printf("Saved user with relationships, ID: %s\n", u.ID)
 	return nil
 }
 
-func (u *User) UpdatePW() error {
-
-	var node db.DynamicNode
+func (u *User) UpdatePW(ctx context.Context) error {
 	if u.ID == "" || u.Password == "" {
 		return fmt.Errorf("invalid identifier/password")
 	}
-
-	node.Label = "User"
-	node.Properties = make(map[string]interface{})
-	node.Properties["id"] = u.ID
-	node.Properties["password"] = u.Password
-
-	_, err := db.Instance.SaveDynamicNode(node)
-	return err
+	return users.UpdatePassword(ctx, u.ID, u.Password)
 }
 
 type Company struct {
@@ -67,37 +73,16 @@ type Company struct {
 	Location string `json:"location"`
 }
 
-func QueryUsers(query map[string]interface{}) ([]User, error) {
-	return queryUsers(query)
+func QueryUsers(ctx context.Context, query map[string]interface{}) ([]User, error) {
+	return queryUsers(ctx, query)
 }
-func QueryUser(query map[string]interface{}) (*User, error) {
-	users, err := queryUsers(query)
+func QueryUser(ctx context.Context, query map[string]interface{}) (*User, error) {
+	users, err := queryUsers(ctx, query)
 	if len(users) > 0 {
 		return &users[0], err
 	}
 	return nil, err
 }
-func queryUsers(query map[string]interface{}) ([]User, error) {
-	users, err := db.Instance.QueryNodes("User", query) // depth of 2
-	if err != nil {
-		return nil, err
-	}
-	var _users []User
-	if len(users) > 0 {
-		config := &mapstructure.DecoderConfig{
-			Result:  &_users,
-			TagName: "json", // Use JSON tags instead of mapstructure tags
-		}
-
-		decoder, err := mapstructure.NewDecoder(config)
-		if err != nil {
-			return nil, err
-		}
-
-		err = decoder.Decode(users)
-		if err != nil {
-			return nil, err
-		}
-	}
-	return _users, nil
+func queryUsers(ctx context.Context, query map[string]interface{}) ([]User, error) {
+	return users.Query(ctx, query)
 }
@@ -0,0 +1,91 @@
+package account
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// MemoryUserStore is an in-process UserStore for tests and local runs
+type MemoryUserStore struct {
+	mu     sync.Mutex
+	nextID int
+	users  []User
+}
+
+func NewMemoryUserStore() *MemoryUserStore {
+	return &MemoryUserStore{}
+}
+
+func (m *MemoryUserStore) Save(ctx context.Context, u *User) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if u.ID != "" {
+		for i := range m.users {
+			if m.users[i].ID == u.ID {
+				if u.Password == "" {
+					u.Password = m.users[i].Password
+				}
+				m.users[i] = *u
+				return nil
+			}
+		}
+	} else {
+		m.nextID++
+		u.ID = fmt.Sprintf("user-%d", m.nextID)
+	}
+	m.users = append(m.users, *u)
+	return nil
+}
+
+func (m *MemoryUserStore) UpdatePassword(ctx context.Context, id, password string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.users {
+		if m.users[i].ID == id {
+			m.users[i].Password = password
+			return nil
+		}
+	}
+	return fmt.Errorf("user %s not found", id)
+}
+
+func (m *MemoryUserStore) Query(ctx context.Context, filters map[string]interface{}) ([]User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var found []User
+	for _, u := range m.users {
+		ok, err := matchesFilters(u, filters)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			found = append(found, u)
+		}
+	}
+	return found, nil
+}
+
+// matchesFilters compares the filters against the user's json fields the way
+// a property match on the User node would
+func matchesFilters(u User, filters map[string]interface{}) (bool, error) {
+	raw, err := json.Marshal(u)
+	if err != nil {
+		return false, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return false, err
+	}
+	for key, want := range filters {
+		got, ok := fields[key]
+		if !ok || fmt.Sprint(got) != fmt.Sprint(want) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,41 @@
+package account
+
+import (
+	"bigfoot/golf/common/models/db"
+	"context"
+)
+
+// neo4jUserStore keeps users as User nodes
+type neo4jUserStore struct {
+	conn *db.Database
+}
+
+// NewNeo4jUserStore returns a UserStore backed by the connection
+func NewNeo4jUserStore(conn *db.Database) UserStore {
+	return neo4jUserStore{conn: conn}
+}
+
+func (s neo4jUserStore) Save(ctx context.Context, u *User) error {
+	userID, err := s.conn.SaveStruct(ctx, u, "User")
+	if err != nil {
+		return err
+	}
+	u.ID = userID
+	return nil
+}
+
+func (s neo4jUserStore) UpdatePassword(ctx context.Context, id, password string) error {
+	_, err := s.conn.SaveDynamicNode(ctx, db.DynamicNode{
+		Label:      "User",
+		Properties: map[string]interface{}{"id": id, "password": password},
+	})
+	return err
+}
+
+func (s neo4jUserStore) Query(ctx context.Context, filters map[string]interface{}) ([]User, error) {
+	users, err := s.conn.QueryNodes(ctx, "User", filters)
+	if err != nil {
+		return nil, err
+	}
+	return db.DecodeAll[User](users)
+}
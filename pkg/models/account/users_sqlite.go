@@ -0,0 +1,128 @@
+package account
+
+import (
+	"bigfoot/golf/common/models/db"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// filterKey is a json field name a user query may filter on
+var filterKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// sqliteUserStore keeps each user as a json record, filtered with json_extract
+type sqliteUserStore struct {
+	conn *sql.DB
+}
+
+// NewSQLiteUserStore returns a UserStore backed by the SQLite database
+func NewSQLiteUserStore(conn *sql.DB) UserStore {
+	return sqliteUserStore{conn: conn}
+}
+
+func (s sqliteUserStore) Save(ctx context.Context, u *User) error {
+	return db.Timed(ctx, func(ctx context.Context) error {
+		tx, err := s.conn.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		if u.ID == "" {
+			u.ID = db.NewID()
+		} else if u.Password == "" {
+			var password sql.NullString
+			err := tx.QueryRowContext(ctx, `SELECT json_extract(data, '$.password') FROM users WHERE id = ?`, u.ID).Scan(&password)
+			if err != nil && err != sql.ErrNoRows {
+				return err
+			}
+			u.Password = password.String
+		}
+		data, err := json.Marshal(u)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO users (id, email, data) VALUES (?, ?, ?)
+			ON CONFLICT (id) DO UPDATE SET email = excluded.email, data = excluded.data`, u.ID, u.Email, string(data)); err != nil {
+			return err
+		}
+		return tx.Commit()
+	})
+}
+
+func (s sqliteUserStore) UpdatePassword(ctx context.Context, id, password string) error {
+	var updated int64
+	err := db.Timed(ctx, func(ctx context.Context) error {
+		result, err := s.conn.ExecContext(ctx, `UPDATE users SET data = json_set(data, '$.password', ?) WHERE id = ?`, password, id)
+		if err != nil {
+			return err
+		}
+		updated, err = result.RowsAffected()
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return fmt.Errorf("user %s not found", id)
+	}
+	return nil
+}
+
+func (s sqliteUserStore) Query(ctx context.Context, filters map[string]interface{}) ([]User, error) {
+	keys := make([]string, 0, len(filters))
+	for key := range filters {
+		if !filterKey.MatchString(key) {
+			return nil, fmt.Errorf("invalid user filter %q", key)
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	query := `SELECT data FROM users`
+	var where []string
+	var args []any
+	for _, key := range keys {
+		where = append(where, `json_extract(data, ?) = ?`)
+		value := filters[key]
+		if b, ok := value.(bool); ok {
+			// json_extract reads json booleans as 1 and 0
+			value = 0
+			if b {
+				value = 1
+			}
+		}
+		args = append(args, "$."+key, value)
+	}
+	if len(where) > 0 {
+		query += ` WHERE ` + strings.Join(where, ` AND `)
+	}
+	query += ` ORDER BY rowid`
+
+	var found []User
+	err := db.Timed(ctx, func(ctx context.Context) error {
+		rows, err := s.conn.QueryContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var data string
+			if err := rows.Scan(&data); err != nil {
+				return err
+			}
+			var u User
+			if err := json.Unmarshal([]byte(data), &u); err != nil {
+				return err
+			}
+			found = append(found, u)
+		}
+		return rows.Err()
+	})
+	return found, err
+}
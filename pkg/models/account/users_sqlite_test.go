@@ -0,0 +1,79 @@
+package account
+
+import (
+	"bigfoot/golf/common/models/db"
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestSQLiteUserStore(t *testing.T) {
+	ctx := context.Background()
+	conn, err := db.OpenSQLite(context.Background(), filepath.Join(t.TempDir(), "golf.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	store := NewSQLiteUserStore(conn)
+
+	admin := User{Email: "pro@example.com", Password: "hash", IsAdmin: true}
+	golfer := User{Email: "golfer@example.com", Provider: "google"}
+	for _, u := range []*User{&admin, &golfer} {
+		if err := store.Save(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// saving without a password keeps the stored one
+	admin.FirstName = "Pat"
+	admin.Password = ""
+	if err := store.Save(ctx, &admin); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		filters map[string]interface{}
+		want    []string
+		wantErr bool
+	}{
+		{name: "no filters", filters: nil, want: []string{admin.Email, golfer.Email}},
+		{name: "by email", filters: map[string]interface{}{"email": golfer.Email}, want: []string{golfer.Email}},
+		{name: "by id", filters: map[string]interface{}{"id": admin.ID}, want: []string{admin.Email}},
+		{name: "by bool", filters: map[string]interface{}{"is_admin": true}, want: []string{admin.Email}},
+		{name: "by two fields", filters: map[string]interface{}{"provider": "google", "is_admin": false}, want: []string{golfer.Email}},
+		{name: "no match", filters: map[string]interface{}{"email": "nobody@example.com"}},
+		{name: "hostile id", filters: map[string]interface{}{"id": `x"}) DETACH DELETE n //`}},
+		{name: "hostile email", filters: map[string]interface{}{"email": "' OR '1'='1"}},
+		{name: "hostile key", filters: map[string]interface{}{"email') OR 1=1 --": "x"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found, err := store.Query(ctx, tt.filters)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if len(found) != len(tt.want) {
+				t.Fatalf("expected %d users, got %+v", len(tt.want), found)
+			}
+			for i, u := range found {
+				if u.Email != tt.want[i] {
+					t.Errorf("expected %s, got %s", tt.want[i], u.Email)
+				}
+			}
+		})
+	}
+
+	if all, _ := store.Query(ctx, nil); len(all) != 2 {
+		t.Errorf("expected hostile filters to leave both users, got %d", len(all))
+	}
+	found, _ := store.Query(ctx, map[string]interface{}{"id": admin.ID})
+	if len(found) != 1 || found[0].Password != "hash" || found[0].FirstName != "Pat" {
+		t.Errorf("expected the update to keep the password, got %+v", found)
+	}
+	if err := store.UpdatePassword(ctx, golfer.ID, "new"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.UpdatePassword(ctx, "missing", "new"); err == nil {
+		t.Error("expected updating a missing user to fail")
+	}
+}
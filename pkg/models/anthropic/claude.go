@@ -130,7 +130,7 @@ func GetAvailableTools() []Tool {
 					},
 					"slot": map[string]interface{}{
 						"type":        "integer",
-						"description": "Slot number for the tee time",
+						"description": "Slot number for the tee time; slots from 10001 start on the 10th tee",
 					},
 					"players": map[string]interface{}{
 						"type":        "integer",
@@ -138,10 +138,43 @@ func GetAvailableTools() []Tool {
 						"minimum":     1,
 						"maximum":     4,
 					},
+					"holes": map[string]interface{}{
+						"type":        "integer",
+						"description": "Round length, 9 or 18 holes (default 18)",
+						"enum":        []int{9, 18},
+					},
 				},
 				"required": []string{"date", "time", "slot", "players"},
 			},
 		},
+		{
+			Name:        "join_waitlist",
+			Description: "Put the user on the waitlist for a fully booked time window; they are offered the first spot that opens",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"date": map[string]interface{}{
+						"type":        "string",
+						"description": "Date in YYYY-MM-DD format",
+					},
+					"earliest_time": map[string]interface{}{
+						"type":        "string",
+						"description": "Earliest acceptable tee time in HH:MM format (24-hour)",
+					},
+					"latest_time": map[string]interface{}{
+						"type":        "string",
+						"description": "Latest acceptable tee time in HH:MM format (24-hour)",
+					},
+					"players": map[string]interface{}{
+						"type":        "integer",
+						"description": "Number of players (1-4)",
+						"minimum":     1,
+						"maximum":     4,
+					},
+				},
+				"required": []string{"date", "earliest_time", "latest_time", "players"},
+			},
+		},
 		{
 			Name:        "cancel_reservation",
 			Description: "Cancel an existing tee time reservation",
@@ -3,6 +3,7 @@ package anthropic
 import (
 	"bigfoot/golf/common/models/auth"
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -32,6 +33,39 @@ type MCPResponse struct {
 	Error   string      `json:"error,omitempty"`
 }
 
+// mcpServerURL is MCP_SERVER_URL, or the local proxy or server by default
+func mcpServerURL(useProxy bool) string {
+	if baseURL := os.Getenv("MCP_SERVER_URL"); baseURL != "" {
+		return baseURL
+	}
+	if useProxy {
+		return "http://localhost:8082" // Proxy server
+	}
+	return "http://localhost:8081" // Direct MCP server
+}
+
+// CheckMCPServer asks the MCP server, or the development proxy, whether it is healthy
+func CheckMCPServer(ctx context.Context) error {
+	useProxy := os.Getenv("MCP_USE_PROXY") == "true"
+	url := mcpServerURL(useProxy) + "/healthz"
+	if useProxy {
+		url = mcpServerURL(useProxy) + "/proxy/health"
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("mcp server answered %s", resp.Status)
+	}
+	return nil
+}
+
 // NewMCPClient creates a new MCP client
 func NewMCPClient(userID, userEmail string) (*MCPClient, error) {
 	// Generate JWT token for the user
@@ -42,17 +76,9 @@ func NewMCPClient(userID, userEmail string) (*MCPClient, error) {
 	
 	// Check if we should use proxy for development
 	useProxy := os.Getenv("MCP_USE_PROXY") == "true"
-	baseURL := os.Getenv("MCP_SERVER_URL")
-	if baseURL == "" {
-		if useProxy {
-			baseURL = "http://localhost:8082" // Proxy server
-		} else {
-			baseURL = "http://localhost:8081" // Direct MCP server
-		}
-	}
 	
 	return &MCPClient{
-		BaseURL:  baseURL,
+		BaseURL:  mcpServerURL(useProxy),
 		Token:    token,
 		UseProxy: useProxy,
 		HTTPClient: &http.Client{
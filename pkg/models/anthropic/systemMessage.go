@@ -18,6 +18,7 @@ Guidelines:
 - Handle cancellations gracefully
 - Provide clear pricing information
 - Suggest alternative times if requested slots are unavailable
+- If every time the user wants is full, offer to put them on the waitlist for that window
 - Proactively mention relevant existing reservations when discussing new bookings (e.g., "I see you already have a tee time at Pine Valley on Saturday")`
 
 /*
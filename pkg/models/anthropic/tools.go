@@ -4,6 +4,8 @@ import (
 	"bigfoot/golf/common/models/account"
 	"bigfoot/golf/common/models/teetimes"
 	"bigfoot/golf/common/models/weather"
+	"context"
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
@@ -16,14 +18,17 @@ type ToolExecutor struct {
 	ResDay    map[string]teetimes.ReservedDay
 	MCPClient *MCPClient
 	UseMCP    bool
+	// course is the course the tools look up and book tee times on
+	course *teetimes.Course
 }
 
-// NewToolExecutor creates a new tool executor for a user
-func NewToolExecutor(userID string) *ToolExecutor {
+// NewToolExecutor creates a new tool executor for a user booking on the course
+func NewToolExecutor(userID string, course *teetimes.Course) *ToolExecutor {
 	return &ToolExecutor{
 		UserID: userID,
 		ResDay: make(map[string]teetimes.ReservedDay),
 		UseMCP: false,
+		course: course,
 	}
 }
 
@@ -34,16 +39,18 @@ func (te *ToolExecutor) SetMCPClient(mcpClient *MCPClient) {
 }
 
 // ExecuteTool executes a tool and returns the result
-func (te *ToolExecutor) ExecuteTool(toolName string, input map[string]interface{}) (string, error) {
+func (te *ToolExecutor) ExecuteTool(ctx context.Context, toolName string, input map[string]interface{}) (string, error) {
 	switch toolName {
 	case "get_available_tee_times":
-		return te.getAvailableTeeTimes(input)
+		return te.getAvailableTeeTimes(ctx, input)
 	case "book_tee_time":
-		return te.bookTeeTime(input)
+		return te.bookTeeTime(ctx, input)
+	case "join_waitlist":
+		return te.joinWaitlist(ctx, input)
 	case "cancel_reservation":
-		return te.cancelReservation(input)
+		return te.cancelReservation(ctx, input)
 	case "get_user_reservations":
-		return te.getUserReservations(input)
+		return te.getUserReservations(ctx, input)
 	case "get_weather_forecast":
 		return te.getWeatherForecast(input)
 	default:
@@ -51,7 +58,7 @@ func (te *ToolExecutor) ExecuteTool(toolName string, input map[string]interface{
 	}
 }
 
-func (te *ToolExecutor) getAvailableTeeTimes(input map[string]interface{}) (string, error) {
+func (te *ToolExecutor) getAvailableTeeTimes(ctx context.Context, input map[string]interface{}) (string, error) {
 	dateStr, ok := input["date"].(string)
 	if !ok {
 		return "", fmt.Errorf("date parameter is required")
@@ -63,8 +70,7 @@ func (te *ToolExecutor) getAvailableTeeTimes(input map[string]interface{}) (stri
 	}
 
 	// Use the existing booking engine to get tee times
-	var booking teetimes.BookingEngine
-	days, err := booking.GetDayTeeTimes(date)
+	days, err := te.course.DayTeeTimes(ctx, date)
 	if err != nil {
 		return "", fmt.Errorf("failed to get tee times: %v", err)
 	}
@@ -79,12 +85,14 @@ func (te *ToolExecutor) getAvailableTeeTimes(input map[string]interface{}) (stri
 	for _, day := range days {
 		te.ResDay[date.Format(time.DateOnly)] = day
 		for _, slot := range day.Times {
-			if len(slot.Players) < 4 { // Only show available slots
-				result.WriteString(fmt.Sprintf("- %s | Slot %d | %d spots available | $%.2f | %s\n",
+			if slot.ID == "" && !slot.Held && slot.Open > 0 { // Only show available slots
+				result.WriteString(fmt.Sprintf("- %s | Tee %d | Slot %d | %d spots available | $%.2f (9 holes $%.2f) | %s\n",
 					slot.TeeTime.Format("3:04 PM"),
+					slot.StartingTee(),
 					slot.Slot,
-					4-len(slot.Players),
+					slot.Open,
 					slot.Price,
+					slot.NinePrice,
 					slot.Group,
 				))
 			}
@@ -98,7 +106,7 @@ func (te *ToolExecutor) getAvailableTeeTimes(input map[string]interface{}) (stri
 	return result.String(), nil
 }
 
-func (te *ToolExecutor) bookTeeTime(input map[string]interface{}) (string, error) {
+func (te *ToolExecutor) bookTeeTime(ctx context.Context, input map[string]interface{}) (string, error) {
 	dateStr, ok := input["date"].(string)
 	if !ok {
 		return "", fmt.Errorf("date parameter is required")
@@ -136,10 +144,27 @@ func (te *ToolExecutor) bookTeeTime(input map[string]interface{}) (string, error
 
 	resDay := te.ResDay[date.Format(time.DateOnly)]
 	if len(resDay.Times) > 0 {
-		reserve := resDay.GetByTime(hour, minute)
+		//the slot picks the starting tee when both tees go off at the time
+		reserve := resDay.GetBySlot(int64(slot))
+		if reserve == nil || reserve.TeeTime.Hour() != hour || reserve.TeeTime.Minute() != minute {
+			reserve = resDay.GetByTime(hour, minute)
+		}
 		if reserve != nil {
+			if holes, ok := input["holes"].(float64); ok {
+				reserve.Holes = int(holes)
+			}
 			reserve.BookingUser = &account.User{ID: te.UserID}
-			err := teetimes.BookTeeTime(*reserve)
+			reserve.Players = []account.User{*reserve.BookingUser}
+			for i := 1; i < int(players); i++ {
+				reserve.Players = append(reserve.Players, account.User{LastName: fmt.Sprintf("Guest %d", i)})
+			}
+			_, err := te.course.PriceReservation(ctx, reserve, time.Now())
+			if err == nil {
+				err = te.course.BookTeeTime(ctx, reserve)
+			}
+			if errors.Is(err, teetimes.ErrSlotUnavailable) {
+				return fmt.Sprintf("Unable to book: %v. Please choose another tee time.", err), nil
+			}
 			if err != nil {
 				return "Problem with the Booking Engine", err
 			}
@@ -151,14 +176,54 @@ func (te *ToolExecutor) bookTeeTime(input map[string]interface{}) (string, error
 		dateStr, timeStr, int(slot), int(players)), nil
 }
 
-func (te *ToolExecutor) cancelReservation(input map[string]interface{}) (string, error) {
+func (te *ToolExecutor) joinWaitlist(ctx context.Context, input map[string]interface{}) (string, error) {
+	dateStr, ok := input["date"].(string)
+	if !ok {
+		return "", fmt.Errorf("date parameter is required")
+	}
+	earliestStr, ok := input["earliest_time"].(string)
+	if !ok {
+		return "", fmt.Errorf("earliest_time parameter is required")
+	}
+	latestStr, ok := input["latest_time"].(string)
+	if !ok {
+		return "", fmt.Errorf("latest_time parameter is required")
+	}
+	players, ok := input["players"].(float64)
+	if !ok {
+		return "", fmt.Errorf("players parameter is required")
+	}
+
+	earliest, err := time.ParseInLocation("2006-01-02 15:04", dateStr+" "+earliestStr, time.Local)
+	if err != nil {
+		return "", fmt.Errorf("invalid date or earliest_time: %s %s", dateStr, earliestStr)
+	}
+	latest, err := time.ParseInLocation("2006-01-02 15:04", dateStr+" "+latestStr, time.Local)
+	if err != nil {
+		return "", fmt.Errorf("invalid date or latest_time: %s %s", dateStr, latestStr)
+	}
+
+	entry := teetimes.WaitlistEntry{UserID: te.UserID, Earliest: earliest, Latest: latest, Players: int64(players)}
+	if err := te.course.JoinWaitlist(ctx, &entry); err != nil {
+		return fmt.Sprintf("Unable to join the waitlist: %v", err), nil
+	}
+
+	return fmt.Sprintf("You are on the waitlist for %s between %s and %s for %d players. If a spot opens we will hold it for you for %d minutes and email you to accept it.",
+		earliest.Format("January 2, 2006"),
+		earliest.Format("3:04 PM"),
+		latest.Format("3:04 PM"),
+		entry.Players,
+		int(teetimes.WaitlistOfferTTL.Minutes())), nil
+}
+
+func (te *ToolExecutor) cancelReservation(ctx context.Context, input map[string]interface{}) (string, error) {
 	reservationID, ok := input["reservation_id"].(string)
 	if !ok {
 		return "", fmt.Errorf("reservation_id parameter is required")
 	}
 
 	// Get user's reservations first to verify ownership
-	reservations, err := teetimes.GetUserReservations(te.UserID, false)
+	reservations, err := te.course.UserReservations(ctx, te.UserID, false)
 	if err != nil {
 		return "", fmt.Errorf("failed to verify reservation ownership: %v", err)
 	}
@@ -175,7 +240,7 @@ func (te *ToolExecutor) cancelReservation(input map[string]interface{}) (string,
 		return "", fmt.Errorf("reservation not found or not owned by user")
 	}
 
-	err = targetReservation.Cancel()
+	err = te.course.Cancel(ctx, targetReservation)
 	if err != nil {
 		return "", fmt.Errorf("failed to cancel reservation: %v", err)
 	}
@@ -185,13 +250,13 @@ func (te *ToolExecutor) cancelReservation(input map[string]interface{}) (string,
 		targetReservation.TeeTime.Format("3:04 PM")), nil
 }
 
-func (te *ToolExecutor) getUserReservations(input map[string]interface{}) (string, error) {
+func (te *ToolExecutor) getUserReservations(ctx context.Context, input map[string]interface{}) (string, error) {
 	includePast := false
 	if val, exists := input["include_past"]; exists {
 		includePast = val.(bool)
 	}
 
-	reservations, err := teetimes.GetUserReservations(te.UserID, includePast)
+	reservations, err := te.course.UserReservations(ctx, te.UserID, includePast)
 	if err != nil {
 		return "", fmt.Errorf("failed to get reservations: %v", err)
 	}
@@ -278,16 +343,14 @@ func (te *ToolExecutor) getWeatherForecast(input map[string]interface{}) (string
 	return result.String(), nil
 }
 
-// GetTeeTimeContext gets the next 2 days of tee times for system context
-func GetTeeTimeContext() string {
+// GetTeeTimeContext gets the next 2 days of tee times on the course for system context
+func GetTeeTimeContext(ctx context.Context, course *teetimes.Course) string {
 	var result strings.Builder
 	result.WriteString("Available tee times for the next 2 days:\n\n")
 
-	var booking teetimes.BookingEngine
-
 	for i := 0; i < 2; i++ {
 		date := time.Now().AddDate(0, 0, i)
-		days, err := booking.GetDayTeeTimes(date)
+		days, err := course.DayTeeTimes(ctx, date)
 		if err != nil {
 			continue
 		}
@@ -302,10 +365,10 @@ func GetTeeTimeContext() string {
 		availableCount := 0
 		for _, day := range days {
 			for _, slot := range day.Times {
-				if len(slot.Players) < 4 && availableCount < 10 { // Limit to 10 per day for context
+				if slot.ID == "" && !slot.Held && slot.Open > 0 && availableCount < 10 { // Limit to 10 per day for context
 					result.WriteString(fmt.Sprintf("  - %s | %d spots | $%.2f\n",
 						slot.TeeTime.Format("3:04 PM"),
-						4-len(slot.Players),
+						slot.Open,
 						slot.Price,
 					))
 					availableCount++
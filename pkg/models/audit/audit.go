@@ -0,0 +1,122 @@
+// Package audit keeps an append-only history of changes to reservations,
+// seasons, block settings and user roles: who changed what, when, and the
+// entity before and after.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+)
+
+// Entity types with a history
+const (
+	Reservation  = "reservation"
+	Season       = "season"
+	BlockSetting = "blockSetting"
+	// User records changes to a user's roles
+	User = "user"
+)
+
+// EntityTypes are the entity types History can be asked for
+var EntityTypes = map[string]bool{Reservation: true, Season: true, BlockSetting: true, User: true}
+
+// Actions recorded on a ChangeEvent
+const (
+	Created   = "created"
+	Updated   = "updated"
+	Moved     = "moved"
+	Cancelled = "cancelled"
+)
+
+// System is the actor for changes made without a signed in user, such as the
+// standing tee time booker
+const System = "system"
+
+// ChangeEvent records one change to an entity. Before and After hold the
+// entity as json; Before is empty for a create.
+type ChangeEvent struct {
+	ID         string    `json:"id"`
+	EntityType string    `json:"entityType"`
+	EntityID   string    `json:"entityId"`
+	Action     string    `json:"action"`
+	Actor      string    `json:"actor"`
+	At         time.Time `json:"at"`
+	Before     string    `json:"before,omitempty" neo4j:",omitempty"`
+	After      string    `json:"after,omitempty" neo4j:",omitempty"`
+}
+
+// Store appends change events and reads them back. There is no way to change
+// or remove an event once appended.
+type Store interface {
+	Append(ctx context.Context, event *ChangeEvent) error
+	// History returns the entity's events oldest first
+	History(ctx context.Context, entityType, entityID string) ([]ChangeEvent, error)
+}
+
+// store is the store Record appends to
+var store Store = NewMemoryStore()
+
+// SetStore replaces the store used for change events
+func SetStore(s Store) {
+	store = s
+}
+
+type actorKey struct{}
+
+// WithActor returns a context whose changes are recorded as made by the user
+func WithActor(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, actorKey{}, userID)
+}
+
+// Actor returns the user making changes with the context, or System
+func Actor(ctx context.Context) string {
+	if userID, ok := ctx.Value(actorKey{}).(string); ok && userID != "" {
+		return userID
+	}
+	return System
+}
+
+// Record appends an event for the change made with the context. before is nil
+// for a create. The change itself has already been saved, so a failure to
+// record it is logged rather than returned.
+func Record(ctx context.Context, entityType, entityID, action string, before, after any) {
+	event := ChangeEvent{
+		EntityType: entityType,
+		EntityID:   entityID,
+		Action:     action,
+		Actor:      Actor(ctx),
+		At:         time.Now(),
+	}
+	var err error
+	if event.Before, err = snapshot(before); err == nil {
+		event.After, err = snapshot(after)
+	}
+	if err == nil {
+		err = store.Append(ctx, &event)
+	}
+	if err != nil {
+		log.Printf("Error recording %s of %s %s: %v", action, entityType, entityID, err)
+	}
+}
+
+// History returns the changes made to the entity, oldest first
+func History(ctx context.Context, entityType, entityID string) ([]ChangeEvent, error) {
+	events, err := store.History(ctx, entityType, entityID)
+	if err != nil {
+		return nil, err
+	}
+	if events == nil {
+		return []ChangeEvent{}, nil
+	}
+	return events, nil
+}
+
+func snapshot(v any) (string, error) {
+	if v == nil {
+		return "", nil
+	}
+	data, err := json.Marshal(v)
+	return string(data), err
+}
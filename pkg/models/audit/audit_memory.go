@@ -0,0 +1,37 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// MemoryStore is an in-process Store for tests and local runs
+type MemoryStore struct {
+	mu     sync.Mutex
+	events []ChangeEvent
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+func (m *MemoryStore) Append(ctx context.Context, event *ChangeEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	event.ID = fmt.Sprintf("event-%d", len(m.events)+1)
+	m.events = append(m.events, *event)
+	return nil
+}
+
+func (m *MemoryStore) History(ctx context.Context, entityType, entityID string) ([]ChangeEvent, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var found []ChangeEvent
+	for _, event := range m.events {
+		if event.EntityType == entityType && event.EntityID == entityID {
+			found = append(found, event)
+		}
+	}
+	return found, nil
+}
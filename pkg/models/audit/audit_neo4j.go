@@ -0,0 +1,31 @@
+package audit
+
+import (
+	"bigfoot/golf/common/models/db"
+	"context"
+)
+
+// neo4jStore keeps each change as a ChangeEvent node
+type neo4jStore struct {
+	conn *db.Database
+}
+
+// NewNeo4jStore returns a Store backed by the connection
+func NewNeo4jStore(conn *db.Database) Store {
+	return neo4jStore{conn: conn}
+}
+
+func (s neo4jStore) Append(ctx context.Context, event *ChangeEvent) error {
+	event.ID = ""
+	id, err := s.conn.SaveStruct(ctx, event, "ChangeEvent")
+	if err != nil {
+		return err
+	}
+	event.ID = id
+	return nil
+}
+
+func (s neo4jStore) History(ctx context.Context, entityType, entityID string) ([]ChangeEvent, error) {
+	return db.QueryAs[ChangeEvent](ctx, s.conn, `MATCH (e:ChangeEvent {entityType: $entityType, entityId: $entityID})
+		RETURN e{.*} AS data ORDER BY e.at, e.id`, map[string]any{"entityType": entityType, "entityID": entityID})
+}
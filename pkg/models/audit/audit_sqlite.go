@@ -0,0 +1,56 @@
+package audit
+
+import (
+	"bigfoot/golf/common/models/db"
+	"context"
+	"database/sql"
+	"encoding/json"
+)
+
+// sqliteStore keeps each change as a json record in change_events
+type sqliteStore struct {
+	conn *sql.DB
+}
+
+// NewSQLiteStore returns a Store backed by the SQLite database
+func NewSQLiteStore(conn *sql.DB) Store {
+	return sqliteStore{conn: conn}
+}
+
+func (s sqliteStore) Append(ctx context.Context, event *ChangeEvent) error {
+	event.ID = db.NewID()
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return db.Timed(ctx, func(ctx context.Context) error {
+		_, err := s.conn.ExecContext(ctx, `INSERT INTO change_events (id, entity_type, entity_id, at, data) VALUES (?, ?, ?, ?, ?)`,
+			event.ID, event.EntityType, event.EntityID, event.At.UnixNano(), string(data))
+		return err
+	})
+}
+
+func (s sqliteStore) History(ctx context.Context, entityType, entityID string) ([]ChangeEvent, error) {
+	var events []ChangeEvent
+	err := db.Timed(ctx, func(ctx context.Context) error {
+		rows, err := s.conn.QueryContext(ctx, `SELECT data FROM change_events
+			WHERE entity_type = ? AND entity_id = ? ORDER BY at, id`, entityType, entityID)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var data string
+			if err := rows.Scan(&data); err != nil {
+				return err
+			}
+			var event ChangeEvent
+			if err := json.Unmarshal([]byte(data), &event); err != nil {
+				return err
+			}
+			events = append(events, event)
+		}
+		return rows.Err()
+	})
+	return events, err
+}
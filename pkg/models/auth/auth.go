@@ -2,9 +2,11 @@ package auth
 
 import (
 	"bigfoot/golf/common/models/account"
+	"bigfoot/golf/common/models/audit"
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/rand/v2"
 	"net/http"
@@ -13,6 +15,7 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/mux"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -44,6 +47,7 @@ type AuthResponse struct {
 	User         account.User `json:"user"`
 	ExpiresIn    time.Time    `json:"expiresIn"`
 	AuthLevel    AuthLevel    `json:"authLevel"`
+	Roles        []Role       `json:"roles,omitempty"`
 }
 type AuthLevel int
 
@@ -107,18 +111,26 @@ type OAuthConfig struct {
 	UserInfoURL  string `json:"userInfoURL"`
 }
 
-type reqKey int
-
-const key reqKey = iota
-
 // JWT Claims
 type Claims struct {
 	UserID string `json:"user_id"`
 	Email  string `json:"email"`
-	Elev   bool   `json:"elev"`
+	// Elev marks a short-lived token issued by a step up, which sensitive
+	// actions require
+	Elev bool `json:"elev"`
+	// SessionID is the refresh session the token was issued to
+	SessionID string `json:"sid,omitempty"`
+	// Roles are the user's roles when the token was issued
+	Roles []Role `json:"roles,omitempty"`
+	// Use is "refresh" on a refresh token and "reset" on a password reset
+	// token; neither can authenticate a request
+	Use string `json:"use,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// refreshUse marks a refresh token's claims
+const refreshUse = "refresh"
+
 // Register a new user
 func (s *AuthServer) HandleRegister(w http.ResponseWriter, r *http.Request) {
 	var req account.User
@@ -132,9 +144,13 @@ func (s *AuthServer) HandleRegister(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Email and password are required", http.StatusBadRequest)
 		return
 	}
+	if err := ValidatePassword(req.Password, req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	// Check if user already exists
-	users, err := account.QueryUsers(map[string]interface{}{
+	users, err := account.QueryUsers(r.Context(), map[string]interface{}{
 		"email": req.Email,
 	})
 	if err != nil {
@@ -167,13 +183,13 @@ func (s *AuthServer) HandleRegister(w http.ResponseWriter, r *http.Request) {
 		UpdatedAt: time.Now(),
 	}
 
-	if err := user.Save(); err != nil {
+	if err := user.Save(r.Context()); err != nil {
 		http.Error(w, "Failed to create user", http.StatusInternalServerError)
 		return
 	}
 
 	// Generate tokens
-	response, err := s.generateTokens(user)
+	response, err := s.signIn(r, user)
 	if err != nil {
 		http.Error(w, "Failed to generate tokens", http.StatusInternalServerError)
 		return
@@ -194,7 +210,7 @@ func (s AuthServer) HandleLogin(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Find user
-	user, err := account.QueryUser(map[string]interface{}{"email": req.Email})
+	user, err := account.QueryUser(r.Context(), map[string]interface{}{"email": req.Email})
 	if err != nil || user == nil {
 		http.Error(w, "Invalid credentials", http.StatusUnauthorized)
 		return
@@ -207,7 +223,7 @@ func (s AuthServer) HandleLogin(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Generate tokens
-	response, err := s.generateTokens(*user)
+	response, err := s.signIn(r, *user)
 	if err != nil {
 		http.Error(w, "Failed to generate tokens", http.StatusInternalServerError)
 		return
@@ -255,14 +271,14 @@ func (s AuthServer) HandleGoogleCallback(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Create or update user
-	user, err := s.createOrUpdateOAuthUser(userInfo, "google")
+	user, err := s.createOrUpdateOAuthUser(r.Context(), userInfo, "google")
 	if err != nil {
 		http.Error(w, "Failed to create/update user", http.StatusInternalServerError)
 		return
 	}
 
 	// Generate tokens
-	response, err := s.generateTokens(*user)
+	response, err := s.signIn(r, *user)
 	if err != nil {
 		http.Error(w, "Failed to generate tokens", http.StatusInternalServerError)
 		return
@@ -311,14 +327,14 @@ func (s AuthServer) HandleAppleCallback(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Create or update user
-	user, err := s.createOrUpdateAppleUser(userInfo)
+	user, err := s.createOrUpdateAppleUser(r.Context(), userInfo)
 	if err != nil {
 		http.Error(w, "Failed to create/update user", http.StatusInternalServerError)
 		return
 	}
 
 	// Generate tokens
-	response, err := s.generateTokens(*user)
+	response, err := s.signIn(r, *user)
 	if err != nil {
 		http.Error(w, "Failed to generate tokens", http.StatusInternalServerError)
 		return
@@ -332,41 +348,51 @@ func (s AuthServer) HandleAppleCallback(w http.ResponseWriter, r *http.Request)
 
 // Get current user info
 func (s AuthServer) HandleMe(w http.ResponseWriter, r *http.Request) {
-	userID := r.Context().Value("user_id").(string)
-
-	user, err := account.QueryUser(map[string]interface{}{"iD": userID})
+	userID, err := ActingUser(r.Context())
 	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	user, err := account.QueryUser(r.Context(), map[string]interface{}{"id": userID})
+	if err != nil || user == nil {
 		http.Error(w, "User not found", http.StatusNotFound)
 		return
 	}
+	user.Password, user.TempStr = "", ""
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(user)
 }
 
-// Refresh JWT token
+// HandleRefreshToken spends the refresh token from the body or the bftapc
+// cookie for a new pair. A token that was already spent revokes its session.
 func (s AuthServer) HandleRefreshToken(w http.ResponseWriter, r *http.Request) {
-	var req struct {
-		RefreshToken string `json:"refresh_token"`
-	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	claims, err := s.refreshClaims(r)
+	if err != nil {
+		http.Error(w, "Invalid refresh token", http.StatusUnauthorized)
 		return
 	}
 
-	// Validate refresh token (simplified - in production, store and validate properly)
-	claims := &Claims{}
-	token, err := jwt.ParseWithClaims(req.RefreshToken, claims, func(token *jwt.Token) (interface{}, error) {
-		return s.jwtSecret, nil
-	})
+	session, tokenID, err := rotateSession(r, claims)
+	if errors.Is(err, ErrSessionNotFound) || errors.Is(err, ErrTokenReused) {
+		http.Error(w, "Invalid refresh token", http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to refresh session", http.StatusInternalServerError)
+		return
+	}
 
-	if err != nil || !token.Valid {
+	// Take the user from the store so a change to the admin flag applies
+	user, err := account.QueryUser(r.Context(), map[string]interface{}{"id": claims.UserID})
+	if err != nil || user == nil {
 		http.Error(w, "Invalid refresh token", http.StatusUnauthorized)
 		return
 	}
 
 	// Generate new tokens
-	response, err := s.generateTokens(account.User{ID: claims.UserID, Email: claims.Email, IsAdmin: claims.Elev})
+	response, err := s.generateTokens(*user, session, tokenID)
 	if err != nil {
 		http.Error(w, "Failed to generate tokens", http.StatusInternalServerError)
 		return
@@ -378,14 +404,120 @@ func (s AuthServer) HandleRefreshToken(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
+// HandleLogout revokes the session of the refresh token in the body or the
+// bftapc cookie and clears the cookie. It succeeds for an unknown token too.
+func (s AuthServer) HandleLogout(w http.ResponseWriter, r *http.Request) {
+	if claims, err := s.refreshClaims(r); err == nil {
+		err := RevokeSession(r.Context(), claims.UserID, claims.SessionID, RevokedLogout)
+		if err != nil && !errors.Is(err, ErrSessionNotFound) {
+			http.Error(w, "Failed to log out", http.StatusInternalServerError)
+			return
+		}
+	}
+	http.SetCookie(w, &http.Cookie{Name: "bftapc", Value: "", Path: "/", HttpOnly: true, MaxAge: -1})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleSessions lists the signed in user's sessions, marking the one making the request
+func (s AuthServer) HandleSessions(w http.ResponseWriter, r *http.Request) {
+	claims, ok := ClaimsFrom(r.Context())
+	if !ok {
+		http.Error(w, ErrNotAuthenticated.Error(), http.StatusUnauthorized)
+		return
+	}
+	list, err := UserSessions(r.Context(), claims.UserID)
+	if err != nil {
+		http.Error(w, "Error listing sessions", http.StatusInternalServerError)
+		return
+	}
+	for i := range list {
+		list[i].Current = list[i].ID == claims.SessionID
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// HandleRevokeSession signs one of the user's sessions out
+func (s AuthServer) HandleRevokeSession(w http.ResponseWriter, r *http.Request) {
+	userID, err := ActingUser(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	err = RevokeSession(r.Context(), userID, mux.Vars(r)["id"], RevokedByUser)
+	if errors.Is(err, ErrSessionNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Error revoking session", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleRevokeAllSessions signs the user out everywhere, this device included
+func (s AuthServer) HandleRevokeAllSessions(w http.ResponseWriter, r *http.Request) {
+	userID, err := ActingUser(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	revoked, err := RevokeUserSessions(r.Context(), userID, RevokedSignOut)
+	if err != nil {
+		http.Error(w, "Error revoking sessions", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{"revoked": revoked})
+}
+
+// refreshClaims verifies the refresh token in the body, or failing that the bftapc cookie
+func (s AuthServer) refreshClaims(r *http.Request) (*Claims, error) {
+	var req struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+	if req.RefreshToken == "" {
+		if cookie, err := r.Cookie("bftapc"); err == nil {
+			req.RefreshToken = cookie.Value
+		}
+	}
+
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(req.RefreshToken, claims, func(token *jwt.Token) (interface{}, error) {
+		return s.jwtSecret, nil
+	})
+	if err != nil || !token.Valid {
+		return nil, errors.New("invalid refresh token")
+	}
+	if claims.Use != refreshUse || claims.SessionID == "" || claims.ID == "" {
+		return nil, errors.New("not a refresh token")
+	}
+	return claims, nil
+}
+
 // Helper functions
 
-func (s AuthServer) generateTokens(user account.User) (*AuthResponse, error) {
+// signIn starts a refresh session for the user on the request's device and
+// returns its first tokens
+func (s AuthServer) signIn(r *http.Request, user account.User) (*AuthResponse, error) {
+	session, tokenID, err := startSession(r, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	return s.generateTokens(user, session, tokenID)
+}
+
+// generateTokens returns an access token and the session's refresh token with the id
+func (s AuthServer) generateTokens(user account.User, session *RefreshSession, tokenID string) (*AuthResponse, error) {
+	roles := UserRoles(user)
 	// Access token (15 minutes)
 	accessClaims := &Claims{
-		UserID: user.ID,
-		Email:  user.Email,
-		Elev:   user.IsAdmin,
+		UserID:    user.ID,
+		Email:     user.Email,
+		Roles:     roles,
+		SessionID: session.ID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(15 * time.Minute)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -398,13 +530,16 @@ func (s AuthServer) generateTokens(user account.User) (*AuthResponse, error) {
 		return nil, err
 	}
 
-	// Refresh token (7 days)
+	// Refresh token, used once and lasting until the session expires
 	refreshClaims := &Claims{
-		UserID: user.ID,
-		Email:  user.Email,
-		Elev:   user.IsAdmin,
+		UserID:    user.ID,
+		Email:     user.Email,
+		Roles:     roles,
+		SessionID: session.ID,
+		Use:       refreshUse,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(7 * 24 * time.Hour)),
+			ID:        tokenID,
+			ExpiresAt: jwt.NewNumericDate(session.ExpiresAt),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
@@ -414,12 +549,14 @@ func (s AuthServer) generateTokens(user account.User) (*AuthResponse, error) {
 	if err != nil {
 		return nil, err
 	}
+	user.Password, user.TempStr = "", ""
 	_resp := AuthResponse{
 		Token:        accessTokenString,
 		RefreshToken: refreshTokenString,
 		User:         user,
 		ExpiresIn:    accessClaims.ExpiresAt.Time,
-		AuthLevel:    LoginLevel,
+		AuthLevel:    LevelOf(roles),
+		Roles:        roles,
 	}
 	return &_resp, nil
 }
@@ -496,7 +633,7 @@ func (s AuthServer) decodeAppleIDToken(idToken string) (*AppleUserInfo, error) {
 	return &userInfo, nil
 }
 
-func (s AuthServer) createOrUpdateOAuthUser(userInfo *GoogleUserInfo, provider string) (*account.User, error) {
+func (s AuthServer) createOrUpdateOAuthUser(ctx context.Context, userInfo *GoogleUserInfo, provider string) (*account.User, error) {
 	var user account.User
 	//err := s.db.Where("provider = ? AND provider_id = ?", provider, userInfo.ID).First(&user).Error
 
@@ -504,7 +641,7 @@ func (s AuthServer) createOrUpdateOAuthUser(userInfo *GoogleUserInfo, provider s
 	_query["provider"] = provider
 	_query["provider_id"] = userInfo.ID
 
-	users, err := account.QueryUsers(_query)
+	users, err := account.QueryUsers(ctx, _query)
 	if err != nil {
 		return nil, err
 	}
@@ -530,7 +667,7 @@ func (s AuthServer) createOrUpdateOAuthUser(userInfo *GoogleUserInfo, provider s
 			}
 		}
 
-		if err := user.Save(); err != nil {
+		if err := user.Save(ctx); err != nil {
 			return nil, err
 		}
 	}
@@ -538,12 +675,12 @@ func (s AuthServer) createOrUpdateOAuthUser(userInfo *GoogleUserInfo, provider s
 	return &user, nil
 }
 
-func (s AuthServer) createOrUpdateAppleUser(userInfo *AppleUserInfo) (*account.User, error) {
+func (s AuthServer) createOrUpdateAppleUser(ctx context.Context, userInfo *AppleUserInfo) (*account.User, error) {
 	_query := make(map[string]interface{})
 	_query["provider"] = "apple"
 	_query["provider_id"] = userInfo.Sub
 
-	users, err := account.QueryUsers(_query)
+	users, err := account.QueryUsers(ctx, _query)
 	if err != nil {
 		return nil, err
 	}
@@ -559,7 +696,7 @@ func (s AuthServer) createOrUpdateAppleUser(userInfo *AppleUserInfo) (*account.U
 			user.IsVerified = userInfo.EmailVerified == "true"
 			user.UpdatedAt = time.Now()
 
-			user.Save()
+			user.Save(ctx)
 			return &user, nil
 		}
 	}
@@ -575,7 +712,7 @@ func (s AuthServer) createOrUpdateAppleUser(userInfo *AppleUserInfo) (*account.U
 		CreatedAt:  time.Now(),
 		UpdatedAt:  time.Now(),
 	}
-	if err := user.Save(); err != nil {
+	if err := user.Save(ctx); err != nil {
 		return nil, err
 	}
 
@@ -591,8 +728,22 @@ func generateRandomString(n int) string {
 	return string(b)
 }
 
-// Authentication middleware
+// AuthenticateMiddleware lets through any signed in user, or with admin only
+// users whose roles reach the course admin's permissions. Routes needing a
+// particular permission use Require.
 func (s AuthServer) AuthenticateMiddleware(admin bool, next http.HandlerFunc) http.HandlerFunc {
+	if admin {
+		return s.authenticate(PermManagePricing, next)
+	}
+	return s.authenticate(PermBook, next)
+}
+
+// Require returns middleware letting through only users whose roles have the permission
+func (s AuthServer) Require(perm Permission, next http.HandlerFunc) http.HandlerFunc {
+	return s.authenticate(perm, next)
+}
+
+func (s AuthServer) authenticate(perm Permission, next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
@@ -611,19 +762,29 @@ func (s AuthServer) AuthenticateMiddleware(admin bool, next http.HandlerFunc) ht
 			return s.jwtSecret, nil
 		})
 
-		if err != nil || !token.Valid {
+		if err != nil || !token.Valid || claims.Use != "" {
 			http.Error(w, "Invalid token", http.StatusUnauthorized)
 			return
 		}
-		//check for admin access
-		if admin && !claims.Elev {
-			http.Error(w, "NoAdmin Access", http.StatusUnauthorized)
+		// a token is only good while the session it was issued to is
+		active, err := sessionActive(r.Context(), claims)
+		if err != nil {
+			http.Error(w, "Error checking session", http.StatusInternalServerError)
+			return
+		}
+		if !active {
+			http.Error(w, "Session ended", http.StatusUnauthorized)
+			return
+		}
+		if !Can(claims.roles(), perm) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
 			return
 		}
 
-		// Add user ID to context
-		ctx := r.Context()
-		ctx = context.WithValue(ctx, key, claims.UserID)
+		// Handlers take the acting user from the claims, never from the request
+		ctx := WithClaims(r.Context(), claims)
+		// Changes made by the request are recorded as the user's
+		ctx = audit.WithActor(ctx, claims.UserID)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	}
 }
@@ -0,0 +1,144 @@
+package auth
+
+import (
+	"bigfoot/golf/common/models/account"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// useMemoryStores swaps the user, auth config, session and authenticator stores for in-memory ones until the test ends
+func useMemoryStores(t *testing.T) *account.MemoryUserStore {
+	t.Helper()
+	users := account.NewMemoryUserStore()
+	account.SetUserStore(users)
+	previous := configs
+	previousSessions := sessions
+	previousTOTPs := totps
+	SetConfigStore(NewMemoryConfigStore())
+	SetSessionStore(NewMemorySessionStore())
+	SetTOTPStore(NewMemoryTOTPStore())
+	t.Cleanup(func() {
+		SetConfigStore(previous)
+		SetSessionStore(previousSessions)
+		SetTOTPStore(previousTOTPs)
+	})
+	return users
+}
+
+// sessionFor stores an active refresh session for the user, for tokens issued to it
+func sessionFor(t *testing.T, userID string) *RefreshSession {
+	t.Helper()
+	session := &RefreshSession{UserID: userID, CreatedAt: time.Now(), ExpiresAt: time.Now().Add(time.Hour)}
+	if err := sessions.Create(context.Background(), session); err != nil {
+		t.Fatal(err)
+	}
+	return session
+}
+
+func TestInitAuthKeepsConfig(t *testing.T) {
+	ctx := context.Background()
+	useMemoryStores(t)
+
+	first := InitAuth(ctx)
+	second := InitAuth(ctx)
+	if len(first.jwtSecret) != 32 || !bytes.Equal(first.jwtSecret, second.jwtSecret) {
+		t.Errorf("expected the saved secret to be reused, got %x then %x", first.jwtSecret, second.jwtSecret)
+	}
+}
+
+func TestRegisterAndLogin(t *testing.T) {
+	useMemoryStores(t)
+	srv := AuthServer{jwtSecret: []byte("test-secret")}
+	post := func(handler http.HandlerFunc, body any) *httptest.ResponseRecorder {
+		payload, _ := json.Marshal(body)
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest("POST", "/auth", bytes.NewReader(payload)))
+		return rec
+	}
+
+	tests := []struct {
+		name     string
+		handler  http.HandlerFunc
+		body     any
+		wantCode int
+	}{
+		{"registers a new golfer", srv.HandleRegister, account.User{Email: "golfer@example.com", Password: "birdie-putt", LastName: "Golfer"}, http.StatusOK},
+		{"rejects a second account for the email", srv.HandleRegister, account.User{Email: "golfer@example.com", Password: "another-putt"}, http.StatusConflict},
+		{"rejects a weak password", srv.HandleRegister, account.User{Email: "weak@example.com", Password: "password"}, http.StatusBadRequest},
+		{"rejects a registration without a password", srv.HandleRegister, account.User{Email: "new@example.com"}, http.StatusBadRequest},
+		{"logs in with the password", srv.HandleLogin, LoginRequest{Email: "golfer@example.com", Password: "birdie-putt"}, http.StatusOK},
+		{"rejects the wrong password", srv.HandleLogin, LoginRequest{Email: "golfer@example.com", Password: "bogey"}, http.StatusUnauthorized},
+		{"rejects an unknown email", srv.HandleLogin, LoginRequest{Email: "nobody@example.com", Password: "birdie-putt"}, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := post(tt.handler, tt.body); rec.Code != tt.wantCode {
+				t.Errorf("expected %d, got %d: %s", tt.wantCode, rec.Code, rec.Body.String())
+			}
+		})
+	}
+
+	rec := post(srv.HandleLogin, LoginRequest{Email: "golfer@example.com", Password: "birdie-putt"})
+	var login AuthResponse
+	if err := json.NewDecoder(rec.Body).Decode(&login); err != nil || login.Token == "" || login.User.ID == "" {
+		t.Fatalf("expected a token for the golfer, got %s", rec.Body.String())
+	}
+
+	req := httptest.NewRequest("GET", "/auth/me", nil)
+	req = req.WithContext(WithClaims(req.Context(), &Claims{UserID: login.User.ID}))
+	me := httptest.NewRecorder()
+	srv.HandleMe(me, req)
+	var user account.User
+	if err := json.NewDecoder(me.Body).Decode(&user); err != nil || user.ID != login.User.ID || user.LastName != "Golfer" || user.Password != "" {
+		t.Errorf("expected /auth/me to return the golfer, got %s", me.Body.String())
+	}
+}
+
+func TestAuthenticateMiddlewareClaims(t *testing.T) {
+	useMemoryStores(t)
+	srv := AuthServer{jwtSecret: []byte("test-secret")}
+	golfer, _ := srv.generateTokens(account.User{ID: "golfer", Email: "golfer@example.com"}, sessionFor(t, "golfer"), "token-1")
+	admin, _ := srv.generateTokens(account.User{ID: "pro", Email: "pro@example.com", IsAdmin: true}, sessionFor(t, "pro"), "token-2")
+
+	tests := []struct {
+		name      string
+		admin     bool
+		token     string
+		spoofID   string
+		wantCode  int
+		wantActor string
+		wantAdmin bool
+	}{
+		{name: "takes the user from the token, not the header", token: golfer.Token, spoofID: "pro", wantCode: http.StatusOK, wantActor: "golfer"},
+		{name: "marks an elevated token as admin", admin: true, token: admin.Token, wantCode: http.StatusOK, wantActor: "pro", wantAdmin: true},
+		{name: "forbids a golfer on an admin route", admin: true, token: golfer.Token, wantCode: http.StatusForbidden},
+		{name: "rejects a request without a token", spoofID: "golfer", wantCode: http.StatusUnauthorized},
+		{name: "rejects a refresh token", token: golfer.RefreshToken, wantCode: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var actor string
+			var isAdmin bool
+			handler := srv.AuthenticateMiddleware(tt.admin, func(w http.ResponseWriter, r *http.Request) {
+				actor, _ = ActingUser(r.Context())
+				isAdmin = Allowed(r.Context(), PermActForGolfers)
+			})
+			req := httptest.NewRequest("GET", "/api", nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			req.Header.Set("X-User-ID", tt.spoofID)
+			req.Header.Set("X-User-Admin", "true")
+			rec := httptest.NewRecorder()
+			handler(rec, req)
+			if rec.Code != tt.wantCode || actor != tt.wantActor || isAdmin != tt.wantAdmin {
+				t.Errorf("expected %d as %q (admin %v), got %d as %q (admin %v)", tt.wantCode, tt.wantActor, tt.wantAdmin, rec.Code, actor, isAdmin)
+			}
+		})
+	}
+}
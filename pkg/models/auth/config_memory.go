@@ -0,0 +1,39 @@
+package auth
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryConfigStore is an in-process ConfigStore for tests and local runs
+type MemoryConfigStore struct {
+	mu     sync.Mutex
+	config *AuthConfig
+}
+
+func NewMemoryConfigStore() *MemoryConfigStore {
+	return &MemoryConfigStore{}
+}
+
+func (m *MemoryConfigStore) Save(ctx context.Context, config *AuthConfig) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if config.ID == "" {
+		config.ID = "auth-config"
+	}
+	saved := *config
+	m.config = &saved
+	return nil
+}
+
+func (m *MemoryConfigStore) Load(ctx context.Context) (*AuthConfig, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.config == nil {
+		return nil, nil
+	}
+	loaded := *m.config
+	return &loaded, nil
+}
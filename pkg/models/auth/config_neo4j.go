@@ -0,0 +1,37 @@
+package auth
+
+import (
+	"bigfoot/golf/common/models/db"
+	"context"
+)
+
+// neo4jConfigStore keeps the auth config as an AuthConfig node
+type neo4jConfigStore struct {
+	conn *db.Database
+}
+
+// NewNeo4jConfigStore returns a ConfigStore backed by the connection
+func NewNeo4jConfigStore(conn *db.Database) ConfigStore {
+	return neo4jConfigStore{conn: conn}
+}
+
+func (s neo4jConfigStore) Save(ctx context.Context, config *AuthConfig) error {
+	id, err := s.conn.SaveStruct(ctx, config, "AuthConfig")
+	if err != nil {
+		return err
+	}
+	config.ID = id
+	return nil
+}
+
+func (s neo4jConfigStore) Load(ctx context.Context) (*AuthConfig, error) {
+	configs, err := s.conn.QueryNodes(ctx, "AuthConfig", nil)
+	if err != nil || len(configs) == 0 {
+		return nil, err
+	}
+	var config AuthConfig
+	if err := db.Decode(configs[0], &config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"bigfoot/golf/common/models/db"
+	"context"
+	"database/sql"
+	"encoding/json"
+)
+
+// sqliteConfigStore keeps the auth config as a json record
+type sqliteConfigStore struct {
+	conn *sql.DB
+}
+
+// NewSQLiteConfigStore returns a ConfigStore backed by the SQLite database
+func NewSQLiteConfigStore(conn *sql.DB) ConfigStore {
+	return sqliteConfigStore{conn: conn}
+}
+
+func (s sqliteConfigStore) Save(ctx context.Context, config *AuthConfig) error {
+	if config.ID == "" {
+		config.ID = db.NewID()
+	}
+	data, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	return db.Timed(ctx, func(ctx context.Context) error {
+		_, err := s.conn.ExecContext(ctx, `INSERT INTO auth_config (id, data) VALUES (?, ?)
+			ON CONFLICT (id) DO UPDATE SET data = excluded.data`, config.ID, string(data))
+		return err
+	})
+}
+
+func (s sqliteConfigStore) Load(ctx context.Context) (*AuthConfig, error) {
+	var data string
+	err := db.Timed(ctx, func(ctx context.Context) error {
+		return s.conn.QueryRowContext(ctx, `SELECT data FROM auth_config ORDER BY rowid LIMIT 1`).Scan(&data)
+	})
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var config AuthConfig
+	if err := json.Unmarshal([]byte(data), &config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
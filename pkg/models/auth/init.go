@@ -1,7 +1,7 @@
 package auth
 
 import (
-	"bigfoot/golf/common/models/db"
+	"context"
 	"crypto/rand"
 	"encoding/json"
 	"log"
@@ -15,7 +15,7 @@ type AuthConfig struct {
 	LocalJSec    []byte `json:"localJSec"`
 }
 
-func NewAuthConfig(srv AuthServer) (AuthConfig, error) {
+func NewAuthConfig(ctx context.Context, srv AuthServer) (AuthConfig, error) {
 	var config AuthConfig
 
 	config.LocalJSec = srv.jwtSecret
@@ -29,13 +29,27 @@ func NewAuthConfig(srv AuthServer) (AuthConfig, error) {
 	}
 	config.AppleConfig = apple
 	config.GoogleConfig = google
-	config.Save()
+	config.Save(ctx)
 	return config, nil
 }
 
-func (a *AuthConfig) Save() error {
-	_, err := db.Instance.SaveStruct(a, "AuthConfig")
-	return err
+// ConfigStore persists the server's auth config, of which there is only one
+type ConfigStore interface {
+	Save(ctx context.Context, config *AuthConfig) error
+	// Load returns the stored config or nil when none has been saved
+	Load(ctx context.Context) (*AuthConfig, error)
+}
+
+// configs is the store behind AuthConfig.Save and LoadLocalConfig
+var configs ConfigStore = neo4jConfigStore{}
+
+// SetConfigStore replaces the store used for the auth config
+func SetConfigStore(store ConfigStore) {
+	configs = store
+}
+
+func (a *AuthConfig) Save(ctx context.Context) error {
+	return configs.Save(ctx, a)
 }
 func (a *AuthConfig) GetServer() (AuthServer, error) {
 	var srv AuthServer
@@ -55,25 +69,14 @@ func (a *AuthConfig) GetServer() (AuthServer, error) {
 	srv.jwtSecret = []byte(a.LocalJSec)
 	return srv, nil
 }
-func LoadLocalConfig() (*AuthConfig, error) {
-	_configs, err := db.Instance.QueryNodes("AuthConfig", nil)
-	if len(_configs) > 0 {
-		config := AuthConfig{
-			ID:           _configs[0]["id"].(string),
-			LocalJSec:    _configs[0]["localJSec"].([]byte),
-			GoogleConfig: _configs[0]["googleConfig"].([]byte),
-			AppleConfig:  _configs[0]["appleConfig"].([]byte),
-		}
-
-		return &config, err
-	}
-	return nil, err
+func LoadLocalConfig(ctx context.Context) (*AuthConfig, error) {
+	return configs.Load(ctx)
 }
 
-func InitAuth() AuthServer {
+func InitAuth(ctx context.Context) AuthServer {
 
 	//Load variables for Auth
-	config, err := LoadLocalConfig()
+	config, err := LoadLocalConfig(ctx)
 	if err != nil || config == nil {
 		//no local config so make one
 
@@ -102,7 +105,7 @@ func InitAuth() AuthServer {
 			googleConfig: googleConfig,
 			appleConfig:  appleConfig,
 		}
-		_, _ = NewAuthConfig(server)
+		_, _ = NewAuthConfig(ctx, server)
 		return server
 	}
 	server, err := config.GetServer()
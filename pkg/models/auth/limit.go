@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"sync"
+	"time"
+)
+
+// attemptLimiter counts attempts per key, blocking a key once it has made
+// max within the window. Counts live in the process, so each
+// server instance limits on its own.
+type attemptLimiter struct {
+	mu       sync.Mutex
+	max      int
+	window   time.Duration
+	failures map[string][]time.Time
+}
+
+func newAttemptLimiter(max int, window time.Duration) *attemptLimiter {
+	return &attemptLimiter{max: max, window: window, failures: make(map[string][]time.Time)}
+}
+
+// Blocked reports whether the key has used up its attempts for now
+func (l *attemptLimiter) Blocked(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.recent(key)) >= l.max
+}
+
+// Record counts an attempt for the key
+func (l *attemptLimiter) Record(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.failures[key] = append(l.recent(key), clock())
+}
+
+// Reset forgets the key's failures after it succeeds
+func (l *attemptLimiter) Reset(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.failures, key)
+}
+
+// recent drops the key's failures older than the window and returns the rest
+func (l *attemptLimiter) recent(key string) []time.Time {
+	cutoff := clock().Add(-l.window)
+	kept := l.failures[key][:0]
+	for _, at := range l.failures[key] {
+		if at.After(cutoff) {
+			kept = append(kept, at)
+		}
+	}
+	if len(kept) == 0 {
+		delete(l.failures, key)
+		return nil
+	}
+	l.failures[key] = kept
+	return kept
+}
@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"bigfoot/golf/common/models/account"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+const (
+	minPasswordLength = 10
+	// maxPasswordBytes is as much as bcrypt hashes; longer is refused rather
+	// than silently cut short
+	maxPasswordBytes = 72
+)
+
+// ErrWeakPassword means the password does not meet the policy
+var ErrWeakPassword = errors.New("password too weak")
+
+// commonPasswords are refused however they are capitalised
+var commonPasswords = map[string]bool{
+	"password1234": true, "password123!": true, "1234567890a": true, "qwertyuiop1": true,
+	"iloveyou123": true, "letmein1234": true, "welcome1234": true, "baseball123": true,
+	"football123": true, "golfer12345": true, "golfball123": true, "bigfoot1234": true,
+}
+
+// ValidatePassword checks a new password against the policy: at least ten
+// characters, letters mixed with digits or symbols, not common and not the
+// user's email name. The error says what is wrong and wraps ErrWeakPassword.
+func ValidatePassword(password string, user account.User) error {
+	if len([]rune(password)) < minPasswordLength {
+		return fmt.Errorf("%w: use at least %d characters", ErrWeakPassword, minPasswordLength)
+	}
+	if len(password) > maxPasswordBytes {
+		return fmt.Errorf("%w: use at most %d characters", ErrWeakPassword, maxPasswordBytes)
+	}
+	var letters, others bool
+	for _, c := range password {
+		if unicode.IsLetter(c) {
+			letters = true
+		} else if !unicode.IsSpace(c) {
+			others = true
+		}
+	}
+	if !letters || !others {
+		return fmt.Errorf("%w: mix letters with numbers or symbols", ErrWeakPassword)
+	}
+	lower := strings.ToLower(password)
+	if commonPasswords[lower] {
+		return fmt.Errorf("%w: this password is too common", ErrWeakPassword)
+	}
+	if name, _, _ := strings.Cut(strings.ToLower(user.Email), "@"); len(name) >= 4 && strings.Contains(lower, name) {
+		return fmt.Errorf("%w: do not use your email in it", ErrWeakPassword)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"bigfoot/golf/common/models/account"
+	"context"
+	"errors"
+)
+
+var (
+	// ErrNotAuthenticated means the request carries no verified claims
+	ErrNotAuthenticated = errors.New("user not authenticated")
+	// ErrForbidden means the signed in user may not act for the user named in the request
+	ErrForbidden = errors.New("not allowed to act for another user")
+)
+
+type claimsKey struct{}
+
+// WithClaims returns a context carrying the verified token claims
+func WithClaims(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, claimsKey{}, claims)
+}
+
+// ClaimsFrom returns the claims AuthenticateMiddleware verified for the request
+func ClaimsFrom(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(*Claims)
+	return claims, ok && claims != nil && claims.UserID != ""
+}
+
+// ActingUser returns the ID of the signed in user. Handlers take the user
+// from here and never from the request body or headers.
+func ActingUser(ctx context.Context) (string, error) {
+	claims, ok := ClaimsFrom(ctx)
+	if !ok {
+		return "", ErrNotAuthenticated
+	}
+	return claims.UserID, nil
+}
+
+// ActFor returns the user a request naming target acts for. An empty target
+// or the user's own ID is the signed in user; any other user needs staff
+// allowed to act for golfers.
+func ActFor(ctx context.Context, target string) (string, error) {
+	userID, err := ActingUser(ctx)
+	if err != nil {
+		return "", err
+	}
+	if target == "" || target == userID {
+		return userID, nil
+	}
+	if !Allowed(ctx, PermActForGolfers) {
+		return "", ErrForbidden
+	}
+	return target, nil
+}
+
+// ProfileEdit is the body of a profile update. A field the body leaves out is
+// nil and keeps its stored value; a field sent empty clears it.
+type ProfileEdit struct {
+	ID        string  `json:"id,omitempty"`
+	Email     *string `json:"email"`
+	FirstName *string `json:"first_name"`
+	LastName  *string `json:"last_name"`
+	Phone     *string `json:"phone"`
+	DOB       *string `json:"dob"`
+	Avatar    *string `json:"avatar"`
+}
+
+// ProfileUpdate returns the stored user with the fields a profile edit may
+// change taken from the edit where it sends them. The ID, password, provider,
+// timestamps and the verified and admin flags always come from the stored
+// user, and a new email must be verified again.
+func ProfileUpdate(stored account.User, edit ProfileEdit) account.User {
+	updated := stored
+	take := func(field *string, sent *string) {
+		if sent != nil {
+			*field = *sent
+		}
+	}
+	take(&updated.FirstName, edit.FirstName)
+	take(&updated.LastName, edit.LastName)
+	take(&updated.Phone, edit.Phone)
+	take(&updated.DOB, edit.DOB)
+	take(&updated.Avatar, edit.Avatar)
+	if edit.Email != nil && *edit.Email != "" && *edit.Email != stored.Email {
+		updated.Email = *edit.Email
+		updated.IsVerified = false
+	}
+	updated.TempStr = ""
+	return updated
+}
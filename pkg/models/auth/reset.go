@@ -0,0 +1,208 @@
+package auth
+
+import (
+	"bigfoot/golf/common/models/account"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// resetLifetime is how long a password reset link works
+const resetLifetime = 30 * time.Minute
+
+// resetUse marks a password reset token's claims
+const resetUse = "reset"
+
+// RevokedPasswordReset is why sessions are revoked when the password is reset
+const RevokedPasswordReset = "password reset"
+
+var (
+	// forgotByEmail and forgotByIP limit reset emails, so the endpoint cannot
+	// flood an inbox or be used to send mail in bulk
+	forgotByEmail = newAttemptLimiter(3, time.Hour)
+	forgotByIP    = newAttemptLimiter(10, time.Hour)
+	// resetByIP limits bad reset links per address
+	resetByIP = newAttemptLimiter(10, time.Hour)
+)
+
+// ErrInvalidResetToken means the reset link is forged, expired or was used
+var ErrInvalidResetToken = errors.New("this reset link is invalid or has expired")
+
+// ForgotPasswordRequest asks for a reset link for the account's email
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+// ResetPasswordRequest sets a new password with the token from a reset link
+type ResetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+// resetClaims are a reset token's claims. Fingerprint hashes the password the
+// user had when it was issued, so the token stops working once the password
+// changes: it can be used once, and no store is needed to remember it.
+type resetClaims struct {
+	Fingerprint string `json:"pwf"`
+	Use         string `json:"use"`
+	jwt.RegisteredClaims
+}
+
+// resetMailer sends the user their reset link
+var resetMailer = func(ctx context.Context, user account.User, link string) error {
+	log.Printf("No reset mailer set, not emailing a reset link to %s", user.Email)
+	return nil
+}
+
+// SetResetMailer sets the function used to email a user their reset link
+func SetResetMailer(mail func(ctx context.Context, user account.User, link string) error) {
+	resetMailer = mail
+}
+
+// HandleForgotPassword emails a reset link when the email has an account.
+// It answers 202 either way, so it does not tell who has an account.
+func (s AuthServer) HandleForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var req ForgotPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	req.Email = strings.TrimSpace(req.Email)
+	if req.Email == "" {
+		http.Error(w, "Email is required", http.StatusBadRequest)
+		return
+	}
+
+	ip, email := clientIP(r), strings.ToLower(req.Email)
+	if forgotByIP.Blocked(ip) || forgotByEmail.Blocked(email) {
+		http.Error(w, "Too many attempts, try again later", http.StatusTooManyRequests)
+		return
+	}
+	forgotByIP.Record(ip)
+	forgotByEmail.Record(email)
+
+	user, err := account.QueryUser(r.Context(), map[string]interface{}{"email": req.Email})
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if user != nil {
+		// Mail in the background so the answer takes as long with or without an account
+		go s.sendResetLink(context.WithoutCancel(r.Context()), *user)
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// sendResetLink emails the user a link to the web app's reset page. The link
+// is built from APP_URL, never the request's host, which the caller controls.
+func (s AuthServer) sendResetLink(ctx context.Context, user account.User) {
+	base := strings.TrimSuffix(os.Getenv("APP_URL"), "/")
+	if base == "" {
+		log.Printf("APP_URL not set, not emailing a reset link to %s", user.Email)
+		return
+	}
+	token, err := s.resetToken(user)
+	if err != nil {
+		log.Printf("Error signing reset token for %s: %v", user.ID, err)
+		return
+	}
+	if err := resetMailer(ctx, user, base+"/reset?token="+url.QueryEscape(token)); err != nil {
+		log.Printf("Error emailing reset link to %s: %v", user.Email, err)
+	}
+}
+
+// HandleResetPassword sets a new password with a token from a reset link and
+// signs the user out everywhere
+func (s AuthServer) HandleResetPassword(w http.ResponseWriter, r *http.Request) {
+	ip := clientIP(r)
+	if resetByIP.Blocked(ip) {
+		http.Error(w, "Too many attempts, try again later", http.StatusTooManyRequests)
+		return
+	}
+	var req ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	user, err := s.resetUser(r.Context(), req.Token)
+	if errors.Is(err, ErrInvalidResetToken) {
+		resetByIP.Record(ip)
+		http.Error(w, "This reset link is invalid or has expired", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if err := ValidatePassword(req.Password, *user); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		http.Error(w, "Failed to hash password", http.StatusInternalServerError)
+		return
+	}
+	updated := account.User{ID: user.ID, Password: string(hashedPassword)}
+	if err := updated.UpdatePW(r.Context()); err != nil {
+		http.Error(w, "Failed to update password", http.StatusInternalServerError)
+		return
+	}
+	if _, err := RevokeUserSessions(r.Context(), user.ID, RevokedPasswordReset); err != nil {
+		log.Printf("Error revoking sessions for %s after a password reset: %v", user.ID, err)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// resetToken signs a reset token for the user lasting resetLifetime
+func (s AuthServer) resetToken(user account.User) (string, error) {
+	now := clock()
+	claims := &resetClaims{
+		Fingerprint: passwordFingerprint(user),
+		Use:         resetUse,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   user.ID,
+			ID:        newTokenID(),
+			ExpiresAt: jwt.NewNumericDate(now.Add(resetLifetime)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.jwtSecret)
+}
+
+// resetUser returns the user a reset token is for, or ErrInvalidResetToken
+// when it is not a valid reset token or their password changed since
+func (s AuthServer) resetUser(ctx context.Context, tokenString string) (*account.User, error) {
+	claims := &resetClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return s.jwtSecret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Name}), jwt.WithTimeFunc(clock))
+	if err != nil || !token.Valid || claims.Use != resetUse || claims.Subject == "" {
+		return nil, ErrInvalidResetToken
+	}
+	user, err := account.QueryUser(ctx, map[string]interface{}{"id": claims.Subject})
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || claims.Fingerprint != passwordFingerprint(*user) {
+		return nil, ErrInvalidResetToken
+	}
+	return user, nil
+}
+
+// passwordFingerprint identifies the user's current password hash without
+// putting it in the token
+func passwordFingerprint(user account.User) string {
+	return hashTokenID(user.ID + ":" + user.Password)
+}
@@ -0,0 +1,210 @@
+package auth
+
+import (
+	"bigfoot/golf/common/models/account"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// useResetLimits gives the test fresh reset rate limits, restoring the
+// server's when it ends
+func useResetLimits(t *testing.T) {
+	t.Helper()
+	byEmail, byIP, resets := forgotByEmail, forgotByIP, resetByIP
+	forgotByEmail = newAttemptLimiter(3, time.Hour)
+	forgotByIP = newAttemptLimiter(10, time.Hour)
+	resetByIP = newAttemptLimiter(10, time.Hour)
+	t.Cleanup(func() { forgotByEmail, forgotByIP, resetByIP = byEmail, byIP, resets })
+}
+
+// postFrom posts the body as JSON to the handler from the address
+func postFrom(handler http.HandlerFunc, ip string, body any) *httptest.ResponseRecorder {
+	payload, _ := json.Marshal(body)
+	req := httptest.NewRequest("POST", "/auth", bytes.NewReader(payload))
+	req.RemoteAddr = ip + ":41000"
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	return rec
+}
+
+func TestForgotPassword(t *testing.T) {
+	users := useMemoryStores(t)
+	useResetLimits(t)
+	t.Setenv("APP_URL", "https://golf.example.com/")
+	users.Save(context.Background(), &account.User{ID: "golfer", Email: "golfer@example.com"})
+	srv := AuthServer{jwtSecret: []byte("test-secret")}
+
+	links := make(chan string, 10)
+	previous := resetMailer
+	SetResetMailer(func(ctx context.Context, user account.User, link string) error {
+		links <- user.ID + " " + link
+		return nil
+	})
+	t.Cleanup(func() { SetResetMailer(previous) })
+
+	tests := []struct {
+		name     string
+		ip       string
+		email    string
+		wantCode int
+		wantMail bool
+	}{
+		{"emails a link for a known email", "10.0.0.1", "golfer@example.com", http.StatusAccepted, true},
+		{"answers the same for an unknown email", "10.0.0.1", "nobody@example.com", http.StatusAccepted, false},
+		{"needs an email", "10.0.0.1", " ", http.StatusBadRequest, false},
+		{"emails again from another address", "10.0.0.2", "golfer@example.com", http.StatusAccepted, true},
+		{"emails a third time", "10.0.0.3", "golfer@example.com", http.StatusAccepted, true},
+		{"limits requests per email", "10.0.0.4", "GOLFER@example.com", http.StatusTooManyRequests, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := postFrom(srv.HandleForgotPassword, tt.ip, ForgotPasswordRequest{Email: tt.email})
+			if rec.Code != tt.wantCode {
+				t.Fatalf("expected %d, got %d: %s", tt.wantCode, rec.Code, rec.Body.String())
+			}
+			if !tt.wantMail {
+				select {
+				case mail := <-links:
+					t.Errorf("expected no mail, got %s", mail)
+				default:
+				}
+				return
+			}
+			select {
+			case mail := <-links:
+				userID, link, _ := strings.Cut(mail, " ")
+				token, ok := strings.CutPrefix(link, "https://golf.example.com/reset?token=")
+				if user, err := srv.resetUser(context.Background(), token); userID != "golfer" || !ok || err != nil || user.ID != "golfer" {
+					t.Errorf("expected a working link for the golfer, got %s %v", mail, err)
+				}
+			case <-time.After(time.Second):
+				t.Error("expected the link emailed")
+			}
+		})
+	}
+
+	for i := range 10 {
+		postFrom(srv.HandleForgotPassword, "10.0.0.9", ForgotPasswordRequest{Email: strings.Repeat("x", i+1) + "@example.com"})
+	}
+	if rec := postFrom(srv.HandleForgotPassword, "10.0.0.9", ForgotPasswordRequest{Email: "golfer2@example.com"}); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("expected requests limited per address, got %d", rec.Code)
+	}
+
+	// A forwarded address is only believed from a trusted proxy
+	forged := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/auth", strings.NewReader(`{"email":"golfer3@example.com"}`))
+		req.RemoteAddr = "10.0.0.9:41000"
+		req.Header.Set("X-Forwarded-For", "198.51.100.1")
+		rec := httptest.NewRecorder()
+		srv.HandleForgotPassword(rec, req)
+		return rec
+	}
+	if rec := forged(); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("expected a forged X-Forwarded-For limited by the real address, got %d", rec.Code)
+	}
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.9")
+	if rec := forged(); rec.Code != http.StatusAccepted {
+		t.Errorf("expected the client behind a trusted proxy limited on its own, got %d", rec.Code)
+	}
+}
+
+func TestResetPassword(t *testing.T) {
+	users := useMemoryStores(t)
+	useResetLimits(t)
+	ctx := context.Background()
+	hash, _ := bcrypt.GenerateFromPassword([]byte("birdie-putt"), bcrypt.MinCost)
+	golfer := account.User{ID: "golfer", Email: "golfer@example.com", Password: string(hash)}
+	users.Save(ctx, &golfer)
+	srv := AuthServer{jwtSecret: []byte("test-secret")}
+
+	login, err := srv.signIn(httptest.NewRequest("POST", "/auth/login", nil), golfer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.signIn(httptest.NewRequest("POST", "/auth/login", nil), golfer)
+	now := useClock(t, time.Now().Add(-time.Hour))
+	expired, _ := srv.resetToken(golfer)
+	*now = time.Now()
+	link, _ := srv.resetToken(golfer)
+	forged, _ := AuthServer{jwtSecret: []byte("other-secret")}.resetToken(golfer)
+
+	tests := []struct {
+		name     string
+		token    string
+		password string
+		wantCode int
+	}{
+		{"refuses an expired link", expired, "eagle-chip-42", http.StatusBadRequest},
+		{"refuses an access token", login.Token, "eagle-chip-42", http.StatusBadRequest},
+		{"refuses a refresh token", login.RefreshToken, "eagle-chip-42", http.StatusBadRequest},
+		{"refuses a link signed with another key", forged, "eagle-chip-42", http.StatusBadRequest},
+		{"refuses a weak password", link, "birdie", http.StatusBadRequest},
+		{"refuses a password with the email in it", link, "golfer-2024!", http.StatusBadRequest},
+		{"resets the password", link, "eagle-chip-42", http.StatusNoContent},
+		{"works once", link, "albatross-77", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := postFrom(srv.HandleResetPassword, "10.0.0.1", ResetPasswordRequest{Token: tt.token, Password: tt.password})
+			if rec.Code != tt.wantCode {
+				t.Errorf("expected %d, got %d: %s", tt.wantCode, rec.Code, rec.Body.String())
+			}
+		})
+	}
+
+	stored, _ := account.QueryUser(ctx, map[string]interface{}{"id": "golfer"})
+	if stored == nil || bcrypt.CompareHashAndPassword([]byte(stored.Password), []byte("eagle-chip-42")) != nil {
+		t.Errorf("expected the new password saved, got %+v", stored)
+	}
+	if active, err := UserSessions(ctx, "golfer"); err != nil || len(active) != 0 {
+		t.Errorf("expected every session revoked, got %d %v", len(active), err)
+	}
+
+	// A reset token never authenticates a request
+	fresh, _ := srv.resetToken(*stored)
+	rec := httptest.NewRecorder()
+	srv.AuthenticateMiddleware(false, func(w http.ResponseWriter, r *http.Request) {})(rec, authorized("GET", "/auth/me", fresh))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected a reset token refused, got %d", rec.Code)
+	}
+
+	// Five bad links have been tried from the address; five more lock it out
+	for range 5 {
+		postFrom(srv.HandleResetPassword, "10.0.0.1", ResetPasswordRequest{Token: forged, Password: "eagle-chip-42"})
+	}
+	if rec := postFrom(srv.HandleResetPassword, "10.0.0.1", ResetPasswordRequest{Token: fresh, Password: "albatross-77"}); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("expected bad links limited per address, got %d", rec.Code)
+	}
+}
+
+func TestValidatePassword(t *testing.T) {
+	user := account.User{Email: "Hacker@example.com"}
+	tests := []struct {
+		password string
+		wantErr  bool
+	}{
+		{"eagle-chip-42", false},
+		{"long enough but words only", true},
+		{"1234567890", true},
+		{"short-1", true},
+		{"Password1234", true},
+		{"myhacker-pass1", true},
+		{strings.Repeat("a1", 37), true},
+		{"über-grün-7!", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.password, func(t *testing.T) {
+			if err := ValidatePassword(tt.password, user); (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
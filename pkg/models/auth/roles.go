@@ -0,0 +1,179 @@
+package auth
+
+import (
+	"bigfoot/golf/common/models/account"
+	"bigfoot/golf/common/models/audit"
+	"context"
+	"errors"
+	"slices"
+)
+
+// Role is a job at the course. Every signed in user is a golfer; staff roles
+// are granted on top.
+type Role string
+
+const (
+	RoleGolfer      Role = "golfer"
+	RoleStarter     Role = "starter"
+	RoleProShop     Role = "proShop"
+	RoleCourseAdmin Role = "courseAdmin"
+	RoleSuperAdmin  Role = "superAdmin"
+)
+
+// Permission is something a route can require
+type Permission string
+
+const (
+	// PermBook covers booking and managing the user's own tee times and account
+	PermBook Permission = "book"
+	// PermViewTeeSheet lets staff see every golfer's outings, pairings and the day's overrides
+	PermViewTeeSheet Permission = "teeSheet.view"
+	// PermActForGolfers lets staff book and edit profiles on a golfer's behalf
+	PermActForGolfers Permission = "golfers.actFor"
+	PermManageOutings Permission = "outings.manage"
+	// PermManageOverrides covers closing the course and delaying the day
+	PermManageOverrides Permission = "overrides.manage"
+	// PermManagePricing covers seasons, block pricing, holidays and deals
+	PermManagePricing Permission = "pricing.manage"
+	PermViewHistory   Permission = "history.view"
+	PermManageRoles   Permission = "roles.manage"
+)
+
+// rolePermissions lists what each role may do. Each role includes the one before it.
+var rolePermissions = map[Role][]Permission{
+	RoleGolfer:      {PermBook},
+	RoleStarter:     {PermBook, PermViewTeeSheet},
+	RoleProShop:     {PermBook, PermViewTeeSheet, PermActForGolfers, PermManageOutings},
+	RoleCourseAdmin: {PermBook, PermViewTeeSheet, PermActForGolfers, PermManageOutings, PermManageOverrides, PermManagePricing, PermViewHistory},
+	RoleSuperAdmin:  {PermBook, PermViewTeeSheet, PermActForGolfers, PermManageOutings, PermManageOverrides, PermManagePricing, PermViewHistory, PermManageRoles},
+}
+
+// roleLevels is the AuthLevel a role gives the web app's pages
+var roleLevels = map[Role]AuthLevel{
+	RoleGolfer:      LoginLevel,
+	RoleStarter:     ClubHouseLevel,
+	RoleProShop:     ClubHouseLevel,
+	RoleCourseAdmin: AdminLevel,
+	RoleSuperAdmin:  AdminLevel,
+}
+
+var (
+	// ErrUnknownRole means the role is not one of the course's roles
+	ErrUnknownRole = errors.New("unknown role")
+	// ErrGolferRole means the golfer role was revoked, which every user keeps
+	ErrGolferRole = errors.New("every user is a golfer")
+	// ErrOwnSuperAdmin means a super admin tried to revoke their own role,
+	// which could leave no one able to grant roles
+	ErrOwnSuperAdmin = errors.New("cannot revoke your own super admin role")
+	// ErrUserNotFound means there is no user with the id
+	ErrUserNotFound = errors.New("user not found")
+)
+
+// Valid reports whether the role is one of the course's roles
+func (r Role) Valid() bool {
+	_, ok := rolePermissions[r]
+	return ok
+}
+
+// UserRoles returns the user's roles, golfer first. A user marked admin
+// before roles existed is a super admin until roles are granted explicitly.
+func UserRoles(u account.User) []Role {
+	roles := []Role{RoleGolfer}
+	for _, name := range u.Roles {
+		if role := Role(name); role.Valid() && !slices.Contains(roles, role) {
+			roles = append(roles, role)
+		}
+	}
+	if u.IsAdmin && len(u.Roles) == 0 {
+		roles = append(roles, RoleSuperAdmin)
+	}
+	return roles
+}
+
+// Can reports whether any of the roles has the permission
+func Can(roles []Role, perm Permission) bool {
+	for _, role := range roles {
+		if slices.Contains(rolePermissions[role], perm) {
+			return true
+		}
+	}
+	return false
+}
+
+// LevelOf returns the highest AuthLevel the roles give
+func LevelOf(roles []Role) AuthLevel {
+	level := NoAuthLevel
+	for _, role := range roles {
+		level = max(level, roleLevels[role])
+	}
+	return level
+}
+
+// Allowed reports whether the signed in user has the permission
+func Allowed(ctx context.Context, perm Permission) bool {
+	claims, ok := ClaimsFrom(ctx)
+	return ok && Can(claims.roles(), perm)
+}
+
+// roles returns the token's roles. A token without any is a golfer's.
+func (c *Claims) roles() []Role {
+	if len(c.Roles) > 0 {
+		return c.Roles
+	}
+	return []Role{RoleGolfer}
+}
+
+// GrantRole gives the user the role. The user's next token carries it.
+func GrantRole(ctx context.Context, userID string, role Role) ([]Role, error) {
+	return changeRoles(ctx, userID, role, func(roles []Role) []Role {
+		if slices.Contains(roles, role) {
+			return roles
+		}
+		return append(roles, role)
+	})
+}
+
+// RevokeRole takes the role from the user. Golfer cannot be revoked, and a
+// super admin cannot revoke their own super admin role.
+func RevokeRole(ctx context.Context, userID string, role Role) ([]Role, error) {
+	if role == RoleGolfer {
+		return nil, ErrGolferRole
+	}
+	if role == RoleSuperAdmin && userID == audit.Actor(ctx) {
+		return nil, ErrOwnSuperAdmin
+	}
+	return changeRoles(ctx, userID, role, func(roles []Role) []Role {
+		return slices.DeleteFunc(roles, func(r Role) bool { return r == role })
+	})
+}
+
+// changeRoles saves the user's roles as change returns them, keeping the
+// admin flag in step and recording any change
+func changeRoles(ctx context.Context, userID string, role Role, change func([]Role) []Role) ([]Role, error) {
+	if !role.Valid() {
+		return nil, ErrUnknownRole
+	}
+	user, err := account.QueryUser(ctx, map[string]interface{}{"id": userID})
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	before := UserRoles(*user)
+	after := change(slices.Clone(before))
+	if slices.Equal(before, after) {
+		return after, nil
+	}
+	user.Roles = make([]string, len(after))
+	for i, r := range after {
+		user.Roles[i] = string(r)
+	}
+	user.IsAdmin = LevelOf(after) >= AdminLevel
+	if err := user.Save(ctx); err != nil {
+		return nil, err
+	}
+	audit.Record(ctx, audit.User, userID, audit.Updated, map[string][]Role{"roles": before}, map[string][]Role{"roles": after})
+	return after, nil
+}
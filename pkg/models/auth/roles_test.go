@@ -0,0 +1,170 @@
+package auth
+
+import (
+	"bigfoot/golf/common/models/account"
+	"bigfoot/golf/common/models/audit"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestRolePermissions(t *testing.T) {
+	tests := []struct {
+		name      string
+		user      account.User
+		wantRoles []Role
+		wantLevel AuthLevel
+		allowed   []Permission
+		denied    []Permission
+	}{
+		{
+			name:      "every user is a golfer",
+			user:      account.User{ID: "golfer"},
+			wantRoles: []Role{RoleGolfer},
+			wantLevel: LoginLevel,
+			allowed:   []Permission{PermBook},
+			denied:    []Permission{PermViewTeeSheet, PermActForGolfers},
+		},
+		{
+			name:      "a starter sees the tee sheet only",
+			user:      account.User{ID: "starter", Roles: []string{"starter"}},
+			wantRoles: []Role{RoleGolfer, RoleStarter},
+			wantLevel: ClubHouseLevel,
+			allowed:   []Permission{PermBook, PermViewTeeSheet},
+			denied:    []Permission{PermActForGolfers, PermManageOutings},
+		},
+		{
+			name:      "the pro shop books for golfers and runs outings",
+			user:      account.User{ID: "shop", Roles: []string{"proShop", "nonsense"}},
+			wantRoles: []Role{RoleGolfer, RoleProShop},
+			wantLevel: ClubHouseLevel,
+			allowed:   []Permission{PermActForGolfers, PermManageOutings},
+			denied:    []Permission{PermManageOverrides, PermManagePricing},
+		},
+		{
+			name:      "a course admin cannot grant roles",
+			user:      account.User{ID: "admin", Roles: []string{"courseAdmin"}, IsAdmin: true},
+			wantRoles: []Role{RoleGolfer, RoleCourseAdmin},
+			wantLevel: AdminLevel,
+			allowed:   []Permission{PermManagePricing, PermViewHistory},
+			denied:    []Permission{PermManageRoles},
+		},
+		{
+			name:      "an admin from before roles is a super admin",
+			user:      account.User{ID: "owner", IsAdmin: true},
+			wantRoles: []Role{RoleGolfer, RoleSuperAdmin},
+			wantLevel: AdminLevel,
+			allowed:   []Permission{PermManageRoles},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			roles := UserRoles(tt.user)
+			if !slices.Equal(roles, tt.wantRoles) || LevelOf(roles) != tt.wantLevel {
+				t.Fatalf("expected %v at level %d, got %v at level %d", tt.wantRoles, tt.wantLevel, roles, LevelOf(roles))
+			}
+			for _, perm := range tt.allowed {
+				if !Can(roles, perm) {
+					t.Errorf("expected %s allowed", perm)
+				}
+			}
+			for _, perm := range tt.denied {
+				if Can(roles, perm) {
+					t.Errorf("expected %s denied", perm)
+				}
+			}
+		})
+	}
+}
+
+func TestRequirePermission(t *testing.T) {
+	useMemoryStores(t)
+	srv := AuthServer{jwtSecret: []byte("test-secret")}
+	token := func(u account.User) string {
+		resp, err := srv.generateTokens(u, sessionFor(t, u.ID), "token-"+u.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.Token
+	}
+	starter := token(account.User{ID: "starter", Roles: []string{"starter"}})
+	admin := token(account.User{ID: "admin", Roles: []string{"courseAdmin"}})
+	bare, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, &Claims{UserID: "owner", SessionID: sessionFor(t, "owner").ID}).SignedString(srv.jwtSecret)
+	sessionless, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, &Claims{UserID: "owner"}).SignedString(srv.jwtSecret)
+
+	tests := []struct {
+		name     string
+		perm     Permission
+		token    string
+		wantCode int
+	}{
+		{"lets a starter see the tee sheet", PermViewTeeSheet, starter, http.StatusOK},
+		{"forbids a starter from closing the course", PermManageOverrides, starter, http.StatusForbidden},
+		{"lets a course admin close the course", PermManageOverrides, admin, http.StatusOK},
+		{"forbids a course admin from granting roles", PermManageRoles, admin, http.StatusForbidden},
+		{"treats a token without roles as a golfer's", PermViewTeeSheet, bare, http.StatusForbidden},
+		{"rejects a request without a token", PermBook, "", http.StatusUnauthorized},
+		{"rejects a token without a session", PermBook, sessionless, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/admin", nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			rec := httptest.NewRecorder()
+			srv.Require(tt.perm, func(w http.ResponseWriter, r *http.Request) {})(rec, req)
+			if rec.Code != tt.wantCode {
+				t.Errorf("expected %d, got %d", tt.wantCode, rec.Code)
+			}
+		})
+	}
+}
+
+func TestGrantAndRevokeRoles(t *testing.T) {
+	users := useMemoryStores(t)
+	events := audit.NewMemoryStore()
+	audit.SetStore(events)
+	t.Cleanup(func() { audit.SetStore(audit.NewMemoryStore()) })
+	ctx := audit.WithActor(context.Background(), "owner")
+	users.Save(ctx, &account.User{ID: "owner", Email: "owner@example.com", IsAdmin: true})
+	users.Save(ctx, &account.User{ID: "golfer", Email: "golfer@example.com"})
+
+	tests := []struct {
+		name      string
+		run       func() ([]Role, error)
+		wantRoles []Role
+		wantErr   error
+	}{
+		{"grants the pro shop", func() ([]Role, error) { return GrantRole(ctx, "golfer", RoleProShop) }, []Role{RoleGolfer, RoleProShop}, nil},
+		{"grants a role once", func() ([]Role, error) { return GrantRole(ctx, "golfer", RoleProShop) }, []Role{RoleGolfer, RoleProShop}, nil},
+		{"grants course admin", func() ([]Role, error) { return GrantRole(ctx, "golfer", RoleCourseAdmin) }, []Role{RoleGolfer, RoleProShop, RoleCourseAdmin}, nil},
+		{"revokes the pro shop", func() ([]Role, error) { return RevokeRole(ctx, "golfer", RoleProShop) }, []Role{RoleGolfer, RoleCourseAdmin}, nil},
+		{"refuses an unknown role", func() ([]Role, error) { return GrantRole(ctx, "golfer", "greenkeeper") }, nil, ErrUnknownRole},
+		{"keeps every user a golfer", func() ([]Role, error) { return RevokeRole(ctx, "golfer", RoleGolfer) }, nil, ErrGolferRole},
+		{"refuses revoking your own super admin", func() ([]Role, error) { return RevokeRole(ctx, "owner", RoleSuperAdmin) }, nil, ErrOwnSuperAdmin},
+		{"reports an unknown user", func() ([]Role, error) { return GrantRole(ctx, "missing", RoleStarter) }, nil, ErrUserNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			roles, err := tt.run()
+			if !errors.Is(err, tt.wantErr) || !slices.Equal(roles, tt.wantRoles) {
+				t.Errorf("expected %v %v, got %v %v", tt.wantRoles, tt.wantErr, roles, err)
+			}
+		})
+	}
+
+	stored, _ := account.QueryUser(ctx, map[string]interface{}{"id": "golfer"})
+	if stored == nil || !slices.Equal(stored.Roles, []string{"golfer", "courseAdmin"}) || !stored.IsAdmin {
+		t.Errorf("expected the roles saved with the admin flag, got %+v", stored)
+	}
+	history, err := audit.History(ctx, audit.User, "golfer")
+	if err != nil || len(history) != 3 || history[0].Actor != "owner" {
+		t.Errorf("expected each change recorded as the owner's, got %+v %v", history, err)
+	}
+}
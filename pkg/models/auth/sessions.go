@@ -0,0 +1,343 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// refreshLifetime is how long a refresh token lasts. Each refresh issues a
+// new token, so a session stays signed in while it is used at least this often.
+const refreshLifetime = 7 * 24 * time.Hour
+
+// sessionCheckTTL is how long a session found active is trusted before an
+// access token's next request asks the store again. Revoking through this
+// server forgets the session at once; other servers notice within the TTL.
+const sessionCheckTTL = 30 * time.Second
+
+// Reasons a session was revoked
+const (
+	RevokedLogout  = "logout"
+	RevokedByUser  = "revoked"
+	RevokedReuse   = "token reused"
+	RevokedSignOut = "signed out everywhere"
+	// RevokedPasswordChange ends the user's other sessions when they change their password
+	RevokedPasswordChange = "password changed"
+)
+
+var (
+	// ErrSessionNotFound means there is no session with the id for the user
+	ErrSessionNotFound = errors.New("session not found")
+	// ErrTokenReused means a refresh token was presented after it was spent
+	ErrTokenReused = errors.New("refresh token already used")
+)
+
+// RefreshSession is one signed in device. Its refresh token can be used once:
+// each refresh swaps it for the next, and presenting a spent token revokes
+// the session, since the token was copied by someone else.
+type RefreshSession struct {
+	ID     string `json:"id"`
+	UserID string `json:"userId"`
+	// TokenHash is the SHA-256 of the current refresh token's id; the token itself is never stored
+	TokenHash     string     `json:"tokenHash,omitempty"`
+	Device        string     `json:"device"`
+	IP            string     `json:"ip"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	LastUsedAt    time.Time  `json:"lastUsedAt"`
+	ExpiresAt     time.Time  `json:"expiresAt"`
+	RevokedAt     *time.Time `json:"revokedAt,omitempty" neo4j:",omitempty"`
+	RevokedReason string     `json:"revokedReason,omitempty" neo4j:",omitempty"`
+	// Current marks the session making the request in a listing; it is not stored
+	Current bool `json:"current,omitempty" neo4j:"-"`
+}
+
+// Active reports whether the session can still refresh at the time
+func (s RefreshSession) Active(at time.Time) bool {
+	return s.RevokedAt == nil && at.Before(s.ExpiresAt)
+}
+
+// SessionStore persists refresh sessions
+type SessionStore interface {
+	Create(ctx context.Context, s *RefreshSession) error
+	// Get returns the session or nil when there is none
+	Get(ctx context.Context, id string) (*RefreshSession, error)
+	// Rotate stores the session's new token hash and use when its current
+	// token hash is still prevHash and it is not revoked, and otherwise
+	// returns ErrTokenReused, so only one of two refreshes racing with the
+	// same token wins
+	Rotate(ctx context.Context, s *RefreshSession, prevHash string) error
+	// Revoke revokes the session if it is not already, returning ErrSessionNotFound when there is none
+	Revoke(ctx context.Context, id, reason string, at time.Time) error
+	// RevokeUser revokes every session of the user, returning how many were
+	RevokeUser(ctx context.Context, userID, reason string, at time.Time) (int, error)
+	// UserSessions returns the user's sessions that are not revoked, newest first
+	UserSessions(ctx context.Context, userID string) ([]RefreshSession, error)
+}
+
+// sessions is the store behind the refresh sessions
+var sessions SessionStore = neo4jSessionStore{}
+
+// SetSessionStore replaces the store used for refresh sessions
+func SetSessionStore(store SessionStore) {
+	sessions = store
+	checked.forget(func(checkedSession) bool { return true })
+}
+
+// checkedSession is a session found active, and until when that is trusted
+type checkedSession struct {
+	userID string
+	until  time.Time
+}
+
+// sessionCache remembers the sessions access tokens were recently checked against
+type sessionCache struct {
+	mu       sync.Mutex
+	sessions map[string]checkedSession
+}
+
+var checked = &sessionCache{sessions: make(map[string]checkedSession)}
+
+// forget drops the cached sessions that match, so their next check goes to the store
+func (c *sessionCache) forget(match func(checkedSession) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for id, s := range c.sessions {
+		if match(s) {
+			delete(c.sessions, id)
+		}
+	}
+}
+
+// sessionActive reports whether the refresh session an access token was
+// issued to can still be used, so the token stops working once its session is
+// signed out, revoked or replaced by a password reset
+func sessionActive(ctx context.Context, claims *Claims) (bool, error) {
+	if claims.SessionID == "" {
+		return false, nil
+	}
+	now := time.Now()
+	checked.mu.Lock()
+	hit, ok := checked.sessions[claims.SessionID]
+	checked.mu.Unlock()
+	if ok && hit.userID == claims.UserID && now.Before(hit.until) {
+		return true, nil
+	}
+
+	session, err := sessions.Get(ctx, claims.SessionID)
+	if err != nil {
+		return false, err
+	}
+	if session == nil || session.UserID != claims.UserID || !session.Active(now) {
+		return false, nil
+	}
+	until := now.Add(sessionCheckTTL)
+	if session.ExpiresAt.Before(until) {
+		until = session.ExpiresAt
+	}
+	checked.forget(func(s checkedSession) bool { return !now.Before(s.until) })
+	checked.mu.Lock()
+	checked.sessions[session.ID] = checkedSession{userID: session.UserID, until: until}
+	checked.mu.Unlock()
+	return true, nil
+}
+
+// newTokenID returns a random id for a refresh token
+func newTokenID() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func hashTokenID(tokenID string) string {
+	sum := sha256.Sum256([]byte(tokenID))
+	return hex.EncodeToString(sum[:])
+}
+
+// startSession saves a session for the user signing in from the request and
+// returns it with the id of its first refresh token
+func startSession(r *http.Request, userID string) (*RefreshSession, string, error) {
+	now := time.Now()
+	tokenID := newTokenID()
+	session := &RefreshSession{
+		UserID:     userID,
+		TokenHash:  hashTokenID(tokenID),
+		Device:     r.UserAgent(),
+		IP:         clientIP(r),
+		CreatedAt:  now,
+		LastUsedAt: now,
+		ExpiresAt:  now.Add(refreshLifetime),
+	}
+	if err := sessions.Create(r.Context(), session); err != nil {
+		return nil, "", err
+	}
+	return session, tokenID, nil
+}
+
+// rotateSession spends the refresh token and returns the session with the id
+// of the token that replaces it. A spent or unknown token revokes the session.
+func rotateSession(r *http.Request, claims *Claims) (*RefreshSession, string, error) {
+	ctx := r.Context()
+	session, err := sessions.Get(ctx, claims.SessionID)
+	if err != nil {
+		return nil, "", err
+	}
+	now := time.Now()
+	if session == nil || session.UserID != claims.UserID || !session.Active(now) {
+		return nil, "", ErrSessionNotFound
+	}
+
+	prevHash := hashTokenID(claims.ID)
+	if prevHash != session.TokenHash {
+		revokeReused(ctx, session.ID, now)
+		return nil, "", ErrTokenReused
+	}
+	tokenID := newTokenID()
+	session.TokenHash = hashTokenID(tokenID)
+	session.Device = r.UserAgent()
+	session.IP = clientIP(r)
+	session.LastUsedAt = now
+	session.ExpiresAt = now.Add(refreshLifetime)
+	err = sessions.Rotate(ctx, session, prevHash)
+	if errors.Is(err, ErrTokenReused) {
+		revokeReused(ctx, session.ID, now)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return session, tokenID, nil
+}
+
+// revokeReused ends a session whose spent token came back: one of the two
+// holders is not the user, and there is no telling which
+func revokeReused(ctx context.Context, sessionID string, at time.Time) {
+	log.Printf("Refresh token reused for session %s, revoking it", sessionID)
+	forgetSession(sessionID)
+	if err := sessions.Revoke(ctx, sessionID, RevokedReuse, at); err != nil && !errors.Is(err, ErrSessionNotFound) {
+		log.Printf("Error revoking session %s: %v", sessionID, err)
+	}
+}
+
+// UserSessions lists the user's sessions that can still refresh, newest first
+func UserSessions(ctx context.Context, userID string) ([]RefreshSession, error) {
+	all, err := sessions.UserSessions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	active := []RefreshSession{}
+	for _, s := range all {
+		if s.Active(now) {
+			s.TokenHash = ""
+			active = append(active, s)
+		}
+	}
+	return active, nil
+}
+
+// RevokeSession signs one of the user's sessions out
+func RevokeSession(ctx context.Context, userID, sessionID, reason string) error {
+	session, err := sessions.Get(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if session == nil || session.UserID != userID {
+		return ErrSessionNotFound
+	}
+	forgetSession(sessionID)
+	return sessions.Revoke(ctx, sessionID, reason, time.Now())
+}
+
+// RevokeUserSessions signs the user out everywhere
+func RevokeUserSessions(ctx context.Context, userID, reason string) (int, error) {
+	checked.forget(func(s checkedSession) bool { return s.userID == userID })
+	return sessions.RevokeUser(ctx, userID, reason, time.Now())
+}
+
+// forgetSession drops the session from the cache of active sessions
+func forgetSession(sessionID string) {
+	checked.mu.Lock()
+	defer checked.mu.Unlock()
+	delete(checked.sessions, sessionID)
+}
+
+// RevokeOtherSessions signs the user out everywhere but the kept session,
+// returning how many sessions were revoked
+func RevokeOtherSessions(ctx context.Context, userID, keepID, reason string) (int, error) {
+	all, err := sessions.UserSessions(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now()
+	revoked := 0
+	for _, s := range all {
+		if s.ID == keepID {
+			continue
+		}
+		forgetSession(s.ID)
+		err := sessions.Revoke(ctx, s.ID, reason, now)
+		if errors.Is(err, ErrSessionNotFound) {
+			continue
+		}
+		if err != nil {
+			return revoked, err
+		}
+		revoked++
+	}
+	return revoked, nil
+}
+
+// clientIP returns the address the request came from. X-Forwarded-For is
+// only believed from a proxy listed in TRUSTED_PROXIES, and then the client is
+// the last hop that is not one of those proxies: anyone else can send the
+// header with whatever address they like.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if !trustedProxy(host) {
+		return host
+	}
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop == "" {
+			continue
+		}
+		host = hop
+		if !trustedProxy(hop) {
+			break
+		}
+	}
+	return host
+}
+
+// trustedProxy reports whether the address is one of the proxies in front of
+// the server, given in TRUSTED_PROXIES as comma separated addresses or CIDR ranges
+func trustedProxy(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, entry := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		entry = strings.TrimSpace(entry)
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			if network.Contains(ip) {
+				return true
+			}
+		} else if proxy := net.ParseIP(entry); proxy != nil && proxy.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
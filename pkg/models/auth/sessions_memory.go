@@ -0,0 +1,94 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemorySessionStore is an in-process SessionStore for tests and local runs
+type MemorySessionStore struct {
+	mu       sync.Mutex
+	nextID   int
+	sessions map[string]RefreshSession
+}
+
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{sessions: make(map[string]RefreshSession)}
+}
+
+func (m *MemorySessionStore) Create(ctx context.Context, s *RefreshSession) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.nextID++
+	s.ID = fmt.Sprintf("session-%d", m.nextID)
+	m.sessions[s.ID] = *s
+	return nil
+}
+
+func (m *MemorySessionStore) Get(ctx context.Context, id string) (*RefreshSession, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.sessions[id]
+	if !ok {
+		return nil, nil
+	}
+	return &s, nil
+}
+
+func (m *MemorySessionStore) Rotate(ctx context.Context, s *RefreshSession, prevHash string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stored, ok := m.sessions[s.ID]
+	if !ok || stored.RevokedAt != nil || stored.TokenHash != prevHash {
+		return ErrTokenReused
+	}
+	stored.TokenHash = s.TokenHash
+	stored.Device, stored.IP = s.Device, s.IP
+	stored.LastUsedAt, stored.ExpiresAt = s.LastUsedAt, s.ExpiresAt
+	m.sessions[s.ID] = stored
+	return nil
+}
+
+func (m *MemorySessionStore) Revoke(ctx context.Context, id, reason string, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.sessions[id]
+	if !ok {
+		return ErrSessionNotFound
+	}
+	if s.RevokedAt == nil {
+		s.RevokedAt, s.RevokedReason = &at, reason
+		m.sessions[id] = s
+	}
+	return nil
+}
+
+func (m *MemorySessionStore) RevokeUser(ctx context.Context, userID, reason string, at time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	revoked := 0
+	for id, s := range m.sessions {
+		if s.UserID == userID && s.RevokedAt == nil {
+			s.RevokedAt, s.RevokedReason = &at, reason
+			m.sessions[id] = s
+			revoked++
+		}
+	}
+	return revoked, nil
+}
+
+func (m *MemorySessionStore) UserSessions(ctx context.Context, userID string) ([]RefreshSession, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var found []RefreshSession
+	for _, s := range m.sessions {
+		if s.UserID == userID && s.RevokedAt == nil {
+			found = append(found, s)
+		}
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].CreatedAt.After(found[j].CreatedAt) })
+	return found, nil
+}
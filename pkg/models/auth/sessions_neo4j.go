@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"bigfoot/golf/common/models/db"
+	"context"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// neo4jSessionStore keeps each refresh session as a RefreshSession node
+type neo4jSessionStore struct {
+	conn *db.Database
+}
+
+// NewNeo4jSessionStore returns a SessionStore backed by the connection
+func NewNeo4jSessionStore(conn *db.Database) SessionStore {
+	return neo4jSessionStore{conn: conn}
+}
+
+func (s neo4jSessionStore) Create(ctx context.Context, session *RefreshSession) error {
+	session.ID = db.NewID()
+	props, err := db.Encode(session)
+	if err != nil {
+		session.ID = ""
+		return err
+	}
+	if _, err := writeCount(ctx, s.conn, `CREATE (s:RefreshSession $props) RETURN count(s)`, map[string]any{"props": props}); err != nil {
+		session.ID = ""
+		return err
+	}
+	return nil
+}
+
+func (s neo4jSessionStore) Get(ctx context.Context, id string) (*RefreshSession, error) {
+	found, err := db.QueryAs[RefreshSession](ctx, s.conn, `MATCH (s:RefreshSession {id: $id}) RETURN s{.*} AS data`,
+		map[string]any{"id": id})
+	if err != nil || len(found) == 0 {
+		return nil, err
+	}
+	return &found[0], nil
+}
+
+func (s neo4jSessionStore) Rotate(ctx context.Context, session *RefreshSession, prevHash string) error {
+	rotated, err := writeCount(ctx, s.conn, `MATCH (s:RefreshSession {id: $id, tokenHash: $prevHash})
+		WHERE s.revokedAt IS NULL
+		SET s.tokenHash = $tokenHash, s.device = $device, s.ip = $ip, s.lastUsedAt = $lastUsedAt, s.expiresAt = $expiresAt
+		RETURN count(s)`, map[string]any{
+		"id": session.ID, "prevHash": prevHash, "tokenHash": session.TokenHash,
+		"device": session.Device, "ip": session.IP, "lastUsedAt": session.LastUsedAt, "expiresAt": session.ExpiresAt,
+	})
+	if err != nil {
+		return err
+	}
+	if rotated == 0 {
+		return ErrTokenReused
+	}
+	return nil
+}
+
+func (s neo4jSessionStore) Revoke(ctx context.Context, id, reason string, at time.Time) error {
+	found, err := writeCount(ctx, s.conn, `MATCH (s:RefreshSession {id: $id})
+		SET s.revokedAt = coalesce(s.revokedAt, $at), s.revokedReason = coalesce(s.revokedReason, $reason)
+		RETURN count(s)`, map[string]any{"id": id, "at": at, "reason": reason})
+	if err != nil {
+		return err
+	}
+	if found == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+func (s neo4jSessionStore) RevokeUser(ctx context.Context, userID, reason string, at time.Time) (int, error) {
+	return writeCount(ctx, s.conn, `MATCH (s:RefreshSession {userId: $userID})
+		WHERE s.revokedAt IS NULL
+		SET s.revokedAt = $at, s.revokedReason = $reason
+		RETURN count(s)`, map[string]any{"userID": userID, "at": at, "reason": reason})
+}
+
+func (s neo4jSessionStore) UserSessions(ctx context.Context, userID string) ([]RefreshSession, error) {
+	return db.QueryAs[RefreshSession](ctx, s.conn, `MATCH (s:RefreshSession {userId: $userID})
+		WHERE s.revokedAt IS NULL
+		RETURN s{.*} AS data ORDER BY s.createdAt DESC`, map[string]any{"userID": userID})
+}
+
+// writeCount runs a write query returning a single count
+func writeCount(ctx context.Context, conn *db.Database, query string, params map[string]any) (int, error) {
+	result, err := conn.ExecuteWrite(ctx, func(ctx context.Context, tx neo4j.ManagedTransaction) (any, error) {
+		res, err := tx.Run(ctx, query, params)
+		if err != nil {
+			return nil, err
+		}
+		record, err := res.Single(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return record.Values[0], nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	count, _ := result.(int64)
+	return int(count), nil
+}
@@ -0,0 +1,128 @@
+package auth
+
+import (
+	"bigfoot/golf/common/models/db"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// sqliteSessionStore keeps each refresh session as a json record in refresh_sessions
+type sqliteSessionStore struct {
+	conn *sql.DB
+}
+
+// NewSQLiteSessionStore returns a SessionStore backed by the SQLite database
+func NewSQLiteSessionStore(conn *sql.DB) SessionStore {
+	return sqliteSessionStore{conn: conn}
+}
+
+func (s sqliteSessionStore) Create(ctx context.Context, session *RefreshSession) error {
+	session.ID = db.NewID()
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	return db.Timed(ctx, func(ctx context.Context) error {
+		_, err := s.conn.ExecContext(ctx, `INSERT INTO refresh_sessions (id, user_id, token_hash, created_at, data) VALUES (?, ?, ?, ?, ?)`,
+			session.ID, session.UserID, session.TokenHash, session.CreatedAt.UnixNano(), string(data))
+		return err
+	})
+}
+
+func (s sqliteSessionStore) Get(ctx context.Context, id string) (*RefreshSession, error) {
+	found, err := s.query(ctx, `SELECT data FROM refresh_sessions WHERE id = ?`, id)
+	if err != nil || len(found) == 0 {
+		return nil, err
+	}
+	return &found[0], nil
+}
+
+func (s sqliteSessionStore) Rotate(ctx context.Context, session *RefreshSession, prevHash string) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	var rotated int64
+	err = db.Timed(ctx, func(ctx context.Context) error {
+		res, err := s.conn.ExecContext(ctx, `UPDATE refresh_sessions SET token_hash = ?, data = ?
+			WHERE id = ? AND token_hash = ? AND revoked_at IS NULL`, session.TokenHash, string(data), session.ID, prevHash)
+		if err != nil {
+			return err
+		}
+		rotated, err = res.RowsAffected()
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if rotated == 0 {
+		return ErrTokenReused
+	}
+	return nil
+}
+
+func (s sqliteSessionStore) Revoke(ctx context.Context, id, reason string, at time.Time) error {
+	var found int
+	err := db.Timed(ctx, func(ctx context.Context) error {
+		if err := s.conn.QueryRowContext(ctx, `SELECT count(*) FROM refresh_sessions WHERE id = ?`, id).Scan(&found); err != nil || found == 0 {
+			return err
+		}
+		_, err := s.conn.ExecContext(ctx, `UPDATE refresh_sessions
+			SET revoked_at = ?, data = json_set(data, '$.revokedAt', ?, '$.revokedReason', ?)
+			WHERE id = ? AND revoked_at IS NULL`, at.UnixNano(), at.Format(time.RFC3339Nano), reason, id)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if found == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+func (s sqliteSessionStore) RevokeUser(ctx context.Context, userID, reason string, at time.Time) (int, error) {
+	var revoked int64
+	err := db.Timed(ctx, func(ctx context.Context) error {
+		res, err := s.conn.ExecContext(ctx, `UPDATE refresh_sessions
+			SET revoked_at = ?, data = json_set(data, '$.revokedAt', ?, '$.revokedReason', ?)
+			WHERE user_id = ? AND revoked_at IS NULL`, at.UnixNano(), at.Format(time.RFC3339Nano), reason, userID)
+		if err != nil {
+			return err
+		}
+		revoked, err = res.RowsAffected()
+		return err
+	})
+	return int(revoked), err
+}
+
+func (s sqliteSessionStore) UserSessions(ctx context.Context, userID string) ([]RefreshSession, error) {
+	return s.query(ctx, `SELECT data FROM refresh_sessions
+		WHERE user_id = ? AND revoked_at IS NULL ORDER BY created_at DESC`, userID)
+}
+
+func (s sqliteSessionStore) query(ctx context.Context, query string, args ...any) ([]RefreshSession, error) {
+	var found []RefreshSession
+	err := db.Timed(ctx, func(ctx context.Context) error {
+		rows, err := s.conn.QueryContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var data string
+			if err := rows.Scan(&data); err != nil {
+				return err
+			}
+			var session RefreshSession
+			if err := json.Unmarshal([]byte(data), &session); err != nil {
+				return err
+			}
+			found = append(found, session)
+		}
+		return rows.Err()
+	})
+	return found, err
+}
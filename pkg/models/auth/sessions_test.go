@@ -0,0 +1,318 @@
+package auth
+
+import (
+	"bigfoot/golf/common/models/account"
+	"bigfoot/golf/common/models/db"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/mux"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// authorized returns a request carrying the access token
+func authorized(method, target, token string) *http.Request {
+	req := httptest.NewRequest(method, target, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	return req
+}
+
+func TestSessionStores(t *testing.T) {
+	ctx := context.Background()
+	stores := []struct {
+		name  string
+		store func(t *testing.T) SessionStore
+	}{
+		{"memory", func(t *testing.T) SessionStore { return NewMemorySessionStore() }},
+		{"sqlite", func(t *testing.T) SessionStore {
+			conn, err := db.OpenSQLite(ctx, filepath.Join(t.TempDir(), "golf.db"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { conn.Close() })
+			return NewSQLiteSessionStore(conn)
+		}},
+	}
+	for _, st := range stores {
+		t.Run(st.name, func(t *testing.T) {
+			store := st.store(t)
+			now := time.Now().UTC().Truncate(time.Millisecond)
+			newSession := func(userID, hash string, age time.Duration) *RefreshSession {
+				s := &RefreshSession{UserID: userID, TokenHash: hash, Device: "phone", IP: "10.0.0.1",
+					CreatedAt: now.Add(-age), LastUsedAt: now.Add(-age), ExpiresAt: now.Add(time.Hour)}
+				if err := store.Create(ctx, s); err != nil || s.ID == "" {
+					t.Fatalf("unexpected error creating session: %v", err)
+				}
+				return s
+			}
+			older := newSession("golfer", "hash-1", time.Hour)
+			newer := newSession("golfer", "hash-2", time.Minute)
+			other := newSession("other", "hash-3", 0)
+
+			rotated := *older
+			rotated.TokenHash, rotated.IP, rotated.LastUsedAt = "hash-1b", "10.0.0.2", now
+
+			tests := []struct {
+				name    string
+				run     func() error
+				wantErr error
+			}{
+				{"rotates with the current token", func() error { return store.Rotate(ctx, &rotated, "hash-1") }, nil},
+				{"refuses the spent token", func() error { return store.Rotate(ctx, &rotated, "hash-1") }, ErrTokenReused},
+				{"revokes a session", func() error { return store.Revoke(ctx, newer.ID, RevokedByUser, now) }, nil},
+				{"revokes a revoked session again", func() error { return store.Revoke(ctx, newer.ID, RevokedLogout, now) }, nil},
+				{"refuses to rotate a revoked session", func() error { return store.Rotate(ctx, newer, "hash-2") }, ErrTokenReused},
+				{"reports an unknown session", func() error { return store.Revoke(ctx, "missing", RevokedByUser, now) }, ErrSessionNotFound},
+			}
+			for _, tt := range tests {
+				if err := tt.run(); !errors.Is(err, tt.wantErr) {
+					t.Errorf("%s: expected %v, got %v", tt.name, tt.wantErr, err)
+				}
+			}
+
+			got, err := store.Get(ctx, older.ID)
+			if err != nil || got == nil || got.TokenHash != "hash-1b" || got.IP != "10.0.0.2" {
+				t.Errorf("expected the rotated token and use stored, got %+v %v", got, err)
+			}
+			if got, _ := store.Get(ctx, newer.ID); got == nil || got.RevokedAt == nil || got.RevokedReason != RevokedByUser {
+				t.Errorf("expected the first revocation kept, got %+v", got)
+			}
+			if list, err := store.UserSessions(ctx, "golfer"); err != nil || len(list) != 1 || list[0].ID != older.ID {
+				t.Errorf("expected only the active session listed, got %+v %v", list, err)
+			}
+			if revoked, err := store.RevokeUser(ctx, "golfer", RevokedSignOut, now); err != nil || revoked != 1 {
+				t.Errorf("expected one session signed out, got %d %v", revoked, err)
+			}
+			if list, _ := store.UserSessions(ctx, "other"); len(list) != 1 || list[0].ID != other.ID {
+				t.Errorf("expected the other user's session untouched, got %+v", list)
+			}
+		})
+	}
+}
+
+func TestRefreshRotationAndReuse(t *testing.T) {
+	users := useMemoryStores(t)
+	hash, _ := bcrypt.GenerateFromPassword([]byte("birdie-putt"), bcrypt.MinCost)
+	users.Save(context.Background(), &account.User{ID: "golfer", Email: "golfer@example.com", Password: string(hash)})
+	srv := AuthServer{jwtSecret: []byte("test-secret")}
+
+	login := func(t *testing.T) AuthResponse {
+		t.Helper()
+		payload, _ := json.Marshal(LoginRequest{Email: "golfer@example.com", Password: "birdie-putt"})
+		rec := httptest.NewRecorder()
+		srv.HandleLogin(rec, httptest.NewRequest("POST", "/auth/login", bytes.NewReader(payload)))
+		var resp AuthResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || resp.RefreshToken == "" || resp.User.Password != "" {
+			t.Fatalf("expected tokens without the password, got %s", rec.Body.String())
+		}
+		return resp
+	}
+	refresh := func(token string) (int, AuthResponse) {
+		payload, _ := json.Marshal(map[string]string{"refresh_token": token})
+		rec := httptest.NewRecorder()
+		srv.HandleRefreshToken(rec, httptest.NewRequest("POST", "/auth/refresh", bytes.NewReader(payload)))
+		var resp AuthResponse
+		json.NewDecoder(rec.Body).Decode(&resp)
+		return rec.Code, resp
+	}
+
+	first := login(t)
+	code, second := refresh(first.RefreshToken)
+	if code != http.StatusOK || second.RefreshToken == first.RefreshToken {
+		t.Fatalf("expected a new refresh token, got %d", code)
+	}
+
+	tests := []struct {
+		name     string
+		token    string
+		wantCode int
+	}{
+		{"rejects the spent token", first.RefreshToken, http.StatusUnauthorized},
+		{"revoked the family, so the newer token is dead too", second.RefreshToken, http.StatusUnauthorized},
+		{"rejects an access token", second.Token, http.StatusUnauthorized},
+		{"rejects garbage", "not-a-token", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code, _ := refresh(tt.token); code != tt.wantCode {
+				t.Errorf("expected %d, got %d", tt.wantCode, code)
+			}
+		})
+	}
+	if list, _ := UserSessions(context.Background(), "golfer"); len(list) != 0 {
+		t.Errorf("expected the reused session gone from the list, got %+v", list)
+	}
+
+	// Logging out with the cookie ends that session only
+	kept, ended := login(t), login(t)
+	req := httptest.NewRequest("POST", "/auth/logout", nil)
+	req.AddCookie(&http.Cookie{Name: "bftapc", Value: ended.RefreshToken})
+	rec := httptest.NewRecorder()
+	srv.HandleLogout(rec, req)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected logout to answer 204, got %d", rec.Code)
+	}
+	if code, _ := refresh(ended.RefreshToken); code != http.StatusUnauthorized {
+		t.Errorf("expected the logged out token refused, got %d", code)
+	}
+	if code, _ := refresh(kept.RefreshToken); code != http.StatusOK {
+		t.Errorf("expected the other device to stay signed in, got %d", code)
+	}
+}
+
+func TestRevokeSessions(t *testing.T) {
+	users := useMemoryStores(t)
+	users.Save(context.Background(), &account.User{ID: "golfer", Email: "golfer@example.com"})
+	users.Save(context.Background(), &account.User{ID: "other", Email: "other@example.com"})
+	srv := AuthServer{jwtSecret: []byte("test-secret")}
+	signIn := func(userID string) *AuthResponse {
+		resp, err := srv.signIn(httptest.NewRequest("POST", "/auth/login", nil), account.User{ID: userID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	golfer, laptop, other := signIn("golfer"), signIn("golfer"), signIn("other")
+	sessionOf := func(resp *AuthResponse) string {
+		claims := &Claims{}
+		jwt.ParseWithClaims(resp.Token, claims, func(*jwt.Token) (interface{}, error) { return srv.jwtSecret, nil })
+		return claims.SessionID
+	}
+
+	rec := httptest.NewRecorder()
+	srv.AuthenticateMiddleware(false, srv.HandleSessions)(rec, authorized("GET", "/auth/sessions", golfer.Token))
+	var list []RefreshSession
+	if err := json.NewDecoder(rec.Body).Decode(&list); err != nil || len(list) != 2 {
+		t.Fatalf("expected the golfer's two sessions, got %d %s", rec.Code, rec.Body.String())
+	}
+	for _, s := range list {
+		if s.TokenHash != "" || s.Current != (s.ID == sessionOf(golfer)) {
+			t.Errorf("expected only this device marked current and no token hashes, got %+v", s)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		method   string
+		target   string
+		vars     map[string]string
+		wantCode int
+	}{
+		{"cannot revoke another user's session", "DELETE", "/auth/sessions/x", map[string]string{"id": sessionOf(other)}, http.StatusNotFound},
+		{"revokes the laptop", "DELETE", "/auth/sessions/x", map[string]string{"id": sessionOf(laptop)}, http.StatusNoContent},
+		{"signs out everywhere", "DELETE", "/auth/sessions", nil, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := srv.HandleRevokeAllSessions
+			if tt.vars != nil {
+				handler = srv.HandleRevokeSession
+			}
+			req := authorized(tt.method, tt.target, golfer.Token)
+			if tt.vars != nil {
+				req = mux.SetURLVars(req, tt.vars)
+			}
+			rec := httptest.NewRecorder()
+			srv.AuthenticateMiddleware(false, handler)(rec, req)
+			if rec.Code != tt.wantCode {
+				t.Errorf("expected %d, got %d: %s", tt.wantCode, rec.Code, rec.Body.String())
+			}
+		})
+	}
+
+	ctx := context.Background()
+	if list, _ := UserSessions(ctx, "golfer"); len(list) != 0 {
+		t.Errorf("expected the golfer signed out everywhere, got %+v", list)
+	}
+	if list, _ := UserSessions(ctx, "other"); len(list) != 1 {
+		t.Errorf("expected the other user still signed in, got %+v", list)
+	}
+}
+
+func TestAccessTokenEndsWithSession(t *testing.T) {
+	users := useMemoryStores(t)
+	users.Save(context.Background(), &account.User{ID: "golfer", Email: "golfer@example.com"})
+	srv := AuthServer{jwtSecret: []byte("test-secret")}
+	me := func(token string) int {
+		rec := httptest.NewRecorder()
+		srv.AuthenticateMiddleware(false, func(w http.ResponseWriter, r *http.Request) {})(rec, authorized("GET", "/auth/me", token))
+		return rec.Code
+	}
+
+	tests := []struct {
+		name   string
+		revoke func(ctx context.Context, resp *AuthResponse, sessionID string)
+	}{
+		{"logging out", func(ctx context.Context, resp *AuthResponse, _ string) {
+			body, _ := json.Marshal(map[string]string{"refresh_token": resp.RefreshToken})
+			srv.HandleLogout(httptest.NewRecorder(), httptest.NewRequest("POST", "/auth/logout", bytes.NewReader(body)))
+		}},
+		{"revoking the session", func(ctx context.Context, _ *AuthResponse, sessionID string) {
+			RevokeSession(ctx, "golfer", sessionID, RevokedByUser)
+		}},
+		{"signing out everywhere", func(ctx context.Context, _ *AuthResponse, _ string) {
+			RevokeUserSessions(ctx, "golfer", RevokedSignOut)
+		}},
+		{"reusing a refresh token", func(ctx context.Context, _ *AuthResponse, sessionID string) {
+			revokeReused(ctx, sessionID, time.Now())
+		}},
+		{"changing the password elsewhere", func(ctx context.Context, _ *AuthResponse, _ string) {
+			RevokeOtherSessions(ctx, "golfer", "", RevokedPasswordChange)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := srv.signIn(httptest.NewRequest("POST", "/auth/login", nil), account.User{ID: "golfer"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			claims := &Claims{}
+			jwt.ParseWithClaims(resp.Token, claims, func(*jwt.Token) (interface{}, error) { return srv.jwtSecret, nil })
+			if code := me(resp.Token); code != http.StatusOK {
+				t.Fatalf("expected the fresh token accepted, got %d", code)
+			}
+			tt.revoke(context.Background(), resp, claims.SessionID)
+			if code := me(resp.Token); code != http.StatusUnauthorized {
+				t.Errorf("expected the access token refused once its session ended, got %d", code)
+			}
+		})
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.5, 172.16.0.0/12")
+	tests := []struct {
+		name      string
+		remote    string
+		forwarded []string
+		want      string
+	}{
+		{"uses the peer without a header", "203.0.113.7:52000", nil, "203.0.113.7"},
+		{"ignores the header from an untrusted peer", "203.0.113.7:52000", []string{"198.51.100.1"}, "203.0.113.7"},
+		{"believes a trusted proxy", "10.0.0.5:52000", []string{"198.51.100.1"}, "198.51.100.1"},
+		{"skips a chain of trusted proxies", "10.0.0.5:52000", []string{"198.51.100.1, 172.20.1.1"}, "198.51.100.1"},
+		{"takes the last untrusted hop, not one the client sent", "10.0.0.5:52000", []string{"1.2.3.4, 198.51.100.1"}, "198.51.100.1"},
+		{"reads every header line", "10.0.0.5:52000", []string{"1.2.3.4", "198.51.100.1"}, "198.51.100.1"},
+		{"falls back to the proxy without a header", "10.0.0.5:52000", nil, "10.0.0.5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tt.remote
+			for _, hop := range tt.forwarded {
+				req.Header.Add("X-Forwarded-For", hop)
+			}
+			if got := clientIP(req); got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
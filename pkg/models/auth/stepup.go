@@ -0,0 +1,284 @@
+package auth
+
+import (
+	"bigfoot/golf/common/models/account"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// stepUpLifetime is how long an elevated token lasts. It is not refreshed:
+// the next refresh returns an ordinary token.
+const stepUpLifetime = 5 * time.Minute
+
+// stepUpAttempts limits wrong codes and passwords per user, so a stolen
+// access token cannot guess its way to an elevated one
+var stepUpAttempts = newAttemptLimiter(5, 15*time.Minute)
+
+// ErrStepUpRequired means the action needs a token from a recent step up
+var ErrStepUpRequired = errors.New("step up required")
+
+// StepUpRequest proves the user again: a code from their authenticator or a
+// recovery code, or their password when they have no authenticator
+type StepUpRequest struct {
+	Code     string `json:"code,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+// Elevated reports whether the request carries a token from a step up
+func Elevated(ctx context.Context) bool {
+	claims, ok := ClaimsFrom(ctx)
+	return ok && claims.Elev
+}
+
+// StepUpRequired answers a request that needs an elevated token, saying so
+// the way RFC 9470 does so the client knows to step up and retry
+func StepUpRequired(w http.ResponseWriter) {
+	w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_user_authentication", error_description="step up required"`)
+	http.Error(w, ErrStepUpRequired.Error(), http.StatusForbidden)
+}
+
+// RequireStepUp returns middleware letting through only users whose roles
+// have the permission and whose token comes from a step up
+func (s AuthServer) RequireStepUp(perm Permission, next http.HandlerFunc) http.HandlerFunc {
+	return s.authenticate(perm, func(w http.ResponseWriter, r *http.Request) {
+		if !Elevated(r.Context()) {
+			StepUpRequired(w)
+			return
+		}
+		next(w, r)
+	})
+}
+
+// HandleStepUp checks the signed in user's second factor and returns a
+// short-lived elevated access token for the same session. The refresh token
+// is unchanged, so the response carries none.
+func (s AuthServer) HandleStepUp(w http.ResponseWriter, r *http.Request) {
+	claims, ok := ClaimsFrom(r.Context())
+	if !ok {
+		http.Error(w, ErrNotAuthenticated.Error(), http.StatusUnauthorized)
+		return
+	}
+	if stepUpAttempts.Blocked(claims.UserID) {
+		http.Error(w, "Too many attempts, try again later", http.StatusTooManyRequests)
+		return
+	}
+
+	var req StepUpRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	user, err := account.QueryUser(r.Context(), map[string]interface{}{"id": claims.UserID})
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if user == nil {
+		http.Error(w, ErrNotAuthenticated.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	err = verifyStepUp(r.Context(), *user, req)
+	switch {
+	case errors.Is(err, ErrInvalidCode):
+		stepUpAttempts.Record(claims.UserID)
+		http.Error(w, "Invalid code or password", http.StatusUnauthorized)
+		return
+	case errors.Is(err, ErrTOTPNotEnrolled):
+		http.Error(w, "Enroll an authenticator to step up", http.StatusForbidden)
+		return
+	case err != nil:
+		http.Error(w, "Failed to verify", http.StatusInternalServerError)
+		return
+	}
+	stepUpAttempts.Reset(claims.UserID)
+
+	response, err := s.elevatedToken(*user, claims.SessionID)
+	if err != nil {
+		http.Error(w, "Failed to generate tokens", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// verifyStepUp checks the user's authenticator when they have one and their
+// password otherwise. Staff always need an authenticator.
+func verifyStepUp(ctx context.Context, user account.User, req StepUpRequest) error {
+	enrolled, err := TOTPEnrolled(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if enrolled {
+		return VerifyTOTP(ctx, user.ID, req.Code)
+	}
+	if LevelOf(UserRoles(user)) >= ClubHouseLevel || user.Password == "" {
+		return ErrTOTPNotEnrolled
+	}
+	if req.Password == "" || bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)) != nil {
+		return ErrInvalidCode
+	}
+	return nil
+}
+
+// reauthenticated reports whether the request proves the user again, with a
+// token from a step up or their current password, answering it when it does
+// not. Wrong passwords count towards the step up lockout.
+func reauthenticated(w http.ResponseWriter, r *http.Request, user account.User, password string) bool {
+	if Elevated(r.Context()) {
+		return true
+	}
+	if stepUpAttempts.Blocked(user.ID) {
+		http.Error(w, "Too many attempts, try again later", http.StatusTooManyRequests)
+		return false
+	}
+	if password == "" || user.Password == "" {
+		StepUpRequired(w)
+		return false
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)) != nil {
+		stepUpAttempts.Record(user.ID)
+		http.Error(w, "Invalid password", http.StatusUnauthorized)
+		return false
+	}
+	stepUpAttempts.Reset(user.ID)
+	return true
+}
+
+// elevatedToken returns an access token from a step up, lasting stepUpLifetime
+func (s AuthServer) elevatedToken(user account.User, sessionID string) (*AuthResponse, error) {
+	roles := UserRoles(user)
+	claims := &Claims{
+		UserID:    user.ID,
+		Email:     user.Email,
+		Elev:      true,
+		Roles:     roles,
+		SessionID: sessionID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(stepUpLifetime)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.jwtSecret)
+	if err != nil {
+		return nil, err
+	}
+	user.Password, user.TempStr = "", ""
+	return &AuthResponse{
+		Token:     token,
+		User:      user,
+		ExpiresIn: claims.ExpiresAt.Time,
+		AuthLevel: max(LevelOf(roles), StepUpLevel),
+		Roles:     roles,
+	}, nil
+}
+
+// HandleTOTPStatus says whether the signed in user has an authenticator
+func (s AuthServer) HandleTOTPStatus(w http.ResponseWriter, r *http.Request) {
+	userID, err := ActingUser(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	enrolled, err := TOTPEnrolled(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]bool{"enrolled": enrolled})
+}
+
+// HandleTOTPEnroll starts enrolling an authenticator, returning the secret
+// and provisioning URI to add it to an app. The request needs an elevated
+// token or the current password, so a stolen access token cannot enroll one.
+func (s AuthServer) HandleTOTPEnroll(w http.ResponseWriter, r *http.Request) {
+	userID, err := ActingUser(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	var req StepUpRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	user, err := account.QueryUser(r.Context(), map[string]interface{}{"id": userID})
+	if err != nil || user == nil {
+		http.Error(w, "No User Found", http.StatusNotFound)
+		return
+	}
+	if !reauthenticated(w, r, *user, req.Password) {
+		return
+	}
+	setup, err := EnrollTOTP(r.Context(), *user)
+	if errors.Is(err, ErrTOTPEnrolled) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to enroll", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(setup)
+}
+
+// HandleTOTPConfirm turns on the pending authenticator with a code from it,
+// returning the recovery codes. Like enrolling, it needs an elevated token or
+// the current password.
+func (s AuthServer) HandleTOTPConfirm(w http.ResponseWriter, r *http.Request) {
+	userID, err := ActingUser(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	var req StepUpRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	user, err := account.QueryUser(r.Context(), map[string]interface{}{"id": userID})
+	if err != nil || user == nil {
+		http.Error(w, "No User Found", http.StatusNotFound)
+		return
+	}
+	if !reauthenticated(w, r, *user, req.Password) {
+		return
+	}
+	codes, err := ConfirmTOTP(r.Context(), user.ID, req.Code)
+	switch {
+	case errors.Is(err, ErrInvalidCode), errors.Is(err, ErrCodeReused), errors.Is(err, ErrTOTPNotEnrolled):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, ErrTOTPEnrolled):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, "Failed to confirm", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string][]string{"recoveryCodes": codes})
+}
+
+// HandleTOTPRemove removes the signed in user's authenticator
+func (s AuthServer) HandleTOTPRemove(w http.ResponseWriter, r *http.Request) {
+	userID, err := ActingUser(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	if err := RemoveTOTP(r.Context(), userID); err != nil {
+		http.Error(w, "Failed to remove authenticator", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
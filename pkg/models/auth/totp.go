@@ -0,0 +1,254 @@
+package auth
+
+import (
+	"bigfoot/golf/common/models/account"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// TOTP codes follow RFC 6238 with the defaults authenticator apps expect:
+// HMAC-SHA1, six digits and a new code every 30 seconds.
+const (
+	totpPeriod = 30
+	totpDigits = 6
+	// totpDrift is how many steps either side of now a code is accepted, so
+	// a phone clock a little off still works
+	totpDrift         = 1
+	totpIssuer        = "Bigfoot Golf"
+	recoveryCodeCount = 10
+)
+
+// clock returns the current time; tests replace it to step through code windows
+var clock = time.Now
+
+var (
+	// ErrTOTPNotEnrolled means the user has no confirmed authenticator
+	ErrTOTPNotEnrolled = errors.New("no authenticator enrolled")
+	// ErrTOTPEnrolled means the user already has a confirmed authenticator,
+	// which must be removed before enrolling another
+	ErrTOTPEnrolled = errors.New("an authenticator is already enrolled")
+	// ErrInvalidCode means the code is wrong, outside the drift window or already used
+	ErrInvalidCode = errors.New("invalid code")
+	// ErrCodeReused means another request used the enrollment first
+	ErrCodeReused = errors.New("code already used")
+)
+
+// TOTPEnrollment is a user's authenticator. The secret is only returned when
+// enrolling, and recovery codes are stored as hashes.
+type TOTPEnrollment struct {
+	UserID string `json:"userId"`
+	// Secret is the base32 shared secret the authenticator app holds
+	Secret      string     `json:"secret"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	ConfirmedAt *time.Time `json:"confirmedAt,omitempty" neo4j:",omitempty"`
+	// RecoveryCodes are the SHA-256 hashes of the recovery codes not yet used
+	RecoveryCodes []string `json:"recoveryCodes,omitempty"`
+	// LastStep is the time step of the last code accepted; a code from it or
+	// an earlier step is refused, so a code works once
+	LastStep int64 `json:"lastStep"`
+	// Uses counts the codes accepted, guarding Use against a lost race
+	Uses int `json:"uses"`
+}
+
+// Confirmed reports whether the user proved the authenticator works
+func (e TOTPEnrollment) Confirmed() bool {
+	return e.ConfirmedAt != nil
+}
+
+// TOTPSetup is what the user needs to add the authenticator to an app
+type TOTPSetup struct {
+	Secret string `json:"secret"`
+	// URI is the otpauth:// provisioning URI an app reads from a QR code
+	URI string `json:"uri"`
+}
+
+// TOTPStore persists authenticator enrollments, one per user
+type TOTPStore interface {
+	// Get returns the user's enrollment or nil when there is none
+	Get(ctx context.Context, userID string) (*TOTPEnrollment, error)
+	// Save creates or replaces the user's enrollment
+	Save(ctx context.Context, e *TOTPEnrollment) error
+	// Use stores the enrollment after a code was accepted when its use count
+	// is still prevUses, and otherwise returns ErrCodeReused, so only one of
+	// two requests racing with the same code wins
+	Use(ctx context.Context, e *TOTPEnrollment, prevUses int) error
+	// Delete removes the user's enrollment, if any
+	Delete(ctx context.Context, userID string) error
+}
+
+// totps is the store behind authenticator enrollments
+var totps TOTPStore = neo4jTOTPStore{}
+
+// SetTOTPStore replaces the store used for authenticator enrollments
+func SetTOTPStore(store TOTPStore) {
+	totps = store
+}
+
+// EnrollTOTP starts enrolling a new authenticator for the user, replacing any
+// enrollment not yet confirmed. The authenticator works once ConfirmTOTP
+// accepts a code from it.
+func EnrollTOTP(ctx context.Context, user account.User) (*TOTPSetup, error) {
+	existing, err := totps.Get(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil && existing.Confirmed() {
+		return nil, ErrTOTPEnrolled
+	}
+
+	raw := make([]byte, 20)
+	rand.Read(raw)
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(raw)
+	if err := totps.Save(ctx, &TOTPEnrollment{UserID: user.ID, Secret: secret, CreatedAt: clock()}); err != nil {
+		return nil, err
+	}
+	return &TOTPSetup{Secret: secret, URI: provisioningURI(user.Email, secret)}, nil
+}
+
+// ConfirmTOTP turns on the pending authenticator when the code is from it,
+// returning the recovery codes. They are shown this once.
+func ConfirmTOTP(ctx context.Context, userID, code string) ([]string, error) {
+	e, err := totps.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if e == nil {
+		return nil, ErrTOTPNotEnrolled
+	}
+	if e.Confirmed() {
+		return nil, ErrTOTPEnrolled
+	}
+	step, ok := matchStep(e.Secret, code, clock(), e.LastStep)
+	if !ok {
+		return nil, ErrInvalidCode
+	}
+
+	codes, hashes := newRecoveryCodes()
+	now := clock()
+	prevUses := e.Uses
+	e.ConfirmedAt = &now
+	e.RecoveryCodes = hashes
+	e.LastStep = step
+	e.Uses++
+	if err := totps.Use(ctx, e, prevUses); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// VerifyTOTP accepts a code from the user's authenticator or one of their
+// recovery codes. Each code works once.
+func VerifyTOTP(ctx context.Context, userID, code string) error {
+	e, err := totps.Get(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if e == nil || !e.Confirmed() {
+		return ErrTOTPNotEnrolled
+	}
+
+	prevUses := e.Uses
+	if step, ok := matchStep(e.Secret, code, clock(), e.LastStep); ok {
+		e.LastStep = step
+	} else if i := slices.Index(e.RecoveryCodes, hashRecoveryCode(code)); i >= 0 {
+		e.RecoveryCodes = slices.Delete(e.RecoveryCodes, i, i+1)
+	} else {
+		return ErrInvalidCode
+	}
+	e.Uses++
+	if err := totps.Use(ctx, e, prevUses); err != nil {
+		if errors.Is(err, ErrCodeReused) {
+			return ErrInvalidCode
+		}
+		return err
+	}
+	return nil
+}
+
+// TOTPEnrolled reports whether the user has a confirmed authenticator
+func TOTPEnrolled(ctx context.Context, userID string) (bool, error) {
+	e, err := totps.Get(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return e != nil && e.Confirmed(), nil
+}
+
+// RemoveTOTP removes the user's authenticator and recovery codes
+func RemoveTOTP(ctx context.Context, userID string) error {
+	return totps.Delete(ctx, userID)
+}
+
+// matchStep returns the time step within the drift window around at whose
+// code matches, skipping steps up to and including after, which were used
+func matchStep(secret, code string, at time.Time, after int64) (int64, bool) {
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	code = strings.TrimSpace(code)
+	if err != nil || len(code) != totpDigits {
+		return 0, false
+	}
+	now := at.Unix() / totpPeriod
+	for step := now - totpDrift; step <= now+totpDrift; step++ {
+		if step > after && subtle.ConstantTimeCompare([]byte(totpCode(key, step)), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// totpCode is the RFC 4226 code for the counter
+func totpCode(key []byte, counter int64) string {
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, uint64(counter))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, value%1000000)
+}
+
+// provisioningURI is the otpauth URI authenticator apps read, labelled with
+// the course and the user's email
+func provisioningURI(email, secret string) string {
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", totpIssuer)
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", fmt.Sprint(totpDigits))
+	params.Set("period", fmt.Sprint(totpPeriod))
+	label := url.PathEscape(totpIssuer + ":" + email)
+	return "otpauth://totp/" + label + "?" + params.Encode()
+}
+
+// newRecoveryCodes returns fresh recovery codes and their hashes
+func newRecoveryCodes() ([]string, []string) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, 5)
+		rand.Read(b)
+		raw := hex.EncodeToString(b)
+		codes[i] = raw[:5] + "-" + raw[5:]
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+	return codes, hashes
+}
+
+// hashRecoveryCode hashes a recovery code, ignoring case, spaces and the dash
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	return hashTokenID(code)
+}
@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// MemoryTOTPStore is an in-process TOTPStore for tests and local runs
+type MemoryTOTPStore struct {
+	mu          sync.Mutex
+	enrollments map[string]TOTPEnrollment
+}
+
+func NewMemoryTOTPStore() *MemoryTOTPStore {
+	return &MemoryTOTPStore{enrollments: make(map[string]TOTPEnrollment)}
+}
+
+func (m *MemoryTOTPStore) Get(ctx context.Context, userID string) (*TOTPEnrollment, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.enrollments[userID]
+	if !ok {
+		return nil, nil
+	}
+	e.RecoveryCodes = slices.Clone(e.RecoveryCodes)
+	return &e, nil
+}
+
+func (m *MemoryTOTPStore) Save(ctx context.Context, e *TOTPEnrollment) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.enrollments[e.UserID] = *e
+	return nil
+}
+
+func (m *MemoryTOTPStore) Use(ctx context.Context, e *TOTPEnrollment, prevUses int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stored, ok := m.enrollments[e.UserID]
+	if !ok || stored.Uses != prevUses {
+		return ErrCodeReused
+	}
+	m.enrollments[e.UserID] = *e
+	return nil
+}
+
+func (m *MemoryTOTPStore) Delete(ctx context.Context, userID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.enrollments, userID)
+	return nil
+}
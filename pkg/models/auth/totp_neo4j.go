@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"bigfoot/golf/common/models/db"
+	"context"
+)
+
+// neo4jTOTPStore keeps each enrollment as a TOTPEnrollment node
+type neo4jTOTPStore struct {
+	conn *db.Database
+}
+
+// NewNeo4jTOTPStore returns a TOTPStore backed by the connection
+func NewNeo4jTOTPStore(conn *db.Database) TOTPStore {
+	return neo4jTOTPStore{conn: conn}
+}
+
+func (s neo4jTOTPStore) Get(ctx context.Context, userID string) (*TOTPEnrollment, error) {
+	found, err := db.QueryAs[TOTPEnrollment](ctx, s.conn, `MATCH (e:TOTPEnrollment {userId: $userID}) RETURN e{.*} AS data`,
+		map[string]any{"userID": userID})
+	if err != nil || len(found) == 0 {
+		return nil, err
+	}
+	return &found[0], nil
+}
+
+func (s neo4jTOTPStore) Save(ctx context.Context, e *TOTPEnrollment) error {
+	props, err := db.Encode(e)
+	if err != nil {
+		return err
+	}
+	_, err = writeCount(ctx, s.conn, `MERGE (e:TOTPEnrollment {userId: $userID})
+		SET e = $props
+		RETURN count(e)`, map[string]any{"userID": e.UserID, "props": props})
+	return err
+}
+
+func (s neo4jTOTPStore) Use(ctx context.Context, e *TOTPEnrollment, prevUses int) error {
+	props, err := db.Encode(e)
+	if err != nil {
+		return err
+	}
+	used, err := writeCount(ctx, s.conn, `MATCH (e:TOTPEnrollment {userId: $userID, uses: $prevUses})
+		SET e = $props
+		RETURN count(e)`, map[string]any{"userID": e.UserID, "prevUses": prevUses, "props": props})
+	if err != nil {
+		return err
+	}
+	if used == 0 {
+		return ErrCodeReused
+	}
+	return nil
+}
+
+func (s neo4jTOTPStore) Delete(ctx context.Context, userID string) error {
+	_, err := writeCount(ctx, s.conn, `OPTIONAL MATCH (e:TOTPEnrollment {userId: $userID})
+		DETACH DELETE e
+		RETURN count(e)`, map[string]any{"userID": userID})
+	return err
+}
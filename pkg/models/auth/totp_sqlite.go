@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"bigfoot/golf/common/models/db"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+)
+
+// sqliteTOTPStore keeps each enrollment as a json record in totp_enrollments
+type sqliteTOTPStore struct {
+	conn *sql.DB
+}
+
+// NewSQLiteTOTPStore returns a TOTPStore backed by the SQLite database
+func NewSQLiteTOTPStore(conn *sql.DB) TOTPStore {
+	return sqliteTOTPStore{conn: conn}
+}
+
+func (s sqliteTOTPStore) Get(ctx context.Context, userID string) (*TOTPEnrollment, error) {
+	var data string
+	err := db.Timed(ctx, func(ctx context.Context) error {
+		return s.conn.QueryRowContext(ctx, `SELECT data FROM totp_enrollments WHERE user_id = ?`, userID).Scan(&data)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var e TOTPEnrollment
+	if err := json.Unmarshal([]byte(data), &e); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (s sqliteTOTPStore) Save(ctx context.Context, e *TOTPEnrollment) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return db.Timed(ctx, func(ctx context.Context) error {
+		_, err := s.conn.ExecContext(ctx, `INSERT INTO totp_enrollments (user_id, uses, data) VALUES (?, ?, ?)
+			ON CONFLICT (user_id) DO UPDATE SET uses = excluded.uses, data = excluded.data`, e.UserID, e.Uses, string(data))
+		return err
+	})
+}
+
+func (s sqliteTOTPStore) Use(ctx context.Context, e *TOTPEnrollment, prevUses int) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	var used int64
+	err = db.Timed(ctx, func(ctx context.Context) error {
+		res, err := s.conn.ExecContext(ctx, `UPDATE totp_enrollments SET uses = ?, data = ? WHERE user_id = ? AND uses = ?`,
+			e.Uses, string(data), e.UserID, prevUses)
+		if err != nil {
+			return err
+		}
+		used, err = res.RowsAffected()
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if used == 0 {
+		return ErrCodeReused
+	}
+	return nil
+}
+
+func (s sqliteTOTPStore) Delete(ctx context.Context, userID string) error {
+	return db.Timed(ctx, func(ctx context.Context) error {
+		_, err := s.conn.ExecContext(ctx, `DELETE FROM totp_enrollments WHERE user_id = ?`, userID)
+		return err
+	})
+}
@@ -0,0 +1,367 @@
+package auth
+
+import (
+	"bigfoot/golf/common/models/account"
+	"bigfoot/golf/common/models/db"
+	"bytes"
+	"context"
+	"encoding/base32"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// useClock fixes the time TOTP codes are checked at until the test ends,
+// returning a pointer that moves it
+func useClock(t *testing.T, at time.Time) *time.Time {
+	t.Helper()
+	now := at
+	previous := clock
+	clock = func() time.Time { return now }
+	t.Cleanup(func() { clock = previous })
+	return &now
+}
+
+// codeAt is the code an authenticator holding the secret shows at the time
+func codeAt(t *testing.T, secret string, at time.Time) string {
+	t.Helper()
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return totpCode(key, at.Unix()/totpPeriod)
+}
+
+// enrollAt enrolls and confirms an authenticator for the user at the time,
+// returning its secret and recovery codes
+func enrollAt(t *testing.T, user account.User, at time.Time) (string, []string) {
+	t.Helper()
+	ctx := context.Background()
+	setup, err := EnrollTOTP(ctx, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	codes, err := ConfirmTOTP(ctx, user.ID, codeAt(t, setup.Secret, at))
+	if err != nil || len(codes) != recoveryCodeCount {
+		t.Fatalf("expected the authenticator confirmed, got %v %v", codes, err)
+	}
+	return setup.Secret, codes
+}
+
+func TestTOTPStores(t *testing.T) {
+	ctx := context.Background()
+	stores := []struct {
+		name  string
+		store func(t *testing.T) TOTPStore
+	}{
+		{"memory", func(t *testing.T) TOTPStore { return NewMemoryTOTPStore() }},
+		{"sqlite", func(t *testing.T) TOTPStore {
+			conn, err := db.OpenSQLite(ctx, filepath.Join(t.TempDir(), "golf.db"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { conn.Close() })
+			return NewSQLiteTOTPStore(conn)
+		}},
+	}
+	for _, st := range stores {
+		t.Run(st.name, func(t *testing.T) {
+			store := st.store(t)
+			if got, err := store.Get(ctx, "golfer"); got != nil || err != nil {
+				t.Fatalf("expected no enrollment yet, got %+v %v", got, err)
+			}
+			pending := &TOTPEnrollment{UserID: "golfer", Secret: "SECRET", CreatedAt: time.Now().UTC()}
+			if err := store.Save(ctx, pending); err != nil {
+				t.Fatal(err)
+			}
+
+			confirmedAt := time.Now().UTC().Truncate(time.Millisecond)
+			used := *pending
+			used.ConfirmedAt, used.RecoveryCodes, used.LastStep, used.Uses = &confirmedAt, []string{"hash-1", "hash-2"}, 42, 1
+			tests := []struct {
+				name    string
+				run     func() error
+				wantErr error
+			}{
+				{"stores a use", func() error { return store.Use(ctx, &used, 0) }, nil},
+				{"refuses a use that lost the race", func() error { return store.Use(ctx, &used, 0) }, ErrCodeReused},
+				{"refuses a use for no enrollment", func() error { return store.Use(ctx, &TOTPEnrollment{UserID: "other", Uses: 1}, 0) }, ErrCodeReused},
+			}
+			for _, tt := range tests {
+				if err := tt.run(); !errors.Is(err, tt.wantErr) {
+					t.Errorf("%s: expected %v, got %v", tt.name, tt.wantErr, err)
+				}
+			}
+
+			got, err := store.Get(ctx, "golfer")
+			if err != nil || got == nil || !got.Confirmed() || got.LastStep != 42 || got.Uses != 1 || len(got.RecoveryCodes) != 2 {
+				t.Errorf("expected the use stored, got %+v %v", got, err)
+			}
+			if err := store.Delete(ctx, "golfer"); err != nil {
+				t.Fatal(err)
+			}
+			if got, _ := store.Get(ctx, "golfer"); got != nil {
+				t.Errorf("expected the enrollment removed, got %+v", got)
+			}
+		})
+	}
+}
+
+func TestTOTPCodes(t *testing.T) {
+	// RFC 6238 appendix B, SHA-1, keeping the last six of the eight digits
+	key := []byte("12345678901234567890")
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		if got := totpCode(key, tt.unix/totpPeriod); got != tt.want {
+			t.Errorf("at %d expected %s, got %s", tt.unix, tt.want, got)
+		}
+	}
+}
+
+func TestTOTPDriftWindow(t *testing.T) {
+	// Halfway through a step, so only the offsets decide which step a code is from
+	enrolled := time.Unix(1700000025, 0)
+	checked := enrolled.Add(10 * time.Minute)
+	user := account.User{ID: "golfer", Email: "golfer@example.com"}
+
+	tests := []struct {
+		name    string
+		codeAt  time.Duration
+		wantErr error
+	}{
+		{"accepts the current code", 0, nil},
+		{"accepts the previous step's code from a slow phone", -totpPeriod * time.Second, nil},
+		{"accepts the next step's code from a fast phone", totpPeriod * time.Second, nil},
+		{"refuses a code two steps old", -2 * totpPeriod * time.Second, ErrInvalidCode},
+		{"refuses a code two steps ahead", 2 * totpPeriod * time.Second, ErrInvalidCode},
+		{"refuses the code used to enroll", enrolled.Sub(checked), ErrInvalidCode},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useMemoryStores(t)
+			now := useClock(t, enrolled)
+			secret, _ := enrollAt(t, user, enrolled)
+			*now = checked
+			if err := VerifyTOTP(context.Background(), user.ID, codeAt(t, secret, checked.Add(tt.codeAt))); !errors.Is(err, tt.wantErr) {
+				t.Errorf("expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestTOTPCodesWorkOnce(t *testing.T) {
+	useMemoryStores(t)
+	enrolled := time.Unix(1700000025, 0)
+	now := useClock(t, enrolled)
+	user := account.User{ID: "golfer", Email: "golfer@example.com"}
+	secret, recovery := enrollAt(t, user, enrolled)
+	*now = enrolled.Add(time.Hour)
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		code    string
+		wantErr error
+	}{
+		{"accepts the next step's code", codeAt(t, secret, now.Add(totpPeriod*time.Second)), nil},
+		{"refuses it again", codeAt(t, secret, now.Add(totpPeriod*time.Second)), ErrInvalidCode},
+		{"refuses the current code once a later one was used", codeAt(t, secret, *now), ErrInvalidCode},
+		{"accepts a recovery code, ignoring case and the dash", strings.ToUpper(strings.ReplaceAll(recovery[0], "-", "")), nil},
+		{"refuses the recovery code again", recovery[0], ErrInvalidCode},
+		{"accepts another recovery code", recovery[1], nil},
+		{"refuses a made up code", "000000", ErrInvalidCode},
+	}
+	for _, tt := range tests {
+		if err := VerifyTOTP(ctx, user.ID, tt.code); !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.wantErr, err)
+		}
+	}
+
+	if _, err := EnrollTOTP(ctx, user); !errors.Is(err, ErrTOTPEnrolled) {
+		t.Errorf("expected a second enrollment refused, got %v", err)
+	}
+	if err := VerifyTOTP(ctx, "other", "123456"); !errors.Is(err, ErrTOTPNotEnrolled) {
+		t.Errorf("expected a user without an authenticator refused, got %v", err)
+	}
+}
+
+func TestTOTPProvisioningURI(t *testing.T) {
+	useMemoryStores(t)
+	setup, err := EnrollTOTP(context.Background(), account.User{ID: "golfer", Email: "golfer@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "otpauth://totp/Bigfoot%20Golf:golfer@example.com?algorithm=SHA1&digits=6&issuer=Bigfoot+Golf&period=30&secret=" + setup.Secret
+	if setup.URI != want || len(setup.Secret) != 32 {
+		t.Errorf("expected %s, got %s", want, setup.URI)
+	}
+}
+
+func TestStepUp(t *testing.T) {
+	users := useMemoryStores(t)
+	previous := stepUpAttempts
+	stepUpAttempts = newAttemptLimiter(5, 15*time.Minute)
+	t.Cleanup(func() { stepUpAttempts = previous })
+	enrolled := time.Unix(1700000025, 0)
+	now := useClock(t, enrolled)
+	ctx := context.Background()
+
+	hash, _ := bcrypt.GenerateFromPassword([]byte("birdie-putt"), bcrypt.MinCost)
+	golfer := account.User{ID: "golfer", Email: "golfer@example.com", Password: string(hash)}
+	shop := account.User{ID: "shop", Email: "shop@example.com", Password: string(hash), Roles: []string{"proShop"}}
+	owner := account.User{ID: "owner", Email: "owner@example.com", Password: string(hash), Roles: []string{"superAdmin"}}
+	for _, u := range []account.User{golfer, shop, owner} {
+		users.Save(ctx, &u)
+	}
+	secret, _ := enrollAt(t, owner, enrolled)
+	*now = enrolled.Add(time.Hour)
+
+	srv := AuthServer{jwtSecret: []byte("test-secret")}
+	signedIn := map[string]*RefreshSession{golfer.ID: sessionFor(t, golfer.ID), shop.ID: sessionFor(t, shop.ID), owner.ID: sessionFor(t, owner.ID)}
+	tokenFor := func(u account.User) string {
+		resp, _ := srv.generateTokens(u, signedIn[u.ID], "token-"+u.ID)
+		return resp.Token
+	}
+	stepUp := func(token string, req StepUpRequest) (*httptest.ResponseRecorder, AuthResponse) {
+		payload, _ := json.Marshal(req)
+		r := httptest.NewRequest("POST", "/auth/stepup", bytes.NewReader(payload))
+		r.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		srv.AuthenticateMiddleware(false, srv.HandleStepUp)(rec, r)
+		var resp AuthResponse
+		json.Unmarshal(rec.Body.Bytes(), &resp)
+		return rec, resp
+	}
+
+	tests := []struct {
+		name      string
+		user      account.User
+		req       StepUpRequest
+		wantCode  int
+		wantLevel AuthLevel
+	}{
+		{"lets a golfer without an authenticator use the password", golfer, StepUpRequest{Password: "birdie-putt"}, http.StatusOK, StepUpLevel},
+		{"refuses a wrong password", golfer, StepUpRequest{Password: "shank"}, http.StatusUnauthorized, NoAuthLevel},
+		{"makes staff enroll an authenticator", shop, StepUpRequest{Password: "birdie-putt"}, http.StatusForbidden, NoAuthLevel},
+		{"refuses the password once an authenticator is enrolled", owner, StepUpRequest{Password: "birdie-putt"}, http.StatusUnauthorized, NoAuthLevel},
+		{"accepts the authenticator's code", owner, StepUpRequest{Code: codeAt(t, secret, *now)}, http.StatusOK, AdminLevel},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, resp := stepUp(tokenFor(tt.user), tt.req)
+			if rec.Code != tt.wantCode || resp.AuthLevel != tt.wantLevel {
+				t.Fatalf("expected %d at level %d, got %d at level %d: %s", tt.wantCode, tt.wantLevel, rec.Code, resp.AuthLevel, rec.Body.String())
+			}
+			if rec.Code != http.StatusOK {
+				return
+			}
+			claims := &Claims{}
+			jwt.ParseWithClaims(resp.Token, claims, func(*jwt.Token) (interface{}, error) { return srv.jwtSecret, nil })
+			if !claims.Elev || claims.SessionID != signedIn[tt.user.ID].ID || resp.RefreshToken != "" || resp.User.Password != "" ||
+				time.Until(claims.ExpiresAt.Time) > stepUpLifetime {
+				t.Errorf("expected a short-lived elevated token for the session, got %+v", claims)
+			}
+
+			// The elevated token passes RequireStepUp where the ordinary one does not
+			for token, want := range map[string]int{tokenFor(tt.user): http.StatusForbidden, resp.Token: http.StatusOK} {
+				rec := httptest.NewRecorder()
+				srv.RequireStepUp(PermBook, func(w http.ResponseWriter, r *http.Request) {})(rec, authorized("POST", "/api/resetapw", token))
+				if rec.Code != want {
+					t.Errorf("expected %d, got %d", want, rec.Code)
+				}
+				if want == http.StatusForbidden && !strings.Contains(rec.Header().Get("WWW-Authenticate"), "insufficient_user_authentication") {
+					t.Errorf("expected the response to ask for a step up, got %q", rec.Header().Get("WWW-Authenticate"))
+				}
+			}
+		})
+	}
+
+	// Five wrong guesses lock the user out for a while, right password or not
+	for range 4 {
+		stepUp(tokenFor(golfer), StepUpRequest{Password: "shank"})
+	}
+	if rec, _ := stepUp(tokenFor(golfer), StepUpRequest{Password: "birdie-putt"}); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("expected the golfer locked out, got %d", rec.Code)
+	}
+	*now = now.Add(16 * time.Minute)
+	if rec, _ := stepUp(tokenFor(golfer), StepUpRequest{Password: "birdie-putt"}); rec.Code != http.StatusOK {
+		t.Errorf("expected the lockout over, got %d", rec.Code)
+	}
+}
+
+func TestTOTPEnrollNeedsReauthentication(t *testing.T) {
+	users := useMemoryStores(t)
+	previous := stepUpAttempts
+	stepUpAttempts = newAttemptLimiter(5, 15*time.Minute)
+	t.Cleanup(func() { stepUpAttempts = previous })
+	now := useClock(t, time.Unix(1700000025, 0))
+	ctx := context.Background()
+
+	hash, _ := bcrypt.GenerateFromPassword([]byte("birdie-putt"), bcrypt.MinCost)
+	golfer := account.User{ID: "golfer", Email: "golfer@example.com", Password: string(hash)}
+	shop := account.User{ID: "shop", Email: "shop@example.com", Password: string(hash), Roles: []string{"proShop"}}
+	for _, u := range []account.User{golfer, shop} {
+		users.Save(ctx, &u)
+	}
+
+	srv := AuthServer{jwtSecret: []byte("test-secret")}
+	ordinary, _ := srv.generateTokens(golfer, sessionFor(t, golfer.ID), "token-golfer")
+	elevated, _ := srv.elevatedToken(shop, sessionFor(t, shop.ID).ID)
+	post := func(handler http.HandlerFunc, target, token string, req StepUpRequest) *httptest.ResponseRecorder {
+		payload, _ := json.Marshal(req)
+		r := httptest.NewRequest("POST", target, bytes.NewReader(payload))
+		r.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		srv.AuthenticateMiddleware(false, handler)(rec, r)
+		return rec
+	}
+
+	// A stolen access token alone can neither enroll nor confirm
+	rec := post(srv.HandleTOTPEnroll, "/auth/totp/enroll", ordinary.Token, StepUpRequest{})
+	if rec.Code != http.StatusForbidden || !strings.Contains(rec.Header().Get("WWW-Authenticate"), "insufficient_user_authentication") {
+		t.Fatalf("expected enrolling to ask for a step up, got %d %q", rec.Code, rec.Header().Get("WWW-Authenticate"))
+	}
+	if rec := post(srv.HandleTOTPEnroll, "/auth/totp/enroll", ordinary.Token, StepUpRequest{Password: "shank"}); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected a wrong password refused, got %d", rec.Code)
+	}
+
+	rec = post(srv.HandleTOTPEnroll, "/auth/totp/enroll", ordinary.Token, StepUpRequest{Password: "birdie-putt"})
+	var setup TOTPSetup
+	if err := json.NewDecoder(rec.Body).Decode(&setup); err != nil || rec.Code != http.StatusOK || setup.Secret == "" {
+		t.Fatalf("expected the password to start enrolling, got %d %v", rec.Code, err)
+	}
+	code := codeAt(t, setup.Secret, *now)
+	if rec := post(srv.HandleTOTPConfirm, "/auth/totp/confirm", ordinary.Token, StepUpRequest{Code: code}); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected confirming without the password to ask for a step up, got %d", rec.Code)
+	}
+	if rec := post(srv.HandleTOTPConfirm, "/auth/totp/confirm", ordinary.Token, StepUpRequest{Code: code, Password: "birdie-putt"}); rec.Code != http.StatusOK {
+		t.Fatalf("expected the authenticator confirmed, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// An elevated token needs no password
+	rec = post(srv.HandleTOTPEnroll, "/auth/totp/enroll", elevated.Token, StepUpRequest{})
+	if err := json.NewDecoder(rec.Body).Decode(&setup); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("expected the elevated token to start enrolling, got %d %v", rec.Code, err)
+	}
+	if rec := post(srv.HandleTOTPConfirm, "/auth/totp/confirm", elevated.Token, StepUpRequest{Code: codeAt(t, setup.Secret, *now)}); rec.Code != http.StatusOK {
+		t.Errorf("expected the elevated token to confirm, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,402 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j/dbtype"
+)
+
+// Structs map to node properties by their neo4j tag, falling back to the name
+// in their json tag; fields with neither are left out. The neo4j tag takes
+// options after the name:
+//
+//	neo4j:"-"               never stored
+//	neo4j:",omitempty"      left out of the properties when zero
+//	neo4j:"players,json"    stored as a json string, for lists of structs
+//
+// Encode leaves out fields holding other structs, since those are separate
+// nodes linked by a relationship, except for time.Time and fields tagged json.
+// A time.Duration is stored as int64 nanoseconds.
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+	bytesType    = reflect.TypeOf([]byte(nil))
+)
+
+type codecField struct {
+	index     []int
+	name      string
+	omitEmpty bool
+	asJSON    bool
+}
+
+// codecFields caches the fields of each struct type the codec has seen
+var codecFields sync.Map
+
+func fieldsOf(t reflect.Type) []codecField {
+	if cached, ok := codecFields.Load(t); ok {
+		return cached.([]codecField)
+	}
+	var fields []codecField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, hasTag := f.Tag.Lookup("neo4j")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "" {
+			jsonName, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if jsonName == "-" {
+				continue
+			}
+			name = jsonName
+		}
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			for _, inner := range fieldsOf(f.Type) {
+				inner.index = append([]int{i}, inner.index...)
+				fields = append(fields, inner)
+			}
+			continue
+		}
+		if !f.IsExported() || (name == "" && !hasTag) {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		field := codecField{index: []int{i}, name: name}
+		for _, opt := range strings.Split(opts, ",") {
+			switch opt {
+			case "omitempty":
+				field.omitEmpty = true
+			case "json":
+				field.asJSON = true
+			}
+		}
+		fields = append(fields, field)
+	}
+	codecFields.Store(t, fields)
+	return fields
+}
+
+// Encode returns the node properties of a struct or pointer to one
+func Encode(v any) (map[string]any, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil, fmt.Errorf("db: encode nil %s", rv.Type())
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("db: encode %s: not a struct", rv.Type())
+	}
+
+	props := make(map[string]any)
+	for _, field := range fieldsOf(rv.Type()) {
+		fv := rv.FieldByIndex(field.index)
+		if field.omitEmpty && fv.IsZero() {
+			continue
+		}
+		if field.asJSON {
+			encoded, err := json.Marshal(fv.Interface())
+			if err != nil {
+				return nil, fmt.Errorf("db: encode %s: %w", field.name, err)
+			}
+			props[field.name] = string(encoded)
+			continue
+		}
+		if value, ok := encodeValue(fv); ok {
+			props[field.name] = value
+		}
+	}
+	return props, nil
+}
+
+// encodeValue converts a field to a value Neo4j can store, reporting false
+// for the struct values that belong on other nodes
+func encodeValue(v reflect.Value) (any, bool) {
+	switch {
+	case v.Type() == timeType:
+		return v.Interface(), true
+	case v.Type() == durationType:
+		return v.Int(), true
+	case v.Type() == bytesType:
+		return v.Bytes(), true
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		return v.Bool(), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	case reflect.String:
+		return v.String(), true
+	case reflect.Pointer:
+		if v.IsNil() {
+			return nil, false
+		}
+		return encodeValue(v.Elem())
+	case reflect.Slice, reflect.Array:
+		if !storable(v.Type().Elem()) {
+			return nil, false
+		}
+		list := make([]any, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			item, _ := encodeValue(v.Index(i))
+			list = append(list, item)
+		}
+		return list, true
+	}
+	return nil, false
+}
+
+// storable reports whether values of the type can be a property or list item
+func storable(t reflect.Type) bool {
+	if t == timeType {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array, reflect.Interface, reflect.Func, reflect.Chan:
+		return false
+	case reflect.Pointer:
+		return storable(t.Elem())
+	}
+	return true
+}
+
+// Decode copies a value read from Neo4j into out, which must be a pointer.
+// Nodes and relationships decode from their properties, and maps into
+// structs by the same names Encode writes. Properties the struct does not
+// have are ignored; a property of the wrong type is an error.
+func Decode(in any, out any) error {
+	rv := reflect.ValueOf(out)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("db: decode into %T: not a pointer", out)
+	}
+	return decodeValue(in, rv.Elem(), rv.Elem().Type().String())
+}
+
+// DecodeAll decodes each record, such as the results of QueryForMap
+func DecodeAll[T any](records []map[string]any) ([]T, error) {
+	var out []T
+	for _, record := range records {
+		var item T
+		if err := Decode(record, &item); err != nil {
+			return nil, err
+		}
+		out = append(out, item)
+	}
+	return out, nil
+}
+
+// QueryAs runs a query returning data rows and decodes them
+func QueryAs[T any](ctx context.Context, conn *Database, query string, params map[string]any) ([]T, error) {
+	records, err := conn.QueryForMap(ctx, query, params)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeAll[T](records)
+}
+
+func decodeValue(in any, out reflect.Value, path string) error {
+	if in == nil {
+		return nil
+	}
+	switch v := in.(type) {
+	case dbtype.Node:
+		in = v.Props
+	case dbtype.Relationship:
+		in = v.Props
+	}
+
+	mismatch := func() error {
+		return fmt.Errorf("db: decode %s: cannot use %T as %s", path, in, out.Type())
+	}
+
+	switch out.Type() {
+	case timeType:
+		t, err := decodeTime(in)
+		if err != nil {
+			return fmt.Errorf("db: decode %s: %w", path, err)
+		}
+		out.Set(reflect.ValueOf(t))
+		return nil
+	case durationType:
+		d, err := decodeDuration(in)
+		if err != nil {
+			return fmt.Errorf("db: decode %s: %w", path, err)
+		}
+		out.SetInt(int64(d))
+		return nil
+	}
+
+	switch out.Kind() {
+	case reflect.Interface:
+		value := reflect.ValueOf(in)
+		if !value.Type().AssignableTo(out.Type()) {
+			return mismatch()
+		}
+		out.Set(value)
+	case reflect.Pointer:
+		if out.IsNil() {
+			out.Set(reflect.New(out.Type().Elem()))
+		}
+		return decodeValue(in, out.Elem(), path)
+	case reflect.Bool:
+		b, ok := in.(bool)
+		if !ok {
+			return mismatch()
+		}
+		out.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, ok := wholeNumber(in)
+		if !ok || out.OverflowInt(n) {
+			return mismatch()
+		}
+		out.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, ok := wholeNumber(in)
+		if !ok || n < 0 || out.OverflowUint(uint64(n)) {
+			return mismatch()
+		}
+		out.SetUint(uint64(n))
+	case reflect.Float32, reflect.Float64:
+		switch n := in.(type) {
+		case float64:
+			out.SetFloat(n)
+		case int64:
+			out.SetFloat(float64(n))
+		default:
+			return mismatch()
+		}
+	case reflect.String:
+		s, ok := in.(string)
+		if !ok {
+			return mismatch()
+		}
+		out.SetString(s)
+	case reflect.Struct:
+		switch v := in.(type) {
+		case map[string]any:
+			for _, field := range fieldsOf(out.Type()) {
+				if value, ok := v[field.name]; ok {
+					if err := decodeValue(value, out.FieldByIndex(field.index), path+"."+field.name); err != nil {
+						return err
+					}
+				}
+			}
+		case string:
+			return decodeJSON(v, out, path)
+		default:
+			return mismatch()
+		}
+	case reflect.Slice:
+		switch v := in.(type) {
+		case []any:
+			list := reflect.MakeSlice(out.Type(), len(v), len(v))
+			for i, item := range v {
+				if err := decodeValue(item, list.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+			out.Set(list)
+		case []byte:
+			if out.Type() != bytesType {
+				return mismatch()
+			}
+			out.SetBytes(append([]byte{}, v...))
+		case string:
+			return decodeJSON(v, out, path)
+		default:
+			return mismatch()
+		}
+	case reflect.Map:
+		m, ok := in.(map[string]any)
+		if !ok || out.Type().Key().Kind() != reflect.String {
+			return mismatch()
+		}
+		decoded := reflect.MakeMapWithSize(out.Type(), len(m))
+		for key, value := range m {
+			item := reflect.New(out.Type().Elem()).Elem()
+			if err := decodeValue(value, item, path+"."+key); err != nil {
+				return err
+			}
+			decoded.SetMapIndex(reflect.ValueOf(key).Convert(out.Type().Key()), item)
+		}
+		out.Set(decoded)
+	default:
+		return mismatch()
+	}
+	return nil
+}
+
+// decodeJSON reads a list or struct stored as a json string
+func decodeJSON(s string, out reflect.Value, path string) error {
+	if s == "" {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(s), out.Addr().Interface()); err != nil {
+		return fmt.Errorf("db: decode %s: %w", path, err)
+	}
+	return nil
+}
+
+func wholeNumber(in any) (int64, bool) {
+	switch n := in.(type) {
+	case int64:
+		return n, true
+	case float64:
+		if n == math.Trunc(n) && n >= math.MinInt64 && n <= math.MaxInt64 {
+			return int64(n), true
+		}
+		return 0, false
+	}
+	// properties built in Go rather than read back from the driver
+	switch v := reflect.ValueOf(in); v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32:
+		return v.Int(), true
+	}
+	return 0, false
+}
+
+func decodeTime(in any) (time.Time, error) {
+	switch t := in.(type) {
+	case time.Time:
+		return t, nil
+	case dbtype.Date:
+		return t.Time(), nil
+	case dbtype.LocalDateTime:
+		return t.Time(), nil
+	case string:
+		return time.Parse(time.RFC3339Nano, t)
+	}
+	return time.Time{}, fmt.Errorf("cannot use %T as time.Time", in)
+}
+
+func decodeDuration(in any) (time.Duration, error) {
+	switch d := in.(type) {
+	case int64:
+		return time.Duration(d), nil
+	case dbtype.Duration:
+		if d.Months != 0 {
+			return 0, fmt.Errorf("duration %s has months, which have no fixed length", d)
+		}
+		return time.Duration(d.Days)*24*time.Hour + time.Duration(d.Seconds)*time.Second + time.Duration(d.Nanos), nil
+	case string:
+		return time.ParseDuration(d)
+	}
+	return 0, fmt.Errorf("cannot use %T as time.Duration", in)
+}
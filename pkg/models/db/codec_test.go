@@ -0,0 +1,221 @@
+package db
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j/dbtype"
+)
+
+type codecGuest struct {
+	Name  string `json:"name"`
+	Email string `json:"email,omitempty"`
+}
+
+type codecStamps struct {
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type codecBooking struct {
+	codecStamps
+	ID       string            `json:"id,omitempty"`
+	Slot     int64             `json:"slot"`
+	Holes    int               `json:"holes"`
+	Price    float32           `json:"price"`
+	Open     bool              `json:"open"`
+	Weekday  time.Weekday      `json:"weekday"`
+	Gap      time.Duration     `json:"gap"`
+	TeeTime  time.Time         `json:"teeTime"`
+	EndsAt   time.Time         `json:"endsAt" neo4j:",omitempty"`
+	Tees     []int             `json:"tees"`
+	Skips    []string          `json:"skips"`
+	Secret   []byte            `json:"secret"`
+	Guests   []codecGuest      `json:"guests" neo4j:"guests,json"`
+	Booker   *codecGuest       `json:"booker"`
+	Players  []codecGuest      `json:"players"`
+	Extra    map[string]string `json:"extra"`
+	Held     bool              `json:"held" neo4j:"-"`
+	Renamed  string            `json:"renamed" neo4j:"stored"`
+	internal string
+	Untagged string
+}
+
+func TestEncode(t *testing.T) {
+	teeTime := time.Date(2025, time.June, 14, 8, 30, 0, 0, time.UTC)
+	booking := codecBooking{
+		codecStamps: codecStamps{CreatedAt: teeTime.Add(-time.Hour)},
+		ID:          "r1",
+		Slot:        7,
+		Holes:       9,
+		Price:       42.5,
+		Open:        true,
+		Weekday:     time.Saturday,
+		Gap:         10 * time.Minute,
+		TeeTime:     teeTime,
+		Tees:        []int{1, 10},
+		Secret:      []byte("key"),
+		Guests:      []codecGuest{{Name: "Pat"}},
+		Booker:      &codecGuest{Name: "Sam"},
+		Players:     []codecGuest{{Name: "Sam"}},
+		Extra:       map[string]string{"a": "b"},
+		Held:        true,
+		Renamed:     "value",
+		internal:    "hidden",
+		Untagged:    "hidden",
+	}
+	props, err := Encode(&booking)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		key     string
+		want    any
+		missing bool
+	}{
+		{name: "string", key: "id", want: "r1"},
+		{name: "int64", key: "slot", want: int64(7)},
+		{name: "int widens", key: "holes", want: int64(9)},
+		{name: "float32 widens", key: "price", want: 42.5},
+		{name: "bool", key: "open", want: true},
+		{name: "named int", key: "weekday", want: int64(6)},
+		{name: "duration as nanoseconds", key: "gap", want: int64(10 * time.Minute)},
+		{name: "time", key: "teeTime", want: teeTime},
+		{name: "embedded struct is flattened", key: "createdAt", want: teeTime.Add(-time.Hour)},
+		{name: "zero time with omitempty", key: "endsAt", missing: true},
+		{name: "list of ints", key: "tees", want: []any{int64(1), int64(10)}},
+		{name: "nil list stays a list", key: "skips", want: []any{}},
+		{name: "bytes", key: "secret", want: []byte("key")},
+		{name: "json tagged list", key: "guests", want: `[{"name":"Pat"}]`},
+		{name: "related struct", key: "booker", missing: true},
+		{name: "related list", key: "players", missing: true},
+		{name: "map", key: "extra", missing: true},
+		{name: "skipped by tag", key: "held", missing: true},
+		{name: "renamed by tag", key: "stored", want: "value"},
+		{name: "unexported", key: "internal", missing: true},
+		{name: "untagged", key: "Untagged", missing: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := props[tt.key]
+			if tt.missing {
+				if ok {
+					t.Errorf("expected %s left out, got %#v", tt.key, got)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %s = %#v, got %#v", tt.key, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	teeTime := time.Date(2025, time.June, 14, 8, 30, 0, 0, time.FixedZone("MDT", -6*60*60))
+	tests := []struct {
+		name string
+		in   codecBooking
+	}{
+		{name: "empty", in: codecBooking{}},
+		{name: "scalars", in: codecBooking{ID: "r1", Slot: 7, Holes: 18, Price: 40, Open: true, Weekday: time.Sunday}},
+		{name: "times keep their zone", in: codecBooking{TeeTime: teeTime, EndsAt: teeTime.Add(4 * time.Hour), codecStamps: codecStamps{CreatedAt: teeTime}}},
+		{name: "duration", in: codecBooking{Gap: 8 * time.Minute}},
+		{name: "lists", in: codecBooking{Tees: []int{1, 10}, Skips: []string{"2025-07-04"}, Secret: []byte{0, 1, 2}}},
+		{name: "json list", in: codecBooking{Guests: []codecGuest{{Name: "Pat", Email: "pat@example.com"}, {Name: "Lee"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			props, err := Encode(tt.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got codecBooking
+			if err := Decode(props, &got); err != nil {
+				t.Fatal(err)
+			}
+			want := tt.in
+			// empty lists come back empty rather than nil
+			if want.Tees == nil {
+				want.Tees = []int{}
+			}
+			if want.Skips == nil {
+				want.Skips = []string{}
+			}
+			if want.Secret == nil {
+				want.Secret = []byte{}
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("round trip changed the value:\n got %+v\nwant %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestDecodeNeo4jValues(t *testing.T) {
+	day := time.Date(2025, time.June, 14, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		in    any
+		check func(codecBooking) bool
+	}{
+		{name: "node properties", in: dbtype.Node{Props: map[string]any{"id": "n1", "slot": int64(3)}},
+			check: func(b codecBooking) bool { return b.ID == "n1" && b.Slot == 3 }},
+		{name: "relationship properties", in: dbtype.Relationship{Props: map[string]any{"guests": `[{"name":"Pat"}]`}},
+			check: func(b codecBooking) bool { return len(b.Guests) == 1 && b.Guests[0].Name == "Pat" }},
+		{name: "date", in: map[string]any{"teeTime": dbtype.Date(day)},
+			check: func(b codecBooking) bool { return b.TeeTime.Equal(day) }},
+		{name: "local datetime", in: map[string]any{"teeTime": dbtype.LocalDateTime(day.Add(8 * time.Hour))},
+			check: func(b codecBooking) bool { return b.TeeTime.Hour() == 8 }},
+		{name: "duration value", in: map[string]any{"gap": dbtype.Duration{Days: 1, Seconds: 90}},
+			check: func(b codecBooking) bool { return b.Gap == 24*time.Hour+90*time.Second }},
+		{name: "nested list of maps", in: map[string]any{"players": []any{map[string]any{"name": "Sam"}, map[string]any{"name": "Lee"}}},
+			check: func(b codecBooking) bool { return len(b.Players) == 2 && b.Players[1].Name == "Lee" }},
+		{name: "map into pointer", in: map[string]any{"booker": map[string]any{"name": "Sam"}},
+			check: func(b codecBooking) bool { return b.Booker != nil && b.Booker.Name == "Sam" }},
+		{name: "whole float into int", in: map[string]any{"holes": float64(9)},
+			check: func(b codecBooking) bool { return b.Holes == 9 }},
+		{name: "null leaves the zero value", in: map[string]any{"teeTime": nil, "slot": nil},
+			check: func(b codecBooking) bool { return b.TeeTime.IsZero() && b.Slot == 0 }},
+		{name: "unknown properties are ignored", in: map[string]any{"lockedAt": day, "id": "n2"},
+			check: func(b codecBooking) bool { return b.ID == "n2" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got codecBooking
+			if err := Decode(tt.in, &got); err != nil {
+				t.Fatal(err)
+			}
+			if !tt.check(got) {
+				t.Errorf("unexpected result %+v", got)
+			}
+		})
+	}
+}
+
+func TestDecodeRejectsWrongTypes(t *testing.T) {
+	tests := []struct {
+		name string
+		in   map[string]any
+		path string
+	}{
+		{name: "string into int", in: map[string]any{"slot": "seven"}, path: "slot"},
+		{name: "fractional float into int", in: map[string]any{"holes": 9.5}, path: "holes"},
+		{name: "int into time", in: map[string]any{"teeTime": int64(5)}, path: "teeTime"},
+		{name: "months into duration", in: map[string]any{"gap": dbtype.Duration{Months: 1}}, path: "gap"},
+		{name: "bad list item", in: map[string]any{"tees": []any{int64(1), "ten"}}, path: "tees[1]"},
+		{name: "bad json", in: map[string]any{"guests": "[{"}, path: "guests"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got codecBooking
+			err := Decode(tt.in, &got)
+			if err == nil || !strings.Contains(err.Error(), tt.path) {
+				t.Errorf("expected an error naming %s, got %v", tt.path, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ErrInvalidIdentifier is returned when a label, relationship type, variable
+// or property name cannot be written into Cypher text
+var ErrInvalidIdentifier = errors.New("invalid cypher identifier")
+
+// Labels are the node labels the builder will write into a query
+var Labels = map[string]bool{
+	"AuthConfig":            true,
+	"ChangeEvent":           true,
+	"DayOverride":           true,
+	"DetailedBlockSettings": true,
+	"Holiday":               true,
+	"Outing":                true,
+	"OutingTeam":            true,
+	"Reservation":           true,
+	"ReservationBlock":      true,
+	"ReservedDay":           true,
+	"Season":                true,
+	"SlotHold":              true,
+	"StandingOccurrence":    true,
+	"StandingReservation":   true,
+	"TeeSlot":               true,
+	"User":                  true,
+	"WaitlistEntry":         true,
+}
+
+// RelationshipTypes are the relationship types the builder will write into a query
+var RelationshipTypes = map[string]bool{
+	"BOOKED_TEETIME":  true,
+	"BOOKS":           true,
+	"HAS_HOLIDAY":     true,
+	"HAS_OVERRIDE":    true,
+	"HAS_RESERVATION": true,
+	"HAS_SETTINGS":    true,
+	"HAS_STANDING":    true,
+	"HAS_TEAM":        true,
+	"HOLDS":           true,
+	"IN_SLOT":         true,
+	"OCCURRED":        true,
+	"ORGANIZES":       true,
+	"WAITLISTED":      true,
+}
+
+// identifier is a variable or property name that needs no quoting
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Cypher builds a query in which every value is a parameter. Labels and
+// relationship types must be in the allowlists and variables and property
+// names must be plain identifiers; the first violation is kept and returned
+// by Build, so a query with a bad name is never run.
+type Cypher struct {
+	clauses []string
+	params  map[string]any
+	err     error
+}
+
+func NewCypher() *Cypher {
+	return &Cypher{params: make(map[string]any)}
+}
+
+// Match appends MATCH (variable:Label {prop: $param, ...})
+func (c *Cypher) Match(variable, label string, props map[string]any) *Cypher {
+	return c.node("MATCH", variable, label, props)
+}
+
+// Merge appends MERGE (variable:Label {prop: $param, ...})
+func (c *Cypher) Merge(variable, label string, props map[string]any) *Cypher {
+	return c.node("MERGE", variable, label, props)
+}
+
+// Create appends CREATE (variable:Label {prop: $param, ...})
+func (c *Cypher) Create(variable, label string, props map[string]any) *Cypher {
+	return c.node("CREATE", variable, label, props)
+}
+
+// MergeRelationship appends MERGE (from)-[variable:TYPE]->(to) between two
+// variables already bound by the query
+func (c *Cypher) MergeRelationship(from, variable, relType, to string) *Cypher {
+	if !RelationshipTypes[relType] {
+		c.fail("relationship type %q", relType)
+	}
+	c.checkVariable(from)
+	c.checkVariable(variable)
+	c.checkVariable(to)
+	c.clauses = append(c.clauses, fmt.Sprintf("MERGE (%s)-[%s:%s]->(%s)", from, variable, relType, to))
+	return c
+}
+
+// Set appends SET variable.prop = $param, ... for each property
+func (c *Cypher) Set(variable string, props map[string]any) *Cypher {
+	if len(props) == 0 {
+		return c
+	}
+	c.checkVariable(variable)
+	c.clauses = append(c.clauses, "SET "+strings.Join(c.assignments(variable, props, " = "), ", "))
+	return c
+}
+
+// OnMatchSet appends ON MATCH SET variable.prop = $param, ... after a Merge
+func (c *Cypher) OnMatchSet(variable string, props map[string]any) *Cypher {
+	if len(props) == 0 {
+		return c
+	}
+	c.checkVariable(variable)
+	c.clauses = append(c.clauses, "ON MATCH SET "+strings.Join(c.assignments(variable, props, " = "), ", "))
+	return c
+}
+
+// OnCreateSet appends ON CREATE SET variable.prop = $param, ... after a Merge
+func (c *Cypher) OnCreateSet(variable string, props map[string]any) *Cypher {
+	if len(props) == 0 {
+		return c
+	}
+	c.checkVariable(variable)
+	c.clauses = append(c.clauses, "ON CREATE SET "+strings.Join(c.assignments(variable, props, " = "), ", "))
+	return c
+}
+
+// Where appends WHERE variable.prop = $param AND ... for each property
+func (c *Cypher) Where(variable string, props map[string]any) *Cypher {
+	if len(props) == 0 {
+		return c
+	}
+	c.checkVariable(variable)
+	c.clauses = append(c.clauses, "WHERE "+strings.Join(c.assignments(variable, props, " = "), " AND "))
+	return c
+}
+
+// Return appends RETURN with the variables or variable.property names given
+func (c *Cypher) Return(items ...string) *Cypher {
+	for _, item := range items {
+		for _, part := range strings.Split(item, ".") {
+			c.checkVariable(part)
+		}
+	}
+	c.clauses = append(c.clauses, "RETURN "+strings.Join(items, ", "))
+	return c
+}
+
+// Build returns the query text and its parameters, or the first invalid name
+func (c *Cypher) Build() (string, map[string]any, error) {
+	if c.err != nil {
+		return "", nil, c.err
+	}
+	return strings.Join(c.clauses, "\n"), c.params, nil
+}
+
+func (c *Cypher) node(clause, variable, label string, props map[string]any) *Cypher {
+	if !Labels[label] {
+		c.fail("label %q", label)
+	}
+	c.checkVariable(variable)
+	node := fmt.Sprintf("(%s:%s)", variable, label)
+	if len(props) > 0 {
+		node = fmt.Sprintf("(%s:%s {%s})", variable, label, strings.Join(c.assignments("", props, ": "), ", "))
+	}
+	c.clauses = append(c.clauses, clause+" "+node)
+	return c
+}
+
+// assignments binds each value as a parameter, in key order so the text is stable
+func (c *Cypher) assignments(variable string, props map[string]any, op string) []string {
+	keys := make([]string, 0, len(props))
+	for key := range props {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if !identifier.MatchString(key) {
+			c.fail("property %q", key)
+			continue
+		}
+		param := c.param(props[key])
+		if variable != "" {
+			key = variable + "." + key
+		}
+		parts = append(parts, key+op+"$"+param)
+	}
+	return parts
+}
+
+func (c *Cypher) param(value any) string {
+	name := fmt.Sprintf("p%d", len(c.params))
+	c.params[name] = value
+	return name
+}
+
+func (c *Cypher) checkVariable(variable string) {
+	if !identifier.MatchString(variable) {
+		c.fail("variable %q", variable)
+	}
+}
+
+func (c *Cypher) fail(format string, args ...any) {
+	if c.err == nil {
+		c.err = fmt.Errorf("%w: %s", ErrInvalidIdentifier, fmt.Sprintf(format, args...))
+	}
+}
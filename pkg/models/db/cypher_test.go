@@ -0,0 +1,167 @@
+package db
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// hostileIDs try to close the property map or string and run their own clause
+var hostileIDs = []string{
+	`abc"}) DETACH DELETE n //`,
+	`abc'}) DETACH DELETE n //`,
+	`}) DETACH DELETE`,
+	`{id: 1}`,
+	"abc`) MATCH (m) DETACH DELETE m",
+	`\" OR 1=1`,
+}
+
+// assertParameterized fails when the value leaked into the query text instead
+// of being bound as one of its parameters
+func assertParameterized(t *testing.T, query string, params map[string]any, value string) {
+	t.Helper()
+	if strings.Contains(query, value) || strings.Contains(query, "DETACH DELETE") {
+		t.Errorf("value %q was written into the query:\n%s", value, query)
+	}
+	for _, p := range params {
+		if p == value {
+			return
+		}
+	}
+	t.Errorf("value %q is not a parameter of %v", value, params)
+}
+
+func TestHostileIDsAreParameters(t *testing.T) {
+	for _, id := range hostileIDs {
+		t.Run(id, func(t *testing.T) {
+			builds := map[string]func() (string, map[string]interface{}, error){
+				"relationship from": func() (string, map[string]interface{}, error) {
+					return relationshipQuery(Relation{NodeN: "User", NodeX: "Reservation", NodeNID: id, NodeXID: "r1", Name: "BOOKED_TEETIME"})
+				},
+				"relationship to": func() (string, map[string]interface{}, error) {
+					return relationshipQuery(Relation{NodeN: "Season", NodeX: "Holiday", NodeNID: "s1", NodeXID: id, Name: "HAS_HOLIDAY"})
+				},
+				"relationship body": func() (string, map[string]interface{}, error) {
+					return relationshipQuery(Relation{NodeN: "User", NodeX: "Reservation", NodeNID: "u1", NodeXID: "r1",
+						Name: "BOOKED_TEETIME", Property: "guests", Body: id})
+				},
+				"update": func() (string, map[string]interface{}, error) {
+					return buildUpdateQuery("User", map[string]interface{}{"id": id, "email": "a@b.c"})
+				},
+				"create": func() (string, map[string]interface{}, error) {
+					return buildCreateQuery("Reservation", map[string]interface{}{"id": "r1", "group": id})
+				},
+				"query": func() (string, map[string]interface{}, error) {
+					return buildQueryCypher("User", map[string]interface{}{"id": id})
+				},
+			}
+			for name, build := range builds {
+				query, params, err := build()
+				if err != nil {
+					t.Fatalf("%s: %v", name, err)
+				}
+				assertParameterized(t, query, params, id)
+			}
+		})
+	}
+}
+
+func TestInvalidIdentifiersAreRejected(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func() (string, map[string]interface{}, error)
+	}{
+		{name: "unknown label", build: func() (string, map[string]interface{}, error) {
+			return buildQueryCypher("Admin", nil)
+		}},
+		{name: "injected label", build: func() (string, map[string]interface{}, error) {
+			return buildQueryCypher("User) DETACH DELETE (n", nil)
+		}},
+		{name: "unknown relationship", build: func() (string, map[string]interface{}, error) {
+			return relationshipQuery(Relation{NodeN: "User", NodeX: "User", NodeNID: "a", NodeXID: "b", Name: "OWNS"})
+		}},
+		{name: "injected relationship", build: func() (string, map[string]interface{}, error) {
+			return relationshipQuery(Relation{NodeN: "User", NodeX: "User", NodeNID: "a", NodeXID: "b", Name: "HAS_TEAM]->(x) DETACH DELETE x //"})
+		}},
+		{name: "injected relationship property", build: func() (string, map[string]interface{}, error) {
+			return relationshipQuery(Relation{NodeN: "User", NodeX: "Reservation", NodeNID: "a", NodeXID: "b", Name: "BOOKED_TEETIME",
+				Property: "guests = 1 DETACH DELETE r //", Body: "x"})
+		}},
+		{name: "injected filter key", build: func() (string, map[string]interface{}, error) {
+			return buildQueryCypher("User", map[string]interface{}{"id = '' OR 1=1 //": "x"})
+		}},
+		{name: "injected property key", build: func() (string, map[string]interface{}, error) {
+			return buildUpdateQuery("User", map[string]interface{}{"id": "u1", "is_admin = true, n.email": "x"})
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, _, err := tt.build()
+			if !errors.Is(err, ErrInvalidIdentifier) {
+				t.Fatalf("expected ErrInvalidIdentifier, got %v with query %q", err, query)
+			}
+			if query != "" {
+				t.Errorf("expected no query text, got %q", query)
+			}
+		})
+	}
+}
+
+func TestCypherBuild(t *testing.T) {
+	query, params, err := NewCypher().
+		Match("n", "Season", map[string]any{"id": "s1"}).
+		Match("x", "Holiday", map[string]any{"id": "h1"}).
+		MergeRelationship("n", "r", "HAS_HOLIDAY", "x").
+		Set("r", map[string]any{"since": 2025}).
+		Return("r").
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "MATCH (n:Season {id: $p0})\nMATCH (x:Holiday {id: $p1})\nMERGE (n)-[r:HAS_HOLIDAY]->(x)\nSET r.since = $p2\nRETURN r"
+	if query != want {
+		t.Errorf("unexpected query:\n%s\nwant:\n%s", query, want)
+	}
+	if params["p0"] != "s1" || params["p1"] != "h1" || params["p2"] != 2025 {
+		t.Errorf("unexpected params %v", params)
+	}
+}
+
+func TestIdempotentCreateQuery(t *testing.T) {
+	query, params, err := buildIdempotentCreateQuery("Reservation", map[string]any{
+		"id":             "r1",
+		IdempotencyKey:   "retry-1",
+		IdempotencyScope: "golfer",
+		"slot":           7,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "MERGE (n:Reservation {idempotencyKey: $p0, userId: $p1})\nON CREATE SET n.id = $p2, n.slot = $p3\nRETURN n.id"
+	if query != want {
+		t.Errorf("unexpected query:\n%s\nwant:\n%s", query, want)
+	}
+	if params["p0"] != "retry-1" || params["p1"] != "golfer" || params["p2"] != "r1" || params["p3"] != 7 {
+		t.Errorf("unexpected params %v", params)
+	}
+
+	if _, _, err := buildIdempotentCreateQuery("Reservation", map[string]any{"id": "r2", IdempotencyKey: "retry-1"}); err == nil {
+		t.Error("expected a key without its user refused")
+	}
+}
+
+func TestNewID(t *testing.T) {
+	seen := make(map[string]bool)
+	last := ""
+	for i := 0; i < 1000; i++ {
+		id := NewID()
+		if len(id) != 36 || seen[id] {
+			t.Fatalf("expected a fresh uuid, got %q", id)
+		}
+		if id < last {
+			t.Errorf("expected ids to sort in creation order, %q came after %q", id, last)
+		}
+		seen[id] = true
+		last = id
+	}
+}
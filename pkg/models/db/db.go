@@ -15,7 +15,6 @@ import (
 type Database struct {
 	Driver neo4j.DriverWithContext
 	//mu     sync.RWMutex
-	ctx context.Context
 	Err error
 }
 
@@ -28,20 +27,24 @@ type DynamicNode struct {
 
 var (
 	Instance     *Database
-	once         sync.Once
+	connectMu    sync.Mutex
 	TimeLocation *time.Location
 )
 
+// InitDB connects Instance to the Neo4j server named by DB_URI, leaving any
+// failure in Instance.Err. It can be called again after a failure; the driver
+// is kept and only the connectivity check is repeated.
 func InitDB(ctx context.Context) {
-
-	once.Do(func() {
-		//
+	connectMu.Lock()
+	defer connectMu.Unlock()
+	if Instance == nil {
+		Instance = &Database{}
+	}
+	if Instance.Driver == nil {
 		dbURI := os.Getenv("DB_URI")
 		dbUser := "neo4j"
 		dbPassword := os.Getenv("DB_ADMIN")
-		Instance = &Database{}
-		var err error
-		Instance.Driver, err = neo4j.NewDriverWithContext(
+		driver, err := neo4j.NewDriverWithContext(
 			dbURI,
 			neo4j.BasicAuth(dbUser, dbPassword, ""),
 			func(c *config.Config) {
@@ -49,20 +52,30 @@ func InitDB(ctx context.Context) {
 				c.MaxConnectionPoolSize = 50
 				c.ConnectionAcquisitionTimeout = time.Second * 30 // seconds
 			})
-
 		if err != nil {
 			Instance.Err = err
+			return
 		}
+		Instance.Driver = driver
+	}
 
-		err = Instance.Driver.VerifyConnectivity(ctx)
-		if err != nil {
-			Instance.Err = err
-		}
-		Instance.ctx = ctx
+	Instance.Err = Instance.Ping(ctx)
+	if Instance.Err == nil {
 		fmt.Println("Connection established.")
+	}
+}
 
-		//defer Neo.session.Close(ctx)
-	})
+// Ping checks the server answers within QueryTimeout
+func (db *Database) Ping(ctx context.Context) error {
+	if db.Driver == nil {
+		if db.Err != nil {
+			return db.Err
+		}
+		return fmt.Errorf("db: not connected to neo4j")
+	}
+	ctx, cancel := WithTimeout(ctx)
+	defer cancel()
+	return QueryErr(ctx, db.Driver.VerifyConnectivity(ctx))
 }
 
 func (db *Database) NewWriteSession(ctx context.Context) neo4j.SessionWithContext {
@@ -74,35 +87,16 @@ func (db *Database) NewReadSession(ctx context.Context) neo4j.SessionWithContext
 }
 
 // Save Dynamic Node
-func (db *Database) SaveDynamicNode(nd DynamicNode) (string, error) {
-	return db.saveNode(db.ctx, nd.Label, nd.Properties)
+func (db *Database) SaveDynamicNode(ctx context.Context, nd DynamicNode) (string, error) {
+	return db.saveNode(ctx, nd.Label, prepareProperties(nd.Properties))
 }
 
 // Query nodes with their relationships
-func (db *Database) QueryForJSON(query string, params map[string]any) ([]byte, error) {
-	session := db.Driver.NewSession(db.ctx, neo4j.SessionConfig{})
-	defer session.Close(db.ctx)
-
-	result, err := session.Run(db.ctx, query, params)
-	if err != nil {
+func (db *Database) QueryForJSON(ctx context.Context, query string, params map[string]any) ([]byte, error) {
+	response, err := db.QueryForMap(ctx, query, params)
+	if err != nil || response == nil {
 		return nil, err
 	}
-
-	// Parse results
-	var response []map[string]any
-	for result.Next(db.ctx) {
-		record := result.Record()
-		nodeData, _ := record.Get("data") // record.Get("data")
-		//response = append(response, nodeData["data"])
-		if dataMap, ok := nodeData.(neo4j.Node); ok { //map[string]any); ok {
-			response = append(response, dataMap.Props)
-		} else if jsonMap, ok := nodeData.(map[string]any); ok {
-			response = append(response, jsonMap)
-		}
-	}
-	if len(response) < 1 {
-		return nil, nil
-	}
 	// Convert to JSON first
 	jsonData, err := json.Marshal(response)
 	return jsonData, err
@@ -110,18 +104,20 @@ func (db *Database) QueryForJSON(query string, params map[string]any) ([]byte, e
 }
 
 // Query nodes with their relationships
-func (db *Database) QueryForMap(query string, params map[string]any) ([]map[string]any, error) {
-	session := db.Driver.NewSession(db.ctx, neo4j.SessionConfig{})
-	defer session.Close(db.ctx)
+func (db *Database) QueryForMap(ctx context.Context, query string, params map[string]any) ([]map[string]any, error) {
+	ctx, cancel := WithTimeout(ctx)
+	defer cancel()
+	session := db.Driver.NewSession(ctx, neo4j.SessionConfig{})
+	defer session.Close(ctx)
 
-	result, err := session.Run(db.ctx, query, params)
+	result, err := session.Run(ctx, query, params)
 	if err != nil {
-		return nil, err
+		return nil, QueryErr(ctx, err)
 	}
 
 	// Parse results
 	var response []map[string]any
-	for result.Next(db.ctx) {
+	for result.Next(ctx) {
 		record := result.Record()
 		nodeData, _ := record.Get("data") // record.Get("data")
 		//response = append(response, nodeData["data"])
@@ -131,9 +127,12 @@ func (db *Database) QueryForMap(query string, params map[string]any) ([]map[stri
 			response = append(response, jsonMap)
 		}
 	}
+	if err := result.Err(); err != nil {
+		return nil, QueryErr(ctx, err)
+	}
 	if len(response) < 1 {
 		return nil, nil
 	}
-	return response, err
+	return response, nil
 
 }
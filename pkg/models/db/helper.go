@@ -5,18 +5,20 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
-	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 )
 
-// Existing helper functions (keeping them from previous version)
-
-func (m *Database) SaveStruct(data interface{}, label string) (string, error) {
-	properties := structToMap(label, data)
-
-	return m.saveNode(m.ctx, label, properties)
+// SaveStruct creates or updates the struct as a node with the label, storing
+// the properties Encode returns for it
+func (m *Database) SaveStruct(ctx context.Context, data interface{}, label string) (string, error) {
+	properties, err := Encode(data)
+	if err != nil {
+		return "", err
+	}
+	return m.saveNode(ctx, label, properties)
 }
 
 type Relation struct {
@@ -29,35 +31,24 @@ type Relation struct {
 	Body     string
 }
 
-func (m *Database) SaveRelationship(data Relation) error {
-
-	var _prop string
-	if data.Property != "" && data.Body != "" {
-		_prop = fmt.Sprintf(`{%s: "%s"}`, data.Property, data.Body)
-	}
-
-	_query := fmt.Sprintf(`
-		MATCH (n:%s {id: "%s"})
-		MATCH (x:%s {id:"%s"})
-		MERGE (n)-[r:%s %s]->(x)
-		RETURN r`, data.NodeN, data.NodeNID, data.NodeX, data.NodeXID, data.Name, _prop)
-	session := m.NewWriteSession(m.ctx)
-	_, err := session.ExecuteWrite(m.ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
-		res, err := tx.Run(m.ctx, _query, nil)
-		if err != nil {
-			return nil, err
-		}
-		if res.Next(m.ctx) {
-			return res.Record().Values[0], nil
-		}
-		return nil, res.Err()
+// SaveRelationship merges the relationship between two saved nodes
+func (m *Database) SaveRelationship(ctx context.Context, data Relation) error {
+	return m.InTransaction(ctx, func(tx *Tx) error {
+		return tx.SaveRelationship(data)
 	})
+}
 
-	if err != nil {
-		return err
+// relationshipQuery merges the relationship between the two nodes, setting
+// its property when the relation has one
+func relationshipQuery(data Relation) (string, map[string]interface{}, error) {
+	c := NewCypher().
+		Match("n", data.NodeN, map[string]any{"id": data.NodeNID}).
+		Match("x", data.NodeX, map[string]any{"id": data.NodeXID}).
+		MergeRelationship("n", "r", data.Name, "x")
+	if data.Property != "" && data.Body != "" {
+		c.Set("r", map[string]any{data.Property: data.Body})
 	}
-
-	return nil
+	return c.Return("r").Build()
 }
 
 func (m *Database) SaveFromJSON(ctx context.Context, jsonData string, label string) (string, error) {
@@ -65,54 +56,53 @@ func (m *Database) SaveFromJSON(ctx context.Context, jsonData string, label stri
 	if err := json.Unmarshal([]byte(jsonData), &properties); err != nil {
 		return "", err
 	}
-	return m.saveNode(ctx, label, properties)
+	return m.saveNode(ctx, label, prepareProperties(properties))
 }
 
-func (m *Database) saveNode(ctx context.Context, label string, properties map[string]interface{}) (string, error) {
-
-	cleanProps := prepareProperties(properties)
-	var cypher string
-	var params map[string]interface{}
-	if _id, exists := cleanProps["id"]; !exists || _id == "" {
-		cleanProps["id"] = generateUUID()
-		cypher, params = buildCreateQuery(label, cleanProps)
-	} else {
-		cypher, params = buildUpdateQuery(label, cleanProps)
-	}
-
-	session := m.NewWriteSession(ctx)
-	result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
-		res, err := tx.Run(ctx, cypher, params)
-		if err != nil {
-			return nil, err
-		}
-		if res.Next(ctx) {
-			return res.Record().Values[0], nil
-		}
-		return nil, res.Err()
+// saveNode creates the node, or updates it when the properties carry an id
+func (m *Database) saveNode(ctx context.Context, label string, cleanProps map[string]interface{}) (string, error) {
+	var id string
+	err := m.InTransaction(ctx, func(tx *Tx) error {
+		var err error
+		id, err = tx.saveNode(label, cleanProps)
+		return err
 	})
+	return id, err
+}
 
-	if err != nil {
-		return "", err
+// nodeQuery builds the query that saves the node and returns its id: an
+// update when the properties carry an id, otherwise a create with a new one.
+// The properties are not changed, so a retried transaction creates the node
+// again rather than updating the one that was rolled back.
+func nodeQuery(label string, cleanProps map[string]interface{}) (string, map[string]interface{}, error) {
+	if _id, exists := cleanProps["id"]; exists && _id != "" {
+		return buildUpdateQuery(label, cleanProps)
 	}
-
-	return result.(string), nil
+	props := make(map[string]interface{}, len(cleanProps)+1)
+	for key, value := range cleanProps {
+		props[key] = value
+	}
+	props["id"] = NewID()
+	if key, _ := props[IdempotencyKey].(string); key != "" {
+		return buildIdempotentCreateQuery(label, props)
+	}
+	return buildCreateQuery(label, props)
 }
 
-func (m *Database) QueryNodes(label string, filters map[string]interface{}) ([]map[string]any, error) {
-	session := m.NewReadSession(m.ctx)
-	defer session.Close(m.ctx)
-
-	cypher, params := buildQueryCypher(label, filters)
-	var nodes []map[string]any
+func (m *Database) QueryNodes(ctx context.Context, label string, filters map[string]interface{}) ([]map[string]any, error) {
+	cypher, params, err := buildQueryCypher(label, filters)
+	if err != nil {
+		return nil, err
+	}
 
-	result, err := session.ExecuteRead(m.ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
-		res, err := tx.Run(m.ctx, cypher, params)
+	result, err := m.ExecuteRead(ctx, func(ctx context.Context, tx neo4j.ManagedTransaction) (interface{}, error) {
+		res, err := tx.Run(ctx, cypher, params)
 		if err != nil {
 			return nil, err
 		}
 
-		for res.Next(m.ctx) {
+		var nodes []map[string]any
+		for res.Next(ctx) {
 			record := res.Record()
 			if len(record.Values) > 0 {
 				if node, ok := record.Values[0].(neo4j.Node); ok {
@@ -130,65 +120,8 @@ func (m *Database) QueryNodes(label string, filters map[string]interface{}) ([]m
 	return result.([]map[string]any), nil
 }
 
-// Helper functions from previous version
-func structToMap(lbl string, obj interface{}) map[string]interface{} {
-	result := make(map[string]interface{})
-	fmt.Println("structure for ", lbl)
-	//relationships := make(map[string][]string) //label and field names
-	v := reflect.ValueOf(obj)
-	t := reflect.TypeOf(obj)
-
-	if v.Kind() == reflect.Ptr {
-		v = v.Elem()
-		t = t.Elem()
-	}
-
-	for i := 0; i < v.NumField(); i++ {
-		field := v.Field(i)
-		fieldType := t.Field(i)
-
-		if !field.CanInterface() {
-			continue
-		}
-
-		jsonTag := fieldType.Tag.Get("json")
-		if jsonTag == "" {
-			continue
-		}
-		fieldName := fieldType.Name
-		if jsonTag != "" && jsonTag != "-" {
-			parts := strings.Split(jsonTag, ",")
-			if parts[0] != "" {
-				fieldName = parts[0]
-			}
-		}
-
-		value := field.Interface()
-		_t := reflect.TypeOf(value)
-		switch _t.Kind() {
-		case reflect.Ptr:
-			elem := _t.Elem()
-			if strings.Contains(elem.PkgPath(), "birdsfoot") {
-				continue
-			}
-		case reflect.Slice, reflect.Array:
-			elem := _t.Elem()
-			if strings.Contains(elem.PkgPath(), "birdsfoot") {
-				continue
-			}
-		case reflect.Map:
-			elemType := _t.Elem()
-			if strings.Contains(elemType.PkgPath(), "birdsfoot") {
-				continue
-			}
-		}
-
-		result[fieldName] = value
-	}
-
-	return result
-}
-
+// prepareProperties readies properties decoded from json for storage, since
+// Neo4j cannot store maps or lists of mixed values
 func prepareProperties(props map[string]interface{}) map[string]interface{} {
 	cleaned := make(map[string]interface{})
 
@@ -226,22 +159,6 @@ func assignLocation(myTime time.Time) time.Time {
 	return time.Date(myTime.Year(), myTime.Month(), myTime.Day(), myTime.Hour(), myTime.Minute(), myTime.Second(), 0, TimeLocation)
 }
 
-/*
-	func isArrayOfCustomStructs(obj interface{}) bool {
-		//v := reflect.ValueOf(obj)
-		t := reflect.TypeOf(obj)
-
-		// Check if it's a slice or array
-		if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
-			return false
-		}
-
-		// Check if the element type is a struct
-		elemType := t.Elem()
-		return strings.Contains(elemType.PkgPath(), "birdsfoot")
-	}
-*/
-
 func convertSlice(slice []interface{}) interface{} {
 	if len(slice) == 0 {
 		return []string{}
@@ -267,63 +184,65 @@ func convertSlice(slice []interface{}) interface{} {
 	return string(jsonBytes)
 }
 
-func buildCreateQuery(label string, properties map[string]interface{}) (string, map[string]interface{}) {
-	var propParts []string
-	params := make(map[string]interface{})
+func buildCreateQuery(label string, properties map[string]interface{}) (string, map[string]interface{}, error) {
+	return NewCypher().Create("n", label, properties).Return("n.id").Build()
+}
 
+// buildIdempotentCreateQuery merges the node on its idempotency key and the
+// user the key belongs to, so a retried save returns the id of the node the
+// first attempt created and another user's key never matches
+func buildIdempotentCreateQuery(label string, properties map[string]interface{}) (string, map[string]interface{}, error) {
+	if scope, _ := properties[IdempotencyScope].(string); scope == "" {
+		return "", nil, fmt.Errorf("saving %s with an idempotency key needs its %s", label, IdempotencyScope)
+	}
+	props := make(map[string]interface{}, len(properties))
 	for key, value := range properties {
-		propParts = append(propParts, fmt.Sprintf("%s: $%s", key, key))
-		params[key] = value
+		if key != IdempotencyKey && key != IdempotencyScope {
+			props[key] = value
+		}
 	}
-
-	cypher := fmt.Sprintf("CREATE (n:%s {%s}) RETURN n.id",
-		label, strings.Join(propParts, ", "))
-
-	return cypher, params
+	return NewCypher().
+		Merge("n", label, map[string]any{IdempotencyKey: properties[IdempotencyKey], IdempotencyScope: properties[IdempotencyScope]}).
+		OnCreateSet("n", props).
+		Return("n.id").
+		Build()
 }
 
-func buildUpdateQuery(label string, properties map[string]interface{}) (string, map[string]interface{}) {
-	var propParts []string
-	params := make(map[string]interface{})
-	var _idLookup string
+// buildUpdateQuery merges the node by id and sets the other properties,
+// leaving the stored password alone when the new one is empty
+func buildUpdateQuery(label string, properties map[string]interface{}) (string, map[string]interface{}, error) {
+	updates := make(map[string]interface{})
 	for key, value := range properties {
 		switch key {
 		case "id":
-			_idLookup = fmt.Sprintf("id:'%s'", value)
 		case "password":
-			if value.(string) != "" {
-				propParts = append(propParts, fmt.Sprintf("n.%s = $%s", key, key))
-				params[key] = value
+			if pw, ok := value.(string); ok && pw != "" {
+				updates[key] = value
 			}
 		default:
-			propParts = append(propParts, fmt.Sprintf("n.%s = $%s", key, key))
-			params[key] = value
+			updates[key] = value
 		}
 	}
+	return NewCypher().
+		Merge("n", label, map[string]any{"id": properties["id"]}).
+		OnMatchSet("n", updates).
+		Return("n.id").
+		Build()
+}
 
-	cypher := fmt.Sprintf("MERGE (n:%s {%s}) ON MATCH SET %s RETURN n.id",
-		label, _idLookup, strings.Join(propParts, ", "))
-
-	return cypher, params
+func buildQueryCypher(label string, filters map[string]interface{}) (string, map[string]interface{}, error) {
+	return NewCypher().Match("n", label, nil).Where("n", filters).Return("n").Build()
 }
 
-func buildQueryCypher(label string, filters map[string]interface{}) (string, map[string]interface{}) {
-	cypher := fmt.Sprintf("MATCH (n:%s)", label)
-	params := make(map[string]interface{})
+// IdempotencyKey is the property a client sets so a retried save creates one node
+const IdempotencyKey = "idempotencyKey"
 
-	if len(filters) > 0 {
-		var whereParts []string
-		for key, value := range filters {
-			whereParts = append(whereParts, fmt.Sprintf("n.%s = $%s", key, key))
-			params[key] = value
-		}
-		cypher += " WHERE " + strings.Join(whereParts, " AND ")
-	}
-
-	cypher += " RETURN n"
-	return cypher, params
-}
+// IdempotencyScope is the property holding the user an idempotency key
+// belongs to; keys are unique per label and user
+const IdempotencyScope = "userId"
 
-func generateUUID() string {
-	return fmt.Sprintf("%d", time.Now().UnixNano())
+// NewID returns a random identifier for a new node. Version 7 UUIDs start
+// with the time, so ids created later also sort later.
+func NewID() string {
+	return uuid.Must(uuid.NewV7()).String()
 }
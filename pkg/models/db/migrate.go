@@ -0,0 +1,145 @@
+package db
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// migrations holds the schema for each backend, one numbered file per version
+//
+//go:embed migrations/sqlite/*.sql migrations/neo4j/*.cypher
+var migrations embed.FS
+
+// Migration is one numbered schema or data change
+type Migration struct {
+	Version int
+	Name    string
+	Body    string
+}
+
+// Statements splits the migration into the statements it runs one at a time,
+// dropping // comment lines. Neo4j cannot mix schema and data changes in one
+// transaction, so each statement commits on its own and must be safe to repeat.
+func (m Migration) Statements() []string {
+	var kept []string
+	for _, line := range strings.Split(m.Body, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "//") {
+			kept = append(kept, line)
+		}
+	}
+	var statements []string
+	for _, statement := range strings.Split(strings.Join(kept, "\n"), ";") {
+		if statement = strings.TrimSpace(statement); statement != "" {
+			statements = append(statements, statement)
+		}
+	}
+	return statements
+}
+
+// loadMigrations reads the embedded migrations matching the pattern in version order
+func loadMigrations(pattern string) ([]Migration, error) {
+	files, err := fs.Glob(migrations, pattern)
+	if err != nil {
+		return nil, err
+	}
+	var loaded []Migration
+	seen := make(map[int]string)
+	for _, file := range files {
+		name := path.Base(file)
+		version, err := strconv.Atoi(strings.SplitN(name, "_", 2)[0])
+		if err != nil {
+			return nil, fmt.Errorf("migration %s must start with its version number", name)
+		}
+		if other, ok := seen[version]; ok {
+			return nil, fmt.Errorf("migrations %s and %s share version %d", other, name, version)
+		}
+		seen[version] = name
+		body, err := migrations.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		loaded = append(loaded, Migration{Version: version, Name: name, Body: string(body)})
+	}
+	sort.Slice(loaded, func(i, j int) bool { return loaded[i].Version < loaded[j].Version })
+	return loaded, nil
+}
+
+// pendingMigrations returns the migrations whose version has not been applied
+func pendingMigrations(all []Migration, applied map[int]bool) []Migration {
+	var pending []Migration
+	for _, m := range all {
+		if !applied[m.Version] {
+			pending = append(pending, m)
+		}
+	}
+	return pending
+}
+
+// Migrate applies the Neo4j migrations not yet recorded by a SchemaMigration
+// node and returns them, or those applied before one failed. With dryRun nothing is changed and the migrations
+// that would run are returned.
+func (db *Database) Migrate(ctx context.Context, dryRun bool) ([]Migration, error) {
+	all, err := loadMigrations("migrations/neo4j/*.cypher")
+	if err != nil {
+		return nil, err
+	}
+	if !dryRun {
+		// two servers starting together record a version once
+		if err := db.runStatement(ctx, `CREATE CONSTRAINT schema_migration_version IF NOT EXISTS
+			FOR (m:SchemaMigration) REQUIRE m.version IS UNIQUE`, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	applied := make(map[int]bool)
+	recorded, err := db.QueryForMap(ctx, `MATCH (m:SchemaMigration) RETURN m{.version} AS data`, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range recorded {
+		if version, ok := m["version"].(int64); ok {
+			applied[int(version)] = true
+		}
+	}
+
+	pending := pendingMigrations(all, applied)
+	if dryRun {
+		return pending, nil
+	}
+	for i, m := range pending {
+		for _, statement := range m.Statements() {
+			if err := db.runStatement(ctx, statement, nil); err != nil {
+				return pending[:i], fmt.Errorf("migration %s: %w", m.Name, err)
+			}
+		}
+		if err := db.runStatement(ctx, `MERGE (m:SchemaMigration {version: $version})
+			ON CREATE SET m.name = $name, m.appliedAt = datetime()`,
+			map[string]any{"version": m.Version, "name": m.Name}); err != nil {
+			return pending[:i], fmt.Errorf("migration %s: %w", m.Name, err)
+		}
+	}
+	return pending, nil
+}
+
+// runStatement runs one statement in its own write transaction
+func (db *Database) runStatement(ctx context.Context, statement string, params map[string]any) error {
+	session := db.NewWriteSession(ctx)
+	defer session.Close(ctx)
+
+	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		res, err := tx.Run(ctx, statement, params)
+		if err != nil {
+			return nil, err
+		}
+		return res.Consume(ctx)
+	})
+	return err
+}
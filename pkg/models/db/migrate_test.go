@@ -0,0 +1,83 @@
+package db
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMigrationStatements(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{name: "one statement", body: "CREATE INDEX a IF NOT EXISTS FOR (n:User) ON (n.email);\n",
+			want: []string{"CREATE INDEX a IF NOT EXISTS FOR (n:User) ON (n.email)"}},
+		{name: "comments and blank lines", body: "// header\n\nMATCH (n) SET n.x = 1;\n  // note\nMATCH (n) SET n.y = 2;",
+			want: []string{"MATCH (n) SET n.x = 1", "MATCH (n) SET n.y = 2"}},
+		{name: "statement over lines", body: "MATCH (r:Reservation)\nWHERE r.cancelled IS NULL\nSET r.cancelled = false;",
+			want: []string{"MATCH (r:Reservation)\nWHERE r.cancelled IS NULL\nSET r.cancelled = false"}},
+		{name: "only comments", body: "// nothing yet\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Migration{Body: tt.body}.Statements()
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNeo4jMigrationsLoad(t *testing.T) {
+	all, err := loadMigrations("migrations/neo4j/*.cypher")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) == 0 {
+		t.Fatal("expected embedded neo4j migrations")
+	}
+	for i, m := range all {
+		if i > 0 && m.Version <= all[i-1].Version {
+			t.Errorf("migration %s is out of order", m.Name)
+		}
+		statements := m.Statements()
+		if len(statements) == 0 {
+			t.Errorf("migration %s has no statements", m.Name)
+		}
+		for _, statement := range statements {
+			// each statement commits alone, so it has to be safe to run again
+			if strings.HasPrefix(statement, "CREATE CONSTRAINT") || strings.HasPrefix(statement, "CREATE INDEX") {
+				if !strings.Contains(statement, "IF NOT EXISTS") {
+					t.Errorf("migration %s: %q is not repeatable", m.Name, statement)
+				}
+			}
+		}
+	}
+}
+
+func TestPendingMigrations(t *testing.T) {
+	all := []Migration{{Version: 1}, {Version: 2}, {Version: 3}}
+	tests := []struct {
+		name    string
+		applied map[int]bool
+		want    []int
+	}{
+		{name: "new database", applied: map[int]bool{}, want: []int{1, 2, 3}},
+		{name: "partly applied", applied: map[int]bool{1: true}, want: []int{2, 3}},
+		{name: "gap left by a failed run", applied: map[int]bool{1: true, 3: true}, want: []int{2}},
+		{name: "up to date", applied: map[int]bool{1: true, 2: true, 3: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []int
+			for _, m := range pendingMigrations(all, tt.applied) {
+				got = append(got, m.Version)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,6 @@
+// Constraints and indexes from the original db/constraints.cypher bootstrap
+CREATE CONSTRAINT user_email_unique IF NOT EXISTS FOR (u:User) REQUIRE u.email IS UNIQUE;
+CREATE CONSTRAINT tee_slot_key_unique IF NOT EXISTS FOR (s:TeeSlot) REQUIRE s.key IS UNIQUE;
+CREATE INDEX outing_start_time IF NOT EXISTS FOR (o:Outing) ON (o.startTime);
+CREATE INDEX day_override_day IF NOT EXISTS FOR (d:DayOverride) ON (d.day);
+CREATE CONSTRAINT standing_occurrence_unique IF NOT EXISTS FOR (o:StandingOccurrence) REQUIRE (o.standingId, o.date) IS UNIQUE;
@@ -0,0 +1,13 @@
+// Every node the stores look up by id gets a unique id
+CREATE CONSTRAINT user_id_unique IF NOT EXISTS FOR (n:User) REQUIRE n.id IS UNIQUE;
+CREATE CONSTRAINT auth_config_id_unique IF NOT EXISTS FOR (n:AuthConfig) REQUIRE n.id IS UNIQUE;
+CREATE CONSTRAINT season_id_unique IF NOT EXISTS FOR (n:Season) REQUIRE n.id IS UNIQUE;
+CREATE CONSTRAINT block_settings_id_unique IF NOT EXISTS FOR (n:DetailedBlockSettings) REQUIRE n.id IS UNIQUE;
+CREATE CONSTRAINT holiday_id_unique IF NOT EXISTS FOR (n:Holiday) REQUIRE n.id IS UNIQUE;
+CREATE CONSTRAINT reservation_id_unique IF NOT EXISTS FOR (n:Reservation) REQUIRE n.id IS UNIQUE;
+CREATE CONSTRAINT slot_hold_id_unique IF NOT EXISTS FOR (n:SlotHold) REQUIRE n.id IS UNIQUE;
+CREATE CONSTRAINT waitlist_entry_id_unique IF NOT EXISTS FOR (n:WaitlistEntry) REQUIRE n.id IS UNIQUE;
+CREATE CONSTRAINT outing_id_unique IF NOT EXISTS FOR (n:Outing) REQUIRE n.id IS UNIQUE;
+CREATE CONSTRAINT outing_team_id_unique IF NOT EXISTS FOR (n:OutingTeam) REQUIRE n.id IS UNIQUE;
+CREATE CONSTRAINT day_override_id_unique IF NOT EXISTS FOR (n:DayOverride) REQUIRE n.id IS UNIQUE;
+CREATE CONSTRAINT standing_id_unique IF NOT EXISTS FOR (n:StandingReservation) REQUIRE n.id IS UNIQUE;
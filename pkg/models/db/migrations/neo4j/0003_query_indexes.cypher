@@ -0,0 +1,5 @@
+// Indexes for the properties the tee sheet, sweepers and season lookups filter on
+CREATE INDEX reservation_tee_time IF NOT EXISTS FOR (n:Reservation) ON (n.teeTime);
+CREATE INDEX slot_hold_expires_at IF NOT EXISTS FOR (n:SlotHold) ON (n.expiresAt);
+CREATE INDEX waitlist_entry_status IF NOT EXISTS FOR (n:WaitlistEntry) ON (n.status);
+CREATE INDEX season_dates IF NOT EXISTS FOR (n:Season) ON (n.beginDate, n.endDate);
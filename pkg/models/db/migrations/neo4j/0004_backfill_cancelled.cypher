@@ -0,0 +1,2 @@
+// Reservations saved before cancellation was tracked count as active
+MATCH (r:Reservation) WHERE r.cancelled IS NULL SET r.cancelled = false;
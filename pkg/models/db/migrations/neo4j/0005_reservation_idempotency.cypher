@@ -0,0 +1,3 @@
+// A retried booking carries the key of its first attempt and is looked up by it.
+// Keys are scoped to the booking user, so they are indexed rather than unique.
+CREATE INDEX reservation_idempotency_key IF NOT EXISTS FOR (r:Reservation) ON (r.idempotencyKey);
@@ -0,0 +1,2 @@
+// An entity's history is read by its type and id, oldest first.
+CREATE INDEX change_event_entity IF NOT EXISTS FOR (e:ChangeEvent) ON (e.entityType, e.entityId);
@@ -0,0 +1,3 @@
+// Refresh sessions are read by id on every refresh and listed by user.
+CREATE CONSTRAINT refresh_session_id_unique IF NOT EXISTS FOR (s:RefreshSession) REQUIRE s.id IS UNIQUE;
+CREATE INDEX refresh_session_user IF NOT EXISTS FOR (s:RefreshSession) ON (s.userId);
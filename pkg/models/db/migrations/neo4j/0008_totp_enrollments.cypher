@@ -0,0 +1,2 @@
+// Authenticator enrollments are read by user on every step up.
+CREATE CONSTRAINT totp_enrollment_user_unique IF NOT EXISTS FOR (e:TOTPEnrollment) REQUIRE e.userId IS UNIQUE;
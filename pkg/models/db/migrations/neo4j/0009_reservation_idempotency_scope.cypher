@@ -0,0 +1,5 @@
+// Idempotency keys are unique per booking user, as 0005 intended. Reservations
+// record their booking user's id so the key and user can be constrained together.
+MATCH (u:User)-[:BOOKED_TEETIME]->(r:Reservation) WHERE r.userId IS NULL SET r.userId = u.id;
+DROP INDEX reservation_idempotency_key IF EXISTS;
+CREATE CONSTRAINT reservation_idempotency_unique IF NOT EXISTS FOR (r:Reservation) REQUIRE (r.userId, r.idempotencyKey) IS UNIQUE;
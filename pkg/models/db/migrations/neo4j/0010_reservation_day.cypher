@@ -0,0 +1,4 @@
+// Reservations record the date of their tee time so the tee sheet and slot
+// bookings match an indexed day instead of scanning every reservation's teeTime
+MATCH (r:Reservation) WHERE r.day IS NULL SET r.day = toString(date(r.teeTime));
+CREATE INDEX reservation_day IF NOT EXISTS FOR (n:Reservation) ON (n.day);
@@ -0,0 +1,114 @@
+-- Each table keeps the columns it is queried by alongside the json encoded
+-- record in data. Days are 2006-01-02 read in the time's own zone, the way
+-- date() reads a Neo4j datetime, and instants are unix nanoseconds.
+
+CREATE TABLE users (
+    id TEXT PRIMARY KEY,
+    email TEXT NOT NULL DEFAULT '',
+    data TEXT NOT NULL
+);
+CREATE INDEX users_email ON users (email);
+
+CREATE TABLE auth_config (
+    id TEXT PRIMARY KEY,
+    data TEXT NOT NULL
+);
+
+CREATE TABLE seasons (
+    id TEXT PRIMARY KEY,
+    begin_day TEXT NOT NULL,
+    end_day TEXT NOT NULL,
+    data TEXT NOT NULL
+);
+
+-- kind is default or override; settings saved on their own have no season yet
+CREATE TABLE block_settings (
+    id TEXT PRIMARY KEY,
+    season_id TEXT REFERENCES seasons (id) ON DELETE CASCADE,
+    kind TEXT NOT NULL DEFAULT 'default',
+    position INTEGER NOT NULL DEFAULT 0,
+    data TEXT NOT NULL
+);
+CREATE INDEX block_settings_season ON block_settings (season_id, kind, position);
+
+CREATE TABLE holidays (
+    id TEXT PRIMARY KEY,
+    season_id TEXT NOT NULL REFERENCES seasons (id) ON DELETE CASCADE,
+    day TEXT NOT NULL,
+    data TEXT NOT NULL
+);
+CREATE INDEX holidays_season ON holidays (season_id, day);
+
+CREATE TABLE reservations (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL DEFAULT '',
+    day TEXT NOT NULL,
+    tee_time INTEGER NOT NULL,
+    slot INTEGER NOT NULL,
+    crossover_slot INTEGER NOT NULL DEFAULT 0,
+    player_count INTEGER NOT NULL DEFAULT 0,
+    cancelled INTEGER NOT NULL DEFAULT 0,
+    data TEXT NOT NULL
+);
+CREATE INDEX reservations_day_slot ON reservations (day, slot);
+CREATE INDEX reservations_user ON reservations (user_id, tee_time);
+
+CREATE TABLE slot_holds (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    day TEXT NOT NULL,
+    slot INTEGER NOT NULL,
+    players INTEGER NOT NULL,
+    expires_at INTEGER NOT NULL,
+    data TEXT NOT NULL
+);
+CREATE INDEX slot_holds_day_slot ON slot_holds (day, slot);
+
+CREATE TABLE waitlist (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    status TEXT NOT NULL,
+    created_at INTEGER NOT NULL,
+    data TEXT NOT NULL
+);
+CREATE INDEX waitlist_status ON waitlist (status, created_at);
+CREATE INDEX waitlist_user ON waitlist (user_id);
+
+CREATE TABLE outings (
+    id TEXT PRIMARY KEY,
+    day TEXT NOT NULL,
+    start_time INTEGER NOT NULL,
+    data TEXT NOT NULL
+);
+CREATE INDEX outings_day ON outings (day, start_time);
+
+CREATE TABLE outing_teams (
+    id TEXT PRIMARY KEY,
+    outing_id TEXT NOT NULL REFERENCES outings (id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    data TEXT NOT NULL
+);
+CREATE INDEX outing_teams_outing ON outing_teams (outing_id, position);
+
+CREATE TABLE day_overrides (
+    id TEXT PRIMARY KEY,
+    day TEXT NOT NULL,
+    data TEXT NOT NULL
+);
+CREATE INDEX day_overrides_day ON day_overrides (day);
+
+CREATE TABLE standing (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    end_day TEXT NOT NULL DEFAULT '',
+    data TEXT NOT NULL
+);
+CREATE INDEX standing_user ON standing (user_id);
+
+CREATE TABLE standing_occurrences (
+    id TEXT PRIMARY KEY,
+    standing_id TEXT NOT NULL,
+    day TEXT NOT NULL,
+    data TEXT NOT NULL,
+    UNIQUE (standing_id, day)
+);
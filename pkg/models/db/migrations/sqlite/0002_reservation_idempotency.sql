@@ -0,0 +1,3 @@
+-- A retried booking carries the key of its first attempt and must not book twice
+ALTER TABLE reservations ADD COLUMN idempotency_key TEXT;
+CREATE UNIQUE INDEX reservations_idempotency ON reservations (user_id, idempotency_key) WHERE idempotency_key IS NOT NULL;
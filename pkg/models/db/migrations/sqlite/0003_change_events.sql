@@ -0,0 +1,9 @@
+-- Append-only history of changes to reservations, seasons and block settings
+CREATE TABLE change_events (
+    id TEXT PRIMARY KEY,
+    entity_type TEXT NOT NULL,
+    entity_id TEXT NOT NULL,
+    at INTEGER NOT NULL,
+    data TEXT NOT NULL
+);
+CREATE INDEX change_events_entity ON change_events (entity_type, entity_id, at);
@@ -0,0 +1,10 @@
+-- One row per signed in device; token_hash is the current refresh token's
+CREATE TABLE refresh_sessions (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    token_hash TEXT NOT NULL,
+    created_at INTEGER NOT NULL,
+    revoked_at INTEGER,
+    data TEXT NOT NULL
+);
+CREATE INDEX refresh_sessions_user ON refresh_sessions (user_id, revoked_at);
@@ -0,0 +1,7 @@
+-- One authenticator per user; uses counts the codes accepted so two requests
+-- with the same code cannot both succeed
+CREATE TABLE totp_enrollments (
+    user_id TEXT PRIMARY KEY,
+    uses INTEGER NOT NULL DEFAULT 0,
+    data TEXT NOT NULL
+);
@@ -0,0 +1,119 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SQLitePath returns the database file named by a sqlite:// URI, such as
+// sqlite:///var/lib/golf/golf.db or sqlite://golf.db for a relative path
+func SQLitePath(uri string) (string, bool) {
+	path, ok := strings.CutPrefix(uri, "sqlite://")
+	if !ok || path == "" {
+		return "", false
+	}
+	return path, true
+}
+
+// OpenSQLite opens the database file and applies any migrations it has not seen
+func OpenSQLite(ctx context.Context, path string) (*sql.DB, error) {
+	conn, err := ConnectSQLite(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := MigrateSQLite(ctx, conn, false); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	fmt.Println("SQLite database opened at", path)
+	return conn, nil
+}
+
+// ConnectSQLite opens the database file without migrating it. The pool is
+// kept to one connection so writes, and the capacity checks made inside their
+// transactions, run one at a time.
+func ConnectSQLite(ctx context.Context, path string) (*sql.DB, error) {
+	conn, err := sql.Open("sqlite", fmt.Sprintf("file:%s?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)", path))
+	if err != nil {
+		return nil, err
+	}
+	conn.SetMaxOpenConns(1)
+	if err := conn.PingContext(ctx); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// MigrateSQLite runs each embedded migration not recorded in schema_migrations
+// in its own transaction and returns them. With dryRun nothing is changed and
+// the migrations that would run are returned.
+func MigrateSQLite(ctx context.Context, conn *sql.DB, dryRun bool) ([]Migration, error) {
+	all, err := loadMigrations("migrations/sqlite/*.sql")
+	if err != nil {
+		return nil, err
+	}
+	if dryRun {
+		var tables int
+		if err := conn.QueryRowContext(ctx, `SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations'`).Scan(&tables); err != nil {
+			return nil, err
+		}
+		if tables == 0 {
+			return all, nil
+		}
+	} else if _, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TEXT NOT NULL
+	)`); err != nil {
+		return nil, err
+	}
+	rows, err := conn.QueryContext(ctx, `SELECT version FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	applied := make(map[int]bool)
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		applied[version] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	pending := pendingMigrations(all, applied)
+	if dryRun {
+		return pending, nil
+	}
+	for i, m := range pending {
+		if err := applyMigration(ctx, conn, m.Version, m.Name, m.Body); err != nil {
+			return pending[:i], fmt.Errorf("migration %s: %w", m.Name, err)
+		}
+	}
+	return pending, nil
+}
+
+func applyMigration(ctx context.Context, conn *sql.DB, version int, name, schema string) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, schema); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)`,
+		version, name, time.Now().UTC().Format(time.RFC3339)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,7 @@
+//go:build !js
+
+package db
+
+// The pure Go driver registers itself as "sqlite" and keeps CGO_ENABLED=0
+// builds working. The wasm front end never opens a database, so it is left out.
+import _ "modernc.org/sqlite"
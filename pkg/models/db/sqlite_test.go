@@ -0,0 +1,76 @@
+package db
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestSQLitePath(t *testing.T) {
+	tests := []struct {
+		uri  string
+		path string
+		ok   bool
+	}{
+		{uri: "sqlite:///var/lib/golf/golf.db", path: "/var/lib/golf/golf.db", ok: true},
+		{uri: "sqlite://golf.db", path: "golf.db", ok: true},
+		{uri: "sqlite://"},
+		{uri: "bolt://neo4j:7687"},
+		{uri: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.uri, func(t *testing.T) {
+			path, ok := SQLitePath(tt.uri)
+			if path != tt.path || ok != tt.ok {
+				t.Errorf("SQLitePath(%q) = %q, %v; want %q, %v", tt.uri, path, ok, tt.path, tt.ok)
+			}
+		})
+	}
+}
+
+func TestMigrateSQLite(t *testing.T) {
+	ctx := context.Background()
+	conn, err := ConnectSQLite(ctx, filepath.Join(t.TempDir(), "golf.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	all, err := loadMigrations("migrations/sqlite/*.sql")
+	if err != nil || len(all) == 0 {
+		t.Fatalf("expected embedded sqlite migrations, got %d: %v", len(all), err)
+	}
+
+	tests := []struct {
+		name        string
+		dryRun      bool
+		wantPending int
+		wantApplied int
+	}{
+		{name: "dry run on a new file changes nothing", dryRun: true, wantPending: len(all), wantApplied: -1},
+		{name: "first run applies everything", wantPending: len(all), wantApplied: len(all)},
+		{name: "dry run after finds nothing", dryRun: true, wantPending: 0, wantApplied: len(all)},
+		{name: "second run applies nothing", wantPending: 0, wantApplied: len(all)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pending, err := MigrateSQLite(ctx, conn, tt.dryRun)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(pending) != tt.wantPending {
+				t.Errorf("expected %d pending migrations, got %d", tt.wantPending, len(pending))
+			}
+			var applied int
+			err = conn.QueryRow(`SELECT count(*) FROM schema_migrations`).Scan(&applied)
+			if tt.wantApplied < 0 {
+				if err == nil {
+					t.Error("expected a dry run to leave the file without schema_migrations")
+				}
+				return
+			}
+			if err != nil || applied != tt.wantApplied {
+				t.Errorf("expected %d migrations recorded, got %d: %v", tt.wantApplied, applied, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// QueryTimeout bounds each query and transaction. DB_QUERY_TIMEOUT overrides
+// it with a duration such as 5s; zero or less leaves queries unbounded.
+var QueryTimeout = queryTimeout(os.Getenv("DB_QUERY_TIMEOUT"))
+
+const defaultQueryTimeout = 15 * time.Second
+
+func queryTimeout(setting string) time.Duration {
+	if setting == "" {
+		return defaultQueryTimeout
+	}
+	d, err := time.ParseDuration(setting)
+	if err != nil {
+		fmt.Printf("DB_QUERY_TIMEOUT %q is not a duration, using %s\n", setting, defaultQueryTimeout)
+		return defaultQueryTimeout
+	}
+	return d
+}
+
+// TimeoutError is returned when a query runs past QueryTimeout. It unwraps to
+// context.DeadlineExceeded, so errors.Is checks for either still hold.
+type TimeoutError struct {
+	Timeout time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("db: query did not finish within %s", e.Timeout)
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return context.DeadlineExceeded
+}
+
+// WithTimeout bounds the context by QueryTimeout. Stores call it before each
+// query and pass the query's error through QueryErr.
+func WithTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if QueryTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeoutCause(ctx, QueryTimeout, &TimeoutError{Timeout: QueryTimeout})
+}
+
+// QueryErr returns the TimeoutError when the query failed because the context
+// from WithTimeout expired, and err otherwise. A caller's own deadline or
+// cancellation is passed through unchanged.
+func QueryErr(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	var timeout *TimeoutError
+	if errors.As(context.Cause(ctx), &timeout) {
+		return timeout
+	}
+	return err
+}
+
+// Timed runs fn with the context bounded by QueryTimeout, for stores whose
+// queries do not go through the Database helpers
+func Timed(ctx context.Context, fn func(ctx context.Context) error) error {
+	ctx, cancel := WithTimeout(ctx)
+	defer cancel()
+	return QueryErr(ctx, fn(ctx))
+}
@@ -0,0 +1,62 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestQueryTimeoutSetting(t *testing.T) {
+	tests := []struct {
+		setting string
+		want    time.Duration
+	}{
+		{"", defaultQueryTimeout},
+		{"5s", 5 * time.Second},
+		{"0", 0},
+		{"soon", defaultQueryTimeout},
+	}
+	for _, tt := range tests {
+		if got := queryTimeout(tt.setting); got != tt.want {
+			t.Errorf("queryTimeout(%q) = %s, want %s", tt.setting, got, tt.want)
+		}
+	}
+}
+
+func TestTimed(t *testing.T) {
+	defer func(saved time.Duration) { QueryTimeout = saved }(QueryTimeout)
+	QueryTimeout = 10 * time.Millisecond
+
+	blocks := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name        string
+		ctx         context.Context
+		fn          func(ctx context.Context) error
+		wantTimeout bool
+		wantErr     error
+	}{
+		{"query past the timeout", context.Background(), blocks, true, context.DeadlineExceeded},
+		{"caller cancelled", cancelled, blocks, false, context.Canceled},
+		{"query error", context.Background(), func(context.Context) error { return errors.ErrUnsupported }, false, errors.ErrUnsupported},
+		{"query finished", context.Background(), func(context.Context) error { return nil }, false, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Timed(tt.ctx, tt.fn)
+			var timeout *TimeoutError
+			if errors.As(err, &timeout) != tt.wantTimeout {
+				t.Errorf("expected timeout %v, got %v", tt.wantTimeout, err)
+			}
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Errorf("expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
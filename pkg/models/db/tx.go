@@ -0,0 +1,131 @@
+package db
+
+import (
+	"context"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// Tx is a unit of work: the nodes and relationships written through it are
+// committed together when the work returns nil and rolled back otherwise.
+// The driver retries the work on transient errors, so it may run more than
+// once and must only change values outside the transaction in OnCommit.
+type Tx struct {
+	ctx      context.Context
+	tx       neo4j.ManagedTransaction
+	onCommit []func()
+}
+
+// InTransaction runs the work in one managed write transaction
+func (db *Database) InTransaction(ctx context.Context, work func(tx *Tx) error) error {
+	var committed *Tx
+	_, err := db.ExecuteWrite(ctx, func(ctx context.Context, mtx neo4j.ManagedTransaction) (any, error) {
+		tx := &Tx{ctx: ctx, tx: mtx}
+		if err := work(tx); err != nil {
+			return nil, err
+		}
+		committed = tx
+		return nil, nil
+	})
+	if err != nil {
+		return err
+	}
+	committed.commit()
+	return nil
+}
+
+// ExecuteWrite runs the work in a managed write transaction on its own
+// session, bounded by QueryTimeout. The work gets the bounded context to pass
+// to the transaction's queries.
+func (db *Database) ExecuteWrite(ctx context.Context, work func(ctx context.Context, tx neo4j.ManagedTransaction) (any, error)) (any, error) {
+	ctx, cancel := WithTimeout(ctx)
+	defer cancel()
+	session := db.NewWriteSession(ctx)
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		return work(ctx, tx)
+	})
+	return result, QueryErr(ctx, err)
+}
+
+// ExecuteRead runs the work in a managed read transaction, like ExecuteWrite
+func (db *Database) ExecuteRead(ctx context.Context, work func(ctx context.Context, tx neo4j.ManagedTransaction) (any, error)) (any, error) {
+	ctx, cancel := WithTimeout(ctx)
+	defer cancel()
+	session := db.NewReadSession(ctx)
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (any, error) {
+		return work(ctx, tx)
+	})
+	return result, QueryErr(ctx, err)
+}
+
+// Context returns the context the transaction runs under
+func (t *Tx) Context() context.Context {
+	return t.ctx
+}
+
+// OnCommit defers fn until the transaction commits, such as copying the ids
+// of created nodes onto the structs they were saved from
+func (t *Tx) OnCommit(fn func()) {
+	t.onCommit = append(t.onCommit, fn)
+}
+
+func (t *Tx) commit() {
+	for _, fn := range t.onCommit {
+		fn()
+	}
+}
+
+// Run runs the query and returns the first value of its first record
+func (t *Tx) Run(query string, params map[string]any) (any, error) {
+	res, err := t.tx.Run(t.ctx, query, params)
+	if err != nil {
+		return nil, err
+	}
+	if res.Next(t.ctx) {
+		return res.Record().Values[0], nil
+	}
+	return nil, res.Err()
+}
+
+// SaveStruct creates or updates the struct as a node with the label and
+// returns its id
+func (t *Tx) SaveStruct(data any, label string) (string, error) {
+	properties, err := Encode(data)
+	if err != nil {
+		return "", err
+	}
+	return t.saveNode(label, properties)
+}
+
+// SaveProperties creates or updates a node with the label from properties
+// already encoded, such as a struct's with properties added, and returns its id
+func (t *Tx) SaveProperties(properties map[string]any, label string) (string, error) {
+	return t.saveNode(label, properties)
+}
+
+// SaveRelationship merges the relationship between two saved nodes
+func (t *Tx) SaveRelationship(data Relation) error {
+	query, params, err := relationshipQuery(data)
+	if err != nil {
+		return err
+	}
+	_, err = t.Run(query, params)
+	return err
+}
+
+func (t *Tx) saveNode(label string, cleanProps map[string]any) (string, error) {
+	query, params, err := nodeQuery(label, cleanProps)
+	if err != nil {
+		return "", err
+	}
+	id, err := t.Run(query, params)
+	if err != nil {
+		return "", err
+	}
+	_id, _ := id.(string)
+	return _id, nil
+}
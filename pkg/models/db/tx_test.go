@@ -0,0 +1,140 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// fakeTx records the statements run on it and fails the one at failAt
+type fakeTx struct {
+	neo4j.ManagedTransaction
+	queries []string
+	failAt  int
+}
+
+func (f *fakeTx) Run(_ context.Context, cypher string, params map[string]any) (neo4j.ResultWithContext, error) {
+	f.queries = append(f.queries, cypher)
+	if len(f.queries) == f.failAt {
+		return nil, errors.New("write failed")
+	}
+	return &fakeResult{value: params["p0"]}, nil
+}
+
+// fakeResult returns one record holding the first parameter, which is the id
+// the node and relationship queries bind first
+type fakeResult struct {
+	neo4j.ResultWithContext
+	value any
+	read  bool
+}
+
+func (r *fakeResult) Next(context.Context) bool {
+	if r.read {
+		return false
+	}
+	r.read = true
+	return true
+}
+
+func (r *fakeResult) Record() *neo4j.Record {
+	return &neo4j.Record{Values: []any{r.value}}
+}
+
+func (r *fakeResult) Err() error { return nil }
+
+type txSeason struct {
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name"`
+}
+
+// saveSeasonWork saves a season with two settings linked to it, the way the
+// season store does
+func saveSeasonWork(season *txSeason, settings []txSeason) func(tx *Tx) error {
+	return func(tx *Tx) error {
+		seasonID, err := tx.SaveStruct(season, "Season")
+		if err != nil {
+			return err
+		}
+		tx.OnCommit(func() { season.ID = seasonID })
+		for i := range settings {
+			settingID, err := tx.SaveStruct(&settings[i], "DetailedBlockSettings")
+			if err != nil {
+				return err
+			}
+			tx.OnCommit(func() { settings[i].ID = settingID })
+			err = tx.SaveRelationship(Relation{NodeN: "Season", NodeX: "DetailedBlockSettings", NodeNID: seasonID, NodeXID: settingID, Name: "HAS_SETTINGS"})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func TestTxUnitOfWork(t *testing.T) {
+	tests := []struct {
+		name        string
+		failAt      int
+		wantQueries int
+		wantErr     bool
+	}{
+		{name: "every write succeeds", wantQueries: 5},
+		{name: "season node fails", failAt: 1, wantQueries: 1, wantErr: true},
+		{name: "second setting fails", failAt: 4, wantQueries: 4, wantErr: true},
+		{name: "last relationship fails", failAt: 5, wantQueries: 5, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			season := txSeason{Name: "Summer"}
+			settings := []txSeason{{Name: "Weekday"}, {Name: "Weekend"}}
+			mtx := &fakeTx{failAt: tt.failAt}
+			tx := &Tx{ctx: context.Background(), tx: mtx}
+
+			err := saveSeasonWork(&season, settings)(tx)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if len(mtx.queries) != tt.wantQueries {
+				t.Errorf("expected %d statements, got %d", tt.wantQueries, len(mtx.queries))
+			}
+			if err != nil {
+				// InTransaction rolls back without running the commit hooks
+				if season.ID != "" || settings[0].ID != "" {
+					t.Errorf("ids assigned before commit: %q %q", season.ID, settings[0].ID)
+				}
+				return
+			}
+
+			tx.commit()
+			if season.ID == "" || settings[0].ID == "" || settings[1].ID == "" {
+				t.Fatalf("expected ids after commit, got %q %q %q", season.ID, settings[0].ID, settings[1].ID)
+			}
+			if season.ID == settings[0].ID || settings[0].ID == settings[1].ID {
+				t.Errorf("expected distinct ids, got %q %q %q", season.ID, settings[0].ID, settings[1].ID)
+			}
+			if !strings.Contains(mtx.queries[2], "MERGE") {
+				t.Errorf("expected the relationship merged, got %s", mtx.queries[2])
+			}
+		})
+	}
+}
+
+func TestNodeQueryLeavesPropertiesAlone(t *testing.T) {
+	props := map[string]any{"name": "Summer"}
+	for attempt := 0; attempt < 2; attempt++ {
+		query, _, err := nodeQuery("Season", props)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(query, "CREATE") {
+			t.Errorf("attempt %d: expected a create, got %s", attempt, query)
+		}
+	}
+	if _, ok := props["id"]; ok {
+		t.Error("expected the caller's properties left without an id")
+	}
+}
@@ -0,0 +1,154 @@
+package storage
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Status is the database's state as the monitor last saw it
+type Status struct {
+	Backend string `json:"backend"`
+	Ready   bool   `json:"ready"`
+	Error   string `json:"error,omitempty"`
+	// Since is when the database last came up or went down
+	Since time.Time `json:"since"`
+	// Attempts counts the failed connects since the database went down
+	Attempts  int       `json:"attempts,omitempty"`
+	NextRetry time.Time `json:"nextRetry,omitempty"`
+}
+
+const (
+	pingInterval = 15 * time.Second
+	minBackoff   = time.Second
+	maxBackoff   = time.Minute
+)
+
+// Monitor pings the database while it is up and reconnects with a doubling
+// backoff while it is down
+type Monitor struct {
+	connect func(ctx context.Context) error
+	ping    func(ctx context.Context) error
+	now     func() time.Time
+
+	mu     sync.RWMutex
+	status Status
+	ready  chan struct{}
+	once   sync.Once
+}
+
+// NewMonitor returns a monitor that starts out down, calling connect until it
+// succeeds and then ping until it fails
+func NewMonitor(connect, ping func(ctx context.Context) error) *Monitor {
+	return &Monitor{
+		connect: connect,
+		ping:    ping,
+		now:     time.Now,
+		status:  Status{Since: time.Now(), Error: "not connected yet"},
+		ready:   make(chan struct{}),
+	}
+}
+
+// Check makes one connect or ping, records the result and returns how long to
+// wait before the next
+func (m *Monitor) Check(ctx context.Context) time.Duration {
+	var err error
+	if m.Status().Ready {
+		err = m.ping(ctx)
+	} else {
+		err = m.connect(ctx)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	m.status.Backend = Backend()
+	if err == nil {
+		if !m.status.Ready {
+			log.Printf("Database %s is ready", m.status.Backend)
+			m.status = Status{Backend: m.status.Backend, Ready: true, Since: now}
+			m.once.Do(func() { close(m.ready) })
+		}
+		return pingInterval
+	}
+
+	if m.status.Ready {
+		log.Printf("Lost the %s database: %v", m.status.Backend, err)
+		m.status.Ready, m.status.Since, m.status.Attempts = false, now, 0
+	}
+	wait := backoff(m.status.Attempts)
+	m.status.Attempts++
+	m.status.Error = err.Error()
+	m.status.NextRetry = now.Add(wait)
+	log.Printf("Database unavailable, retrying in %s: %v", wait, err)
+	return wait
+}
+
+// Run checks the database until the context ends
+func (m *Monitor) Run(ctx context.Context) {
+	for {
+		timer := time.NewTimer(m.Check(ctx))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// Status returns the state from the last check
+func (m *Monitor) Status() Status {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.status
+}
+
+// Ready is closed the first time the database connects
+func (m *Monitor) Ready() <-chan struct{} {
+	return m.ready
+}
+
+// backoff doubles the wait after each failed attempt up to maxBackoff
+func backoff(attempt int) time.Duration {
+	wait := minBackoff
+	for i := 0; i < attempt && wait < maxBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, maxBackoff)
+}
+
+var (
+	monitorMu sync.RWMutex
+	monitor   *Monitor
+)
+
+// Start connects to the database in the background, retrying until it is up
+// and reconnecting whenever it is lost. Wait on the monitor's Ready channel
+// before serving anything that reads the database at startup.
+func Start(ctx context.Context) *Monitor {
+	m := NewMonitor(Connect, Ping)
+	UseMonitor(m)
+	go m.Run(ctx)
+	return m
+}
+
+// UseMonitor makes the monitor the one DBStatus reports
+func UseMonitor(m *Monitor) {
+	monitorMu.Lock()
+	defer monitorMu.Unlock()
+	monitor = m
+}
+
+// DBStatus reports the database's state. Without a monitor the stores were
+// wired directly, as tests do, and are taken to be ready.
+func DBStatus() Status {
+	monitorMu.RLock()
+	m := monitor
+	monitorMu.RUnlock()
+	if m == nil {
+		return Status{Backend: Backend(), Ready: true}
+	}
+	return m.Status()
+}
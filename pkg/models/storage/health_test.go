@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMonitorReconnects(t *testing.T) {
+	down := errors.New("connection refused")
+	connectErr, pingErr := down, error(nil)
+	m := NewMonitor(
+		func(context.Context) error { return connectErr },
+		func(context.Context) error { return pingErr },
+	)
+	now := time.Date(2026, 5, 1, 8, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+
+	tests := []struct {
+		name      string
+		connect   error
+		ping      error
+		wantWait  time.Duration
+		wantReady bool
+		wantTries int
+	}{
+		{"first connect fails", down, nil, time.Second, false, 1},
+		{"backs off", down, nil, 2 * time.Second, false, 2},
+		{"backs off again", down, nil, 4 * time.Second, false, 3},
+		{"connects", nil, nil, pingInterval, true, 0},
+		{"stays up while pings answer", nil, nil, pingInterval, true, 0},
+		{"ping fails", nil, down, time.Second, false, 1},
+		{"reconnects", nil, nil, pingInterval, true, 0},
+	}
+	for _, tt := range tests {
+		connectErr, pingErr = tt.connect, tt.ping
+		now = now.Add(time.Minute)
+		wait := m.Check(context.Background())
+		status := m.Status()
+		if wait != tt.wantWait || status.Ready != tt.wantReady || status.Attempts != tt.wantTries {
+			t.Fatalf("%s: expected wait %s ready %v attempts %d, got %s %v %d",
+				tt.name, tt.wantWait, tt.wantReady, tt.wantTries, wait, status.Ready, status.Attempts)
+		}
+		if !status.Ready && !status.NextRetry.Equal(now.Add(wait)) {
+			t.Errorf("%s: expected the next retry at %s, got %s", tt.name, now.Add(wait), status.NextRetry)
+		}
+	}
+	select {
+	case <-m.Ready():
+	default:
+		t.Error("expected Ready closed once the database connected")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{0, time.Second},
+		{1, 2 * time.Second},
+		{5, 32 * time.Second},
+		{6, time.Minute},
+		{100, time.Minute},
+	}
+	for _, tt := range tests {
+		if got := backoff(tt.attempt); got != tt.want {
+			t.Errorf("backoff(%d) = %s, want %s", tt.attempt, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+// Package storage points the models' stores at a backend. The binaries call
+// Connect, which picks Neo4j or SQLite from DB_URI; tests wire the in-memory stores.
+package storage
+
+import (
+	"bigfoot/golf/common/models/account"
+	"bigfoot/golf/common/models/audit"
+	"bigfoot/golf/common/models/auth"
+	"bigfoot/golf/common/models/db"
+	"bigfoot/golf/common/models/teetimes"
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"sync"
+)
+
+// Connect opens the database named by DB_URI and backs every store with it. A
+// sqlite:// URI opens a SQLite file; anything else, such as
+// bolt://neo4j:7687, connects to Neo4j. Either way the schema migrations the
+// database has not seen are applied before the stores are wired. Connect can
+// be called again after it fails, which is how Start reconnects. The stores
+// are wired only once, by the first call that succeeds; later calls leave
+// them in place and check the backend answers, since the SQLite pool and the
+// Neo4j driver re-dial on their own.
+func Connect(ctx context.Context) error {
+	connectMu.Lock()
+	defer connectMu.Unlock()
+	if Backend() != "" {
+		return Ping(ctx)
+	}
+	if path, ok := db.SQLitePath(os.Getenv("DB_URI")); ok {
+		conn, err := db.OpenSQLite(ctx, path)
+		if err != nil {
+			return err
+		}
+		UseSQLite(conn)
+		return nil
+	}
+	db.InitDB(ctx)
+	if db.Instance.Err != nil {
+		return db.Instance.Err
+	}
+	if _, err := db.Instance.Migrate(ctx, false); err != nil {
+		return err
+	}
+	UseNeo4j(db.Instance)
+	return nil
+}
+
+// connectMu keeps Connect to one call at a time, so the stores are wired once
+var connectMu sync.Mutex
+
+// Ping checks the backend Connect last wired still answers
+func Ping(ctx context.Context) error {
+	backend.mu.Lock()
+	ping := backend.ping
+	backend.mu.Unlock()
+	if ping == nil {
+		return errors.New("storage: not connected")
+	}
+	return ping(ctx)
+}
+
+// backend is the database the stores were last wired to
+var backend struct {
+	mu   sync.Mutex
+	name string
+	ping func(ctx context.Context) error
+	// closer releases the SQLite connection when other stores replace it
+	closer func() error
+	// course is the tee time stores wired with the backend
+	course teetimes.Stores
+}
+
+func setBackend(name string, ping func(ctx context.Context) error, closer func() error) {
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+	if backend.closer != nil {
+		backend.closer()
+	}
+	backend.name, backend.ping, backend.closer = name, ping, closer
+}
+
+// useCourse keeps the tee time stores for Course
+func useCourse(stores teetimes.Stores) {
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+	backend.course = stores
+}
+
+// Course returns a Course over the tee time stores last wired
+func Course() *teetimes.Course {
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+	return teetimes.NewCourse(backend.course)
+}
+
+// Backend names the database the stores are wired to
+func Backend() string {
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+	return backend.name
+}
+
+// UseNeo4j backs every model store with the Neo4j connection
+func UseNeo4j(conn *db.Database) {
+	setBackend("neo4j", conn.Ping, nil)
+	account.SetUserStore(account.NewNeo4jUserStore(conn))
+	audit.SetStore(audit.NewNeo4jStore(conn))
+	auth.SetConfigStore(auth.NewNeo4jConfigStore(conn))
+	auth.SetSessionStore(auth.NewNeo4jSessionStore(conn))
+	auth.SetTOTPStore(auth.NewNeo4jTOTPStore(conn))
+	useCourse(teetimes.Stores{
+		Seasons:   teetimes.NewNeo4jSeasonStore(conn),
+		Bookings:  teetimes.NewNeo4jBookingStore(conn),
+		Waitlist:  teetimes.NewNeo4jWaitlistStore(conn),
+		Outings:   teetimes.NewNeo4jOutingStore(conn),
+		Overrides: teetimes.NewNeo4jOverrideStore(conn),
+		Standing:  teetimes.NewNeo4jStandingStore(conn),
+	})
+}
+
+// UseSQLite backs every model store with the SQLite database
+func UseSQLite(conn *sql.DB) {
+	setBackend("sqlite", func(ctx context.Context) error {
+		return db.Timed(ctx, conn.PingContext)
+	}, conn.Close)
+	account.SetUserStore(account.NewSQLiteUserStore(conn))
+	audit.SetStore(audit.NewSQLiteStore(conn))
+	auth.SetConfigStore(auth.NewSQLiteConfigStore(conn))
+	auth.SetSessionStore(auth.NewSQLiteSessionStore(conn))
+	auth.SetTOTPStore(auth.NewSQLiteTOTPStore(conn))
+	useCourse(teetimes.Stores{
+		Seasons:   teetimes.NewSQLiteSeasonStore(conn),
+		Bookings:  teetimes.NewSQLiteBookingStore(conn),
+		Waitlist:  teetimes.NewSQLiteWaitlistStore(conn),
+		Outings:   teetimes.NewSQLiteOutingStore(conn),
+		Overrides: teetimes.NewSQLiteOverrideStore(conn),
+		Standing:  teetimes.NewSQLiteStandingStore(conn),
+	})
+}
+
+// Memory is the set of in-memory stores wired by UseMemory, kept so tests
+// can seed and inspect them
+type Memory struct {
+	Users      *account.MemoryUserStore
+	Audit      *audit.MemoryStore
+	AuthConfig *auth.MemoryConfigStore
+	Sessions   *auth.MemorySessionStore
+	TOTP       *auth.MemoryTOTPStore
+	Seasons    *teetimes.MemorySeasonStore
+	Bookings   *teetimes.MemoryBookingStore
+	Waitlist   *teetimes.MemoryWaitlistStore
+	Outings    *teetimes.MemoryOutingStore
+	Overrides  *teetimes.MemoryOverrideStore
+	Standing   *teetimes.MemoryStandingStore
+}
+
+// UseMemory backs every model store with a fresh in-memory store
+func UseMemory() *Memory {
+	m := &Memory{
+		Users:      account.NewMemoryUserStore(),
+		Audit:      audit.NewMemoryStore(),
+		AuthConfig: auth.NewMemoryConfigStore(),
+		Sessions:   auth.NewMemorySessionStore(),
+		TOTP:       auth.NewMemoryTOTPStore(),
+		Seasons:    teetimes.NewMemorySeasonStore(),
+		Bookings:   teetimes.NewMemoryBookingStore(),
+		Waitlist:   teetimes.NewMemoryWaitlistStore(),
+		Outings:    teetimes.NewMemoryOutingStore(),
+		Overrides:  teetimes.NewMemoryOverrideStore(),
+		Standing:   teetimes.NewMemoryStandingStore(),
+	}
+	setBackend("memory", func(context.Context) error { return nil }, nil)
+	account.SetUserStore(m.Users)
+	audit.SetStore(m.Audit)
+	auth.SetConfigStore(m.AuthConfig)
+	auth.SetSessionStore(m.Sessions)
+	auth.SetTOTPStore(m.TOTP)
+	useCourse(teetimes.Stores{
+		Seasons:   m.Seasons,
+		Bookings:  m.Bookings,
+		Waitlist:  m.Waitlist,
+		Outings:   m.Outings,
+		Overrides: m.Overrides,
+		Standing:  m.Standing,
+	})
+	return m
+}
@@ -0,0 +1,33 @@
+package storage
+
+import (
+	"bigfoot/golf/common/models/teetimes"
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestConnectWiresStoresOnce(t *testing.T) {
+	t.Setenv("DB_URI", "sqlite://"+filepath.Join(t.TempDir(), "golf.db"))
+	t.Cleanup(func() { UseMemory() })
+	ctx := context.Background()
+	wired := func() teetimes.Stores {
+		backend.mu.Lock()
+		defer backend.mu.Unlock()
+		return backend.course
+	}
+
+	if err := Connect(ctx); err != nil {
+		t.Fatalf("unexpected error connecting: %v", err)
+	}
+	first := wired()
+	if err := Connect(ctx); err != nil {
+		t.Fatalf("unexpected error reconnecting: %v", err)
+	}
+	if wired() != first {
+		t.Error("expected a reconnect to keep the stores wired by the first connect")
+	}
+	if Backend() != "sqlite" {
+		t.Errorf("expected the sqlite backend, got %q", Backend())
+	}
+}
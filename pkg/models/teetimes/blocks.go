@@ -3,6 +3,8 @@ package teetimes
 import (
 	"bigfoot/golf/common/models/account"
 	"bigfoot/golf/common/models/db"
+	"context"
+	"errors"
 	"fmt"
 	"time"
 )
@@ -34,9 +36,15 @@ func NewReservationBlock(season Season) ReservationBlock {
 	return block
 }
 
-func (r *ReservationBlock) Save() error {
+// errNeedsNeo4j is returned when saving a legacy ReservationBlock while the
+// app runs on SQLite
+var errNeedsNeo4j = errors.New("reservation blocks need the Neo4j database")
 
-	_strOut, err := db.Instance.SaveStruct(r, "ReservationBlock")
+func (r *ReservationBlock) Save(ctx context.Context) error {
+	if db.Instance == nil {
+		return errNeedsNeo4j
+	}
+	_strOut, err := db.Instance.SaveStruct(ctx, r, "ReservationBlock")
 	if err != nil {
 		fmt.Println(err)
 	}
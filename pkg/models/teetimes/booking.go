@@ -1,8 +1,8 @@
 package teetimes
 
 import (
-	"bigfoot/golf/common/models/db"
-	"encoding/json"
+	"bigfoot/golf/common/models/audit"
+	"context"
 	"fmt"
 	"log"
 	"time"
@@ -13,10 +13,13 @@ type BookingEngine struct {
 	ActiveBlocks []ReservationBlock
 }
 
+// AppConfigFile is the course's season config, see seasons.yaml
 type AppConfigFile struct {
-	CreatedAt time.Time `yaml:"createdAt" json:"createdAt"`
-	UpdatedAt time.Time `yaml:"updatedAt" json:"updatedAt"`
-	Seasons   []Season  `yaml:"seasons" json:"seasons"`
+	CreatedAt time.Time        `yaml:"createdAt" json:"createdAt"`
+	UpdatedAt time.Time        `yaml:"updatedAt" json:"updatedAt"`
+	Latitude  float32          `yaml:"latitude" json:"latitude"`
+	Longitude float32          `yaml:"longitude" json:"longitude"`
+	Seasons   []SeasonTemplate `yaml:"seasons" json:"seasons"`
 }
 
 type SettingType int
@@ -40,7 +43,9 @@ type DetailedBlockSettings struct {
 	BeginOverride time.Time `yaml:"beginOverride" json:"beginOverride"`
 	EndOverride   time.Time `yaml:"endOverride" json:"endOverride"`
 	Price         float32   `yaml:"price" json:"price"`
+	NinePrice     float32   `yaml:"ninePrice" json:"ninePrice"` // 0 prices nine holes at NineHoleShare
 	IsAvail       bool      `yaml:"isAvail" json:"isAvail"`
+	UpdatedBy     string    `yaml:"-" json:"updatedBy,omitempty" neo4j:",omitempty"`
 }
 
 func GetDetailedBlockSettings(season Season) []DetailedBlockSettings {
@@ -52,47 +57,70 @@ func GetDetailedBlockSettings(season Season) []DetailedBlockSettings {
 	return dbs
 }
 
-func BookTeeTime(res Reservation) error {
+// BookTeeTime books the reservation's slot, returning a SlotUnavailableError
+// if the slot is taken or lacks room for every player. The booking user and
+// players booked by id are stored with just their id and name.
+func (c *Course) BookTeeTime(ctx context.Context, res *Reservation) error {
 	if res.BookingUser == nil {
 		return fmt.Errorf("no user found")
 	}
 	if len(res.Players) == 0 {
 		res.Players = append(res.Players, *res.BookingUser)
 	}
-	return res.Save()
+	// a new booking is never cancelled and gets its id from the store, whatever the client sent
+	res.ID, res.Cancelled, res.CancelledAt = "", false, nil
+	res.PlayerCount = int64(len(res.Players))
+	res.withoutProfiles()
+	res.Tee = SlotTee(res.Slot)
+	res.Holes = res.RoundHoles()
+	if res.CreatedAt.IsZero() {
+		res.CreatedAt = time.Now()
+	}
+	blockers, err := c.dayBlockers(ctx, res.TeeTime)
+	if err != nil {
+		return err
+	}
+	if slotBlocked(res.TeeTime, blockers) {
+		return &SlotUnavailableError{TeeTime: res.TeeTime, Slot: res.Slot}
+	}
+	createdAt := res.CreatedAt
+	res.UpdatedBy = audit.Actor(ctx)
+	if err := c.stores.Bookings.BookSlot(ctx, res); err != nil {
+		return err
+	}
+	// a replayed booking comes back as first created and is already recorded
+	if res.CreatedAt.Equal(createdAt) {
+		audit.Record(ctx, audit.Reservation, res.ID, audit.Created, nil, res)
+	}
+	return nil
 }
 
-func (b *BookingEngine) GetDayTeeTimes(_date time.Time) ([]ReservedDay, error) {
-	var days []Reservation
-	//slotQuery := ""
-	//if slot != nil && *slot >= 0 {
-	//	slotQuery = fmt.Sprintf(` {slot: %d}`, slot)
-	//}
-	//get the tee times
-	query := fmt.Sprintf(`MATCH (n:Reservation) WHERE date(n.createdAt) = date("%s")
-		MATCH (u:User)-[r:BOOKED_TEETIME]->(n)
-		WITH n, u {.*} as user, COLLECT(u {.*}) as players
-		RETURN n{.* , user, players} as data`, _date.Format(time.DateOnly))
-
-	dayWithRelationships, err := db.Instance.QueryForJSON(query, nil) // depth of 2
+// DayTeeTimes lays out the day's tee sheet with its bookings, holds and
+// prices, or nothing when no season covers the day
+func (c *Course) DayTeeTimes(ctx context.Context, _date time.Time) ([]ReservedDay, error) {
+	days, err := c.stores.Bookings.DayReservations(ctx, _date)
 	if err != nil {
 		log.Printf("Error querying with relationships: %v", err)
 		return nil, err
 	}
-	if dayWithRelationships != nil {
-		err = json.Unmarshal(dayWithRelationships, &days)
-		if err != nil {
-			return nil, err
-		}
-	}
 	var daysOut []ReservedDay
 	//no block so create times
-	_seas, err := GetSeason(_date)
+	_seas, err := c.Season(ctx, _date)
 	if err != nil {
 		return nil, err
 	}
 	if _seas != nil {
-		_newDay := NewReservedDay(_date, *_seas, days)
+		blockers, err := c.dayBlockers(ctx, _date)
+		if err != nil {
+			return nil, err
+		}
+		_newDay := NewReservedDay(_date, *_seas, days, blockers...)
+		holds, err := c.stores.Bookings.ActiveHolds(ctx, _date, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		_newDay.MarkHeld(holds)
+		_newDay.ApplyPricing(DefaultPricingEngine(*_seas), *_seas, time.Now())
 		daysOut = append(daysOut, _newDay)
 	}
 
@@ -100,50 +128,36 @@ func (b *BookingEngine) GetDayTeeTimes(_date time.Time) ([]ReservedDay, error) {
 
 }
 
-func (d *DetailedBlockSettings) Save() (string, error) {
+// SaveSetting writes the block setting as the context's actor, records the
+// change and returns the setting's id
+func (c *Course) SaveSetting(ctx context.Context, d *DetailedBlockSettings) (string, error) {
 	//differentiate weekday, holiday, morning Afternoon Times
-	_id, err := db.Instance.SaveStruct(d, "DetailedBlockSettings")
-	if err != nil || _id == "" {
+	var before *DetailedBlockSettings
+	if d.ID != "" {
+		var err error
+		if before, err = c.stores.Seasons.GetSetting(ctx, d.ID); err != nil {
+			return "", err
+		}
+	}
+	d.UpdatedBy = audit.Actor(ctx)
+	if err := c.stores.Seasons.SaveSetting(ctx, d); err != nil {
 		fmt.Println(err)
 		return "", err
 	}
-	d.ID = _id
-	return _id, nil
+	if before == nil {
+		audit.Record(ctx, audit.BlockSetting, d.ID, audit.Created, nil, d)
+	} else {
+		audit.Record(ctx, audit.BlockSetting, d.ID, audit.Updated, before, d)
+	}
+	return d.ID, nil
 }
 func (d *DetailedBlockSettings) MatchesType(_day time.Time, _time time.Time) bool {
 	dayType := []int{int(WeekdayMorning), int(WeekdayAfternoon)}
 	if _day.Weekday() == time.Saturday || _day.Weekday() == time.Sunday {
-		dayType = []int{int(WeekdayMorning), int(WeekendAfternoon)}
+		dayType = []int{int(WeekendMorning), int(WeekendAfternoon)}
 	}
 	if d.Type >= dayType[0] && d.Type <= dayType[1] {
 		return true
 	}
 	return false
 }
-
-func mapToBlockDetails(settingMap map[string]interface{}) DetailedBlockSettings {
-	var dbs DetailedBlockSettings
-
-	if id, ok := settingMap["id"].(string); ok {
-		dbs.ID = id
-	}
-	if name, ok := settingMap["name"].(string); ok {
-		dbs.Name = name
-	}
-	if settingType, ok := settingMap["type"].(int64); ok {
-		dbs.Type = int(settingType)
-	}
-	if beginOverride, ok := settingMap["beginOverride"].(time.Time); ok {
-		dbs.BeginOverride = beginOverride
-	}
-	if endOverride, ok := settingMap["endOverride"].(time.Time); ok {
-		dbs.EndOverride = endOverride
-	}
-	if price, ok := settingMap["price"].(float64); ok {
-		dbs.Price = float32(price)
-	}
-	if isAvail, ok := settingMap["isAvail"].(bool); ok {
-		dbs.IsAvail = isAvail
-	}
-	return dbs
-}
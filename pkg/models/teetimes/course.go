@@ -0,0 +1,23 @@
+package teetimes
+
+// Stores are the stores a Course books against
+type Stores struct {
+	Seasons   SeasonStore
+	Bookings  BookingStore
+	Waitlist  WaitlistStore
+	Outings   OutingStore
+	Overrides DayOverrideStore
+	Standing  StandingStore
+}
+
+// Course lays out, prices, books and cancels tee times against the stores it
+// was built with. The binaries build one from the stores storage wires and
+// hand it to the handlers and background jobs.
+type Course struct {
+	stores Stores
+}
+
+// NewCourse returns a course working against the stores
+func NewCourse(stores Stores) *Course {
+	return &Course{stores: stores}
+}
@@ -2,6 +2,7 @@ package teetimes
 
 import (
 	"bigfoot/golf/common/models/db"
+	"context"
 	"time"
 )
 
@@ -10,11 +11,24 @@ type ReservedDay struct {
 	Day time.Time `json:"day"`
 	//Reservations map[int]Reservation
 	Times []Reservation `json:"reservations"`
+	Tees  []int         `json:"tees,omitempty"` // starting tees, Times holds each tee's slots in tee time order
 }
 
-func NewReservedDay(day time.Time, _season Season, _reserved []Reservation) ReservedDay {
+// SlotBlocker takes tee times off the public sheet, e.g. an outing's window
+type SlotBlocker interface {
+	BlocksSlot(teeTime time.Time) bool
+}
+
+// NewReservedDay lays out the day's slots on each of the season's starting
+// tees, listing every booking in a slot and then, when the slot has spots
+// left, an open tee time carrying how many. Open tee times are left out where
+// a blocker has reserved them, a booked group will take them at the turn, or
+// the season is closed.
+func NewReservedDay(day time.Time, _season Season, _reserved []Reservation, _blockers ...SlotBlocker) ReservedDay {
 	var resDay ReservedDay
 	resDay.Day = day
+	resDay.Tees = _season.StartingTees()
+	crossovers := heldCrossovers(_reserved)
 
 	//add the reservations
 	_slot := 1
@@ -24,51 +38,119 @@ func NewReservedDay(day time.Time, _season Season, _reserved []Reservation) Rese
 		if _firstTime.After(_season.LastTeeTime) {
 			break
 		}
-		reserved := checkIfReserved(int64(_slot), _reserved)
-		if reserved != nil {
-			reservations = append(reservations, *reserved)
-			_slot++
-		} else {
-			_blockSetting := _season.GetTimeDetails(day, _firstTime)
+		_blockSetting := _season.GetTimeDetails(day, _firstTime)
+		_laidOut := false
+		for _, tee := range resDay.Tees {
+			slot := TeeSlot(tee, int64(_slot))
+			reserved := slotReservations(slot, _reserved)
+			var booked int64
+			for _, res := range reserved {
+				booked += res.bookedPlayers()
+			}
+			if len(reserved) > 0 {
+				reservations = append(reservations, reserved...)
+				_laidOut = true
+			}
 			if _blockSetting != nil {
 				_teeTime := time.Date(day.Year(), day.Month(), day.Day(), _firstTime.Hour(), _firstTime.Minute(), 0, 0, db.TimeLocation)
-				reservations = append(reservations, NewReservation(nil, nil, _teeTime, int64(_slot), *_blockSetting))
-				_slot++
+				if _season.IsOpen && booked < MaxPlayersPerSlot && !slotBlocked(_teeTime, _blockers) && !crossovers[slot] {
+					open := NewReservation(nil, nil, _teeTime, slot, *_blockSetting)
+					open.Tee = tee
+					open.Open = MaxPlayersPerSlot - booked
+					reservations = append(reservations, open)
+				}
+				_laidOut = true
 			}
 		}
+		if _laidOut {
+			_slot++
+		}
 		_firstTime = _firstTime.Add(_season.Gap)
 	}
 	resDay.Times = reservations
 	return resDay
 }
 
-func checkIfReserved(slot int64, reserved []Reservation) *Reservation {
+func slotBlocked(teeTime time.Time, blockers []SlotBlocker) bool {
+	for _, blocker := range blockers {
+		if blocker.BlocksSlot(teeTime) {
+			return true
+		}
+	}
+	return false
+}
+
+// slotReservations returns the bookings in the slot
+func slotReservations(slot int64, reserved []Reservation) []Reservation {
+	var found []Reservation
 	for _, res := range reserved {
 		if res.Slot == slot {
-			return &res
+			found = append(found, res)
+		}
+	}
+	return found
+}
+
+// bookedPlayers is the number of spots the reservation takes in its slot
+func (r *Reservation) bookedPlayers() int64 {
+	if r.PlayerCount == 0 {
+		return int64(len(r.Players))
+	}
+	return r.PlayerCount
+}
+
+// MarkHeld takes the spots other golfers are holding during checkout off the
+// open tee times, flagging those with none left as unavailable
+func (r *ReservedDay) MarkHeld(holds []SlotHold) {
+	for i := range r.Times {
+		if r.Times[i].ID != "" {
+			continue
+		}
+		for _, hold := range holds {
+			if hold.Slot == r.Times[i].Slot {
+				r.Times[i].Open -= hold.Players
+				r.Times[i].Held = r.Times[i].Open <= 0
+			}
+		}
+	}
+}
+
+// slotAt returns the open slot laid out at the tee time, the given slot when
+// it is not zero, or nil when the tee sheet has no such slot open at that time
+func (r *ReservedDay) slotAt(teeTime time.Time, slot int64) *Reservation {
+	for i := range r.Times {
+		laidOut := &r.Times[i]
+		if laidOut.ID == "" && laidOut.TeeTime.Equal(teeTime) && (slot == 0 || laidOut.Slot == slot) {
+			return laidOut
 		}
 	}
 	return nil
 }
 
+// GetBySlot returns the slot's open tee time, or nil when it has none
 func (r *ReservedDay) GetBySlot(slot int64) *Reservation {
 	for _, res := range r.Times {
-		if res.Slot == slot {
+		if res.ID == "" && res.Slot == slot {
 			return &res
 		}
 	}
 	return nil
 }
+
+// GetByTime returns the first open tee time at the hour and minute, or nil
 func (r *ReservedDay) GetByTime(hour, minute int) *Reservation {
 	for _, res := range r.Times {
-		if res.TeeTime.Hour() == hour && res.TeeTime.Minute() == minute {
+		if res.ID == "" && res.TeeTime.Hour() == hour && res.TeeTime.Minute() == minute {
 			return &res
 		}
 	}
 	return nil
 }
-func GetUnbookedReservation(tm time.Time) *Reservation {
-	seas, _ := GetSeason(tm)
+
+// UnbookedReservation returns the open reservation the season lays out at the
+// tee time, or nil
+func (c *Course) UnbookedReservation(ctx context.Context, tm time.Time) *Reservation {
+	seas, _ := c.Season(ctx, tm)
 	settings := seas.GetTimeDetails(tm, tm)
 	if settings != nil && settings.IsAvail {
 		hourDiff := tm.Hour() - seas.FirstTeeTime.Hour()
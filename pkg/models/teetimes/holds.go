@@ -0,0 +1,120 @@
+package teetimes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// HoldTTL is how long a slot stays reserved for a golfer during checkout
+const HoldTTL = 5 * time.Minute
+
+var (
+	// ErrHoldNotFound is returned when releasing a hold that expired or belongs to another user
+	ErrHoldNotFound = errors.New("slot hold not found")
+	// ErrHoldPlayers is returned for a hold on fewer than one or more than MaxPlayersPerSlot spots
+	ErrHoldPlayers = fmt.Errorf("holds are for 1 to %d players", MaxPlayersPerSlot)
+)
+
+// SlotHold temporarily claims spots in a slot for one user while they finish booking
+type SlotHold struct {
+	ID        string    `json:"id,omitempty"`
+	UserID    string    `json:"userId"`
+	TeeTime   time.Time `json:"teeTime"`
+	Slot      int64     `json:"slot"`
+	Players   int64     `json:"players"`
+	CreatedAt time.Time `json:"createdAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// IsActive reports whether the hold still claims its slot at the given time
+func (h *SlotHold) IsActive(now time.Time) bool {
+	return h.ExpiresAt.After(now)
+}
+
+// PlaceHold claims the requested players, 1 to MaxPlayersPerSlot, in the
+// hold's slot for HoldTTL. The slot must be open on the day's tee sheet and
+// still to come. A user holds one slot at a time, so any earlier hold they
+// had, a waitlist offer's included, is replaced.
+func (c *Course) PlaceHold(ctx context.Context, hold *SlotHold) error {
+	return c.placeHold(ctx, hold, HoldTTL)
+}
+
+func (c *Course) placeHold(ctx context.Context, hold *SlotHold, ttl time.Duration) error {
+	if hold.UserID == "" {
+		return fmt.Errorf("no user found")
+	}
+	if hold.Players < 1 || hold.Players > MaxPlayersPerSlot {
+		return ErrHoldPlayers
+	}
+	hold.CreatedAt = time.Now()
+	if !hold.TeeTime.After(hold.CreatedAt) {
+		return &SlotUnavailableError{TeeTime: hold.TeeTime, Slot: hold.Slot, Requested: int(hold.Players)}
+	}
+	days, err := c.DayTeeTimes(ctx, hold.TeeTime)
+	if err != nil {
+		return err
+	}
+	if len(days) == 0 || days[0].slotAt(hold.TeeTime, hold.Slot) == nil {
+		return &SlotUnavailableError{TeeTime: hold.TeeTime, Slot: hold.Slot, Requested: int(hold.Players)}
+	}
+	hold.ExpiresAt = hold.CreatedAt.Add(ttl)
+	return c.stores.Bookings.PlaceHold(ctx, hold)
+}
+
+// ReleaseHold gives a user's held spots back before the hold expires
+func (c *Course) ReleaseHold(ctx context.Context, userID, holdID string) error {
+	return c.stores.Bookings.ReleaseHold(ctx, userID, holdID)
+}
+
+// DayHolds returns the holds still active on the given day
+func (c *Course) DayHolds(ctx context.Context, day time.Time) ([]SlotHold, error) {
+	return c.stores.Bookings.ActiveHolds(ctx, day, time.Now())
+}
+
+// StartHoldSweeper passes lapsed waitlist offers along and releases expired
+// holds every interval until ctx is done
+func (c *Course) StartHoldSweeper(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				if _, err := c.ExpireWaitlistOffers(ctx, now); err != nil {
+					log.Printf("Error expiring waitlist offers: %v", err)
+				}
+				released, err := c.stores.Bookings.ReleaseExpiredHolds(ctx, now)
+				if err != nil {
+					log.Printf("Error releasing expired holds: %v", err)
+				} else if released > 0 {
+					log.Printf("Released %d expired slot holds", released)
+				}
+			}
+		}
+	}()
+}
+
+// heldPlayers totals the spots other users are holding in a slot
+func heldPlayers(holds []SlotHold, userID string, now time.Time) int {
+	held := 0
+	for _, hold := range holds {
+		if hold.UserID != userID && hold.IsActive(now) {
+			held += int(hold.Players)
+		}
+	}
+	return held
+}
+
+// claimHold sizes a hold against the spots left in its slot
+func claimHold(hold *SlotHold, booked int, held int) error {
+	remaining := MaxPlayersPerSlot - booked - held
+	if remaining <= 0 || int(hold.Players) > remaining {
+		return &SlotUnavailableError{TeeTime: hold.TeeTime, Slot: hold.Slot, Requested: int(hold.Players), Remaining: remaining}
+	}
+	return nil
+}
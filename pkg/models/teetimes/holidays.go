@@ -0,0 +1,148 @@
+package teetimes
+
+import (
+	"bigfoot/golf/common/models/db"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+var ErrHolidayNotFound = errors.New("holiday not found")
+
+// HolidayDate is a day priced and booked with the season's Holiday settings.
+// Federal holidays are computed per year; course holidays are stored on the season.
+// A stored holiday with Skip set suppresses the federal holiday on the same date.
+type HolidayDate struct {
+	ID        string    `yaml:"id" json:"id"`
+	Name      string    `yaml:"name" json:"name"`
+	Date      time.Time `yaml:"date" json:"date"`
+	Federal   bool      `yaml:"federal" json:"federal"`
+	Skip      bool      `yaml:"skip" json:"skip"`
+	CreatedAt time.Time `yaml:"createdAt" json:"createdAt"`
+	UpdatedAt time.Time `yaml:"updatedAt" json:"updatedAt"`
+}
+
+// FederalHolidays computes the US federal holidays observed on their actual date for the year
+func FederalHolidays(year int) []HolidayDate {
+	on := func(month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, courseLocation())
+	}
+	return []HolidayDate{
+		{Name: "New Year's Day", Date: on(time.January, 1), Federal: true},
+		{Name: "Martin Luther King Jr. Day", Date: nthWeekday(year, time.January, time.Monday, 3), Federal: true},
+		{Name: "Presidents' Day", Date: nthWeekday(year, time.February, time.Monday, 3), Federal: true},
+		{Name: "Memorial Day", Date: nthWeekday(year, time.May, time.Monday, -1), Federal: true},
+		{Name: "Juneteenth", Date: on(time.June, 19), Federal: true},
+		{Name: "Independence Day", Date: on(time.July, 4), Federal: true},
+		{Name: "Labor Day", Date: nthWeekday(year, time.September, time.Monday, 1), Federal: true},
+		{Name: "Columbus Day", Date: nthWeekday(year, time.October, time.Monday, 2), Federal: true},
+		{Name: "Veterans Day", Date: on(time.November, 11), Federal: true},
+		{Name: "Thanksgiving Day", Date: nthWeekday(year, time.November, time.Thursday, 4), Federal: true},
+		{Name: "Christmas Day", Date: on(time.December, 25), Federal: true},
+	}
+}
+
+// nthWeekday finds the nth weekday of the month, or the last one when n is -1
+func nthWeekday(year int, month time.Month, weekday time.Weekday, n int) time.Time {
+	if n < 0 {
+		last := time.Date(year, month+1, 0, 0, 0, 0, 0, courseLocation())
+		return last.AddDate(0, 0, -((int(last.Weekday()) - int(weekday) + 7) % 7))
+	}
+	first := time.Date(year, month, 1, 0, 0, 0, 0, courseLocation())
+	offset := (int(weekday) - int(first.Weekday()) + 7) % 7
+	return first.AddDate(0, 0, offset+7*(n-1))
+}
+
+// courseLocation is the course's time zone, local time until the database sets it
+func courseLocation() *time.Location {
+	if db.TimeLocation != nil {
+		return db.TimeLocation
+	}
+	return time.Local
+}
+
+func sameDay(a, b time.Time) bool {
+	return a.Year() == b.Year() && a.YearDay() == b.YearDay()
+}
+
+// HolidayOn returns the season's holiday on the day, if any
+func (s *Season) HolidayOn(day time.Time) *HolidayDate {
+	for i := range s.Holidays {
+		if sameDay(s.Holidays[i].Date, day) && !s.Holidays[i].Skip {
+			return &s.Holidays[i]
+		}
+	}
+	return nil
+}
+
+// BuildCalendar merges the federal holidays falling in the season with the
+// course holidays, letting a stored entry replace or skip a federal one
+func (s *Season) BuildCalendar(course []HolidayDate) []HolidayDate {
+	var calendar []HolidayDate
+	for year := s.BeginDate.Year(); year <= s.EndDate.Year(); year++ {
+		for _, h := range FederalHolidays(year) {
+			if h.Date.Before(dayStart(s.BeginDate)) || h.Date.After(s.EndDate) {
+				continue
+			}
+			if overridden(h, course) {
+				continue
+			}
+			calendar = append(calendar, h)
+		}
+	}
+	return append(calendar, course...)
+}
+
+func overridden(h HolidayDate, course []HolidayDate) bool {
+	for _, c := range course {
+		if sameDay(c.Date, h.Date) {
+			return true
+		}
+	}
+	return false
+}
+
+func dayStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// loadHolidays fills the season's calendar from the stored course holidays
+func (s *Season) loadHolidays(ctx context.Context, store SeasonStore) error {
+	course, err := store.Holidays(ctx, s.ID)
+	if err != nil {
+		log.Printf("Error querying holidays: %v", err)
+		return err
+	}
+	s.Holidays = s.BuildCalendar(course)
+	return nil
+}
+
+// CourseHolidays returns the holidays stored on the season
+func (c *Course) CourseHolidays(ctx context.Context, seasonID string) ([]HolidayDate, error) {
+	holidays, err := c.stores.Seasons.Holidays(ctx, seasonID)
+	if err != nil {
+		log.Printf("Error querying holidays: %v", err)
+		return nil, err
+	}
+	return holidays, nil
+}
+
+// SaveHoliday adds or updates a course holiday on the season
+func (c *Course) SaveHoliday(ctx context.Context, seasonID string, h *HolidayDate) error {
+	if h.Name == "" || h.Date.IsZero() {
+		return fmt.Errorf("holiday needs a name and date")
+	}
+	h.Date = dayStart(h.Date)
+	if h.CreatedAt.IsZero() {
+		h.CreatedAt = time.Now()
+	}
+	h.UpdatedAt = time.Now()
+	return c.stores.Seasons.SaveHoliday(ctx, seasonID, h)
+}
+
+// DeleteHoliday removes a course holiday, restoring any federal holiday it replaced
+func (c *Course) DeleteHoliday(ctx context.Context, holidayID string) error {
+	return c.stores.Seasons.DeleteHoliday(ctx, holidayID)
+}
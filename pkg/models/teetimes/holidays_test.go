@@ -0,0 +1,76 @@
+package teetimes
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFederalHolidays(t *testing.T) {
+	want := map[string]string{
+		"Martin Luther King Jr. Day": "2026-01-19",
+		"Presidents' Day":            "2026-02-16",
+		"Memorial Day":               "2026-05-25",
+		"Independence Day":           "2026-07-04",
+		"Labor Day":                  "2026-09-07",
+		"Columbus Day":               "2026-10-12",
+		"Thanksgiving Day":           "2026-11-26",
+	}
+	for _, h := range FederalHolidays(2026) {
+		if date, ok := want[h.Name]; ok && h.Date.Format(time.DateOnly) != date {
+			t.Errorf("%s = %s, want %s", h.Name, h.Date.Format(time.DateOnly), date)
+		}
+	}
+}
+
+func TestGetTimeDetailsResolvesHolidays(t *testing.T) {
+	first := time.Date(2026, time.June, 21, 6, 0, 0, 0, time.UTC)
+	season := Season{
+		BeginDate:    time.Date(2026, time.June, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:      time.Date(2026, time.August, 31, 0, 0, 0, 0, time.UTC),
+		FirstTeeTime: first,
+		DefaultSettings: []DetailedBlockSettings{
+			{Type: int(WeekdayMidday), Name: "Weekday Midday", BeginOverride: first, EndOverride: first.Add(12 * time.Hour), Price: 60, IsAvail: true},
+			{Type: int(WeekendMidday), Name: "Weekend Midday", BeginOverride: first, EndOverride: first.Add(12 * time.Hour), Price: 79, IsAvail: true},
+		},
+	}
+	memberGuest := HolidayDate{Name: "Member-Guest", Date: time.Date(2026, time.August, 12, 0, 0, 0, 0, time.UTC)}
+	season.Holidays = season.BuildCalendar([]HolidayDate{
+		memberGuest,
+		{Name: "Open for Juneteenth", Date: time.Date(2026, time.June, 19, 0, 0, 0, 0, time.UTC), Skip: true},
+	})
+	teeTime := first.Add(3 * time.Hour)
+
+	tests := []struct {
+		name     string
+		day      time.Time
+		wantType SettingType
+	}{
+		{"weekday", time.Date(2026, time.June, 10, 0, 0, 0, 0, time.UTC), WeekdayMidday},
+		{"weekend", time.Date(2026, time.June, 13, 0, 0, 0, 0, time.UTC), WeekendMidday},
+		{"federal holiday", time.Date(2026, time.July, 4, 0, 0, 0, 0, time.UTC), Holiday},
+		{"course holiday", memberGuest.Date, Holiday},
+		{"skipped federal holiday", time.Date(2026, time.June, 19, 0, 0, 0, 0, time.UTC), WeekdayMidday},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			setting := season.GetTimeDetails(tc.day, teeTime)
+			if setting == nil {
+				t.Fatal("no setting resolved")
+			}
+			if SettingType(setting.Type) != tc.wantType {
+				t.Errorf("type = %d, want %d", setting.Type, tc.wantType)
+			}
+		})
+	}
+
+	if setting := season.GetTimeDetails(memberGuest.Date, teeTime); setting == nil || setting.Price != 79 || setting.Name != memberGuest.Name {
+		t.Errorf("holiday setting = %+v, want the season's weekend price without a holiday setting", setting)
+	}
+
+	season.DefaultSettings = append(season.DefaultSettings, DetailedBlockSettings{
+		Type: int(Holiday), Name: "Holiday", BeginOverride: first, EndOverride: first.Add(12 * time.Hour), Price: 89, IsAvail: true,
+	})
+	if setting := season.GetTimeDetails(time.Date(2026, time.July, 4, 0, 0, 0, 0, time.UTC), teeTime); setting == nil || setting.Price != 89 {
+		t.Errorf("holiday setting = %+v, want the season's holiday price", setting)
+	}
+}
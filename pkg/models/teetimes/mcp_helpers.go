@@ -1,153 +1,105 @@
 package teetimes
 
 import (
-	"bigfoot/golf/common/models/db"
+	"bigfoot/golf/common/models/account"
 	"context"
 	"fmt"
 	"time"
-
-	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 )
 
-// GetUserReservationsForMCP wraps the existing GetUserReservations for MCP usage
-func GetUserReservationsForMCP(userID string) ([]Reservation, error) {
-	// Use the existing GetUserReservations function with includePast=true to get all reservations
-	return GetUserReservations(userID, true)
+// GetUserReservationsForMCP returns all of the user's reservations, past ones included
+func (c *Course) GetUserReservationsForMCP(ctx context.Context, userID string) ([]Reservation, error) {
+	return c.UserReservations(ctx, userID, true)
 }
 
-// CreateReservation creates a new reservation for a user
-func CreateReservation(userID string, teeTime time.Time, players int) (*Reservation, error) {
-	ctx := context.Background()
-	driver := db.Instance
-
-	session := driver.NewWriteSession(ctx)
-	defer session.Close(ctx)
-
-	reservationID := generateReservationID()
-
-	query := `
-		MATCH (u:User {id: $userID})
-		MATCH (b:ReservationBlock {date: $date, time: $time, available: true})
-		WHERE b.slots >= $players
-		CREATE (r:Reservation {
-			id: $reservationID,
-			date: $date,
-			time: $time,
-			players: $players,
-			status: 'confirmed',
-			created: datetime()
-		})
-		CREATE (u)-[:HAS_RESERVATION]->(r)
-		CREATE (r)-[:BOOKS]->(b)
-		SET b.slots = b.slots - $players
-		RETURN r
-	`
-
-	result, err := session.Run(ctx, query, map[string]interface{}{
-		"userID":        userID,
-		"reservationID": reservationID,
-		"date":          teeTime.Format("2006-01-02"),
-		"time":          teeTime.Format("15:04"),
-		"players":       players,
-	})
+// CreateReservation books the open slot at the tee time for the user and
+// guests up to players, priced and capacity checked like any other booking.
+// It returns a SlotUnavailableError when no slot at the time is open.
+func (c *Course) CreateReservation(ctx context.Context, userID string, teeTime time.Time, players int) (*Reservation, error) {
+	days, err := c.DayTeeTimes(ctx, teeTime)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create reservation: %v", err)
+		return nil, err
 	}
-
-	if result.Next(ctx) {
-		record := result.Record()
-		resNode, _ := record.Get("r")
-		if resNode != nil {
-			res := nodeToReservation(resNode)
-			return &res, nil
+	if len(days) == 0 {
+		return nil, fmt.Errorf("no season found for %s", teeTime.Format(time.DateOnly))
+	}
+	var slot *Reservation
+	for i := range days[0].Times {
+		open := &days[0].Times[i]
+		if open.TeeTime.Equal(teeTime) && open.ID == "" && !open.Held && open.Open >= int64(players) {
+			slot = open
+			break
 		}
 	}
+	if slot == nil {
+		return nil, &SlotUnavailableError{TeeTime: teeTime, Requested: players}
+	}
 
-	return nil, fmt.Errorf("failed to create reservation")
+	booker := account.User{ID: userID}
+	res := Reservation{
+		TeeTime:     teeTime,
+		Slot:        slot.Slot,
+		BookingUser: &booker,
+		Players:     []account.User{booker},
+	}
+	for i := 1; i < players; i++ {
+		res.Players = append(res.Players, account.User{LastName: fmt.Sprintf("Guest %d", i)})
+	}
+	if _, err := c.PriceReservation(ctx, &res, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := c.BookTeeTime(ctx, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
 }
 
-// CancelReservation cancels an existing reservation
-func CancelReservation(userID, reservationID string) error {
-	ctx := context.Background()
-	driver := db.Instance
-
-	session := driver.NewWriteSession(ctx)
-	defer session.Close(ctx)
-
-	query := `
-		MATCH (u:User {id: $userID})-[:HAS_RESERVATION]->(r:Reservation {id: $reservationID})
-		MATCH (r)-[:BOOKS]->(b:ReservationBlock)
-		SET r.status = 'cancelled'
-		SET b.slots = b.slots + r.players
-		RETURN r
-	`
-
-	result, err := session.Run(ctx, query, map[string]interface{}{
-		"userID":        userID,
-		"reservationID": reservationID,
-	})
+// CancelReservation cancels the user's reservation like any other
+// cancellation, recording the change and offering the spots to the waitlist
+func (c *Course) CancelReservation(ctx context.Context, userID, reservationID string) error {
+	res, err := c.stores.Bookings.GetReservation(ctx, reservationID)
 	if err != nil {
-		return fmt.Errorf("failed to cancel reservation: %v", err)
+		return err
 	}
-
-	if !result.Next(ctx) {
+	if res == nil || res.Cancelled || res.BookingUser == nil || res.BookingUser.ID != userID {
 		return fmt.Errorf("reservation not found or unauthorized")
 	}
-
-	return nil
+	return c.Cancel(ctx, res)
 }
 
-// GetAvailableTeeTimes returns available tee times for a given date
-func GetAvailableTeeTimes(date time.Time, timeRange string, players int) ([]ReservationBlock, error) {
-	ctx := context.Background()
-	driver := db.Instance.Driver
-
-	session := driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
-	defer session.Close(ctx)
-
-	dateStr := date.Format("2006-01-02")
+// GetAvailableTeeTimes returns the open tee times on the date with room for
+// the players, within the time range when one of morning, midday or
+// afternoon is given
+func (c *Course) GetAvailableTeeTimes(ctx context.Context, date time.Time, timeRange string, players int) ([]Reservation, error) {
+	if players < 1 || players > MaxPlayersPerSlot {
+		return nil, nil
+	}
+	days, err := c.DayTeeTimes(ctx, date)
+	if err != nil || len(days) == 0 {
+		return nil, err
+	}
 
-	var timeFilter string
+	// the whole day unless a range narrows it, as hours from midnight
+	from, until := 0, 24
 	switch timeRange {
 	case "morning":
-		timeFilter = "AND b.time >= '06:00' AND b.time < '12:00'"
+		from, until = 6, 12
 	case "midday":
-		timeFilter = "AND b.time >= '11:00' AND b.time < '14:00'"
+		from, until = 11, 14
 	case "afternoon":
-		timeFilter = "AND b.time >= '14:00' AND b.time < '18:00'"
-	default:
-		timeFilter = ""
+		from, until = 14, 18
 	}
 
-	query := fmt.Sprintf(`
-		MATCH (b:ReservationBlock)
-		WHERE b.date = $date 
-		AND b.available = true 
-		AND b.slots >= $players
-		%s
-		RETURN b
-		ORDER BY b.time
-	`, timeFilter)
-
-	result, err := session.Run(ctx, query, map[string]interface{}{
-		"date":    dateStr,
-		"players": players,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to query available tee times: %v", err)
-	}
-
-	var blocks []ReservationBlock
-	for result.Next(ctx) {
-		record := result.Record()
-		blockNode, _ := record.Get("b")
-		if blockNode != nil {
-			block := nodeToReservationBlock(blockNode)
-			blocks = append(blocks, block)
+	var open []Reservation
+	for _, slot := range days[0].Times {
+		if slot.ID != "" || slot.Held || slot.Open < int64(players) {
+			continue
+		}
+		if hour := slot.TeeTime.Hour(); hour >= from && hour < until {
+			open = append(open, slot)
 		}
 	}
-
-	return blocks, nil
+	return open, nil
 }
 
 // GetCourseConditions returns current course conditions
@@ -163,27 +115,3 @@ func GetCourseConditions() (map[string]interface{}, error) {
 		"lastUpdate": time.Now().Format(time.RFC3339),
 	}, nil
 }
-
-// Helper function to generate reservation ID
-func generateReservationID() string {
-	return fmt.Sprintf("RES-%d", time.Now().Unix())
-}
-
-// Helper functions to convert Neo4j nodes to structs
-func nodeToReservation(node interface{}) Reservation {
-	// Implementation would convert Neo4j node to Reservation struct
-	// This is a placeholder
-	return Reservation{}
-}
-
-func nodeToSeason(node interface{}) *Season {
-	// Implementation would convert Neo4j node to Season struct
-	// This is a placeholder
-	return &Season{}
-}
-
-func nodeToReservationBlock(node interface{}) ReservationBlock {
-	// Implementation would convert Neo4j node to ReservationBlock struct
-	// This is a placeholder
-	return ReservationBlock{}
-}
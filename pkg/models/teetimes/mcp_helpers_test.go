@@ -0,0 +1,181 @@
+package teetimes
+
+import (
+	"bigfoot/golf/common/models/audit"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCreateReservation(t *testing.T) {
+	ctx := context.Background()
+	stores := useMemoryStores(t)
+	season := overrideSeason()
+	season.BeginDate = time.Date(2026, time.May, 1, 0, 0, 0, 0, time.UTC)
+	season.EndDate = time.Date(2026, time.September, 30, 0, 0, 0, 0, time.UTC)
+	if err := stores.seasons.Save(ctx, &season); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	teeTime := time.Date(2026, time.June, 10, 7, 30, 0, 0, time.UTC)
+	course := NewCourse(Stores{
+		Seasons:   stores.seasons,
+		Bookings:  stores.bookings,
+		Waitlist:  stores.waitlist,
+		Outings:   stores.outings,
+		Overrides: stores.overrides,
+	})
+
+	tests := []struct {
+		name    string
+		teeTime time.Time
+		players int
+		wantErr error
+	}{
+		{"books the open slot", teeTime, 2, nil},
+		{"books the spots left in the slot", teeTime, 2, nil},
+		{"refuses the slot once full", teeTime, 1, ErrSlotUnavailable},
+		{"refuses more players than a slot holds", teeTime.Add(10 * time.Minute), MaxPlayersPerSlot + 1, ErrSlotUnavailable},
+		{"refuses a time outside course hours", teeTime.Add(10 * time.Hour), 1, ErrSlotUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := course.CreateReservation(ctx, "golfer", tt.teeTime, tt.players)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if res.ID == "" || res.PlayerCount != int64(tt.players) || res.Total != res.Price*float32(tt.players) {
+				t.Errorf("expected a priced booking for %d, got %+v", tt.players, res)
+			}
+		})
+	}
+
+	if booked := stores.bookings.SlotReservations(teeTime, 4); len(booked) != 2 {
+		t.Errorf("expected two bookings in the 7:30 slot, got %+v", booked)
+	}
+}
+
+func TestGetAvailableTeeTimes(t *testing.T) {
+	ctx := context.Background()
+	teeTime := time.Date(2026, time.June, 10, 7, 30, 0, 0, time.UTC)
+	backends := []struct {
+		name string
+		use  func(t *testing.T) *Course
+	}{
+		{"memory", func(t *testing.T) *Course { return useMemoryStores(t).course }},
+		{"sqlite", func(t *testing.T) *Course { c, _ := useSQLiteStores(t); return c }},
+	}
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			c := backend.use(t)
+			season := overrideSeason()
+			season.BeginDate = time.Date(2026, time.May, 1, 0, 0, 0, 0, time.UTC)
+			season.EndDate = time.Date(2026, time.September, 30, 0, 0, 0, 0, time.UTC)
+			if err := c.SaveSeason(ctx, &season); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if _, err := c.CreateReservation(ctx, "golfer", teeTime, 2); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			tests := []struct {
+				name      string
+				timeRange string
+				players   int
+				want      int
+			}{
+				{"keeps the part-booked slot for a pair", "", 2, 13},
+				{"leaves out the part-booked slot for a threesome", "", 3, 12},
+				{"keeps to the morning", "morning", 4, 12},
+				{"finds nothing in the afternoon", "afternoon", 2, 0},
+				{"finds nothing for more players than a slot holds", "", MaxPlayersPerSlot + 1, 0},
+			}
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					open, err := c.GetAvailableTeeTimes(ctx, teeTime, tt.timeRange, tt.players)
+					if err != nil {
+						t.Fatalf("unexpected error: %v", err)
+					}
+					if len(open) != tt.want {
+						t.Fatalf("expected %d open tee times, got %d", tt.want, len(open))
+					}
+					for _, slot := range open {
+						if slot.TeeTime.Equal(teeTime) && (tt.players > 2 || slot.Open != 2) {
+							t.Errorf("expected the 7:30 slot to offer its 2 spots left, got %d for %d players", slot.Open, tt.players)
+						}
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestCancelReservation(t *testing.T) {
+	// a weekday a week or so out, which the season prices as a weekday morning
+	day := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 7)
+	for day.Weekday() != time.Wednesday {
+		day = day.AddDate(0, 0, 1)
+	}
+	teeTime := day.Add(7*time.Hour + 30*time.Minute)
+	backends := []struct {
+		name string
+		use  func(t *testing.T) *Course
+	}{
+		{"memory", func(t *testing.T) *Course { return useMemoryStores(t).course }},
+		{"sqlite", func(t *testing.T) *Course { c, _ := useSQLiteStores(t); return c }},
+	}
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			c := backend.use(t)
+			ctx := context.Background()
+			var offered []string
+			defer SetWaitlistNotifier(waitlistNotifier)
+			SetWaitlistNotifier(func(_ context.Context, entry WaitlistEntry) { offered = append(offered, entry.UserID) })
+
+			season := overrideSeason()
+			season.BeginDate, season.EndDate = teeTime.AddDate(0, 0, -1), teeTime.AddDate(0, 0, 1)
+			if err := c.SaveSeason(ctx, &season); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			res, err := c.CreateReservation(ctx, "golfer", teeTime, 2)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			waiting := WaitlistEntry{UserID: "waiting", Earliest: teeTime.Add(-time.Hour), Latest: teeTime.Add(time.Hour), Players: 2}
+			if err := c.JoinWaitlist(ctx, &waiting); err != nil {
+				t.Fatalf("unexpected waitlist error: %v", err)
+			}
+
+			tests := []struct {
+				name    string
+				userID  string
+				id      string
+				wantErr bool
+			}{
+				{"refuses another golfer's reservation", "other", res.ID, true},
+				{"refuses an unknown reservation", "golfer", "missing", true},
+				{"cancels the golfer's reservation", "golfer", res.ID, false},
+				{"refuses a cancelled reservation", "golfer", res.ID, true},
+			}
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					err := c.CancelReservation(audit.WithActor(ctx, tt.userID), tt.userID, tt.id)
+					if (err != nil) != tt.wantErr {
+						t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+					}
+				})
+			}
+
+			events, err := audit.History(ctx, audit.Reservation, res.ID)
+			if err != nil || len(events) != 2 || events[1].Action != audit.Cancelled || events[1].Actor != "golfer" {
+				t.Errorf("expected the golfer's cancel recorded, got %+v %v", events, err)
+			}
+			if len(offered) != 1 || offered[0] != "waiting" {
+				t.Errorf("expected the freed spots offered to the waitlist, got %v", offered)
+			}
+		})
+	}
+}
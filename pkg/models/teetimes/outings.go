@@ -0,0 +1,278 @@
+package teetimes
+
+import (
+	"bigfoot/golf/common/models/account"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Outing formats
+const (
+	OutingBlock   = "block"   // groups go off consecutive tee times in the window
+	OutingShotgun = "shotgun" // every group starts at once from its own hole
+)
+
+// ShotgunRoundLength is how long a shotgun start holds the course
+const ShotgunRoundLength = 5 * time.Hour
+
+var (
+	ErrOutingNotFound = errors.New("outing not found")
+	ErrOutingFull     = errors.New("outing does not have room for this team")
+	ErrOutingConflict = errors.New("outing overlaps booked tee times or another outing")
+	ErrTeamNotFound   = errors.New("outing team not found")
+	ErrNotOrganizer   = errors.New("only the outing organizer can change its teams")
+)
+
+// Outing reserves the tee sheet between StartTime and EndTime for a group event
+type Outing struct {
+	ID            string        `json:"id,omitempty"`
+	Name          string        `json:"name"`
+	Format        string        `json:"format"`
+	StartTime     time.Time     `json:"startTime"`
+	EndTime       time.Time     `json:"endTime"`
+	StartingHoles int           `json:"startingHoles,omitempty"` // shotgun only
+	GroupsPerHole int           `json:"groupsPerHole,omitempty"` // shotgun only, A and B groups
+	Gap           time.Duration `json:"gap,omitempty"`           // block only, copied from the season
+	MaxGolfers    int64         `json:"maxGolfers"`
+	MaxTeams      int64         `json:"maxTeams"`
+	OrganizerID   string        `json:"organizerId"`
+	Price         float32       `json:"price"`
+	Teams         []OutingTeam  `json:"teams,omitempty"`
+	TeeTimes      []Reservation `json:"teeTimes,omitempty"`
+	CreatedAt     time.Time     `json:"createdAt"`
+	UpdatedAt     time.Time     `json:"updatedAt"`
+	UpdatedBy     *account.User `json:"updatedBy,omitempty"`
+}
+
+// OutingTeam is one group registered by the organizer
+type OutingTeam struct {
+	ID           string    `json:"id,omitempty"`
+	OutingID     string    `json:"outingId"`
+	Name         string    `json:"name"`
+	Players      []Guest   `json:"players" neo4j:"players,json"`
+	RegisteredBy string    `json:"registeredBy"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// Pairing is a team's starting assignment on the starter's sheet
+type Pairing struct {
+	Start   string     `json:"start"`
+	TeeTime time.Time  `json:"teeTime"`
+	Hole    int        `json:"hole"`
+	Team    OutingTeam `json:"team"`
+}
+
+// OutingStore persists outings and their teams. RegisterTeam must check the
+// outing's golfer and team limits and add the team atomically.
+type OutingStore interface {
+	Create(ctx context.Context, outing *Outing) error
+	Get(ctx context.Context, id string) (*Outing, error)
+	DayOutings(ctx context.Context, day time.Time) ([]Outing, error)
+	RegisterTeam(ctx context.Context, team *OutingTeam) error
+	RemoveTeam(ctx context.Context, outingID, teamID string) error
+}
+
+// BlocksSlot reports whether the tee time is reserved for the outing
+func (o *Outing) BlocksSlot(teeTime time.Time) bool {
+	return !teeTime.Before(o.StartTime) && teeTime.Before(o.EndTime)
+}
+
+// Registered is the number of golfers signed up so far
+func (o *Outing) Registered() int64 {
+	var count int64
+	for _, team := range o.Teams {
+		count += int64(len(team.Players))
+	}
+	return count
+}
+
+func (o *Outing) validate() error {
+	if o.Name == "" {
+		return fmt.Errorf("outing needs a name")
+	}
+	switch o.Format {
+	case OutingShotgun:
+		if o.StartingHoles < 1 || o.StartingHoles > 18 {
+			return fmt.Errorf("shotgun starts need between 1 and 18 starting holes")
+		}
+		if o.GroupsPerHole < 1 || o.GroupsPerHole > 2 {
+			return fmt.Errorf("shotgun starts allow 1 or 2 groups per hole")
+		}
+		if o.EndTime.IsZero() {
+			o.EndTime = o.StartTime.Add(ShotgunRoundLength)
+		}
+	case OutingBlock:
+	default:
+		return fmt.Errorf("unknown outing format %q", o.Format)
+	}
+	if !o.EndTime.After(o.StartTime) {
+		return fmt.Errorf("outing must end after it starts")
+	}
+	if o.StartTime.Year() != o.EndTime.Year() || o.StartTime.YearDay() != o.EndTime.YearDay() {
+		return fmt.Errorf("outing must start and end on the same day")
+	}
+	return nil
+}
+
+// groups is how many teams the outing can send off
+func (o *Outing) groups() int {
+	if o.Format == OutingShotgun {
+		return o.StartingHoles * o.GroupsPerHole
+	}
+	if o.Gap <= 0 {
+		return 0
+	}
+	return int((o.EndTime.Sub(o.StartTime)-1)/o.Gap) + 1
+}
+
+// CreateOuting reserves the outing's window on the tee sheet. The window must
+// be free of bookings and other outings.
+func (c *Course) CreateOuting(ctx context.Context, outing *Outing) error {
+	if err := outing.validate(); err != nil {
+		return err
+	}
+	season, err := c.Season(ctx, outing.StartTime)
+	if err != nil {
+		return err
+	}
+	if season == nil {
+		return fmt.Errorf("no season found for %s", outing.StartTime.Format(time.DateOnly))
+	}
+	outing.Gap = season.Gap
+
+	days, err := c.DayTeeTimes(ctx, outing.StartTime)
+	if err != nil {
+		return err
+	}
+	for _, day := range days {
+		for _, res := range day.Times {
+			if res.ID != "" && outing.BlocksSlot(res.TeeTime) {
+				return ErrOutingConflict
+			}
+		}
+	}
+	existing, err := c.stores.Outings.DayOutings(ctx, outing.StartTime)
+	if err != nil {
+		return err
+	}
+	for _, other := range existing {
+		if outing.StartTime.Before(other.EndTime) && other.StartTime.Before(outing.EndTime) {
+			return ErrOutingConflict
+		}
+	}
+
+	outing.MaxTeams = int64(outing.groups())
+	if outing.MaxGolfers <= 0 || outing.MaxGolfers > outing.MaxTeams*MaxPlayersPerSlot {
+		outing.MaxGolfers = outing.MaxTeams * MaxPlayersPerSlot
+	}
+	outing.Teams = nil
+	outing.CreatedAt = time.Now()
+	outing.UpdatedAt = outing.CreatedAt
+	return c.stores.Outings.Create(ctx, outing)
+}
+
+// Outing returns the outing with the id and its teams, or ErrOutingNotFound
+func (c *Course) Outing(ctx context.Context, id string) (*Outing, error) {
+	outing, err := c.stores.Outings.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if outing == nil {
+		return nil, ErrOutingNotFound
+	}
+	return outing, nil
+}
+
+// DayOutings returns the outings on the day in start order
+func (c *Course) DayOutings(ctx context.Context, day time.Time) ([]Outing, error) {
+	return c.stores.Outings.DayOutings(ctx, day)
+}
+
+// RegisterOutingTeam adds a team for the organizer, or any admin, against the outing's capacity
+func (c *Course) RegisterOutingTeam(ctx context.Context, userID string, isAdmin bool, team *OutingTeam) error {
+	outing, err := c.Outing(ctx, team.OutingID)
+	if err != nil {
+		return err
+	}
+	if outing.OrganizerID != userID && !isAdmin {
+		return ErrNotOrganizer
+	}
+	if len(team.Players) < 1 || len(team.Players) > MaxPlayersPerSlot {
+		return fmt.Errorf("teams need between 1 and %d players", MaxPlayersPerSlot)
+	}
+	if team.Name == "" {
+		team.Name = fmt.Sprintf("Team %d", len(outing.Teams)+1)
+	}
+	team.RegisteredBy = userID
+	team.CreatedAt = time.Now()
+	return c.stores.Outings.RegisterTeam(ctx, team)
+}
+
+// RemoveOutingTeam drops a team, freeing its spots
+func (c *Course) RemoveOutingTeam(ctx context.Context, userID string, isAdmin bool, outingID, teamID string) error {
+	outing, err := c.Outing(ctx, outingID)
+	if err != nil {
+		return err
+	}
+	if outing.OrganizerID != userID && !isAdmin {
+		return ErrNotOrganizer
+	}
+	return c.stores.Outings.RemoveTeam(ctx, outingID, teamID)
+}
+
+// Pairings assigns teams to starting holes for a shotgun or to consecutive
+// tee times for a block, in registration order
+func (o *Outing) Pairings() []Pairing {
+	var pairings []Pairing
+	for i, team := range o.Teams {
+		p := Pairing{Team: team, TeeTime: o.StartTime, Hole: 1}
+		if o.Format == OutingShotgun {
+			p.Hole = i/o.GroupsPerHole + 1
+			p.Start = fmt.Sprintf("Hole %d", p.Hole)
+			if o.GroupsPerHole > 1 {
+				p.Start += string(rune('A' + i%o.GroupsPerHole))
+			}
+		} else {
+			p.TeeTime = o.StartTime.Add(o.Gap * time.Duration(i))
+			p.Start = p.TeeTime.Format("3:04 PM")
+		}
+		pairings = append(pairings, p)
+	}
+	return pairings
+}
+
+// WriteRoster writes one CSV row per registered player with their start
+func (o *Outing) WriteRoster(w io.Writer) error {
+	out := csv.NewWriter(w)
+	if err := out.Write([]string{"Team", "Start", "Player", "Email", "Phone"}); err != nil {
+		return err
+	}
+	for _, p := range o.Pairings() {
+		for _, player := range p.Team.Players {
+			row := []string{p.Team.Name, p.Start, strings.TrimSpace(player.Name), player.Email, player.Phone}
+			if err := out.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// outingBlocks returns the outings reserving slots on the day as slot blockers
+func (c *Course) outingBlocks(ctx context.Context, day time.Time) ([]SlotBlocker, error) {
+	outings, err := c.stores.Outings.DayOutings(ctx, day)
+	if err != nil {
+		return nil, err
+	}
+	var blockers []SlotBlocker
+	for i := range outings {
+		blockers = append(blockers, &outings[i])
+	}
+	return blockers, nil
+}
@@ -0,0 +1,94 @@
+package teetimes
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryOutingStore is an in-process OutingStore for tests and local runs
+type MemoryOutingStore struct {
+	mu      sync.Mutex
+	nextID  int
+	outings map[string]*Outing
+}
+
+func NewMemoryOutingStore() *MemoryOutingStore {
+	return &MemoryOutingStore{outings: make(map[string]*Outing)}
+}
+
+func (m *MemoryOutingStore) Create(ctx context.Context, outing *Outing) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.nextID++
+	outing.ID = fmt.Sprintf("outing-%d", m.nextID)
+	stored := *outing
+	m.outings[outing.ID] = &stored
+	return nil
+}
+
+func (m *MemoryOutingStore) Get(ctx context.Context, id string) (*Outing, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	outing, ok := m.outings[id]
+	if !ok {
+		return nil, nil
+	}
+	out := *outing
+	out.Teams = append([]OutingTeam(nil), outing.Teams...)
+	return &out, nil
+}
+
+func (m *MemoryOutingStore) DayOutings(ctx context.Context, day time.Time) ([]Outing, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var outings []Outing
+	for _, outing := range m.outings {
+		if sameDay(outing.StartTime, day) {
+			out := *outing
+			out.Teams = append([]OutingTeam(nil), outing.Teams...)
+			outings = append(outings, out)
+		}
+	}
+	sort.Slice(outings, func(i, j int) bool { return outings[i].StartTime.Before(outings[j].StartTime) })
+	return outings, nil
+}
+
+func (m *MemoryOutingStore) RegisterTeam(ctx context.Context, team *OutingTeam) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	outing, ok := m.outings[team.OutingID]
+	if !ok {
+		return ErrOutingNotFound
+	}
+	if outing.Registered()+int64(len(team.Players)) > outing.MaxGolfers || int64(len(outing.Teams))+1 > outing.MaxTeams {
+		return ErrOutingFull
+	}
+	m.nextID++
+	team.ID = fmt.Sprintf("team-%d", m.nextID)
+	outing.Teams = append(outing.Teams, *team)
+	return nil
+}
+
+func (m *MemoryOutingStore) RemoveTeam(ctx context.Context, outingID, teamID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	outing, ok := m.outings[outingID]
+	if !ok {
+		return ErrOutingNotFound
+	}
+	for i, team := range outing.Teams {
+		if team.ID == teamID {
+			outing.Teams = append(outing.Teams[:i], outing.Teams[i+1:]...)
+			return nil
+		}
+	}
+	return ErrTeamNotFound
+}
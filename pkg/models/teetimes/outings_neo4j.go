@@ -0,0 +1,126 @@
+package teetimes
+
+import (
+	"bigfoot/golf/common/models/db"
+	"context"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// neo4jOutingStore keeps Outing nodes with their OutingTeam nodes. Registering
+// a team writes the outing node first so concurrent registrations serialize
+// on its lock before the roster is counted.
+type neo4jOutingStore struct {
+	conn *db.Database
+}
+
+// NewNeo4jOutingStore returns an OutingStore for outings backed by the connection
+func NewNeo4jOutingStore(conn *db.Database) OutingStore {
+	return neo4jOutingStore{conn: conn}
+}
+
+const outingWithTeamsQuery = `
+	OPTIONAL MATCH (o)-[:HAS_TEAM]->(t:OutingTeam)
+	WITH o, t ORDER BY t.createdAt
+	WITH o, COLLECT(t{.*}) AS teams
+	RETURN o{.*, teams} as data
+	ORDER BY o.startTime`
+
+const lockOutingQuery = `
+	MATCH (o:Outing {id: $outingID})
+	SET o.lockedAt = datetime()
+	WITH o
+	OPTIONAL MATCH (o)-[:HAS_TEAM]->(t:OutingTeam)
+	RETURN o.maxGolfers AS maxGolfers, o.maxTeams AS maxTeams,
+		coalesce(sum(t.playerCount), 0) AS golfers, count(t) AS teams`
+
+func (s neo4jOutingStore) Create(ctx context.Context, outing *Outing) error {
+	outing.ID = db.NewID()
+	props, err := db.Encode(outing)
+	if err != nil {
+		outing.ID = ""
+		return err
+	}
+	_, err = runWriteCount(ctx, s.conn, `CREATE (o:Outing $props)
+		WITH o
+		OPTIONAL MATCH (u:User {id: $organizerID})
+		FOREACH (_ IN CASE WHEN u IS NULL THEN [] ELSE [1] END | MERGE (u)-[:ORGANIZES]->(o))
+		RETURN count(o)`, map[string]any{"props": props, "organizerID": outing.OrganizerID})
+	if err != nil {
+		outing.ID = ""
+	}
+	return err
+}
+
+func (s neo4jOutingStore) Get(ctx context.Context, id string) (*Outing, error) {
+	outings, err := queryOutings(ctx, s.conn, `MATCH (o:Outing {id: $id})`+outingWithTeamsQuery, map[string]any{"id": id})
+	if err != nil || len(outings) == 0 {
+		return nil, err
+	}
+	return &outings[0], nil
+}
+
+func (s neo4jOutingStore) DayOutings(ctx context.Context, day time.Time) ([]Outing, error) {
+	return queryOutings(ctx, s.conn, `MATCH (o:Outing) WHERE date(o.startTime) = date($day)`+outingWithTeamsQuery,
+		map[string]any{"day": day})
+}
+
+func (s neo4jOutingStore) RegisterTeam(ctx context.Context, team *OutingTeam) error {
+	team.ID = db.NewID()
+	props, err := db.Encode(team)
+	if err != nil {
+		team.ID = ""
+		return err
+	}
+	props["playerCount"] = len(team.Players)
+
+	_, err = s.conn.ExecuteWrite(ctx, func(ctx context.Context, tx neo4j.ManagedTransaction) (any, error) {
+		lock, err := tx.Run(ctx, lockOutingQuery, map[string]any{"outingID": team.OutingID})
+		if err != nil {
+			return nil, err
+		}
+		if !lock.Next(ctx) {
+			if err := lock.Err(); err != nil {
+				return nil, err
+			}
+			return nil, ErrOutingNotFound
+		}
+		record := lock.Record()
+		maxGolfers, _ := record.Values[0].(int64)
+		maxTeams, _ := record.Values[1].(int64)
+		golfers, _ := record.Values[2].(int64)
+		teams, _ := record.Values[3].(int64)
+		if golfers+int64(len(team.Players)) > maxGolfers || teams+1 > maxTeams {
+			return nil, ErrOutingFull
+		}
+
+		_, err = tx.Run(ctx, `MATCH (o:Outing {id: $outingID})
+			CREATE (o)-[:HAS_TEAM]->(t:OutingTeam $props)`, map[string]any{
+			"outingID": team.OutingID,
+			"props":    props,
+		})
+		return nil, err
+	})
+	if err != nil {
+		team.ID = ""
+	}
+	return err
+}
+
+func (s neo4jOutingStore) RemoveTeam(ctx context.Context, outingID, teamID string) error {
+	removed, err := runWriteCount(ctx, s.conn, `MATCH (:Outing {id: $outingID})-[:HAS_TEAM]->(t:OutingTeam {id: $teamID})
+		DETACH DELETE t
+		RETURN count(*)`, map[string]any{"outingID": outingID, "teamID": teamID})
+	if err != nil {
+		return err
+	}
+	if removed == 0 {
+		return ErrTeamNotFound
+	}
+	return nil
+}
+
+func queryOutings(ctx context.Context, conn *db.Database, query string, params map[string]any) ([]Outing, error) {
+	return db.QueryAs[Outing](ctx, conn, query, params)
+}
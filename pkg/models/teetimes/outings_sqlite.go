@@ -0,0 +1,101 @@
+package teetimes
+
+import (
+	"bigfoot/golf/common/models/db"
+	"context"
+	"database/sql"
+	"time"
+)
+
+// sqliteOutingStore keeps outings with their registered teams in a table of their own
+type sqliteOutingStore struct {
+	conn *sql.DB
+}
+
+// NewSQLiteOutingStore returns an OutingStore backed by the SQLite database
+func NewSQLiteOutingStore(conn *sql.DB) OutingStore {
+	return sqliteOutingStore{conn: conn}
+}
+
+func (s sqliteOutingStore) Create(ctx context.Context, outing *Outing) error {
+	outing.ID = db.NewID()
+	stored := *outing
+	stored.Teams, stored.TeeTimes = nil, nil
+	data, err := toJSON(stored)
+	if err == nil {
+		_, err = execCount(ctx, s.conn, `INSERT INTO outings (id, day, start_time, data) VALUES (?, ?, ?, ?)`,
+			outing.ID, sqlDay(outing.StartTime), outing.StartTime.UnixNano(), data)
+	}
+	if err != nil {
+		outing.ID = ""
+	}
+	return err
+}
+
+func (s sqliteOutingStore) Get(ctx context.Context, id string) (*Outing, error) {
+	outing, err := firstJSON[Outing](ctx, s.conn, `SELECT data FROM outings WHERE id = ?`, id)
+	if err != nil || outing == nil {
+		return nil, err
+	}
+	outing.Teams, err = outingTeams(ctx, s.conn, outing.ID)
+	return outing, err
+}
+
+func (s sqliteOutingStore) DayOutings(ctx context.Context, day time.Time) ([]Outing, error) {
+	outings, err := queryJSON[Outing](ctx, s.conn, `SELECT data FROM outings WHERE day = ? ORDER BY start_time`, sqlDay(day))
+	if err != nil {
+		return nil, err
+	}
+	for i := range outings {
+		if outings[i].Teams, err = outingTeams(ctx, s.conn, outings[i].ID); err != nil {
+			return nil, err
+		}
+	}
+	return outings, nil
+}
+
+func (s sqliteOutingStore) RegisterTeam(ctx context.Context, team *OutingTeam) error {
+	team.ID = db.NewID()
+	err := withTx(ctx, s.conn, func(ctx context.Context, tx *sql.Tx) error {
+		outing, err := firstJSON[Outing](ctx, tx, `SELECT data FROM outings WHERE id = ?`, team.OutingID)
+		if err != nil {
+			return err
+		}
+		if outing == nil {
+			return ErrOutingNotFound
+		}
+		if outing.Teams, err = outingTeams(ctx, tx, outing.ID); err != nil {
+			return err
+		}
+		if outing.Registered()+int64(len(team.Players)) > outing.MaxGolfers || int64(len(outing.Teams))+1 > outing.MaxTeams {
+			return ErrOutingFull
+		}
+		data, err := toJSON(team)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `INSERT INTO outing_teams (id, outing_id, position, data) VALUES (?, ?, ?, ?)`,
+			team.ID, team.OutingID, len(outing.Teams), data)
+		return err
+	})
+	if err != nil {
+		team.ID = ""
+	}
+	return err
+}
+
+func (s sqliteOutingStore) RemoveTeam(ctx context.Context, outingID, teamID string) error {
+	removed, err := execCount(ctx, s.conn, `DELETE FROM outing_teams WHERE id = ? AND outing_id = ?`, teamID, outingID)
+	if err != nil {
+		return err
+	}
+	if removed == 0 {
+		return ErrTeamNotFound
+	}
+	return nil
+}
+
+// outingTeams returns the outing's teams in the order they registered
+func outingTeams(ctx context.Context, q sqlRunner, outingID string) ([]OutingTeam, error) {
+	return queryJSON[OutingTeam](ctx, q, `SELECT data FROM outing_teams WHERE outing_id = ? ORDER BY position`, outingID)
+}
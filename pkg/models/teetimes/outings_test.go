@@ -0,0 +1,127 @@
+package teetimes
+
+import (
+	"bigfoot/golf/common/models/account"
+	"bigfoot/golf/common/models/db"
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewReservedDayExcludesOutings(t *testing.T) {
+	if db.TimeLocation == nil {
+		db.TimeLocation = time.UTC
+	}
+	first := time.Date(2026, time.June, 21, 7, 0, 0, 0, time.UTC)
+	season := Season{
+		IsOpen:       true,
+		FirstTeeTime: first,
+		LastTeeTime:  first.Add(2 * time.Hour),
+		Gap:          10 * time.Minute,
+		DefaultSettings: []DetailedBlockSettings{
+			{Type: int(WeekdayMidday), Name: "Weekday Midday", BeginOverride: first, EndOverride: first.Add(3 * time.Hour), Price: 60, IsAvail: true},
+		},
+	}
+	day := time.Date(2026, time.June, 10, 0, 0, 0, 0, time.UTC)
+	outing := Outing{StartTime: day.Add(7*time.Hour + 30*time.Minute), EndTime: day.Add(8 * time.Hour)}
+
+	resDay := NewReservedDay(day, season, nil, &outing)
+	if len(resDay.Times) != 10 {
+		t.Fatalf("expected 10 public slots, got %d", len(resDay.Times))
+	}
+	for _, res := range resDay.Times {
+		if outing.BlocksSlot(res.TeeTime) {
+			t.Errorf("slot %d at %s is inside the outing", res.Slot, res.TeeTime.Format("15:04"))
+		}
+	}
+	if res := resDay.GetByTime(8, 0); res == nil || res.Slot != 7 {
+		t.Errorf("expected 8:00 to keep slot 7, got %+v", res)
+	}
+}
+
+func TestBookTeeTimeRejectsOutingSlots(t *testing.T) {
+	ctx := context.Background()
+	stores := useMemoryStores(t)
+	store := stores.outings
+
+	start := time.Date(2026, time.June, 10, 8, 0, 0, 0, time.UTC)
+	if err := store.Create(ctx, &Outing{Name: "Rotary Scramble", Format: OutingBlock, StartTime: start, EndTime: start.Add(time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+
+	user := account.User{ID: "golfer"}
+	res := Reservation{TeeTime: start.Add(20 * time.Minute), Slot: 9, BookingUser: &user}
+	if err := stores.course.BookTeeTime(ctx, &res); !errors.Is(err, ErrSlotUnavailable) {
+		t.Fatalf("expected outing slot to be unavailable, got %v", err)
+	}
+	res = Reservation{TeeTime: start.Add(time.Hour), Slot: 13, BookingUser: &user}
+	if err := stores.course.BookTeeTime(ctx, &res); err != nil {
+		t.Fatalf("expected slot after the outing to book, got %v", err)
+	}
+}
+
+func TestRegisterOutingTeams(t *testing.T) {
+	ctx := context.Background()
+	stores := useMemoryStores(t)
+	store := stores.outings
+
+	start := time.Date(2026, time.June, 10, 8, 0, 0, 0, time.UTC)
+	outing := Outing{
+		Name: "Member-Guest", Format: OutingShotgun, StartTime: start, EndTime: start.Add(ShotgunRoundLength),
+		StartingHoles: 2, GroupsPerHole: 1, MaxTeams: 2, MaxGolfers: 6, OrganizerID: "organizer",
+	}
+	if err := store.Create(ctx, &outing); err != nil {
+		t.Fatal(err)
+	}
+	players := func(n int) []Guest {
+		var guests []Guest
+		for i := 0; i < n; i++ {
+			guests = append(guests, Guest{Name: "Player " + string(rune('A'+i)), Email: "p@example.com"})
+		}
+		return guests
+	}
+
+	tests := []struct {
+		name    string
+		userID  string
+		isAdmin bool
+		players int
+		wantErr error
+	}{
+		{"stranger", "someone", false, 2, ErrNotOrganizer},
+		{"organizer", "organizer", false, 4, nil},
+		{"over golfer limit", "organizer", false, 4, ErrOutingFull},
+		{"admin", "admin", true, 2, nil},
+		{"over team limit", "organizer", false, 1, ErrOutingFull},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			team := OutingTeam{OutingID: outing.ID, Players: players(tc.players)}
+			err := stores.course.RegisterOutingTeam(ctx, tc.userID, tc.isAdmin, &team)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+
+	saved, err := stores.course.Outing(ctx, outing.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pairings := saved.Pairings()
+	if len(pairings) != 2 || pairings[0].Start != "Hole 1" || pairings[1].Start != "Hole 2" {
+		t.Errorf("unexpected pairings %+v", pairings)
+	}
+
+	var roster bytes.Buffer
+	if err := saved.WriteRoster(&roster); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(roster.String()), "\n")
+	if len(lines) != 7 {
+		t.Errorf("expected header and 6 player rows, got %d lines", len(lines))
+	}
+}
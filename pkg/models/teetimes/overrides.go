@@ -0,0 +1,257 @@
+package teetimes
+
+import (
+	"bigfoot/golf/common/models/account"
+	"bigfoot/golf/common/models/audit"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Day override kinds
+const (
+	OverrideClosed  = "closed"  // no tee times all day
+	OverrideBlocked = "blocked" // no tee times in a window, e.g. aeration
+	OverrideDelay   = "delay"   // the sheet starts late and bookings move back
+)
+
+var (
+	ErrCourseClosed     = errors.New("the course is closed")
+	ErrOverrideNotFound = errors.New("day override not found")
+)
+
+// DayOverride changes the tee sheet for one day
+type DayOverride struct {
+	ID           string    `json:"id,omitempty"`
+	Day          time.Time `json:"day"`
+	Kind         string    `json:"kind"`
+	Reason       string    `json:"reason"`
+	Start        time.Time `json:"start,omitempty" neo4j:",omitempty"` // blocked window, or the tee times a delay pushed back
+	End          time.Time `json:"end,omitempty" neo4j:",omitempty"`
+	DelayMinutes int       `json:"delayMinutes,omitempty"`
+	CreatedBy    string    `json:"createdBy"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// OverrideResult lists the bookings an override moved or cancelled so the golfers can be told
+type OverrideResult struct {
+	Override  DayOverride   `json:"override"`
+	Moved     []Reservation `json:"moved,omitempty"`
+	Cancelled []Reservation `json:"cancelled,omitempty"`
+}
+
+// DayOverrideStore persists day overrides
+type DayOverrideStore interface {
+	Save(ctx context.Context, override *DayOverride) error
+	DayOverrides(ctx context.Context, day time.Time) ([]DayOverride, error)
+	Delete(ctx context.Context, id string) error
+}
+
+var overrideNotifier = func(result OverrideResult) {
+	log.Printf("Day override %s on %s affected %d golfers", result.Override.Kind,
+		result.Override.Day.Format(time.DateOnly), len(result.Golfers()))
+}
+
+// SetOverrideNotifier sets the function used to tell golfers their tee time moved or was cancelled
+func SetOverrideNotifier(notify func(result OverrideResult)) {
+	overrideNotifier = notify
+}
+
+// BlocksSlot reports whether the override takes the tee time off the sheet
+func (d *DayOverride) BlocksSlot(teeTime time.Time) bool {
+	if !sameDay(d.Day, teeTime) {
+		return false
+	}
+	if d.Kind == OverrideClosed {
+		return true
+	}
+	return !teeTime.Before(d.Start) && teeTime.Before(d.End)
+}
+
+// Golfers returns each booking user once, for notifications
+func (r *OverrideResult) Golfers() []account.User {
+	seen := make(map[string]bool)
+	var golfers []account.User
+	for _, list := range [][]Reservation{r.Moved, r.Cancelled} {
+		for _, res := range list {
+			if res.BookingUser == nil || seen[res.BookingUser.ID] {
+				continue
+			}
+			seen[res.BookingUser.ID] = true
+			golfers = append(golfers, *res.BookingUser)
+		}
+	}
+	return golfers
+}
+
+// DayOverrides returns the overrides in effect on the day
+func (c *Course) DayOverrides(ctx context.Context, day time.Time) ([]DayOverride, error) {
+	return c.stores.Overrides.DayOverrides(ctx, day)
+}
+
+// DeleteDayOverride reopens what the override blocked. Bookings a delay moved stay where they are.
+func (c *Course) DeleteDayOverride(ctx context.Context, id string) error {
+	return c.stores.Overrides.Delete(ctx, id)
+}
+
+// CloseDay closes the course, or a window when start and end are set, and
+// cancels the bookings it covers
+func (c *Course) CloseDay(ctx context.Context, override DayOverride) (*OverrideResult, error) {
+	days, err := c.DayTeeTimes(ctx, override.Day)
+	if err != nil {
+		return nil, err
+	}
+	var booked []Reservation
+	for _, day := range days {
+		for _, res := range day.Times {
+			if res.ID != "" {
+				booked = append(booked, res)
+			}
+		}
+	}
+	return c.applyClosure(ctx, override, booked)
+}
+
+func (c *Course) applyClosure(ctx context.Context, override DayOverride, booked []Reservation) (*OverrideResult, error) {
+	switch override.Kind {
+	case OverrideClosed:
+		override.Start, override.End = time.Time{}, time.Time{}
+	case OverrideBlocked:
+		if !override.End.After(override.Start) || !sameDay(override.Start, override.Day) {
+			return nil, fmt.Errorf("blocked window must end after it starts on the same day")
+		}
+	default:
+		return nil, fmt.Errorf("unknown closure kind %q", override.Kind)
+	}
+	override.CreatedAt = time.Now()
+	if err := c.stores.Overrides.Save(ctx, &override); err != nil {
+		return nil, err
+	}
+
+	result := &OverrideResult{Override: override}
+	for i := range booked {
+		if !override.BlocksSlot(booked[i].TeeTime) {
+			continue
+		}
+		//freed spots are not offered to the waitlist, the slot is off the sheet
+		if err := c.cancelReservation(ctx, &booked[i]); err != nil {
+			return result, err
+		}
+		result.Cancelled = append(result.Cancelled, booked[i])
+	}
+	overrideNotifier(*result)
+	return result, nil
+}
+
+// DelayDay pushes the day's start back by the delay, rounded up to whole
+// slots, moving every booking back with it. Bookings that no longer fit, past
+// the last tee time or without room at their new slot, are cancelled.
+func (c *Course) DelayDay(ctx context.Context, day time.Time, minutes int, reason, by string) (*OverrideResult, error) {
+	season, err := c.Season(ctx, day)
+	if err != nil {
+		return nil, err
+	}
+	if season == nil {
+		return nil, fmt.Errorf("no season found for %s", day.Format(time.DateOnly))
+	}
+	return c.applyDelay(ctx, *season, day, minutes, reason, by)
+}
+
+func (c *Course) applyDelay(ctx context.Context, season Season, day time.Time, minutes int, reason, by string) (*OverrideResult, error) {
+	if !season.IsOpen {
+		return nil, ErrCourseClosed
+	}
+	if minutes <= 0 || season.Gap <= 0 {
+		return nil, fmt.Errorf("delay must be a positive number of minutes")
+	}
+	slots := (time.Duration(minutes)*time.Minute + season.Gap - 1) / season.Gap
+	delay := slots * season.Gap
+
+	existing, err := c.stores.Overrides.DayOverrides(ctx, day)
+	if err != nil {
+		return nil, err
+	}
+	var delayed time.Duration
+	for _, o := range existing {
+		if o.Kind == OverrideClosed {
+			return nil, ErrCourseClosed
+		}
+		if o.Kind == OverrideDelay {
+			delayed += time.Duration(o.DelayMinutes) * time.Minute
+		}
+	}
+
+	first := onDay(day, season.FirstTeeTime)
+	last := onDay(day, season.LastTeeTime)
+	override := DayOverride{
+		Day: day, Kind: OverrideDelay, Reason: reason, CreatedBy: by, CreatedAt: time.Now(),
+		Start: first, End: first.Add(delayed + delay), DelayMinutes: int(delay.Minutes()),
+	}
+
+	moved, stranded, err := c.stores.Bookings.ShiftReservations(ctx, day, delay, int64(slots), last)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.stores.Overrides.Save(ctx, &override); err != nil {
+		return nil, err
+	}
+
+	result := &OverrideResult{Override: override}
+	for i := range moved {
+		before := moved[i]
+		before.TeeTime, before.Slot = before.TeeTime.Add(-delay), before.Slot-int64(slots)
+		if before.CrossoverSlot > 0 {
+			before.CrossoverSlot -= int64(slots)
+		}
+		audit.Record(ctx, audit.Reservation, moved[i].ID, audit.Moved, before, moved[i])
+		result.Moved = append(result.Moved, moved[i])
+	}
+	for i := range stranded {
+		if err := c.cancelReservation(ctx, &stranded[i]); err != nil {
+			return result, err
+		}
+		result.Cancelled = append(result.Cancelled, stranded[i])
+	}
+	overrideNotifier(*result)
+	return result, nil
+}
+
+// onDay puts the season's clock time on the given day
+func onDay(day time.Time, clock time.Time) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), clock.Hour(), clock.Minute(), 0, 0, courseLocation())
+}
+
+// SetSeasonOpen opens or closes the whole season's tee sheet
+func (c *Course) SetSeasonOpen(ctx context.Context, seasonID string, open bool) error {
+	before, err := c.stores.Seasons.Get(ctx, seasonID)
+	if err != nil {
+		return err
+	}
+	if err := c.stores.Seasons.SetOpen(ctx, seasonID, open, audit.Actor(ctx)); err != nil {
+		return err
+	}
+	if before != nil {
+		after := *before
+		after.IsOpen, after.UpdatedBy = open, audit.Actor(ctx)
+		audit.Record(ctx, audit.Season, seasonID, audit.Updated, before, after)
+	}
+	return nil
+}
+
+// dayBlockers returns the outings and overrides taking slots off the day's sheet
+func (c *Course) dayBlockers(ctx context.Context, day time.Time) ([]SlotBlocker, error) {
+	blockers, err := c.outingBlocks(ctx, day)
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := c.stores.Overrides.DayOverrides(ctx, day)
+	if err != nil {
+		return nil, err
+	}
+	for i := range overrides {
+		blockers = append(blockers, &overrides[i])
+	}
+	return blockers, nil
+}
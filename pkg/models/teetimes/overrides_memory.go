@@ -0,0 +1,55 @@
+package teetimes
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// MemoryOverrideStore is an in-process DayOverrideStore for tests and local runs
+type MemoryOverrideStore struct {
+	mu        sync.Mutex
+	nextID    int
+	overrides []DayOverride
+}
+
+func NewMemoryOverrideStore() *MemoryOverrideStore {
+	return &MemoryOverrideStore{}
+}
+
+func (m *MemoryOverrideStore) Save(ctx context.Context, override *DayOverride) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.nextID++
+	override.ID = fmt.Sprintf("override-%d", m.nextID)
+	m.overrides = append(m.overrides, *override)
+	return nil
+}
+
+func (m *MemoryOverrideStore) DayOverrides(ctx context.Context, day time.Time) ([]DayOverride, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var overrides []DayOverride
+	for _, o := range m.overrides {
+		if sameDay(o.Day, day) {
+			overrides = append(overrides, o)
+		}
+	}
+	return overrides, nil
+}
+
+func (m *MemoryOverrideStore) Delete(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, o := range m.overrides {
+		if o.ID == id {
+			m.overrides = append(m.overrides[:i], m.overrides[i+1:]...)
+			return nil
+		}
+	}
+	return ErrOverrideNotFound
+}
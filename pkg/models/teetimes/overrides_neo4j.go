@@ -0,0 +1,50 @@
+package teetimes
+
+import (
+	"bigfoot/golf/common/models/db"
+	"context"
+	"time"
+)
+
+// neo4jOverrideStore keeps DayOverride nodes keyed by their day
+type neo4jOverrideStore struct {
+	conn *db.Database
+}
+
+// NewNeo4jOverrideStore returns a DayOverrideStore for day overrides backed by the connection
+func NewNeo4jOverrideStore(conn *db.Database) DayOverrideStore {
+	return neo4jOverrideStore{conn: conn}
+}
+
+func (s neo4jOverrideStore) Save(ctx context.Context, override *DayOverride) error {
+	override.ID = db.NewID()
+	props, err := db.Encode(override)
+	if err != nil {
+		override.ID = ""
+		return err
+	}
+	_, err = runWriteCount(ctx, s.conn, `CREATE (o:DayOverride $props) RETURN count(o)`, map[string]any{"props": props})
+	if err != nil {
+		override.ID = ""
+	}
+	return err
+}
+
+func (s neo4jOverrideStore) DayOverrides(ctx context.Context, day time.Time) ([]DayOverride, error) {
+	return db.QueryAs[DayOverride](ctx, s.conn, `MATCH (o:DayOverride)
+		WHERE date(o.day) = date($day)
+		RETURN o{.*} as data
+		ORDER BY o.createdAt ASC`, map[string]any{"day": day})
+}
+
+func (s neo4jOverrideStore) Delete(ctx context.Context, id string) error {
+	deleted, err := runWriteCount(ctx, s.conn, `MATCH (o:DayOverride {id: $id}) DETACH DELETE o RETURN count(*)`,
+		map[string]any{"id": id})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrOverrideNotFound
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package teetimes
+
+import (
+	"bigfoot/golf/common/models/db"
+	"context"
+	"database/sql"
+	"time"
+)
+
+// sqliteOverrideStore keeps day overrides keyed by their day
+type sqliteOverrideStore struct {
+	conn *sql.DB
+}
+
+// NewSQLiteOverrideStore returns a DayOverrideStore for day overrides backed by the SQLite database
+func NewSQLiteOverrideStore(conn *sql.DB) DayOverrideStore {
+	return sqliteOverrideStore{conn: conn}
+}
+
+func (s sqliteOverrideStore) Save(ctx context.Context, override *DayOverride) error {
+	override.ID = db.NewID()
+	data, err := toJSON(override)
+	if err == nil {
+		_, err = execCount(ctx, s.conn, `INSERT INTO day_overrides (id, day, data) VALUES (?, ?, ?)`, override.ID, sqlDay(override.Day), data)
+	}
+	if err != nil {
+		override.ID = ""
+	}
+	return err
+}
+
+func (s sqliteOverrideStore) DayOverrides(ctx context.Context, day time.Time) ([]DayOverride, error) {
+	return queryJSON[DayOverride](ctx, s.conn, `SELECT data FROM day_overrides WHERE day = ? ORDER BY rowid`, sqlDay(day))
+}
+
+func (s sqliteOverrideStore) Delete(ctx context.Context, id string) error {
+	deleted, err := execCount(ctx, s.conn, `DELETE FROM day_overrides WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrOverrideNotFound
+	}
+	return nil
+}
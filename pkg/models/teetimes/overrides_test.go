@@ -0,0 +1,235 @@
+package teetimes
+
+import (
+	"bigfoot/golf/common/models/account"
+	"bigfoot/golf/common/models/db"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func overrideSeason() Season {
+	if db.TimeLocation == nil {
+		db.TimeLocation = time.UTC
+	}
+	first := time.Date(2026, time.June, 21, 7, 0, 0, 0, time.UTC)
+	return Season{
+		IsOpen:       true,
+		FirstTeeTime: first,
+		LastTeeTime:  first.Add(2 * time.Hour),
+		Gap:          10 * time.Minute,
+		DefaultSettings: []DetailedBlockSettings{
+			{Type: int(WeekdayMorning), Name: "Weekday Morning", BeginOverride: first, EndOverride: first.Add(3 * time.Hour), Price: 50, IsAvail: true},
+		},
+	}
+}
+
+func bookForTest(t *testing.T, store *MemoryBookingStore, userID string, teeTime time.Time, slot int64) Reservation {
+	ctx := context.Background()
+	t.Helper()
+	res := Reservation{TeeTime: teeTime, Slot: slot, PlayerCount: 2, BookingUser: &account.User{ID: userID}}
+	if err := store.BookSlot(ctx, &res); err != nil {
+		t.Fatalf("unexpected booking error: %v", err)
+	}
+	return res
+}
+
+func TestDayOverrideBlocksSlot(t *testing.T) {
+	day := time.Date(2026, time.June, 10, 0, 0, 0, 0, time.UTC)
+	at := func(hour, minute int) time.Time {
+		return day.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+	}
+
+	tests := []struct {
+		name     string
+		override DayOverride
+		teeTime  time.Time
+		want     bool
+	}{
+		{"closed blocks the whole day", DayOverride{Day: day, Kind: OverrideClosed}, at(15, 0), true},
+		{"closed leaves other days alone", DayOverride{Day: day, Kind: OverrideClosed}, at(24+8, 0), false},
+		{"window start is blocked", DayOverride{Day: day, Kind: OverrideBlocked, Start: at(8, 0), End: at(9, 0)}, at(8, 0), true},
+		{"window end is open", DayOverride{Day: day, Kind: OverrideBlocked, Start: at(8, 0), End: at(9, 0)}, at(9, 0), false},
+		{"before the window is open", DayOverride{Day: day, Kind: OverrideBlocked, Start: at(8, 0), End: at(9, 0)}, at(7, 50), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.override.BlocksSlot(tt.teeTime); got != tt.want {
+				t.Errorf("BlocksSlot(%s) = %v, want %v", tt.teeTime.Format(time.DateTime), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClosedSeasonHasNoOpenSlots(t *testing.T) {
+	season := overrideSeason()
+	day := time.Date(2026, time.June, 10, 0, 0, 0, 0, time.UTC)
+	booked := Reservation{ID: "booked", Slot: 2, TeeTime: day.Add(7*time.Hour + 10*time.Minute)}
+
+	if open := NewReservedDay(day, season, nil); len(open.Times) != 13 {
+		t.Fatalf("expected 13 slots on an open season, got %d", len(open.Times))
+	}
+	season.IsOpen = false
+	closed := NewReservedDay(day, season, []Reservation{booked})
+	if len(closed.Times) != 1 || closed.Times[0].ID != "booked" {
+		t.Errorf("expected only the existing booking on a closed season, got %+v", closed.Times)
+	}
+}
+
+func TestApplyClosure(t *testing.T) {
+	ctx := context.Background()
+	day := time.Date(2026, time.June, 10, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name          string
+		override      DayOverride
+		wantCancelled []string
+		wantErr       bool
+	}{
+		{
+			name:          "closed cancels every booking",
+			override:      DayOverride{Day: day, Kind: OverrideClosed, Reason: "flooding"},
+			wantCancelled: []string{"early", "mid", "late"},
+		},
+		{
+			name:          "blocked cancels bookings in the window",
+			override:      DayOverride{Day: day, Kind: OverrideBlocked, Reason: "aeration", Start: day.Add(7*time.Hour + 30*time.Minute), End: day.Add(8*time.Hour + 30*time.Minute)},
+			wantCancelled: []string{"mid"},
+		},
+		{
+			name:     "blocked window must end after it starts",
+			override: DayOverride{Day: day, Kind: OverrideBlocked, Start: day.Add(9 * time.Hour), End: day.Add(8 * time.Hour)},
+			wantErr:  true,
+		},
+		{
+			name:     "delays go through DelayDay",
+			override: DayOverride{Day: day, Kind: OverrideDelay},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stores := useMemoryStores(t)
+			var notified []OverrideResult
+			defer SetOverrideNotifier(overrideNotifier)
+			SetOverrideNotifier(func(result OverrideResult) { notified = append(notified, result) })
+
+			booked := []Reservation{
+				bookForTest(t, stores.bookings, "early", day.Add(7*time.Hour), 1),
+				bookForTest(t, stores.bookings, "mid", day.Add(8*time.Hour), 7),
+				bookForTest(t, stores.bookings, "late", day.Add(8*time.Hour+50*time.Minute), 12),
+			}
+
+			result, err := stores.course.applyClosure(ctx, tt.override, booked)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error")
+				}
+				if overrides, _ := stores.overrides.DayOverrides(ctx, day); len(overrides) != 0 {
+					t.Errorf("expected no override saved, got %+v", overrides)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var cancelled []string
+			for _, golfer := range result.Golfers() {
+				cancelled = append(cancelled, golfer.ID)
+			}
+			if len(cancelled) != len(tt.wantCancelled) {
+				t.Fatalf("expected %v cancelled, got %v", tt.wantCancelled, cancelled)
+			}
+			for i := range cancelled {
+				if cancelled[i] != tt.wantCancelled[i] {
+					t.Errorf("expected %v cancelled, got %v", tt.wantCancelled, cancelled)
+				}
+			}
+			for _, res := range result.Cancelled {
+				if left := stores.bookings.SlotReservations(res.TeeTime, res.Slot); len(left) != 0 {
+					t.Errorf("expected slot %d to be empty, got %+v", res.Slot, left)
+				}
+			}
+			if len(notified) != 1 {
+				t.Errorf("expected one notification, got %d", len(notified))
+			}
+			if overrides, _ := stores.overrides.DayOverrides(ctx, day); len(overrides) != 1 {
+				t.Errorf("expected the override to be saved, got %+v", overrides)
+			}
+		})
+	}
+}
+
+func TestClosedDayRejectsBookings(t *testing.T) {
+	ctx := context.Background()
+	stores := useMemoryStores(t)
+	day := time.Date(2026, time.June, 10, 0, 0, 0, 0, time.UTC)
+	if err := stores.overrides.Save(ctx, &DayOverride{Day: day, Kind: OverrideClosed}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	res := Reservation{TeeTime: day.Add(8 * time.Hour), Slot: 7, BookingUser: &account.User{ID: "golfer"}}
+	if err := stores.course.BookTeeTime(ctx, &res); !errors.Is(err, ErrSlotUnavailable) {
+		t.Fatalf("expected ErrSlotUnavailable on a closed day, got %v", err)
+	}
+	next := Reservation{TeeTime: day.AddDate(0, 0, 1).Add(8 * time.Hour), Slot: 7, BookingUser: &account.User{ID: "golfer"}}
+	if err := stores.course.BookTeeTime(ctx, &next); err != nil {
+		t.Fatalf("expected the next day to book, got %v", err)
+	}
+}
+
+func TestApplyDelay(t *testing.T) {
+	ctx := context.Background()
+	stores := useMemoryStores(t)
+	defer SetOverrideNotifier(overrideNotifier)
+	SetOverrideNotifier(func(OverrideResult) {})
+
+	season := overrideSeason()
+	day := time.Date(2026, time.June, 10, 0, 0, 0, 0, time.UTC)
+	bookForTest(t, stores.bookings, "early", day.Add(7*time.Hour), 1)
+	bookForTest(t, stores.bookings, "last", day.Add(8*time.Hour+50*time.Minute), 12)
+	tomorrow := bookForTest(t, stores.bookings, "tomorrow", day.AddDate(0, 0, 1).Add(7*time.Hour), 1)
+
+	//15 minutes rounds up to two 10 minute slots
+	result, err := stores.course.applyDelay(ctx, season, day, 15, "frost", "admin")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Override.DelayMinutes != 20 {
+		t.Errorf("expected a 20 minute delay, got %d", result.Override.DelayMinutes)
+	}
+	if len(result.Moved) != 1 || result.Moved[0].BookingUser.ID != "early" {
+		t.Fatalf("expected only the early booking moved, got %+v", result.Moved)
+	}
+	moved := result.Moved[0]
+	if !moved.TeeTime.Equal(day.Add(7*time.Hour+20*time.Minute)) || moved.Slot != 3 {
+		t.Errorf("expected the early booking at 7:20 in slot 3, got %s slot %d", moved.TeeTime.Format("15:04"), moved.Slot)
+	}
+	if len(stores.bookings.SlotReservations(moved.TeeTime, moved.Slot)) != 1 {
+		t.Errorf("expected the moved booking in its new slot")
+	}
+	if len(result.Cancelled) != 1 || result.Cancelled[0].BookingUser.ID != "last" {
+		t.Errorf("expected the last booking cancelled past the last tee time, got %+v", result.Cancelled)
+	}
+	if len(stores.bookings.SlotReservations(tomorrow.TeeTime, tomorrow.Slot)) != 1 {
+		t.Errorf("expected the next day's booking untouched")
+	}
+
+	//a second delay stacks on the first
+	result, err = stores.course.applyDelay(ctx, season, day, 10, "more frost", "admin")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := day.Add(7*time.Hour + 30*time.Minute); !result.Override.End.Equal(want) {
+		t.Errorf("expected the sheet to start at %s, got %s", want.Format("15:04"), result.Override.End.Format("15:04"))
+	}
+	if len(result.Moved) != 1 || result.Moved[0].Slot != 4 {
+		t.Errorf("expected the early booking moved to slot 4, got %+v", result.Moved)
+	}
+
+	season.IsOpen = false
+	if _, err := stores.course.applyDelay(ctx, season, day, 10, "frost", "admin"); !errors.Is(err, ErrCourseClosed) {
+		t.Errorf("expected ErrCourseClosed for a closed season, got %v", err)
+	}
+}
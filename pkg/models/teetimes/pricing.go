@@ -0,0 +1,356 @@
+package teetimes
+
+import (
+	"bigfoot/golf/common/models/account"
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+// DefaultRates are the base green fees per player for each setting type
+var DefaultRates = map[SettingType]float32{
+	WeekdayMorning:   50,
+	WeekdayMidday:    60,
+	WeekdayAfternoon: 50,
+	WeekendMorning:   69,
+	WeekendMidday:    79,
+	WeekendAfternoon: 69,
+	Holiday:          79,
+}
+
+// PriceInput is everything a rule may look at when pricing one player's round
+type PriceInput struct {
+	TeeTime     time.Time
+	Now         time.Time
+	Setting     DetailedBlockSettings
+	Holes       int           // round length, eighteen unless NineHoles
+	Utilization float64       // share of the day's capacity already booked, 0 to 1
+	Player      *account.User // nil prices a standard adult rate
+}
+
+// AppliedRule explains one adjustment made while pricing
+type AppliedRule struct {
+	Rule       string  `json:"rule"`
+	Detail     string  `json:"detail"`
+	Adjustment float32 `json:"adjustment"`
+}
+
+// PriceQuote is a price along with the rules that produced it
+type PriceQuote struct {
+	Price   float32       `json:"price"`
+	Applied []AppliedRule `json:"applied"`
+}
+
+// ReservationQuote prices every player on a reservation
+type ReservationQuote struct {
+	Players []PriceQuote `json:"players"`
+	Total   float32      `json:"total"`
+}
+
+// PriceRule adjusts a running price, returning nil when it does not apply
+type PriceRule interface {
+	Apply(in PriceInput, price float32) (float32, *AppliedRule)
+}
+
+// PricingEngine runs its rules in order, each adjusting the previous price
+type PricingEngine struct {
+	Rules []PriceRule
+}
+
+func NewPricingEngine(rules ...PriceRule) *PricingEngine {
+	return &PricingEngine{Rules: rules}
+}
+
+// DefaultPricingEngine is the course's standard rule set for a season
+func DefaultPricingEngine(season Season) *PricingEngine {
+	return NewPricingEngine(
+		BaseRateRule{Rates: DefaultRates},
+		DailyDealRule{Deals: season.DailyDeals()},
+		UtilizationRule{SurgeAbove: 0.8, SurgePct: 0.10, DiscountBelow: 0.3, DiscountPct: 0.15, DiscountWithin: 48 * time.Hour},
+		LeadTimeRule{BookAhead: 14 * 24 * time.Hour, BookAheadPct: 0.05, LastMinute: 2 * time.Hour, LastMinutePct: 0.20},
+		AgeRule{JuniorMaxAge: 17, JuniorPct: 0.25, SeniorMinAge: 65, SeniorPct: 0.15},
+	)
+}
+
+// Quote prices a single player's round
+func (e *PricingEngine) Quote(in PriceInput) PriceQuote {
+	var quote PriceQuote
+	for _, rule := range e.Rules {
+		price, applied := rule.Apply(in, quote.Price)
+		if applied == nil {
+			continue
+		}
+		price = roundCents(float32(math.Max(0, float64(price))))
+		applied.Adjustment = roundCents(price - quote.Price)
+		quote.Price = price
+		quote.Applied = append(quote.Applied, *applied)
+	}
+	return quote
+}
+
+// QuoteReservation prices each player on the reservation, guests at the adult rate
+func (e *PricingEngine) QuoteReservation(res *Reservation, setting DetailedBlockSettings, utilization float64, now time.Time) ReservationQuote {
+	var out ReservationQuote
+	players := res.Players
+	if len(players) == 0 && res.BookingUser != nil {
+		players = []account.User{*res.BookingUser}
+	}
+	for i := range players {
+		in := PriceInput{TeeTime: res.TeeTime, Now: now, Setting: setting, Holes: res.RoundHoles(), Utilization: utilization}
+		if players[i].ID != "" {
+			in.Player = &players[i]
+		}
+		quote := e.Quote(in)
+		out.Players = append(out.Players, quote)
+		out.Total += quote.Price
+	}
+	out.Total = roundCents(out.Total)
+	return out
+}
+
+// BaseRateRule starts from the setting's price for the round length, falling
+// back to the rate card
+type BaseRateRule struct {
+	Rates map[SettingType]float32
+}
+
+func (b BaseRateRule) Apply(in PriceInput, price float32) (float32, *AppliedRule) {
+	setting := in.Setting
+	if setting.Price <= 0 {
+		setting.Price = b.Rates[SettingType(setting.Type)]
+	}
+	detail := fmt.Sprintf("%s rate", setting.Name)
+	if in.Holes == NineHoles {
+		detail = fmt.Sprintf("%s nine-hole rate", setting.Name)
+	}
+	return setting.PriceFor(in.Holes), &AppliedRule{Rule: "base", Detail: detail}
+}
+
+// DailyDealRule lowers the price to any available deal covering the tee time
+type DailyDealRule struct {
+	Deals []DetailedBlockSettings
+}
+
+func (d DailyDealRule) Apply(in PriceInput, price float32) (float32, *AppliedRule) {
+	for _, deal := range d.Deals {
+		if !deal.IsAvail || deal.PriceFor(in.Holes) >= price {
+			continue
+		}
+		if in.TeeTime.Before(deal.BeginOverride) || in.TeeTime.After(deal.EndOverride) {
+			continue
+		}
+		return deal.PriceFor(in.Holes), &AppliedRule{Rule: "dailyDeal", Detail: deal.Name}
+	}
+	return price, nil
+}
+
+// UtilizationRule surges busy days and discounts slow days close to the tee time
+type UtilizationRule struct {
+	SurgeAbove     float64
+	SurgePct       float32
+	DiscountBelow  float64
+	DiscountPct    float32
+	DiscountWithin time.Duration
+}
+
+func (u UtilizationRule) Apply(in PriceInput, price float32) (float32, *AppliedRule) {
+	if u.SurgePct > 0 && in.Utilization >= u.SurgeAbove {
+		return price * (1 + u.SurgePct), &AppliedRule{Rule: "surge", Detail: fmt.Sprintf("%.0f%% of the day booked", in.Utilization*100)}
+	}
+	if u.DiscountPct > 0 && in.Utilization < u.DiscountBelow && in.TeeTime.Sub(in.Now) <= u.DiscountWithin {
+		return price * (1 - u.DiscountPct), &AppliedRule{Rule: "slowDay", Detail: fmt.Sprintf("only %.0f%% of the day booked", in.Utilization*100)}
+	}
+	return price, nil
+}
+
+// LeadTimeRule rewards booking well ahead and fills tee times about to go unused
+type LeadTimeRule struct {
+	BookAhead     time.Duration
+	BookAheadPct  float32
+	LastMinute    time.Duration
+	LastMinutePct float32
+}
+
+func (l LeadTimeRule) Apply(in PriceInput, price float32) (float32, *AppliedRule) {
+	lead := in.TeeTime.Sub(in.Now)
+	if l.BookAheadPct > 0 && lead >= l.BookAhead {
+		return price * (1 - l.BookAheadPct), &AppliedRule{Rule: "bookAhead", Detail: fmt.Sprintf("booked %d days ahead", int(lead.Hours()/24))}
+	}
+	if l.LastMinutePct > 0 && lead >= 0 && lead <= l.LastMinute {
+		return price * (1 - l.LastMinutePct), &AppliedRule{Rule: "lastMinute", Detail: fmt.Sprintf("tee time in %d minutes", int(lead.Minutes()))}
+	}
+	return price, nil
+}
+
+// AgeRule discounts juniors and seniors based on the player's date of birth
+type AgeRule struct {
+	JuniorMaxAge int
+	JuniorPct    float32
+	SeniorMinAge int
+	SeniorPct    float32
+}
+
+func (a AgeRule) Apply(in PriceInput, price float32) (float32, *AppliedRule) {
+	if in.Player == nil {
+		return price, nil
+	}
+	age, ok := ageOn(in.Player.DOB, in.TeeTime)
+	if !ok {
+		return price, nil
+	}
+	if a.JuniorPct > 0 && age <= a.JuniorMaxAge {
+		return price * (1 - a.JuniorPct), &AppliedRule{Rule: "junior", Detail: fmt.Sprintf("age %d", age)}
+	}
+	if a.SeniorPct > 0 && age >= a.SeniorMinAge {
+		return price * (1 - a.SeniorPct), &AppliedRule{Rule: "senior", Detail: fmt.Sprintf("age %d", age)}
+	}
+	return price, nil
+}
+
+// ageOn returns the age in whole years on the given day of a DOB entered on the profile page
+func ageOn(dob string, on time.Time) (int, bool) {
+	for _, layout := range []string{time.DateOnly, "01/02/2006", time.RFC3339} {
+		born, err := time.Parse(layout, dob)
+		if err != nil {
+			continue
+		}
+		age := on.Year() - born.Year()
+		if on.Month() < born.Month() || (on.Month() == born.Month() && on.Day() < born.Day()) {
+			age--
+		}
+		return age, age >= 0
+	}
+	return 0, false
+}
+
+func roundCents(price float32) float32 {
+	return float32(math.Round(float64(price)*100) / 100)
+}
+
+// Utilization is the share of the day's player capacity already booked
+func (r *ReservedDay) Utilization() float64 {
+	if len(r.Times) == 0 {
+		return 0
+	}
+	var booked int64
+	slots := make(map[int64]bool)
+	for _, res := range r.Times {
+		slots[res.Slot] = true
+		if res.ID != "" {
+			booked += res.bookedPlayers()
+		}
+	}
+	return float64(booked) / float64(len(slots)*MaxPlayersPerSlot)
+}
+
+// ApplyPricing sets the adult eighteen and nine-hole rates on every open slot of the day
+func (r *ReservedDay) ApplyPricing(engine *PricingEngine, season Season, now time.Time) {
+	utilization := r.Utilization()
+	for i := range r.Times {
+		if r.Times[i].ID != "" {
+			continue
+		}
+		setting := season.SettingFor(r.Times[i].TeeTime)
+		if setting == nil {
+			continue
+		}
+		in := PriceInput{TeeTime: r.Times[i].TeeTime, Now: now, Setting: *setting, Utilization: utilization}
+		r.Times[i].Price = engine.Quote(in).Price
+		in.Holes = NineHoles
+		r.Times[i].NinePrice = engine.Quote(in).Price
+	}
+}
+
+// PriceGridRow is one slot of the admin's price preview
+type PriceGridRow struct {
+	TeeTime time.Time  `json:"teeTime"`
+	Slot    int64      `json:"slot"`
+	Group   string     `json:"group"`
+	Booked  bool       `json:"booked"`
+	Quote   PriceQuote `json:"quote"`
+}
+
+// PriceGrid previews the adult price of every slot on the day as of now
+func (c *Course) PriceGrid(ctx context.Context, day time.Time, now time.Time) ([]PriceGridRow, error) {
+	days, err := c.DayTeeTimes(ctx, day)
+	if err != nil || len(days) == 0 {
+		return nil, err
+	}
+	season, err := c.Season(ctx, day)
+	if err != nil || season == nil {
+		return nil, err
+	}
+	engine := DefaultPricingEngine(*season)
+	utilization := days[0].Utilization()
+
+	var grid []PriceGridRow
+	rows := make(map[int64]int)
+	for _, res := range days[0].Times {
+		setting := season.SettingFor(res.TeeTime)
+		if setting == nil {
+			continue
+		}
+		// one row a slot, booked when any reservation is in it
+		if row, ok := rows[res.Slot]; ok {
+			grid[row].Booked = grid[row].Booked || res.ID != ""
+			continue
+		}
+		rows[res.Slot] = len(grid)
+		grid = append(grid, PriceGridRow{
+			TeeTime: res.TeeTime,
+			Slot:    res.Slot,
+			Group:   setting.Name,
+			Booked:  res.ID != "",
+			Quote:   engine.Quote(PriceInput{TeeTime: res.TeeTime, Now: now, Setting: *setting, Utilization: utilization}),
+		})
+	}
+	return grid, nil
+}
+
+// PriceReservation quotes the reservation against the current tee sheet and
+// sets its per-person and total price, its slot, tee and round length, and
+// the crossover slot it will hold at the turn. The slot must be one the tee
+// sheet lays out open at the tee time, or zero to take the first open there.
+func (c *Course) PriceReservation(ctx context.Context, res *Reservation, now time.Time) (*ReservationQuote, error) {
+	days, err := c.DayTeeTimes(ctx, res.TeeTime)
+	if err != nil {
+		return nil, err
+	}
+	season, err := c.Season(ctx, res.TeeTime)
+	if err != nil {
+		return nil, err
+	}
+	if season == nil || len(days) == 0 {
+		return nil, fmt.Errorf("no season found for %s", res.TeeTime.Format(time.DateOnly))
+	}
+	return priceOnDay(res, *season, days[0], now)
+}
+
+// priceOnDay prices the reservation against a tee sheet already loaded for its day
+func priceOnDay(res *Reservation, season Season, day ReservedDay, now time.Time) (*ReservationQuote, error) {
+	if !season.IsOpen {
+		return nil, ErrCourseClosed
+	}
+	setting := season.SettingFor(res.TeeTime)
+	if setting == nil || !setting.IsAvail {
+		return nil, &SlotUnavailableError{TeeTime: res.TeeTime, Slot: res.Slot}
+	}
+	slot := day.slotAt(res.TeeTime, res.Slot)
+	if slot == nil {
+		return nil, &SlotUnavailableError{TeeTime: res.TeeTime, Slot: res.Slot}
+	}
+	res.Slot = slot.Slot
+	if err := season.prepareRound(res); err != nil {
+		return nil, err
+	}
+
+	quote := DefaultPricingEngine(season).QuoteReservation(res, *setting, day.Utilization(), now)
+	if len(quote.Players) > 0 {
+		res.Price = quote.Players[0].Price
+	}
+	res.Total = quote.Total
+	res.SettingType = setting.Type
+	res.Group = setting.Name
+	return &quote, nil
+}
@@ -0,0 +1,107 @@
+package teetimes
+
+import (
+	"bigfoot/golf/common/models/account"
+	"testing"
+	"time"
+)
+
+func TestPricingEngineQuote(t *testing.T) {
+	now := time.Date(2026, time.June, 1, 9, 0, 0, 0, time.UTC)
+	midday := DetailedBlockSettings{Name: "Weekday Midday", Type: int(WeekdayMidday), Price: 60, IsAvail: true}
+	season := Season{OverideSettings: []DetailedBlockSettings{{
+		Name: "Tuesday Twilight", Type: int(DailyDeal), Price: 35, IsAvail: true,
+		BeginOverride: time.Date(2026, time.June, 9, 16, 0, 0, 0, time.UTC),
+		EndOverride:   time.Date(2026, time.June, 9, 20, 0, 0, 0, time.UTC),
+	}}}
+	engine := DefaultPricingEngine(season)
+
+	tests := []struct {
+		name        string
+		teeTime     time.Time
+		setting     DetailedBlockSettings
+		utilization float64
+		player      *account.User
+		want        float32
+		rules       []string
+	}{
+		{"base rate", now.Add(72 * time.Hour), midday, 0.5, nil, 60, []string{"base"}},
+		{"rate card fallback", now.Add(72 * time.Hour), DetailedBlockSettings{Type: int(WeekendMidday)}, 0.5, nil, 79, []string{"base"}},
+		{"surge", now.Add(72 * time.Hour), midday, 0.9, nil, 66, []string{"base", "surge"}},
+		{"slow day close in", now.Add(24 * time.Hour), midday, 0.1, nil, 51, []string{"base", "slowDay"}},
+		{"slow day far out", now.Add(72 * time.Hour), midday, 0.1, nil, 60, []string{"base"}},
+		{"book ahead", now.Add(20 * 24 * time.Hour), midday, 0.5, nil, 57, []string{"base", "bookAhead"}},
+		{"last minute", now.Add(time.Hour), midday, 0.5, nil, 48, []string{"base", "lastMinute"}},
+		{"junior", now.Add(72 * time.Hour), midday, 0.5, &account.User{DOB: "2012-04-01"}, 45, []string{"base", "junior"}},
+		{"senior", now.Add(72 * time.Hour), midday, 0.5, &account.User{DOB: "03/15/1955"}, 51, []string{"base", "senior"}},
+		{"unparsable dob", now.Add(72 * time.Hour), midday, 0.5, &account.User{DOB: "sometime"}, 60, []string{"base"}},
+		{"daily deal", time.Date(2026, time.June, 9, 17, 0, 0, 0, time.UTC), midday, 0.5, nil, 35, []string{"base", "dailyDeal"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			quote := engine.Quote(PriceInput{TeeTime: tc.teeTime, Now: now, Setting: tc.setting, Utilization: tc.utilization, Player: tc.player})
+			if quote.Price != tc.want {
+				t.Errorf("price = %.2f, want %.2f (%+v)", quote.Price, tc.want, quote.Applied)
+			}
+			if len(quote.Applied) != len(tc.rules) {
+				t.Fatalf("applied = %+v, want rules %v", quote.Applied, tc.rules)
+			}
+			var sum float32
+			for i, rule := range quote.Applied {
+				if rule.Rule != tc.rules[i] {
+					t.Errorf("rule %d = %s, want %s", i, rule.Rule, tc.rules[i])
+				}
+				sum += rule.Adjustment
+			}
+			if roundCents(sum) != quote.Price {
+				t.Errorf("adjustments sum to %.2f, want %.2f", sum, quote.Price)
+			}
+		})
+	}
+}
+
+func TestQuoteReservationPricesEachPlayer(t *testing.T) {
+	now := time.Date(2026, time.June, 1, 9, 0, 0, 0, time.UTC)
+	setting := DetailedBlockSettings{Name: "Weekday Midday", Type: int(WeekdayMidday), Price: 60, IsAvail: true}
+	res := &Reservation{
+		TeeTime: now.Add(72 * time.Hour),
+		Players: []account.User{
+			{ID: "u1", DOB: "1950-01-01"},
+			{LastName: "Guest 1", DOB: "1950-01-01"},
+		},
+	}
+
+	quote := DefaultPricingEngine(Season{}).QuoteReservation(res, setting, 0.5, now)
+	if len(quote.Players) != 2 {
+		t.Fatalf("players quoted = %d, want 2", len(quote.Players))
+	}
+	if quote.Players[0].Price != 51 || quote.Players[1].Price != 60 {
+		t.Errorf("player prices = %.2f, %.2f, want 51, 60", quote.Players[0].Price, quote.Players[1].Price)
+	}
+	if quote.Total != 111 {
+		t.Errorf("total = %.2f, want 111", quote.Total)
+	}
+}
+
+func TestAgeOn(t *testing.T) {
+	tests := []struct {
+		name    string
+		dob     string
+		on      time.Time
+		wantAge int
+	}{
+		{"the day before a birthday", "2008-06-15", time.Date(2026, time.June, 14, 9, 0, 0, 0, time.UTC), 17},
+		{"on the birthday", "2008-06-15", time.Date(2026, time.June, 15, 9, 0, 0, 0, time.UTC), 18},
+		{"born after February in a leap year, on the birthday", "2008-03-01", time.Date(2026, time.March, 1, 9, 0, 0, 0, time.UTC), 18},
+		{"born on a leap day, the day before March", "2008-02-29", time.Date(2026, time.February, 28, 9, 0, 0, 0, time.UTC), 17},
+		{"born on a leap day, on March 1", "2008-02-29", time.Date(2026, time.March, 1, 9, 0, 0, 0, time.UTC), 18},
+		{"the profile page's layout", "03/01/1961", time.Date(2026, time.March, 1, 9, 0, 0, 0, time.UTC), 65},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if age, ok := ageOn(tt.dob, tt.on); !ok || age != tt.wantAge {
+				t.Errorf("expected age %d, got %d (%v)", tt.wantAge, age, ok)
+			}
+		})
+	}
+}
@@ -2,65 +2,84 @@ package teetimes
 
 import (
 	"bigfoot/golf/common/models/account"
+	"bigfoot/golf/common/models/audit"
 	"bigfoot/golf/common/models/db"
-	"encoding/json"
+	"context"
 	"fmt"
+	"log"
 	"time"
 )
 
 type Reservation struct {
-	ID          string         `json:"id,omitempty"`
-	TeeTime     time.Time      `json:"teeTime"`
-	BookingUser *account.User  `json:"user,omitempty"`
-	Players     []account.User `json:"players"`
-	Slot        int64          `json:"slot"`
-	Price       float32        `json:"price"`
-	SettingType int            `json:"type"`
-	Group       string         `json:"group"`
-	CreatedAt   time.Time      `json:"createdAt"`
-	UpdatedAt   time.Time      `json:"updatedAt"`
+	ID            string         `json:"id,omitempty"`
+	TeeTime       time.Time      `json:"teeTime"`
+	BookingUser   *account.User  `json:"user,omitempty"`
+	Players       []account.User `json:"players"`
+	Slot          int64          `json:"slot"`
+	Tee           int            `json:"tee,omitempty"`
+	Holes         int            `json:"holes,omitempty"`
+	CrossoverSlot int64          `json:"crossoverSlot,omitempty"` // slot held on the other tee at the turn
+	PlayerCount   int64          `json:"playerCount"`
+	Price         float32        `json:"price"`
+	NinePrice     float32        `json:"ninePrice,omitempty" neo4j:"-"` // open slots only
+	Total         float32        `json:"total"`
+	SettingType   int            `json:"type"`
+	Group         string         `json:"group"`
+	Held          bool           `json:"held,omitempty" neo4j:"-"`
+	Open          int64          `json:"open,omitempty" neo4j:"-"` // spots left, open slots only
+	// IdempotencyKey is chosen by the client for each booking it attempts, so
+	// a retry of the same attempt returns the first reservation
+	IdempotencyKey string `json:"idempotencyKey,omitempty" neo4j:",omitempty"`
+	// Cancelled reservations are kept for their history but left out of the
+	// day's tee sheet, the user's reservations and slot capacity
+	Cancelled   bool       `json:"cancelled,omitempty"`
+	CancelledAt *time.Time `json:"cancelledAt,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+	UpdatedBy   string     `json:"updatedBy,omitempty" neo4j:",omitempty"`
 }
 
-type Outing struct {
-	ID         string
-	Name       string
-	StartTime  time.Time
-	EndTime    time.Time
-	MaxGolfers int64
-	TeeTimes   []Reservation
-	CreatedAt  time.Time
-	UpdatedAt  time.Time
-	UpdatedBy  *account.User
+// ReservationStore reads and writes reservations outside of slot booking
+type ReservationStore interface {
+	// SaveReservation writes the reservation and links it to its booking user
+	SaveReservation(ctx context.Context, res *Reservation) error
+	// GetReservation returns the reservation with the id, cancelled or not,
+	// with its booking user's id, or nil
+	GetReservation(ctx context.Context, id string) (*Reservation, error)
+	// UserReservations returns the user's reservations from today, or from a
+	// year ago with includePast, soonest first or latest first with includePast.
+	// Cancelled reservations are left out.
+	UserReservations(ctx context.Context, userID string, includePast bool, now time.Time) ([]Reservation, error)
+	// DayReservations returns the day's bookings with only the ids and names
+	// of their golfers, since the tee sheet is public, leaving out cancelled
+	// reservations
+	DayReservations(ctx context.Context, day time.Time) ([]Reservation, error)
 }
 
-func (r *Reservation) Save() error {
-	_id, err := db.Instance.SaveStruct(r, "Reservation")
-	if err != nil {
-		fmt.Println(err)
-		return err
+// SaveReservation writes the reservation as the context's actor and records
+// the change
+func (c *Course) SaveReservation(ctx context.Context, r *Reservation) error {
+	if r.BookingUser == nil {
+		return fmt.Errorf("no user found")
 	}
-	r.ID = _id
-	var guests []Guest
-	for _, guest := range r.Players {
-		if guest.ID == "" {
-			guests = append(guests, Guest{Name: guest.LastName, Email: guest.Email, Phone: guest.Phone})
+	var before *Reservation
+	if r.ID != "" {
+		var err error
+		if before, err = c.stores.Bookings.GetReservation(ctx, r.ID); err != nil {
+			return err
 		}
-		//TODO BUILD ADDING EXISTING USER FUNCTIONALITY
 	}
-	_rel := db.Relation{NodeN: "User", NodeX: "Reservation", NodeNID: r.BookingUser.ID, NodeXID: r.ID, Name: "BOOKED_TEETIME"}
-	if len(guests) > 0 {
-		_rel.Property = "guests"
-		_g, _ := json.Marshal(guests)
-		_rel.Body = string(_g)
-	}
-
-	//add Relationship
-	err = db.Instance.SaveRelationship(_rel)
-	if err != nil {
+	r.UpdatedBy = audit.Actor(ctx)
+	if err := c.stores.Bookings.SaveReservation(ctx, r); err != nil {
+		fmt.Println(err)
 		return err
 	}
-
-	return err
+	if before == nil {
+		audit.Record(ctx, audit.Reservation, r.ID, audit.Created, nil, r)
+	} else {
+		audit.Record(ctx, audit.Reservation, r.ID, audit.Updated, before, r)
+	}
+	return nil
 }
 
 func NewReservation(_user *account.User, _players []account.User, _teeTime time.Time, _slot int64, _setting DetailedBlockSettings) Reservation {
@@ -78,118 +97,115 @@ func NewReservation(_user *account.User, _players []account.User, _teeTime time.
 	return reserv
 }
 
-// GetUserReservations retrieves all reservations for a specific user
-func GetUserReservations(userID string, includePast bool) ([]Reservation, error) {
-	var query string
-	if includePast {
-		query = fmt.Sprintf(`MATCH (u:User {id: "%s"})-[r:BOOKED_TEETIME]->(res:Reservation)
-			WHERE date(res.teeTime) >= date() - duration({months: 12})
-			WITH res, r.guests as guests
-			RETURN res{.*, guests} as data
-			ORDER BY res.teeTime DESC`, userID)
-	} else {
-		query = fmt.Sprintf(`MATCH (u:User {id: "%s"})-[r:BOOKED_TEETIME]->(res:Reservation)
-			WHERE date(res.teeTime) >= date()
-			WITH res, r.guests as guests
-			RETURN res{.*, guests} as data
-			ORDER BY res.teeTime ASC`, userID)
-	}
-
-	reservationMaps, err := db.Instance.QueryForMap(query, nil)
+// UserReservations retrieves the user's reservations that are not cancelled
+func (c *Course) UserReservations(ctx context.Context, userID string, includePast bool) ([]Reservation, error) {
+	reservations, err := c.stores.Bookings.UserReservations(ctx, userID, includePast, time.Now())
 	if err != nil {
 		return nil, err
 	}
-
-	if len(reservationMaps) == 0 {
+	if len(reservations) == 0 {
 		return []Reservation{}, nil
 	}
-
-	return convertMapsToReservations(reservationMaps), nil
+	return reservations, nil
 }
 
-// GetReservationByTime gets the exact reservation by time
-func GetReservationByTime(tm time.Time) *Reservation {
-
-	query := fmt.Sprintf(`MATCH (u:User)-[r:BOOKED_TEETIME]->(res:Reservation)
-			WHERE datetime(res.teeTime) >= datetime(%s)
-			RETURN res{.*, guests} as data`, tm.Format(time.RFC3339))
-
-	reservationMaps, err := db.Instance.QueryForMap(query, nil)
+// ReservationByTime gets the exact reservation by time
+func (c *Course) ReservationByTime(ctx context.Context, tm time.Time) *Reservation {
+	reservations, err := c.stores.Bookings.DayReservations(ctx, tm)
 	if err != nil {
 		return nil
 	}
-
-	if len(reservationMaps) == 0 {
-		return nil
+	for i := range reservations {
+		if reservations[i].TeeTime.Equal(tm) {
+			return &reservations[i]
+		}
 	}
+	//there is no Reservation Check to see if one is available
+	return c.UnbookedReservation(ctx, tm)
+}
 
-	res := convertMapsToReservations(reservationMaps)
-	if len(res) > 0 {
-		return &res[0]
-	} else {
-		//there is no Reservation Check to see if one is available
-		return GetUnbookedReservation(tm)
+// Cancel marks the reservation as cancelled and offers the freed spots to the waitlist
+func (c *Course) Cancel(ctx context.Context, r *Reservation) error {
+	if err := c.cancelReservation(ctx, r); err != nil {
+		return err
+	}
+	if _, err := c.OfferOpenSpot(ctx, *r); err != nil {
+		log.Printf("Error offering cancelled reservation %s to waitlist: %v", r.ID, err)
 	}
+	return nil
 }
 
-// CancelReservation cancels a reservation by marking it as cancelled
-func (r *Reservation) Cancel() error {
-	query := fmt.Sprintf(`MATCH (res:Reservation {id: "%s"}) 
-		SET res.cancelled = true, res.cancelledAt = datetime()
-		RETURN res`, r.ID)
-
-	_, err := db.Instance.QueryForMap(query, nil)
-	return err
+// cancelReservation soft-deletes the reservation as the context's actor and
+// records the change
+func (c *Course) cancelReservation(ctx context.Context, r *Reservation) error {
+	before := *r
+	now := time.Now()
+	r.Cancelled, r.CancelledAt = true, &now
+	r.UpdatedAt, r.UpdatedBy = now, audit.Actor(ctx)
+	if err := c.stores.Bookings.CancelReservation(ctx, r); err != nil {
+		*r = before
+		return err
+	}
+	audit.Record(ctx, audit.Reservation, r.ID, audit.Cancelled, before, r)
+	return nil
 }
 
-// convertMapsToReservations manually converts []map[string]any to []Reservation preserving time.Time locations
-func convertMapsToReservations(maps []map[string]any) []Reservation {
-	var reservations []Reservation
-
-	for _, m := range maps {
-		var reservation Reservation
+// named keeps only a user's id and name
+func named(u account.User) account.User {
+	return account.User{ID: u.ID, FirstName: u.FirstName, LastName: u.LastName}
+}
 
-		if id, ok := m["id"].(string); ok {
-			reservation.ID = id
-		}
-		if teeTime, ok := m["teeTime"].(time.Time); ok {
-			reservation.TeeTime = teeTime
-		}
-		if slot, ok := m["slot"].(int64); ok {
-			reservation.Slot = slot
-		}
-		if price, ok := m["price"].(float64); ok {
-			reservation.Price = float32(price)
-		}
-		if settingType, ok := m["type"].(int64); ok {
-			reservation.SettingType = int(settingType)
-		}
-		if group, ok := m["group"].(string); ok {
-			reservation.Group = group
+// withoutProfiles keeps only the ids and names of the booking user and the
+// players booked by id, once their stored details have priced the round.
+// Guests keep the contact details the booker entered for them.
+func (r *Reservation) withoutProfiles() {
+	if r.BookingUser != nil {
+		booker := named(*r.BookingUser)
+		r.BookingUser = &booker
+	}
+	for i, player := range r.Players {
+		if player.ID != "" {
+			r.Players[i] = named(player)
 		}
-		if createdAt, ok := m["createdAt"].(time.Time); ok {
-			reservation.CreatedAt = createdAt
+	}
+}
+
+// forTeeSheet keeps only the ids and names of the reservations' booking users
+// and players, so no password, contact or birth date reaches the public tee sheet
+func forTeeSheet(reservations []Reservation) []Reservation {
+	for i := range reservations {
+		if user := reservations[i].BookingUser; user != nil {
+			booker := named(*user)
+			reservations[i].BookingUser = &booker
 		}
-		if updatedAt, ok := m["updatedAt"].(time.Time); ok {
-			reservation.UpdatedAt = updatedAt
+		players := make([]account.User, len(reservations[i].Players))
+		for j, player := range reservations[i].Players {
+			players[j] = named(player)
 		}
+		reservations[i].Players = players
+	}
+	return reservations
+}
 
-		// Handle guests from relationship property
-		if guestsStr, ok := m["guests"].(string); ok && guestsStr != "" {
-			var guests []Guest
-			if err := json.Unmarshal([]byte(guestsStr), &guests); err == nil {
-				for _, guest := range guests {
-					reservation.Players = append(reservation.Players, account.User{
-						LastName: guest.Name,
-						Email:    guest.Email,
-						Phone:    guest.Phone,
-					})
-				}
-			}
+// decodeReservations decodes reservation rows, adding the guests kept as json
+// on the booking relationship to the players
+func decodeReservations(maps []map[string]any) ([]Reservation, error) {
+	reservations, err := db.DecodeAll[Reservation](maps)
+	if err != nil {
+		return nil, err
+	}
+	for i, m := range maps {
+		var guests []Guest
+		if err := db.Decode(m["guests"], &guests); err != nil {
+			return nil, err
+		}
+		for _, guest := range guests {
+			reservations[i].Players = append(reservations[i].Players, account.User{
+				LastName: guest.Name,
+				Email:    guest.Email,
+				Phone:    guest.Phone,
+			})
 		}
-
-		reservations = append(reservations, reservation)
 	}
-
-	return reservations
+	return reservations, nil
 }
@@ -0,0 +1,202 @@
+package teetimes
+
+import (
+	"bigfoot/golf/common/models/account"
+	"bigfoot/golf/common/models/audit"
+	"bigfoot/golf/common/models/db"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// TestNeo4jRowsDecode decodes rows shaped like the Neo4j stores' queries return
+func TestNeo4jRowsDecode(t *testing.T) {
+	teeTime := time.Date(2025, time.June, 14, 8, 30, 0, 0, time.FixedZone("MDT", -6*60*60))
+
+	t.Run("reservations", func(t *testing.T) {
+		res := Reservation{ID: "r1", TeeTime: teeTime, Slot: 7, PlayerCount: 3, Price: 40, Total: 120, SettingType: int(WeekendMorning), IdempotencyKey: "k1", NinePrice: 25, Held: true, BookingUser: &account.User{ID: "u1"}}
+		props, err := reservationProps(&res)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if props[db.IdempotencyScope] != "u1" {
+			t.Errorf("expected the key scoped to the booking user, got %v", props[db.IdempotencyScope])
+		}
+		if props["day"] != "2025-06-14" {
+			t.Errorf("expected the tee time's day to be indexed, got %v", props["day"])
+		}
+		for _, key := range []string{"ninePrice", "held", "user", "players"} {
+			if _, ok := props[key]; ok {
+				t.Errorf("expected %s left off the node", key)
+			}
+		}
+		props["guests"] = `[{"name":"Guest 1"},{"name":"Guest 2","email":"g2@example.com"}]`
+		props["user"] = map[string]any{"id": "u1", "email": "u1@example.com", "first_name": "Una"}
+
+		tests := []struct {
+			name  string
+			check func(Reservation) bool
+		}{
+			{name: "tee time keeps its zone", check: func(r Reservation) bool {
+				return r.TeeTime.Equal(teeTime) && r.TeeTime.Location() == teeTime.Location()
+			}},
+			{name: "numbers", check: func(r Reservation) bool {
+				return r.Slot == 7 && r.PlayerCount == 3 && r.Total == 120 && r.SettingType == int(WeekendMorning)
+			}},
+			{name: "derived tee and holes", check: func(r Reservation) bool { return r.Tee == 1 && r.Holes == 18 }},
+			{name: "idempotency key", check: func(r Reservation) bool { return r.IdempotencyKey == "k1" }},
+			{name: "booking user", check: func(r Reservation) bool { return r.BookingUser != nil && r.BookingUser.FirstName == "Una" }},
+			{name: "guests from the relationship", check: func(r Reservation) bool {
+				return len(r.Players) == 2 && r.Players[1].LastName == "Guest 2" && r.Players[1].Email == "g2@example.com"
+			}},
+		}
+		decoded, err := decodeReservations([]map[string]any{props})
+		if err != nil || len(decoded) != 1 {
+			t.Fatalf("expected one reservation, got %d: %v", len(decoded), err)
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				if !tt.check(decoded[0]) {
+					t.Errorf("unexpected reservation %+v", decoded[0])
+				}
+			})
+		}
+	})
+
+	t.Run("season with settings", func(t *testing.T) {
+		season := Season{ID: "s1", Year: 2025, Name: "Summer", BeginDate: teeTime, EndDate: teeTime.AddDate(0, 3, 0), Gap: 10 * time.Minute, Turn: 2 * time.Hour, Tees: []int{1, 10}, IsOpen: true}
+		props, err := db.Encode(season)
+		if err != nil {
+			t.Fatal(err)
+		}
+		setting, err := db.Encode(DetailedBlockSettings{ID: "b1", Name: "Weekend Morning", Type: int(WeekendMorning), Price: 60, IsAvail: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		props["defaultSettings"] = []any{setting}
+		props["overideSettings"] = []any{}
+
+		seasons, err := db.DecodeAll[Season]([]map[string]any{props})
+		if err != nil || len(seasons) != 1 {
+			t.Fatalf("expected one season, got %d: %v", len(seasons), err)
+		}
+		got := seasons[0]
+		if got.Gap != season.Gap || got.Turn != season.Turn || len(got.Tees) != 2 || got.Tees[1] != 10 || !got.IsOpen {
+			t.Errorf("unexpected season %+v", got)
+		}
+		if len(got.DefaultSettings) != 1 || got.DefaultSettings[0].Price != 60 || len(got.OverideSettings) != 0 {
+			t.Errorf("unexpected settings %+v %+v", got.DefaultSettings, got.OverideSettings)
+		}
+	})
+}
+
+// TestCancelKeepsHistory cancels a booking on each backend and checks it
+// leaves the lists and slot but keeps its history
+func TestCancelKeepsHistory(t *testing.T) {
+	teeTime := time.Now().AddDate(0, 0, 2).Truncate(time.Hour)
+	golfer := account.User{ID: "golfer", LastName: "Golfer"}
+
+	tests := []struct {
+		name string
+		use  func(t *testing.T) *Course
+	}{
+		{"memory", func(t *testing.T) *Course { return useMemoryStores(t).course }},
+		{"sqlite", func(t *testing.T) *Course { c, _ := useSQLiteStores(t); return c }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := tt.use(t)
+			ctx := audit.WithActor(context.Background(), golfer.ID)
+
+			res := Reservation{TeeTime: teeTime, Slot: 1, BookingUser: &golfer, Players: make([]account.User, MaxPlayersPerSlot)}
+			if err := c.BookTeeTime(ctx, &res); err != nil {
+				t.Fatal(err)
+			}
+			if err := c.Cancel(audit.WithActor(context.Background(), "pro"), &res); err != nil {
+				t.Fatal(err)
+			}
+
+			if mine, err := c.UserReservations(ctx, golfer.ID, false); err != nil || len(mine) != 0 {
+				t.Errorf("expected no reservations for the golfer, got %d: %v", len(mine), err)
+			}
+			if day, err := c.stores.Bookings.DayReservations(ctx, teeTime); err != nil || len(day) != 0 {
+				t.Errorf("expected no reservations on the day, got %d: %v", len(day), err)
+			}
+			again := Reservation{TeeTime: teeTime, Slot: 1, BookingUser: &golfer, Players: make([]account.User, MaxPlayersPerSlot)}
+			if err := c.BookTeeTime(ctx, &again); err != nil {
+				t.Errorf("expected the cancelled slot free to book: %v", err)
+			}
+
+			events, err := audit.History(ctx, audit.Reservation, res.ID)
+			if err != nil || len(events) != 2 {
+				t.Fatalf("expected a created and a cancelled event, got %d: %v", len(events), err)
+			}
+			if events[0].Action != audit.Created || events[0].Actor != golfer.ID || events[0].Before != "" {
+				t.Errorf("expected the golfer's create first, got %+v", events[0])
+			}
+			var before, after Reservation
+			if err := json.Unmarshal([]byte(events[1].Before), &before); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal([]byte(events[1].After), &after); err != nil {
+				t.Fatal(err)
+			}
+			if events[1].Action != audit.Cancelled || events[1].Actor != "pro" || before.Cancelled || !after.Cancelled || after.UpdatedBy != "pro" {
+				t.Errorf("expected the pro's cancel with the reservation before and after, got %+v", events[1])
+			}
+		})
+	}
+}
+
+func TestSaveRecordsBefore(t *testing.T) {
+	golfer := account.User{ID: "golfer", LastName: "Golfer"}
+	tests := []struct {
+		name string
+		use  func(t *testing.T) *Course
+	}{
+		{"memory", func(t *testing.T) *Course { return useMemoryStores(t).course }},
+		{"sqlite", func(t *testing.T) *Course { c, _ := useSQLiteStores(t); return c }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := tt.use(t)
+			ctx := audit.WithActor(context.Background(), "pro")
+
+			res := Reservation{TeeTime: time.Now().AddDate(0, 0, 2).Truncate(time.Hour), Slot: 1, BookingUser: &golfer, Price: 50}
+			if err := c.SaveReservation(ctx, &res); err != nil {
+				t.Fatal(err)
+			}
+			res.Price = 40
+			if err := c.SaveReservation(ctx, &res); err != nil {
+				t.Fatal(err)
+			}
+			events, err := audit.History(ctx, audit.Reservation, res.ID)
+			if err != nil || len(events) != 2 || events[0].Action != audit.Created || events[1].Action != audit.Updated {
+				t.Fatalf("expected a created and an updated event, got %+v %v", events, err)
+			}
+			var before Reservation
+			if err := json.Unmarshal([]byte(events[1].Before), &before); err != nil || before.Price != 50 {
+				t.Errorf("expected the reservation before the update, got %+v %v", before, err)
+			}
+
+			season := overrideSeason()
+			if err := c.SaveSeason(ctx, &season); err != nil {
+				t.Fatal(err)
+			}
+			setting := season.DefaultSettings[0]
+			setting.Price = 35
+			if _, err := c.SaveSetting(ctx, &setting); err != nil {
+				t.Fatal(err)
+			}
+			events, err = audit.History(ctx, audit.BlockSetting, setting.ID)
+			if err != nil || len(events) != 1 || events[0].Action != audit.Updated {
+				t.Fatalf("expected an updated event, got %+v %v", events, err)
+			}
+			var settingBefore DetailedBlockSettings
+			if err := json.Unmarshal([]byte(events[0].Before), &settingBefore); err != nil || settingBefore.Price != season.DefaultSettings[0].Price {
+				t.Errorf("expected the setting before the update, got %+v %v", settingBefore, err)
+			}
+		})
+	}
+}
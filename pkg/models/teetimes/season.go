@@ -1,9 +1,9 @@
 package teetimes
 
 import (
-	"bigfoot/golf/common/helper"
-	"bigfoot/golf/common/models/db"
+	"bigfoot/golf/common/models/audit"
 	"bigfoot/golf/common/models/weather"
+	"context"
 	"fmt"
 	"log"
 	"time"
@@ -19,97 +19,57 @@ type Season struct {
 	FirstTeeTime    time.Time                   `yaml:"firstTeeTime" json:"firstTeeTime"`
 	LastTeeTime     time.Time                   `yaml:"lastTeeTime" json:"lastTeeTime"`
 	Gap             time.Duration               `yaml:"gap" json:"gap"`
+	Tees            []int                       `yaml:"tees" json:"tees"` // starting tees, the first tee when empty
+	Turn            time.Duration               `yaml:"turn" json:"turn"` // time to play a nine, DefaultTurn when 0
 	IsOpen          bool                        `yaml:"isOpen" json:"isOpen"`
 	DefaultSettings []DetailedBlockSettings     `yaml:"defaultSettings" json:"defaultSettings"`
 	OverideSettings []DetailedBlockSettings     `yaml:"overideSettings" json:"overideSettings"`
+	Holidays        []HolidayDate               `yaml:"holidays" json:"holidays"`
+	UpdatedBy       string                      `yaml:"-" json:"updatedBy,omitempty" neo4j:",omitempty"`
 }
 
-func NewSeason(year int, name string, begin time.Time, end time.Time) Season {
-	var lat, lon float32
-	var err error
-	lat = 40.745152
-	lon = -79.665367
-
-	var seas Season
-	seas.Year = year
-	seas.BeginDate = begin
-	seas.Name = name
-	seas.EndDate = end
-	_middleDt := seas.BeginDate.Add(seas.EndDate.Sub(seas.BeginDate) / 2)
-	seas.SolarTimes, err = weather.GetSunriseAndSunset(_middleDt, lat, lon)
+// InitNewSeason builds the year's seasons from the season config and saves
+// the ones that have not already ended
+func (c *Course) InitNewSeason(ctx context.Context, year int) ([]Season, error) {
+	cfg, err := LoadAppConfig()
 	if err != nil {
-		fmt.Println(err)
-		log.Fatal(err)
+		return nil, err
+	}
+	seasons, err := cfg.BuildSeasons(year)
+	if err != nil {
+		return nil, err
 	}
-
-	//seas.Settings = GetDefaultBlockSettings(seas)
-	seas.FirstTeeTime = seas.SolarTimes.Sunrise
-	seas.LastTeeTime = seas.SolarTimes.Sunset
-	seas.Gap = time.Minute * 10
-	seas.IsOpen = true
-	_weekdayCost := 60
-	_weekendCost := 79
-	_morningOverrideslots := 8
-	_eveningOverrideSlots := 50
-	_morningDiscount := 10
-	_afternoonDiscount := 10
-	_morningEnd := seas.FirstTeeTime.Add(seas.Gap * time.Duration(_morningOverrideslots))
-	_eveningStart := seas.FirstTeeTime.Add(seas.Gap * time.Duration(_eveningOverrideSlots))
-	//write the code to add price overrides
-	seas.DefaultSettings = append(seas.DefaultSettings, DetailedBlockSettings{
-		Type: int(WeekdayMorning), Name: "Weekday Morning", BeginOverride: seas.FirstTeeTime,
-		EndOverride: _morningEnd,
-		Price:       float32(_weekdayCost) - float32(_morningDiscount), IsAvail: true,
-	})
-	seas.DefaultSettings = append(seas.DefaultSettings, DetailedBlockSettings{
-		Type: int(WeekdayMidday), Name: "Weekday Midday", BeginOverride: _morningEnd.Add(time.Minute),
-		EndOverride: _eveningStart.Add(time.Minute * -1),
-		Price:       float32(_weekdayCost), IsAvail: true,
-	})
-	seas.DefaultSettings = append(seas.DefaultSettings, DetailedBlockSettings{
-		Type: int(WeekdayAfternoon), Name: "Weekday Afternoon", BeginOverride: _eveningStart,
-		EndOverride: seas.LastTeeTime.Add(time.Minute),
-		Price:       float32(_weekdayCost) - float32(_afternoonDiscount), IsAvail: true,
-	})
-	seas.DefaultSettings = append(seas.DefaultSettings, DetailedBlockSettings{
-		Type: int(WeekendMorning), Name: "Weekend Morning", BeginOverride: seas.FirstTeeTime,
-		EndOverride: _morningEnd,
-		Price:       float32(_weekendCost) - float32(_morningDiscount), IsAvail: true,
-	})
-	seas.DefaultSettings = append(seas.DefaultSettings, DetailedBlockSettings{
-		Type: int(WeekendMidday), Name: "Weekend Midday", BeginOverride: _morningEnd.Add(time.Minute),
-		EndOverride: _eveningStart.Add(time.Minute * -1),
-		Price:       float32(_weekendCost), IsAvail: true,
-	})
-	seas.DefaultSettings = append(seas.DefaultSettings, DetailedBlockSettings{
-		Type: int(WeekendAfternoon), Name: "Weekend Afternoon", BeginOverride: _eveningStart,
-		EndOverride: seas.LastTeeTime.Add(time.Minute),
-		Price:       float32(_weekendCost) - float32(_afternoonDiscount), IsAvail: true,
-	})
-
-	return seas
-}
-
-func InitNewSeason(year int) []Season {
 
 	var s []Season
-
-	//setup the Four Seasons
-	_seasons := helper.GetSeasonsMetereological(year)
-	for season, dts := range _seasons {
-		if dts[1].After(time.Now()) {
-			seas := NewSeason(year, season, dts[0], dts[1])
-			//reservationBlock := NewReservationBlock(seas)
-			seas.Save()
+	for _, seas := range seasons {
+		if seas.EndDate.After(time.Now()) {
+			if err := c.SaveSeason(ctx, &seas); err != nil {
+				return nil, err
+			}
 			s = append(s, seas)
 		}
 	}
-
-	return s
-
+	return s, nil
 }
 func (s *Season) GetTimeDetails(_date time.Time, _time time.Time) *DetailedBlockSettings {
 
+	//holidays use the holiday settings ahead of the weekday and weekend ones
+	_holiday := s.HolidayOn(_date)
+	if _holiday != nil {
+		for _, setting := range s.DefaultSettings {
+			if setting.Type == int(Holiday) && setting.covers(_time) {
+				return &setting
+			}
+		}
+		//seasons saved without holiday settings price holidays at their weekend rates
+		for _, setting := range s.DefaultSettings {
+			if setting.Type >= int(WeekendMorning) && setting.Type <= int(WeekendAfternoon) && setting.covers(_time) {
+				setting.Type = int(Holiday)
+				setting.Name = _holiday.Name
+				return &setting
+			}
+		}
+	}
 	for _, setting := range s.DefaultSettings {
 		if setting.MatchesType(_date, _time) {
 			if setting.BeginOverride.Before(_time) && setting.EndOverride.After(_time) {
@@ -122,121 +82,143 @@ func (s *Season) GetTimeDetails(_date time.Time, _time time.Time) *DetailedBlock
 	}
 	return nil
 }
-func (s *Season) Save() error {
-	_strOut, err := db.Instance.SaveStruct(s, "Season")
+
+// covers reports whether the clock time falls in the setting's hours, ends included
+func (d *DetailedBlockSettings) covers(_time time.Time) bool {
+	return !d.BeginOverride.After(_time) && !d.EndOverride.Before(_time)
+}
+
+// SettingFor resolves the block setting for a tee time on any day of the season
+func (s *Season) SettingFor(teeTime time.Time) *DetailedBlockSettings {
+	ft := s.FirstTeeTime
+	_clock := time.Date(ft.Year(), ft.Month(), ft.Day(), teeTime.Hour(), teeTime.Minute(), 0, 0, ft.Location())
+	return s.GetTimeDetails(teeTime, _clock)
+}
+
+// DailyDeals are the override settings priced as a deal for a window of tee times
+func (s *Season) DailyDeals() []DetailedBlockSettings {
+	var deals []DetailedBlockSettings
+	for _, setting := range s.OverideSettings {
+		if setting.Type == int(DailyDeal) {
+			deals = append(deals, setting)
+		}
+	}
+	return deals
+}
+
+// AddDailyDeal attaches a deal to the season covering the deal's window
+func (c *Course) AddDailyDeal(ctx context.Context, deal DetailedBlockSettings) (*Season, error) {
+	deal.Type = int(DailyDeal)
+	if !deal.EndOverride.After(deal.BeginOverride) {
+		return nil, fmt.Errorf("deal must end after it begins")
+	}
+	seas, err := c.Season(ctx, deal.BeginOverride)
 	if err != nil {
-		fmt.Println(err)
-		return err
+		return nil, err
+	}
+	if seas == nil {
+		return nil, fmt.Errorf("no season found for %s", deal.BeginOverride.Format(time.DateOnly))
+	}
+	deal.UpdatedBy = audit.Actor(ctx)
+	if err := c.stores.Seasons.AddOverride(ctx, seas.ID, &deal); err != nil {
+		return nil, err
 	}
-	s.ID = _strOut
+	audit.Record(ctx, audit.BlockSetting, deal.ID, audit.Created, nil, deal)
+	seas.OverideSettings = append(seas.OverideSettings, deal)
+	return seas, nil
+}
+
+// SeasonStore persists seasons with their block settings and course holidays.
+// Seasons come back without their holiday calendar, which Course loads.
+type SeasonStore interface {
+	// Save writes the season with its default and override settings
+	Save(ctx context.Context, season *Season) error
+	SaveSetting(ctx context.Context, setting *DetailedBlockSettings) error
+	// GetSetting returns the block setting with the id, or nil
+	GetSetting(ctx context.Context, id string) (*DetailedBlockSettings, error)
+	// AddOverride saves the setting as one of the season's overrides
+	AddOverride(ctx context.Context, seasonID string, setting *DetailedBlockSettings) error
+	// SetOpen opens or closes the season, stamping it as updated by the user
+	SetOpen(ctx context.Context, seasonID string, open bool, by string) error
+	// Seasons returns the seasons that have not ended by the day
+	Seasons(ctx context.Context, from time.Time) ([]Season, error)
+	// SeasonOn returns the season the day falls in, or nil
+	SeasonOn(ctx context.Context, day time.Time) (*Season, error)
+	// Get returns the season with the id, or nil
+	Get(ctx context.Context, id string) (*Season, error)
+	Holidays(ctx context.Context, seasonID string) ([]HolidayDate, error)
+	SaveHoliday(ctx context.Context, seasonID string, h *HolidayDate) error
+	DeleteHoliday(ctx context.Context, id string) error
+}
 
-	for i := range s.DefaultSettings {
-		_dID, err := s.DefaultSettings[i].Save()
-		if err != nil {
+// SaveSeason writes the season and its settings as the context's actor and
+// records the change
+func (c *Course) SaveSeason(ctx context.Context, s *Season) error {
+	var before *Season
+	if s.ID != "" {
+		var err error
+		if before, err = c.stores.Seasons.Get(ctx, s.ID); err != nil {
 			return err
 		}
-		s.DefaultSettings[i].ID = _dID
-		//add Relationship
-		err = db.Instance.SaveRelationship(db.Relation{NodeN: "Season", NodeX: "DetailedBlockSettings", NodeNID: s.ID, NodeXID: s.DefaultSettings[i].ID, Name: "HAS_SETTINGS"})
-		if err != nil {
+	}
+	s.UpdatedBy = audit.Actor(ctx)
+	if err := c.stores.Seasons.Save(ctx, s); err != nil {
+		fmt.Println(err)
+		return err
+	}
+	if before == nil {
+		audit.Record(ctx, audit.Season, s.ID, audit.Created, nil, s)
+	} else {
+		audit.Record(ctx, audit.Season, s.ID, audit.Updated, before, s)
+	}
+	for i := range s.Holidays {
+		if s.Holidays[i].Federal {
+			continue
+		}
+		if err := c.SaveHoliday(ctx, s.ID, &s.Holidays[i]); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func GetSeasons(_time time.Time) ([]Season, error) {
-	//get the tee times
-	query := fmt.Sprintf(`MATCH (n:Season) 
-		MATCH (n)-[r:HAS_SETTINGS]->(x:DetailedBlockSettings)
-		WITH n, COLLECT(x{.*}) AS defaultSettings
-		WHERE date(n.endDate) >= date("%s")
-		RETURN n{.*, defaultSettings} as data`, _time.Format(time.DateOnly))
-
-	dayWithRelationships, err := db.Instance.QueryForMap(query, nil) // depth of 2
+// Seasons returns the seasons that have not ended by the day with their holiday calendars
+func (c *Course) Seasons(ctx context.Context, _time time.Time) ([]Season, error) {
+	seasonOut, err := c.stores.Seasons.Seasons(ctx, _time)
 	if err != nil {
 		log.Printf("Error querying with relationships: %v", err)
 		return nil, err
 	}
-	if len(dayWithRelationships) == 0 {
-		return nil, nil
+	for i := range seasonOut {
+		if err := seasonOut[i].loadHolidays(ctx, c.stores.Seasons); err != nil {
+			return nil, err
+		}
 	}
-
-	seasonOut := convertMapsToSeasons(dayWithRelationships)
 	return seasonOut, nil
 }
 
-func GetSeason(_time time.Time) (*Season, error) {
-	//get the tee times
-	query := fmt.Sprintf(`MATCH (n:Season) 
-		MATCH (n)-[r:HAS_SETTINGS]->(x:DetailedBlockSettings)
-		WITH n, COLLECT(x{.*}) AS defaultSettings
-		WHERE date(n.endDate) >= date("%s") AND date(n.beginDate) <= date("%s")
-		RETURN n{.*, defaultSettings} as data`, _time.Format(time.DateOnly), _time.Format(time.DateOnly))
+// Season returns the season the day falls in with its holiday calendar, or nil
+func (c *Course) Season(ctx context.Context, _time time.Time) (*Season, error) {
+	season, err := c.stores.Seasons.SeasonOn(ctx, _time)
+	return loadedSeason(ctx, c.stores.Seasons, season, err)
+}
 
-	dayWithRelationships, err := db.Instance.QueryForMap(query, nil) // depth of 2
+// SeasonByID loads a season with its settings and holiday calendar
+func (c *Course) SeasonByID(ctx context.Context, id string) (*Season, error) {
+	season, err := c.stores.Seasons.Get(ctx, id)
+	return loadedSeason(ctx, c.stores.Seasons, season, err)
+}
+
+func loadedSeason(ctx context.Context, store SeasonStore, season *Season, err error) (*Season, error) {
 	if err != nil {
 		log.Printf("Error querying with relationships: %v", err)
 		return nil, err
 	}
-	if len(dayWithRelationships) == 0 {
+	if season == nil {
 		return nil, nil
 	}
-	fmt.Println("Application timezone set to:", time.Local.String())
-	seasonOut := convertMapsToSeasons(dayWithRelationships)
-	if len(seasonOut) > 0 {
-		return &seasonOut[0], nil
-	}
-	return nil, nil
-}
-
-// convertMapsToSeasons manually converts []map[string]any to []Season preserving time.Time locations
-func convertMapsToSeasons(maps []map[string]any) []Season {
-	var seasons []Season
-
-	for _, m := range maps {
-		var season Season
-
-		// Convert basic fields
-		if id, ok := m["id"].(string); ok {
-			season.ID = id
-		}
-		if year, ok := m["year"].(int64); ok {
-			season.Year = int(year)
-		}
-		if name, ok := m["name"].(string); ok {
-			season.Name = name
-		}
-		if beginDate, ok := m["beginDate"].(time.Time); ok {
-			season.BeginDate = beginDate
-		}
-		if endDate, ok := m["endDate"].(time.Time); ok {
-			season.EndDate = endDate
-		}
-		if firstTeeTime, ok := m["firstTeeTime"].(time.Time); ok {
-			season.FirstTeeTime = firstTeeTime
-		}
-		if lastTeeTime, ok := m["lastTeeTime"].(time.Time); ok {
-			season.LastTeeTime = lastTeeTime
-		}
-		if gap, ok := m["gap"].(int64); ok {
-			season.Gap = time.Duration(gap)
-		}
-		if isOpen, ok := m["isOpen"].(bool); ok {
-			season.IsOpen = isOpen
-		}
-
-		// Convert defaultSettings array
-		if defaultSettings, ok := m["defaultSettings"].([]interface{}); ok {
-			for _, setting := range defaultSettings {
-				if settingMap, ok := setting.(map[string]interface{}); ok {
-					season.DefaultSettings = append(season.DefaultSettings, mapToBlockDetails(settingMap))
-				}
-			}
-		}
-
-		seasons = append(seasons, season)
+	if err := season.loadHolidays(ctx, store); err != nil {
+		return nil, err
 	}
-
-	return seasons
+	return season, nil
 }
@@ -0,0 +1,213 @@
+package teetimes
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// MemorySeasonStore is an in-process SeasonStore for tests and local runs
+type MemorySeasonStore struct {
+	mu       sync.Mutex
+	nextID   int
+	seasons  []Season
+	holidays map[string][]HolidayDate // keyed by season id
+}
+
+func NewMemorySeasonStore() *MemorySeasonStore {
+	return &MemorySeasonStore{holidays: make(map[string][]HolidayDate)}
+}
+
+func (m *MemorySeasonStore) Save(ctx context.Context, season *Season) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if season.ID == "" {
+		season.ID = m.newID("season")
+	}
+	for i := range season.DefaultSettings {
+		m.assignSetting(&season.DefaultSettings[i])
+	}
+	for i := range season.OverideSettings {
+		m.assignSetting(&season.OverideSettings[i])
+	}
+	saved := copySeason(*season)
+	saved.Holidays = nil
+	for i := range m.seasons {
+		if m.seasons[i].ID == season.ID {
+			m.seasons[i] = saved
+			return nil
+		}
+	}
+	m.seasons = append(m.seasons, saved)
+	return nil
+}
+
+func (m *MemorySeasonStore) SaveSetting(ctx context.Context, setting *DetailedBlockSettings) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.assignSetting(setting)
+	for i := range m.seasons {
+		replaceSetting(m.seasons[i].DefaultSettings, *setting)
+		replaceSetting(m.seasons[i].OverideSettings, *setting)
+	}
+	return nil
+}
+
+func (m *MemorySeasonStore) GetSetting(ctx context.Context, id string) (*DetailedBlockSettings, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, season := range m.seasons {
+		for _, setting := range slices.Concat(season.DefaultSettings, season.OverideSettings) {
+			if setting.ID == id {
+				return &setting, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+func (m *MemorySeasonStore) AddOverride(ctx context.Context, seasonID string, setting *DetailedBlockSettings) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	season := m.find(seasonID)
+	if season == nil {
+		return fmt.Errorf("no season found for id %s", seasonID)
+	}
+	m.assignSetting(setting)
+	season.OverideSettings = append(season.OverideSettings, *setting)
+	return nil
+}
+
+func (m *MemorySeasonStore) SetOpen(ctx context.Context, seasonID string, open bool, by string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	season := m.find(seasonID)
+	if season == nil {
+		return fmt.Errorf("no season found for id %s", seasonID)
+	}
+	season.IsOpen, season.UpdatedBy = open, by
+	return nil
+}
+
+func (m *MemorySeasonStore) Seasons(ctx context.Context, from time.Time) ([]Season, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var seasons []Season
+	for _, season := range m.seasons {
+		if !dayStart(season.EndDate).Before(dayStart(from)) {
+			seasons = append(seasons, copySeason(season))
+		}
+	}
+	return seasons, nil
+}
+
+func (m *MemorySeasonStore) SeasonOn(ctx context.Context, day time.Time) (*Season, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, season := range m.seasons {
+		if !dayStart(season.EndDate).Before(dayStart(day)) && !dayStart(season.BeginDate).After(dayStart(day)) {
+			found := copySeason(season)
+			return &found, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *MemorySeasonStore) Get(ctx context.Context, id string) (*Season, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if season := m.find(id); season != nil {
+		found := copySeason(*season)
+		return &found, nil
+	}
+	return nil, nil
+}
+
+func (m *MemorySeasonStore) Holidays(ctx context.Context, seasonID string) ([]HolidayDate, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]HolidayDate(nil), m.holidays[seasonID]...), nil
+}
+
+func (m *MemorySeasonStore) SaveHoliday(ctx context.Context, seasonID string, h *HolidayDate) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if h.ID != "" {
+		for id, holidays := range m.holidays {
+			for i := range holidays {
+				if holidays[i].ID == h.ID {
+					m.holidays[id][i] = *h
+					return nil
+				}
+			}
+		}
+	} else {
+		h.ID = m.newID("holiday")
+	}
+	m.holidays[seasonID] = append(m.holidays[seasonID], *h)
+	return nil
+}
+
+func (m *MemorySeasonStore) DeleteHoliday(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for seasonID, holidays := range m.holidays {
+		for i := range holidays {
+			if holidays[i].ID == id {
+				m.holidays[seasonID] = append(holidays[:i], holidays[i+1:]...)
+				return nil
+			}
+		}
+	}
+	return ErrHolidayNotFound
+}
+
+func (m *MemorySeasonStore) find(id string) *Season {
+	for i := range m.seasons {
+		if m.seasons[i].ID == id {
+			return &m.seasons[i]
+		}
+	}
+	return nil
+}
+
+func (m *MemorySeasonStore) assignSetting(setting *DetailedBlockSettings) {
+	if setting.ID == "" {
+		setting.ID = m.newID("setting")
+	}
+}
+
+func (m *MemorySeasonStore) newID(prefix string) string {
+	m.nextID++
+	return fmt.Sprintf("%s-%d", prefix, m.nextID)
+}
+
+// copySeason copies the season's settings so callers cannot change the stored season
+func copySeason(season Season) Season {
+	season.Tees = append([]int(nil), season.Tees...)
+	season.DefaultSettings = append([]DetailedBlockSettings(nil), season.DefaultSettings...)
+	season.OverideSettings = append([]DetailedBlockSettings(nil), season.OverideSettings...)
+	season.Holidays = append([]HolidayDate(nil), season.Holidays...)
+	return season
+}
+
+func replaceSetting(settings []DetailedBlockSettings, setting DetailedBlockSettings) {
+	for i := range settings {
+		if settings[i].ID == setting.ID {
+			settings[i] = setting
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package teetimes
+
+import (
+	"bigfoot/golf/common/models/db"
+	"context"
+	"fmt"
+	"time"
+)
+
+// seasonWithSettingsQuery follows a match on (n:Season) to return the season with its settings
+const seasonWithSettingsQuery = `
+		MATCH (n)-[r:HAS_SETTINGS]->(x:DetailedBlockSettings)
+		WITH n, COLLECT(x{.*}) AS defaultSettings
+		OPTIONAL MATCH (n)-[:HAS_OVERRIDE]->(o:DetailedBlockSettings)
+		WITH n, defaultSettings, COLLECT(o{.*}) AS overideSettings`
+
+// neo4jSeasonStore keeps Season nodes linked to their DetailedBlockSettings
+// and Holiday nodes
+type neo4jSeasonStore struct {
+	conn *db.Database
+}
+
+// NewNeo4jSeasonStore returns a SeasonStore backed by the connection
+func NewNeo4jSeasonStore(conn *db.Database) SeasonStore {
+	return neo4jSeasonStore{conn: conn}
+}
+
+// Save writes the season, its settings and their relationships in one
+// transaction, so a failure part way leaves no orphaned settings
+func (s neo4jSeasonStore) Save(ctx context.Context, season *Season) error {
+	return s.conn.InTransaction(ctx, func(tx *db.Tx) error {
+		seasonID, err := tx.SaveStruct(season, "Season")
+		if err != nil {
+			return err
+		}
+		tx.OnCommit(func() { season.ID = seasonID })
+
+		for i := range season.DefaultSettings {
+			settingID, err := saveSetting(tx, &season.DefaultSettings[i])
+			if err != nil {
+				return err
+			}
+			err = tx.SaveRelationship(db.Relation{NodeN: "Season", NodeX: "DetailedBlockSettings", NodeNID: seasonID, NodeXID: settingID, Name: "HAS_SETTINGS"})
+			if err != nil {
+				return err
+			}
+		}
+		for i := range season.OverideSettings {
+			if err := addOverride(tx, seasonID, &season.OverideSettings[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s neo4jSeasonStore) SaveSetting(ctx context.Context, setting *DetailedBlockSettings) error {
+	return s.conn.InTransaction(ctx, func(tx *db.Tx) error {
+		_, err := saveSetting(tx, setting)
+		return err
+	})
+}
+
+// saveSetting writes the setting and sets its id once the transaction commits
+func saveSetting(tx *db.Tx, setting *DetailedBlockSettings) (string, error) {
+	//differentiate weekday, holiday, morning Afternoon Times
+	_id, err := tx.SaveStruct(setting, "DetailedBlockSettings")
+	if err != nil {
+		return "", err
+	}
+	if _id == "" {
+		return "", fmt.Errorf("setting %s was not saved", setting.Name)
+	}
+	tx.OnCommit(func() { setting.ID = _id })
+	return _id, nil
+}
+
+func (s neo4jSeasonStore) GetSetting(ctx context.Context, id string) (*DetailedBlockSettings, error) {
+	settings, err := db.QueryAs[DetailedBlockSettings](ctx, s.conn, `MATCH (x:DetailedBlockSettings {id: $id})
+		RETURN x{.*} as data`, map[string]any{"id": id})
+	if err != nil || len(settings) == 0 {
+		return nil, err
+	}
+	return &settings[0], nil
+}
+
+func (s neo4jSeasonStore) AddOverride(ctx context.Context, seasonID string, setting *DetailedBlockSettings) error {
+	return s.conn.InTransaction(ctx, func(tx *db.Tx) error {
+		return addOverride(tx, seasonID, setting)
+	})
+}
+
+func addOverride(tx *db.Tx, seasonID string, setting *DetailedBlockSettings) error {
+	settingID, err := saveSetting(tx, setting)
+	if err != nil {
+		return err
+	}
+	return tx.SaveRelationship(db.Relation{NodeN: "Season", NodeX: "DetailedBlockSettings", NodeNID: seasonID, NodeXID: settingID, Name: "HAS_OVERRIDE"})
+}
+
+func (s neo4jSeasonStore) SetOpen(ctx context.Context, seasonID string, open bool, by string) error {
+	updated, err := runWriteCount(ctx, s.conn, `MATCH (s:Season {id: $id})
+		SET s.isOpen = $open, s.updatedBy = $by
+		RETURN count(s)`, map[string]any{"id": seasonID, "open": open, "by": by})
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return fmt.Errorf("no season found for id %s", seasonID)
+	}
+	return nil
+}
+
+func (s neo4jSeasonStore) Seasons(ctx context.Context, from time.Time) ([]Season, error) {
+	return s.query(ctx, `MATCH (n:Season)`+seasonWithSettingsQuery+`
+		WHERE date(n.endDate) >= date($day)
+		RETURN n{.*, defaultSettings, overideSettings} as data`, map[string]any{"day": from.Format(time.DateOnly)})
+}
+
+func (s neo4jSeasonStore) SeasonOn(ctx context.Context, day time.Time) (*Season, error) {
+	return s.first(ctx, `MATCH (n:Season)`+seasonWithSettingsQuery+`
+		WHERE date(n.endDate) >= date($day) AND date(n.beginDate) <= date($day)
+		RETURN n{.*, defaultSettings, overideSettings} as data`, map[string]any{"day": day.Format(time.DateOnly)})
+}
+
+func (s neo4jSeasonStore) Get(ctx context.Context, id string) (*Season, error) {
+	return s.first(ctx, `MATCH (n:Season {id: $id})`+seasonWithSettingsQuery+`
+		RETURN n{.*, defaultSettings, overideSettings} as data`, map[string]any{"id": id})
+}
+
+func (s neo4jSeasonStore) Holidays(ctx context.Context, seasonID string) ([]HolidayDate, error) {
+	return db.QueryAs[HolidayDate](ctx, s.conn, `MATCH (s:Season {id: $seasonID})-[:HAS_HOLIDAY]->(h:Holiday)
+		RETURN h{.*} as data ORDER BY h.date`, map[string]any{"seasonID": seasonID})
+}
+
+func (s neo4jSeasonStore) SaveHoliday(ctx context.Context, seasonID string, h *HolidayDate) error {
+	return s.conn.InTransaction(ctx, func(tx *db.Tx) error {
+		_id, err := tx.SaveStruct(h, "Holiday")
+		if err != nil {
+			return err
+		}
+		tx.OnCommit(func() { h.ID = _id })
+		return tx.SaveRelationship(db.Relation{NodeN: "Season", NodeX: "Holiday", NodeNID: seasonID, NodeXID: _id, Name: "HAS_HOLIDAY"})
+	})
+}
+
+func (s neo4jSeasonStore) DeleteHoliday(ctx context.Context, id string) error {
+	deleted, err := runWriteCount(ctx, s.conn, `MATCH (h:Holiday {id: $id}) DETACH DELETE h RETURN count(*)`, map[string]any{"id": id})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrHolidayNotFound
+	}
+	return nil
+}
+
+func (s neo4jSeasonStore) query(ctx context.Context, query string, params map[string]any) ([]Season, error) {
+	return db.QueryAs[Season](ctx, s.conn, query, params)
+}
+
+func (s neo4jSeasonStore) first(ctx context.Context, query string, params map[string]any) (*Season, error) {
+	seasons, err := s.query(ctx, query, params)
+	if err != nil || len(seasons) == 0 {
+		return nil, err
+	}
+	return &seasons[0], nil
+}
@@ -0,0 +1,208 @@
+package teetimes
+
+import (
+	"bigfoot/golf/common/models/db"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const (
+	settingDefault  = "default"
+	settingOverride = "override"
+)
+
+// sqliteSeasonStore keeps seasons with their block settings and holidays in
+// their own tables, the settings ordered as the season lists them
+type sqliteSeasonStore struct {
+	conn *sql.DB
+}
+
+// NewSQLiteSeasonStore returns a SeasonStore backed by the SQLite database
+func NewSQLiteSeasonStore(conn *sql.DB) SeasonStore {
+	return sqliteSeasonStore{conn: conn}
+}
+
+func (s sqliteSeasonStore) Save(ctx context.Context, season *Season) error {
+	if season.ID == "" {
+		season.ID = db.NewID()
+	}
+	return withTx(ctx, s.conn, func(ctx context.Context, tx *sql.Tx) error {
+		stored := *season
+		stored.DefaultSettings, stored.OverideSettings, stored.Holidays = nil, nil, nil
+		data, err := toJSON(stored)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO seasons (id, begin_day, end_day, data) VALUES (?, ?, ?, ?)
+			ON CONFLICT (id) DO UPDATE SET begin_day = excluded.begin_day, end_day = excluded.end_day, data = excluded.data`,
+			season.ID, sqlDay(season.BeginDate), sqlDay(season.EndDate), data); err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM block_settings WHERE season_id = ?`, season.ID); err != nil {
+			return err
+		}
+		for i := range season.DefaultSettings {
+			if err := putSetting(ctx, tx, season.ID, settingDefault, i, &season.DefaultSettings[i]); err != nil {
+				return err
+			}
+		}
+		for i := range season.OverideSettings {
+			if err := putSetting(ctx, tx, season.ID, settingOverride, i, &season.OverideSettings[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s sqliteSeasonStore) SaveSetting(ctx context.Context, setting *DetailedBlockSettings) error {
+	if setting.ID == "" {
+		setting.ID = db.NewID()
+	}
+	data, err := toJSON(setting)
+	if err != nil {
+		return err
+	}
+	_, err = execCount(ctx, s.conn, `INSERT INTO block_settings (id, data) VALUES (?, ?)
+		ON CONFLICT (id) DO UPDATE SET data = excluded.data`, setting.ID, data)
+	return err
+}
+
+func (s sqliteSeasonStore) GetSetting(ctx context.Context, id string) (*DetailedBlockSettings, error) {
+	return firstJSON[DetailedBlockSettings](ctx, s.conn, `SELECT data FROM block_settings WHERE id = ?`, id)
+}
+
+func (s sqliteSeasonStore) AddOverride(ctx context.Context, seasonID string, setting *DetailedBlockSettings) error {
+	return withTx(ctx, s.conn, func(ctx context.Context, tx *sql.Tx) error {
+		var overrides int
+		err := tx.QueryRowContext(ctx, `SELECT count(b.id) FROM seasons s
+			LEFT JOIN block_settings b ON b.season_id = s.id AND b.kind = ?
+			WHERE s.id = ? GROUP BY s.id`, settingOverride, seasonID).Scan(&overrides)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("no season found for id %s", seasonID)
+		}
+		if err != nil {
+			return err
+		}
+		return putSetting(ctx, tx, seasonID, settingOverride, overrides, setting)
+	})
+}
+
+func (s sqliteSeasonStore) SetOpen(ctx context.Context, seasonID string, open bool, by string) error {
+	value := "false"
+	if open {
+		value = "true"
+	}
+	updated, err := execCount(ctx, s.conn, `UPDATE seasons SET data = json_set(data, '$.isOpen', json(?), '$.updatedBy', ?) WHERE id = ?`, value, by, seasonID)
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return fmt.Errorf("no season found for id %s", seasonID)
+	}
+	return nil
+}
+
+func (s sqliteSeasonStore) Seasons(ctx context.Context, from time.Time) ([]Season, error) {
+	seasons, err := queryJSON[Season](ctx, s.conn, `SELECT data FROM seasons WHERE end_day >= ? ORDER BY begin_day`, sqlDay(from))
+	if err != nil {
+		return nil, err
+	}
+	for i := range seasons {
+		if err := s.loadSettings(ctx, &seasons[i]); err != nil {
+			return nil, err
+		}
+	}
+	return seasons, nil
+}
+
+func (s sqliteSeasonStore) SeasonOn(ctx context.Context, day time.Time) (*Season, error) {
+	return s.first(ctx, `SELECT data FROM seasons WHERE end_day >= ? AND begin_day <= ? ORDER BY begin_day LIMIT 1`, sqlDay(day), sqlDay(day))
+}
+
+func (s sqliteSeasonStore) Get(ctx context.Context, id string) (*Season, error) {
+	return s.first(ctx, `SELECT data FROM seasons WHERE id = ?`, id)
+}
+
+func (s sqliteSeasonStore) Holidays(ctx context.Context, seasonID string) ([]HolidayDate, error) {
+	return queryJSON[HolidayDate](ctx, s.conn, `SELECT data FROM holidays WHERE season_id = ? ORDER BY day`, seasonID)
+}
+
+func (s sqliteSeasonStore) SaveHoliday(ctx context.Context, seasonID string, h *HolidayDate) error {
+	if h.ID == "" {
+		h.ID = db.NewID()
+	}
+	data, err := toJSON(h)
+	if err != nil {
+		return err
+	}
+	_, err = execCount(ctx, s.conn, `INSERT INTO holidays (id, season_id, day, data) VALUES (?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET day = excluded.day, data = excluded.data`, h.ID, seasonID, sqlDay(h.Date), data)
+	return err
+}
+
+func (s sqliteSeasonStore) DeleteHoliday(ctx context.Context, id string) error {
+	deleted, err := execCount(ctx, s.conn, `DELETE FROM holidays WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrHolidayNotFound
+	}
+	return nil
+}
+
+func (s sqliteSeasonStore) first(ctx context.Context, query string, args ...any) (*Season, error) {
+	season, err := firstJSON[Season](ctx, s.conn, query, args...)
+	if err != nil || season == nil {
+		return nil, err
+	}
+	return season, s.loadSettings(ctx, season)
+}
+
+// loadSettings fills the season's default and override settings in the order they were saved
+func (s sqliteSeasonStore) loadSettings(ctx context.Context, season *Season) error {
+	season.DefaultSettings, season.OverideSettings = nil, nil
+	return db.Timed(ctx, func(ctx context.Context) error {
+		rows, err := s.conn.QueryContext(ctx, `SELECT kind, data FROM block_settings WHERE season_id = ? ORDER BY kind, position`, season.ID)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var kind, data string
+			if err := rows.Scan(&kind, &data); err != nil {
+				return err
+			}
+			var setting DetailedBlockSettings
+			if err := fromJSON(data, &setting); err != nil {
+				return err
+			}
+			if kind == settingOverride {
+				season.OverideSettings = append(season.OverideSettings, setting)
+			} else {
+				season.DefaultSettings = append(season.DefaultSettings, setting)
+			}
+		}
+		return rows.Err()
+	})
+}
+
+// putSetting writes the setting as the season's default or override at the position
+func putSetting(ctx context.Context, tx *sql.Tx, seasonID, kind string, position int, setting *DetailedBlockSettings) error {
+	if setting.ID == "" {
+		setting.ID = db.NewID()
+	}
+	data, err := toJSON(setting)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `INSERT INTO block_settings (id, season_id, kind, position, data) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET season_id = excluded.season_id, kind = excluded.kind,
+			position = excluded.position, data = excluded.data`, setting.ID, seasonID, kind, position, data)
+	return err
+}
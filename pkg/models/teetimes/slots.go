@@ -0,0 +1,139 @@
+package teetimes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// MaxPlayersPerSlot is the number of golfers a single tee time can hold
+const MaxPlayersPerSlot = 4
+
+// ErrSlotUnavailable is matched by every SlotUnavailableError so callers can
+// use errors.Is without caring why the slot was rejected
+var ErrSlotUnavailable = errors.New("tee time is no longer available")
+
+// SlotUnavailableError reports a booking rejected because the slot is taken
+// or does not have room for the requested players
+type SlotUnavailableError struct {
+	TeeTime   time.Time
+	Slot      int64
+	Requested int
+	Remaining int
+}
+
+func (e *SlotUnavailableError) Error() string {
+	if e.Remaining <= 0 {
+		return fmt.Sprintf("tee time %s (slot %d) is already taken", e.TeeTime.Format("Jan 2 3:04 PM"), e.Slot)
+	}
+	return fmt.Sprintf("tee time %s (slot %d) only has %d of %d requested spots open",
+		e.TeeTime.Format("Jan 2 3:04 PM"), e.Slot, e.Remaining, e.Requested)
+}
+
+func (e *SlotUnavailableError) Is(target error) bool {
+	return target == ErrSlotUnavailable
+}
+
+// BookingStore persists reservations and slot holds, checking and claiming
+// slot capacity atomically. Booking a slot consumes the booker's own hold and
+// must also pass checkCrossover against the groups making the turn. A user
+// holds one slot at a time, so placing a hold replaces any they already have. When the
+// booking user already has a reservation with the same IdempotencyKey, BookSlot
+// books nothing and fills res with that reservation.
+type BookingStore interface {
+	ReservationStore
+	BookSlot(ctx context.Context, res *Reservation) error
+	CancelReservation(ctx context.Context, res *Reservation) error
+	PlaceHold(ctx context.Context, hold *SlotHold) error
+	ReleaseHold(ctx context.Context, userID, holdID string) error
+	ActiveHolds(ctx context.Context, day time.Time, now time.Time) ([]SlotHold, error)
+	ReleaseExpiredHolds(ctx context.Context, now time.Time) (int, error)
+	// ShiftReservations moves the bookings on the day later by the duration
+	// and slot count, as reslot plans them, returning the moved reservations
+	// and those left where they were because they no longer fit, each with
+	// their booking user
+	ShiftReservations(ctx context.Context, day time.Time, by time.Duration, slots int64, last time.Time) ([]Reservation, []Reservation, error)
+}
+
+// reslot plans moving the day's bookings later by the duration and slot
+// count, earliest first. Each booking must pass checkCapacity and
+// checkCrossover at its new slot against the bookings already placed and the
+// holds of other golfers, and still tee off by last; those that do not are
+// stranded where they were.
+func reslot(bookings []Reservation, holds []SlotHold, by time.Duration, slots int64, last, now time.Time) ([]Reservation, []Reservation) {
+	sort.SliceStable(bookings, func(i, j int) bool {
+		if !bookings[i].TeeTime.Equal(bookings[j].TeeTime) {
+			return bookings[i].TeeTime.Before(bookings[j].TeeTime)
+		}
+		return bookings[i].Slot < bookings[j].Slot
+	})
+	var moved, stranded, placed []Reservation
+	for _, res := range bookings {
+		next := res
+		next.TeeTime = res.TeeTime.Add(by)
+		next.Slot += slots
+		if next.CrossoverSlot > 0 {
+			next.CrossoverSlot += slots
+		}
+		// older bookings may carry only their players
+		next.PlayerCount = res.bookedPlayers()
+		userID := ""
+		if res.BookingUser != nil {
+			userID = res.BookingUser.ID
+		}
+
+		booked, conflicts := slotHeld(holds, next.Slot, userID, now), 0
+		if next.CrossoverSlot > 0 {
+			conflicts += slotHeld(holds, next.CrossoverSlot, userID, now)
+		}
+		for _, other := range placed {
+			if other.Slot == next.Slot {
+				booked += int(other.bookedPlayers())
+			}
+			if next.CrossoverSlot > 0 && other.Slot == next.CrossoverSlot {
+				conflicts += int(other.bookedPlayers())
+			}
+			if other.CrossoverSlot == next.Slot {
+				conflicts++
+			}
+		}
+		if next.TeeTime.After(last) || checkCapacity(&next, booked) != nil || checkCrossover(&next, conflicts) != nil {
+			stranded = append(stranded, res)
+			placed = append(placed, res)
+			continue
+		}
+		next.PlayerCount = res.PlayerCount
+		moved = append(moved, next)
+		placed = append(placed, next)
+	}
+	return moved, stranded
+}
+
+// slotHeld counts the players held in the slot by users other than userID
+func slotHeld(holds []SlotHold, slot int64, userID string, now time.Time) int {
+	var in []SlotHold
+	for _, hold := range holds {
+		if hold.Slot == slot {
+			in = append(in, hold)
+		}
+	}
+	return heldPlayers(in, userID, now)
+}
+
+// slotKey identifies a slot on a given day
+func slotKey(teeTime time.Time, slot int64) string {
+	return fmt.Sprintf("%s#%d", teeTime.Format(time.DateOnly), slot)
+}
+
+// checkCapacity returns a SlotUnavailableError if the reservation does not fit
+// in the slot given the number of players already booked or held by others
+func checkCapacity(res *Reservation, booked int) error {
+	remaining := MaxPlayersPerSlot - booked
+	requested := int(res.PlayerCount)
+	if requested <= 0 || requested > remaining {
+		return &SlotUnavailableError{TeeTime: res.TeeTime, Slot: res.Slot, Requested: requested, Remaining: remaining}
+	}
+	return nil
+}
@@ -0,0 +1,311 @@
+package teetimes
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryBookingStore is an in-process BookingStore for tests and local runs
+type MemoryBookingStore struct {
+	mu     sync.Mutex
+	nextID int
+	slots  map[string][]Reservation
+	holds  map[string][]SlotHold
+}
+
+func NewMemoryBookingStore() *MemoryBookingStore {
+	return &MemoryBookingStore{
+		slots: make(map[string][]Reservation),
+		holds: make(map[string][]SlotHold),
+	}
+}
+
+func (m *MemoryBookingStore) BookSlot(ctx context.Context, res *Reservation) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if existing, ok := m.replay(res); ok {
+		*res = existing
+		return nil
+	}
+	key := slotKey(res.TeeTime, res.Slot)
+	booked := m.bookedPlayers(key) + heldPlayers(m.holds[key], res.BookingUser.ID, time.Now())
+	if err := checkCapacity(res, booked); err != nil {
+		return err
+	}
+	if err := checkCrossover(res, m.crossoverConflicts(res)); err != nil {
+		return err
+	}
+
+	res.ID = m.newID()
+	res.UpdatedAt = time.Now()
+	m.slots[key] = append(m.slots[key], *res)
+	m.dropHolds(key, func(h SlotHold) bool { return h.UserID == res.BookingUser.ID })
+	return nil
+}
+
+func (m *MemoryBookingStore) CancelReservation(ctx context.Context, res *Reservation) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := slotKey(res.TeeTime, res.Slot)
+	for i, existing := range m.slots[key] {
+		if existing.ID == res.ID {
+			existing.Cancelled, existing.CancelledAt = true, res.CancelledAt
+			existing.UpdatedAt, existing.UpdatedBy = res.UpdatedAt, res.UpdatedBy
+			m.slots[key][i] = existing
+			return nil
+		}
+	}
+	return fmt.Errorf("reservation %s not found", res.ID)
+}
+
+func (m *MemoryBookingStore) PlaceHold(ctx context.Context, hold *SlotHold) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := slotKey(hold.TeeTime, hold.Slot)
+	held := heldPlayers(m.holds[key], hold.UserID, hold.CreatedAt)
+	if err := claimHold(hold, m.bookedPlayers(key), held); err != nil {
+		return err
+	}
+
+	for other := range m.holds {
+		m.dropHolds(other, func(h SlotHold) bool { return h.UserID == hold.UserID })
+	}
+	hold.ID = m.newID()
+	m.holds[key] = append(m.holds[key], *hold)
+	return nil
+}
+
+func (m *MemoryBookingStore) ReleaseHold(ctx context.Context, userID, holdID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for key := range m.holds {
+		if m.dropHolds(key, func(h SlotHold) bool { return h.ID == holdID && h.UserID == userID }) > 0 {
+			return nil
+		}
+	}
+	return ErrHoldNotFound
+}
+
+func (m *MemoryBookingStore) ActiveHolds(ctx context.Context, day time.Time, now time.Time) ([]SlotHold, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var active []SlotHold
+	for _, holds := range m.holds {
+		for _, hold := range holds {
+			if hold.IsActive(now) && hold.TeeTime.Format(time.DateOnly) == day.Format(time.DateOnly) {
+				active = append(active, hold)
+			}
+		}
+	}
+	return active, nil
+}
+
+func (m *MemoryBookingStore) ReleaseExpiredHolds(ctx context.Context, now time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	released := 0
+	for key := range m.holds {
+		released += m.dropHolds(key, func(h SlotHold) bool { return !h.IsActive(now) })
+	}
+	return released, nil
+}
+
+func (m *MemoryBookingStore) ShiftReservations(ctx context.Context, day time.Time, by time.Duration, slots int64, last time.Time) ([]Reservation, []Reservation, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var bookings []Reservation
+	shifted := make(map[string][]Reservation)
+	for key, reservations := range m.slots {
+		for _, res := range reservations {
+			if res.Cancelled || !sameDay(res.TeeTime, day) {
+				shifted[key] = append(shifted[key], res)
+				continue
+			}
+			bookings = append(bookings, res)
+		}
+	}
+	var holds []SlotHold
+	for _, held := range m.holds {
+		for _, hold := range held {
+			if sameDay(hold.TeeTime, day) {
+				holds = append(holds, hold)
+			}
+		}
+	}
+
+	moved, stranded := reslot(bookings, holds, by, slots, last, time.Now())
+	for i := range moved {
+		moved[i].UpdatedAt = time.Now()
+		key := slotKey(moved[i].TeeTime, moved[i].Slot)
+		shifted[key] = append(shifted[key], moved[i])
+	}
+	for _, res := range stranded {
+		key := slotKey(res.TeeTime, res.Slot)
+		shifted[key] = append(shifted[key], res)
+	}
+	m.slots = shifted
+	return moved, stranded, nil
+}
+
+// SlotReservations returns the reservations held for a slot, leaving out cancelled ones
+func (m *MemoryBookingStore) SlotReservations(teeTime time.Time, slot int64) []Reservation {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var held []Reservation
+	for _, res := range m.slots[slotKey(teeTime, slot)] {
+		if !res.Cancelled {
+			held = append(held, res)
+		}
+	}
+	return held
+}
+
+// crossoverConflicts counts the groups turning into the reservation's slot and
+// the players already booked or held in its own crossover slot
+func (m *MemoryBookingStore) crossoverConflicts(res *Reservation) int {
+	conflicts := 0
+	if res.CrossoverSlot > 0 {
+		key := slotKey(res.TeeTime, res.CrossoverSlot)
+		conflicts += m.bookedPlayers(key) + heldPlayers(m.holds[key], res.BookingUser.ID, time.Now())
+	}
+	for _, reservations := range m.slots {
+		for _, existing := range reservations {
+			if !existing.Cancelled && existing.CrossoverSlot == res.Slot && sameDay(existing.TeeTime, res.TeeTime) {
+				conflicts++
+			}
+		}
+	}
+	return conflicts
+}
+
+// replay finds the reservation booked earlier with the same idempotency key
+func (m *MemoryBookingStore) replay(res *Reservation) (Reservation, bool) {
+	if res.IdempotencyKey == "" {
+		return Reservation{}, false
+	}
+	for _, reservations := range m.slots {
+		for _, existing := range reservations {
+			if existing.IdempotencyKey == res.IdempotencyKey && existing.BookingUser != nil && existing.BookingUser.ID == res.BookingUser.ID {
+				return existing, true
+			}
+		}
+	}
+	return Reservation{}, false
+}
+
+func (m *MemoryBookingStore) bookedPlayers(key string) int {
+	booked := 0
+	for _, existing := range m.slots[key] {
+		if !existing.Cancelled {
+			booked += int(existing.PlayerCount)
+		}
+	}
+	return booked
+}
+
+// dropHolds removes the holds in a slot that match and returns how many were removed
+func (m *MemoryBookingStore) dropHolds(key string, match func(SlotHold) bool) int {
+	var kept []SlotHold
+	for _, hold := range m.holds[key] {
+		if !match(hold) {
+			kept = append(kept, hold)
+		}
+	}
+	dropped := len(m.holds[key]) - len(kept)
+	if len(kept) == 0 {
+		delete(m.holds, key)
+	} else {
+		m.holds[key] = kept
+	}
+	return dropped
+}
+
+func (m *MemoryBookingStore) newID() string {
+	m.nextID++
+	return fmt.Sprintf("mem-%d", m.nextID)
+}
+
+func (m *MemoryBookingStore) SaveReservation(ctx context.Context, res *Reservation) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if res.ID == "" {
+		res.ID = m.newID()
+	}
+	res.UpdatedAt = time.Now()
+	for key, reservations := range m.slots {
+		for i := range reservations {
+			if reservations[i].ID == res.ID {
+				m.slots[key] = append(reservations[:i], reservations[i+1:]...)
+			}
+		}
+	}
+	key := slotKey(res.TeeTime, res.Slot)
+	m.slots[key] = append(m.slots[key], *res)
+	return nil
+}
+
+func (m *MemoryBookingStore) GetReservation(ctx context.Context, id string) (*Reservation, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, reservations := range m.slots {
+		for _, res := range reservations {
+			if res.ID == id {
+				return &res, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+func (m *MemoryBookingStore) UserReservations(ctx context.Context, userID string, includePast bool, now time.Time) ([]Reservation, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	from := dayStart(now)
+	if includePast {
+		from = from.AddDate(-1, 0, 0)
+	}
+	var found []Reservation
+	for _, reservations := range m.slots {
+		for _, res := range reservations {
+			if !res.Cancelled && res.BookingUser != nil && res.BookingUser.ID == userID && !dayStart(res.TeeTime).Before(from) {
+				found = append(found, res)
+			}
+		}
+	}
+	sort.Slice(found, func(i, j int) bool {
+		if includePast {
+			return found[i].TeeTime.After(found[j].TeeTime)
+		}
+		return found[i].TeeTime.Before(found[j].TeeTime)
+	})
+	return found, nil
+}
+
+func (m *MemoryBookingStore) DayReservations(ctx context.Context, day time.Time) ([]Reservation, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var found []Reservation
+	for _, reservations := range m.slots {
+		for _, res := range reservations {
+			if !res.Cancelled && sameDay(res.TeeTime, day) {
+				found = append(found, res)
+			}
+		}
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].Slot < found[j].Slot })
+	return forTeeSheet(found), nil
+}
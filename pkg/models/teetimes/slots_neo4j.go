@@ -0,0 +1,511 @@
+package teetimes
+
+import (
+	"bigfoot/golf/common/models/account"
+	"bigfoot/golf/common/models/db"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// neo4jBookingStore books slots inside a single write transaction. The TeeSlot
+// node is merged and written first so concurrent bookings for the same slot
+// serialize on its write lock before capacity is counted.
+type neo4jBookingStore struct {
+	conn *db.Database
+}
+
+// NewNeo4jBookingStore returns a BookingStore for reservations and slot holds backed by the connection
+func NewNeo4jBookingStore(conn *db.Database) BookingStore {
+	return neo4jBookingStore{conn: conn}
+}
+
+const lockSlotQuery = `
+	MERGE (s:TeeSlot {key: $key})
+	ON CREATE SET s.day = date($teeTime), s.slot = $slot
+	SET s.lockedAt = datetime()
+	WITH s
+	OPTIONAL MATCH (r:Reservation)
+	WHERE r.day = $day AND r.slot = $slot AND coalesce(r.cancelled, false) = false
+	WITH s, coalesce(sum(coalesce(r.playerCount, $capacity)), 0) AS booked
+	OPTIONAL MATCH (h:SlotHold)-[:HOLDS]->(s)
+	WHERE h.expiresAt > $now AND h.userId <> $userID
+	RETURN booked, coalesce(sum(h.players), 0) AS held`
+
+// crossoverQuery counts the groups on the day that will take the slot at the turn
+const crossoverQuery = `
+	MATCH (r:Reservation)
+	WHERE r.day = $day AND r.crossoverSlot = $slot AND coalesce(r.cancelled, false) = false
+	RETURN count(r)`
+
+const createReservationQuery = `
+	MATCH (u:User {id: $userID})
+	MATCH (s:TeeSlot {key: $key})
+	CREATE (r:Reservation $props)
+	CREATE (u)-[b:BOOKED_TEETIME]->(r)
+	SET b.guests = $guests
+	CREATE (r)-[:IN_SLOT]->(s)
+	WITH r, s
+	OPTIONAL MATCH (h:SlotHold {userId: $userID})-[:HOLDS]->(s)
+	DETACH DELETE h
+	RETURN DISTINCT r.id`
+
+// replayQuery finds the reservation the user booked earlier with the same
+// idempotency key, through the key's unique constraint
+const replayQuery = `
+	MATCH (r:Reservation {userId: $userID, idempotencyKey: $key})
+	MATCH (u:User {id: $userID})-[b:BOOKED_TEETIME]->(r)
+	RETURN r{.*, guests: b.guests} AS data`
+
+const createHoldQuery = `
+	MATCH (s:TeeSlot {key: $key})
+	OPTIONAL MATCH (old:SlotHold {userId: $userID})
+	DETACH DELETE old
+	WITH DISTINCT s
+	CREATE (h:SlotHold $props)-[:HOLDS]->(s)
+	RETURN h.id`
+
+// lockSlot takes the slot's write lock and returns the players booked and the
+// players held by users other than userID
+func lockSlot(ctx context.Context, tx neo4j.ManagedTransaction, teeTime time.Time, slot int64, userID string) (int, int, error) {
+	lock, err := tx.Run(ctx, lockSlotQuery, map[string]any{
+		"key":      slotKey(teeTime, slot),
+		"teeTime":  teeTime,
+		"day":      reservationDay(teeTime),
+		"slot":     slot,
+		"capacity": MaxPlayersPerSlot,
+		"userID":   userID,
+		"now":      time.Now(),
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	record, err := lock.Single(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	booked, _ := record.Get("booked")
+	held, _ := record.Get("held")
+	_booked, _ := booked.(int64)
+	_held, _ := held.(int64)
+	return int(_booked), int(_held), nil
+}
+
+func (s neo4jBookingStore) BookSlot(ctx context.Context, res *Reservation) error {
+	guests, err := guestsJSON(res.Players)
+	if err != nil {
+		return err
+	}
+	res.ID = db.NewID()
+
+	var earlier []Reservation
+	_, err = s.conn.ExecuteWrite(ctx, func(ctx context.Context, tx neo4j.ManagedTransaction) (any, error) {
+		booked, held, err := lockSlot(ctx, tx, res.TeeTime, res.Slot, res.BookingUser.ID)
+		if err != nil {
+			return nil, err
+		}
+		// a retry carries the same slot, so it waits on the lock above and
+		// then finds the reservation the first attempt created
+		if res.IdempotencyKey != "" {
+			earlier, err = replayed(ctx, tx, res)
+			if err != nil || len(earlier) > 0 {
+				return nil, err
+			}
+		}
+		if err := checkCapacity(res, booked+held); err != nil {
+			return nil, err
+		}
+		conflicts, err := crossoverConflicts(ctx, tx, res)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkCrossover(res, conflicts); err != nil {
+			return nil, err
+		}
+
+		props, err := reservationProps(res)
+		if err != nil {
+			return nil, err
+		}
+		created, err := tx.Run(ctx, createReservationQuery, map[string]any{
+			"key":    slotKey(res.TeeTime, res.Slot),
+			"userID": res.BookingUser.ID,
+			"props":  props,
+			"guests": guests,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if !created.Next(ctx) {
+			if err := created.Err(); err != nil {
+				return nil, err
+			}
+			return nil, fmt.Errorf("no user found for id %s", res.BookingUser.ID)
+		}
+		return created.Record().Values[0], nil
+	})
+	if err != nil {
+		res.ID = ""
+		return err
+	}
+	if len(earlier) > 0 {
+		user := res.BookingUser
+		*res = earlier[0]
+		res.BookingUser = user
+	}
+	return nil
+}
+
+// replayed returns the reservation already booked with the reservation's idempotency key
+func replayed(ctx context.Context, tx neo4j.ManagedTransaction, res *Reservation) ([]Reservation, error) {
+	found, err := tx.Run(ctx, replayQuery, map[string]any{"userID": res.BookingUser.ID, "key": res.IdempotencyKey})
+	if err != nil {
+		return nil, err
+	}
+	var maps []map[string]any
+	for found.Next(ctx) {
+		if data, ok := found.Record().Values[0].(map[string]any); ok {
+			maps = append(maps, data)
+		}
+	}
+	if err := found.Err(); err != nil {
+		return nil, err
+	}
+	return decodeReservations(maps)
+}
+
+// crossoverConflicts locks the reservation's crossover slot, so a booking there
+// serializes with this one, and counts the players in it along with the groups
+// turning into the reservation's own slot
+func crossoverConflicts(ctx context.Context, tx neo4j.ManagedTransaction, res *Reservation) (int, error) {
+	conflicts := 0
+	if res.CrossoverSlot > 0 {
+		booked, held, err := lockSlot(ctx, tx, res.TeeTime, res.CrossoverSlot, res.BookingUser.ID)
+		if err != nil {
+			return 0, err
+		}
+		conflicts += booked + held
+	}
+	turning, err := tx.Run(ctx, crossoverQuery, map[string]any{"day": reservationDay(res.TeeTime), "slot": res.Slot})
+	if err != nil {
+		return 0, err
+	}
+	record, err := turning.Single(ctx)
+	if err != nil {
+		return 0, err
+	}
+	count, _ := record.Values[0].(int64)
+	return conflicts + int(count), nil
+}
+
+func (s neo4jBookingStore) CancelReservation(ctx context.Context, res *Reservation) error {
+	cancelled, err := runWriteCount(ctx, s.conn, `MATCH (res:Reservation {id: $id})
+		SET res.cancelled = true, res.cancelledAt = $at, res.updatedAt = $at, res.updatedBy = $by
+		RETURN count(res)`, map[string]any{"id": res.ID, "at": res.UpdatedAt, "by": res.UpdatedBy})
+	if err != nil {
+		return err
+	}
+	if cancelled == 0 {
+		return fmt.Errorf("reservation %s not found", res.ID)
+	}
+	return nil
+}
+
+func (s neo4jBookingStore) PlaceHold(ctx context.Context, hold *SlotHold) error {
+	hold.ID = db.NewID()
+	_, err := s.conn.ExecuteWrite(ctx, func(ctx context.Context, tx neo4j.ManagedTransaction) (any, error) {
+		booked, held, err := lockSlot(ctx, tx, hold.TeeTime, hold.Slot, hold.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if err := claimHold(hold, booked, held); err != nil {
+			return nil, err
+		}
+		props, err := db.Encode(hold)
+		if err != nil {
+			return nil, err
+		}
+		created, err := tx.Run(ctx, createHoldQuery, map[string]any{
+			"key":    slotKey(hold.TeeTime, hold.Slot),
+			"userID": hold.UserID,
+			"props":  props,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return created.Single(ctx)
+	})
+	if err != nil {
+		hold.ID = ""
+	}
+	return err
+}
+
+func (s neo4jBookingStore) ReleaseHold(ctx context.Context, userID, holdID string) error {
+	released, err := runWriteCount(ctx, s.conn, `MATCH (h:SlotHold {id: $id, userId: $userID})
+		DETACH DELETE h
+		RETURN count(h)`, map[string]any{"id": holdID, "userID": userID})
+	if err != nil {
+		return err
+	}
+	if released == 0 {
+		return ErrHoldNotFound
+	}
+	return nil
+}
+
+func (s neo4jBookingStore) ActiveHolds(ctx context.Context, day time.Time, now time.Time) ([]SlotHold, error) {
+	return db.QueryAs[SlotHold](ctx, s.conn, `MATCH (h:SlotHold)
+		WHERE date(h.teeTime) = date($day) AND h.expiresAt > $now
+		RETURN h{.*} as data`, map[string]any{"day": day.Format(time.DateOnly), "now": now})
+}
+
+func (s neo4jBookingStore) ReleaseExpiredHolds(ctx context.Context, now time.Time) (int, error) {
+	return runWriteCount(ctx, s.conn, `MATCH (h:SlotHold) WHERE h.expiresAt <= $now
+		DETACH DELETE h
+		RETURN count(h)`, map[string]any{"now": now})
+}
+
+// dayBookingsQuery reads the day's bookings for a shift, locking the day's
+// slots so no booking lands between the plan and the move
+const dayBookingsQuery = `
+	OPTIONAL MATCH (s:TeeSlot {day: date($day)})
+	SET s.lockedAt = datetime()
+	WITH count(s) AS locked
+	MATCH (u:User)-[b:BOOKED_TEETIME]->(r:Reservation)
+	WHERE r.day = $day AND coalesce(r.cancelled, false) = false
+	RETURN r{.*, guests: b.guests, user: u{.id, .email, .first_name, .last_name}} AS data
+	ORDER BY r.slot`
+
+// dayHoldsQuery reads the holds still active on the day
+const dayHoldsQuery = `
+	MATCH (h:SlotHold)
+	WHERE date(h.teeTime) = date($day) AND h.expiresAt > $now
+	RETURN h{.*} AS data`
+
+const shiftReservationsQuery = `
+	MATCH (u:User)-[b:BOOKED_TEETIME]->(r:Reservation)
+	WHERE r.day = $day AND r.id IN $ids AND coalesce(r.cancelled, false) = false
+	OPTIONAL MATCH (r)-[old:IN_SLOT]->(:TeeSlot)
+	DELETE old
+	WITH DISTINCT u, b, r
+	SET r.teeTime = r.teeTime + duration({minutes: $minutes}), r.slot = r.slot + $slots, r.updatedAt = datetime(),
+		r.crossoverSlot = CASE WHEN coalesce(r.crossoverSlot, 0) > 0 THEN r.crossoverSlot + $slots ELSE r.crossoverSlot END
+	SET r.day = toString(date(r.teeTime))
+	MERGE (s:TeeSlot {key: r.day + '#' + toString(r.slot)})
+	ON CREATE SET s.day = date(r.teeTime), s.slot = r.slot
+	SET s.lockedAt = datetime()
+	MERGE (r)-[:IN_SLOT]->(s)
+	RETURN r{.*, guests: b.guests, user: u{.id, .email, .first_name, .last_name}} AS data
+	ORDER BY r.slot`
+
+func (s neo4jBookingStore) ShiftReservations(ctx context.Context, day time.Time, by time.Duration, slots int64, last time.Time) ([]Reservation, []Reservation, error) {
+	var stranded []Reservation
+	result, err := s.conn.ExecuteWrite(ctx, func(ctx context.Context, tx neo4j.ManagedTransaction) (any, error) {
+		now := time.Now()
+		maps, err := collectData(ctx, tx, dayBookingsQuery, map[string]any{"day": reservationDay(day)})
+		if err != nil {
+			return nil, err
+		}
+		bookings, err := decodeReservations(maps)
+		if err != nil {
+			return nil, err
+		}
+		holdMaps, err := collectData(ctx, tx, dayHoldsQuery, map[string]any{"day": reservationDay(day), "now": now})
+		if err != nil {
+			return nil, err
+		}
+		holds, err := db.DecodeAll[SlotHold](holdMaps)
+		if err != nil {
+			return nil, err
+		}
+
+		var moved []Reservation
+		moved, stranded = reslot(bookings, holds, by, slots, last, now)
+		ids := make([]string, len(moved))
+		for i := range moved {
+			ids[i] = moved[i].ID
+		}
+		return collectData(ctx, tx, shiftReservationsQuery, map[string]any{
+			"day":     reservationDay(day),
+			"ids":     ids,
+			"minutes": int64(by.Minutes()),
+			"slots":   slots,
+		})
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	maps, _ := result.([]map[string]any)
+	moved, err := decodeReservations(maps)
+	if err != nil {
+		return nil, nil, err
+	}
+	return moved, stranded, nil
+}
+
+// collectData runs a query returning one map per row
+func collectData(ctx context.Context, tx neo4j.ManagedTransaction, query string, params map[string]any) ([]map[string]any, error) {
+	res, err := tx.Run(ctx, query, params)
+	if err != nil {
+		return nil, err
+	}
+	var maps []map[string]any
+	for res.Next(ctx) {
+		if data, ok := res.Record().Values[0].(map[string]any); ok {
+			maps = append(maps, data)
+		}
+	}
+	return maps, res.Err()
+}
+
+// runWriteCount runs a write query that returns a single count
+func runWriteCount(ctx context.Context, conn *db.Database, query string, params map[string]any) (int, error) {
+	result, err := conn.ExecuteWrite(ctx, func(ctx context.Context, tx neo4j.ManagedTransaction) (any, error) {
+		res, err := tx.Run(ctx, query, params)
+		if err != nil {
+			return nil, err
+		}
+		record, err := res.Single(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return record.Values[0], nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	count, _ := result.(int64)
+	return int(count), nil
+}
+
+// reservationDay is the indexed day property reservations are matched on, the
+// tee time's date as slotKey writes it
+func reservationDay(teeTime time.Time) string {
+	return teeTime.Format(time.DateOnly)
+}
+
+// reservationProps returns the stored properties of a newly booked reservation
+func reservationProps(res *Reservation) (map[string]any, error) {
+	props, err := db.Encode(res)
+	if err != nil {
+		return nil, err
+	}
+	props["tee"] = res.StartingTee()
+	props["holes"] = res.RoundHoles()
+	props["day"] = reservationDay(res.TeeTime)
+	props["cancelled"] = false
+	props["updatedAt"] = time.Now()
+	// idempotency keys are unique per booking user
+	if res.BookingUser != nil {
+		props[db.IdempotencyScope] = res.BookingUser.ID
+	}
+	return props, nil
+}
+
+// guestsJSON encodes the players without an account as the guests relationship property
+func guestsJSON(players []account.User) (any, error) {
+	var guests []Guest
+	for _, guest := range players {
+		if guest.ID == "" {
+			guests = append(guests, Guest{Name: guest.LastName, Email: guest.Email, Phone: guest.Phone})
+		}
+	}
+	if len(guests) == 0 {
+		return nil, nil
+	}
+	_g, err := json.Marshal(guests)
+	if err != nil {
+		return nil, err
+	}
+	return string(_g), nil
+}
+
+// SaveReservation writes the reservation and its BOOKED_TEETIME relationship
+// in one transaction
+func (s neo4jBookingStore) SaveReservation(ctx context.Context, res *Reservation) error {
+	_rel := db.Relation{NodeN: "User", NodeX: "Reservation", NodeNID: res.BookingUser.ID, Name: "BOOKED_TEETIME"}
+	//TODO BUILD ADDING EXISTING USER FUNCTIONALITY
+	guests, err := guestsJSON(res.Players)
+	if err != nil {
+		return err
+	}
+	if guests != nil {
+		_rel.Property = "guests"
+		_rel.Body = guests.(string)
+	}
+
+	props, err := db.Encode(res)
+	if err != nil {
+		return err
+	}
+	props["day"] = reservationDay(res.TeeTime)
+	props[db.IdempotencyScope] = res.BookingUser.ID
+
+	return s.conn.InTransaction(ctx, func(tx *db.Tx) error {
+		_id, err := tx.SaveProperties(props, "Reservation")
+		if err != nil {
+			return err
+		}
+		tx.OnCommit(func() { res.ID = _id })
+		rel := _rel
+		rel.NodeXID = _id
+		return tx.SaveRelationship(rel)
+	})
+}
+
+func (s neo4jBookingStore) GetReservation(ctx context.Context, id string) (*Reservation, error) {
+	reservationMaps, err := s.conn.QueryForMap(ctx, `MATCH (u:User)-[r:BOOKED_TEETIME]->(res:Reservation {id: $id})
+			WITH res, r.guests as guests, u{.id} as user
+			RETURN res{.*, guests, user} as data`, map[string]any{"id": id})
+	if err != nil {
+		return nil, err
+	}
+	reservations, err := decodeReservations(reservationMaps)
+	if err != nil || len(reservations) == 0 {
+		return nil, err
+	}
+	return &reservations[0], nil
+}
+
+func (s neo4jBookingStore) UserReservations(ctx context.Context, userID string, includePast bool, now time.Time) ([]Reservation, error) {
+	query := `MATCH (u:User {id: $userID})-[r:BOOKED_TEETIME]->(res:Reservation)
+			WHERE date(res.teeTime) >= date($from) AND coalesce(res.cancelled, false) = false
+			WITH res, r.guests as guests
+			RETURN res{.*, guests} as data
+			ORDER BY res.teeTime ASC`
+	from := now
+	if includePast {
+		query = `MATCH (u:User {id: $userID})-[r:BOOKED_TEETIME]->(res:Reservation)
+			WHERE date(res.teeTime) >= date($from) AND coalesce(res.cancelled, false) = false
+			WITH res, r.guests as guests
+			RETURN res{.*, guests} as data
+			ORDER BY res.teeTime DESC`
+		from = now.AddDate(-1, 0, 0)
+	}
+
+	reservationMaps, err := s.conn.QueryForMap(ctx, query, map[string]any{"userID": userID, "from": from.Format(time.DateOnly)})
+	if err != nil {
+		return nil, err
+	}
+	return decodeReservations(reservationMaps)
+}
+
+func (s neo4jBookingStore) DayReservations(ctx context.Context, day time.Time) ([]Reservation, error) {
+	dayWithRelationships, err := s.conn.QueryForMap(ctx, `MATCH (n:Reservation) WHERE n.day = $day AND coalesce(n.cancelled, false) = false
+		MATCH (u:User)-[r:BOOKED_TEETIME]->(n)
+		WITH n, u {.id, .first_name, .last_name} as user, COLLECT(u {.id, .first_name, .last_name}) as players
+		RETURN n{.* , user, players} as data`, map[string]any{"day": reservationDay(day)}) // depth of 2
+	if err != nil {
+		return nil, err
+	}
+	reservations, err := decodeReservations(dayWithRelationships)
+	if err != nil {
+		return nil, err
+	}
+	return forTeeSheet(reservations), nil
+}
@@ -0,0 +1,274 @@
+package teetimes
+
+import (
+	"bigfoot/golf/common/models/account"
+	"bigfoot/golf/common/models/db"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// sqliteBookingStore books slots inside a transaction on the store's single
+// connection, so capacity is counted and claimed without another write between.
+// Reservations keep their booking user's id and read the user back from users.
+type sqliteBookingStore struct {
+	conn *sql.DB
+}
+
+// NewSQLiteBookingStore returns a BookingStore for reservations and slot holds backed by the SQLite database
+func NewSQLiteBookingStore(conn *sql.DB) BookingStore {
+	return sqliteBookingStore{conn: conn}
+}
+
+// reservationColumns selects a reservation with its booking user
+const reservationColumns = `SELECT r.user_id, r.data, coalesce(u.data, '') FROM reservations r LEFT JOIN users u ON u.id = r.user_id`
+
+// slotPlayers returns the players booked into the slot and the players held
+// there by users other than userID
+func slotPlayers(ctx context.Context, q sqlRunner, teeTime time.Time, slot int64, userID string, now time.Time) (int, int, error) {
+	var booked, held int
+	err := q.QueryRowContext(ctx, `SELECT coalesce(sum(player_count), 0) FROM reservations
+		WHERE day = ? AND slot = ? AND cancelled = 0`, sqlDay(teeTime), slot).Scan(&booked)
+	if err != nil {
+		return 0, 0, err
+	}
+	err = q.QueryRowContext(ctx, `SELECT coalesce(sum(players), 0) FROM slot_holds
+		WHERE day = ? AND slot = ? AND expires_at > ? AND user_id <> ?`, sqlDay(teeTime), slot, now.UnixNano(), userID).Scan(&held)
+	return booked, held, err
+}
+
+func (s sqliteBookingStore) BookSlot(ctx context.Context, res *Reservation) error {
+	now := time.Now()
+	replayed := false
+	err := withTx(ctx, s.conn, func(ctx context.Context, tx *sql.Tx) error {
+		if res.IdempotencyKey != "" {
+			earlier, err := queryReservations(ctx, tx, reservationColumns+` WHERE r.user_id = ? AND r.idempotency_key = ?`,
+				res.BookingUser.ID, res.IdempotencyKey)
+			if err != nil {
+				return err
+			}
+			if len(earlier) > 0 {
+				*res = earlier[0]
+				replayed = true
+				return nil
+			}
+		}
+		booked, held, err := slotPlayers(ctx, tx, res.TeeTime, res.Slot, res.BookingUser.ID, now)
+		if err != nil {
+			return err
+		}
+		if err := checkCapacity(res, booked+held); err != nil {
+			return err
+		}
+		conflicts := 0
+		if res.CrossoverSlot > 0 {
+			booked, held, err := slotPlayers(ctx, tx, res.TeeTime, res.CrossoverSlot, res.BookingUser.ID, now)
+			if err != nil {
+				return err
+			}
+			conflicts += booked + held
+		}
+		var turning int
+		if err := tx.QueryRowContext(ctx, `SELECT count(*) FROM reservations WHERE day = ? AND crossover_slot = ? AND cancelled = 0`,
+			sqlDay(res.TeeTime), res.Slot).Scan(&turning); err != nil {
+			return err
+		}
+		if err := checkCrossover(res, conflicts+turning); err != nil {
+			return err
+		}
+
+		res.ID = db.NewID()
+		res.UpdatedAt = now
+		if err := putReservation(ctx, tx, res); err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `DELETE FROM slot_holds WHERE day = ? AND slot = ? AND user_id = ?`,
+			sqlDay(res.TeeTime), res.Slot, res.BookingUser.ID)
+		return err
+	})
+	if err != nil && !replayed {
+		res.ID = ""
+	}
+	return err
+}
+
+func (s sqliteBookingStore) CancelReservation(ctx context.Context, res *Reservation) error {
+	at := res.UpdatedAt.Format(time.RFC3339Nano)
+	cancelled, err := execCount(ctx, s.conn, `UPDATE reservations SET cancelled = 1,
+		data = json_set(data, '$.cancelled', json('true'), '$.cancelledAt', ?, '$.updatedAt', ?, '$.updatedBy', ?)
+		WHERE id = ?`, at, at, res.UpdatedBy, res.ID)
+	if err != nil {
+		return err
+	}
+	if cancelled == 0 {
+		return fmt.Errorf("reservation %s not found", res.ID)
+	}
+	return nil
+}
+
+func (s sqliteBookingStore) PlaceHold(ctx context.Context, hold *SlotHold) error {
+	hold.ID = db.NewID()
+	err := withTx(ctx, s.conn, func(ctx context.Context, tx *sql.Tx) error {
+		booked, held, err := slotPlayers(ctx, tx, hold.TeeTime, hold.Slot, hold.UserID, hold.CreatedAt)
+		if err != nil {
+			return err
+		}
+		if err := claimHold(hold, booked, held); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM slot_holds WHERE user_id = ?`, hold.UserID); err != nil {
+			return err
+		}
+		data, err := toJSON(hold)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `INSERT INTO slot_holds (id, user_id, day, slot, players, expires_at, data) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			hold.ID, hold.UserID, sqlDay(hold.TeeTime), hold.Slot, hold.Players, hold.ExpiresAt.UnixNano(), data)
+		return err
+	})
+	if err != nil {
+		hold.ID = ""
+	}
+	return err
+}
+
+func (s sqliteBookingStore) ReleaseHold(ctx context.Context, userID, holdID string) error {
+	released, err := execCount(ctx, s.conn, `DELETE FROM slot_holds WHERE id = ? AND user_id = ?`, holdID, userID)
+	if err != nil {
+		return err
+	}
+	if released == 0 {
+		return ErrHoldNotFound
+	}
+	return nil
+}
+
+func (s sqliteBookingStore) ActiveHolds(ctx context.Context, day time.Time, now time.Time) ([]SlotHold, error) {
+	return queryJSON[SlotHold](ctx, s.conn, `SELECT data FROM slot_holds WHERE day = ? AND expires_at > ?`, sqlDay(day), now.UnixNano())
+}
+
+func (s sqliteBookingStore) ReleaseExpiredHolds(ctx context.Context, now time.Time) (int, error) {
+	return execCount(ctx, s.conn, `DELETE FROM slot_holds WHERE expires_at <= ?`, now.UnixNano())
+}
+
+func (s sqliteBookingStore) ShiftReservations(ctx context.Context, day time.Time, by time.Duration, slots int64, last time.Time) ([]Reservation, []Reservation, error) {
+	var moved, stranded []Reservation
+	err := withTx(ctx, s.conn, func(ctx context.Context, tx *sql.Tx) error {
+		now := time.Now()
+		bookings, err := queryReservations(ctx, tx, reservationColumns+` WHERE r.day = ? AND r.cancelled = 0 ORDER BY r.slot`, sqlDay(day))
+		if err != nil {
+			return err
+		}
+		holds, err := queryJSON[SlotHold](ctx, tx, `SELECT data FROM slot_holds WHERE day = ? AND expires_at > ?`, sqlDay(day), now.UnixNano())
+		if err != nil {
+			return err
+		}
+		moved, stranded = reslot(bookings, holds, by, slots, last, now)
+		for i := range moved {
+			moved[i].UpdatedAt = now
+			if err := putReservation(ctx, tx, &moved[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return moved, stranded, nil
+}
+
+func (s sqliteBookingStore) SaveReservation(ctx context.Context, res *Reservation) error {
+	if res.ID == "" {
+		res.ID = db.NewID()
+	}
+	res.UpdatedAt = time.Now()
+	return putReservation(ctx, s.conn, res)
+}
+
+func (s sqliteBookingStore) GetReservation(ctx context.Context, id string) (*Reservation, error) {
+	found, err := queryReservations(ctx, s.conn, reservationColumns+` WHERE r.id = ?`, id)
+	if err != nil || len(found) == 0 {
+		return nil, err
+	}
+	return &found[0], nil
+}
+
+func (s sqliteBookingStore) UserReservations(ctx context.Context, userID string, includePast bool, now time.Time) ([]Reservation, error) {
+	query := reservationColumns + ` WHERE r.user_id = ? AND r.day >= ? AND r.cancelled = 0 ORDER BY r.tee_time ASC`
+	from := now
+	if includePast {
+		query = reservationColumns + ` WHERE r.user_id = ? AND r.day >= ? AND r.cancelled = 0 ORDER BY r.tee_time DESC`
+		from = now.AddDate(-1, 0, 0)
+	}
+	return queryReservations(ctx, s.conn, query, userID, sqlDay(from))
+}
+
+func (s sqliteBookingStore) DayReservations(ctx context.Context, day time.Time) ([]Reservation, error) {
+	reservations, err := queryReservations(ctx, s.conn, reservationColumns+` WHERE r.day = ? AND r.cancelled = 0 ORDER BY r.slot`, sqlDay(day))
+	if err != nil {
+		return nil, err
+	}
+	return forTeeSheet(reservations), nil
+}
+
+// putReservation writes the reservation's row, keeping only its booking user's id
+func putReservation(ctx context.Context, q sqlRunner, res *Reservation) error {
+	userID := ""
+	stored := *res
+	if res.BookingUser != nil {
+		userID = res.BookingUser.ID
+		stored.BookingUser = nil
+	}
+	data, err := toJSON(stored)
+	if err != nil {
+		return err
+	}
+	var key any
+	if res.IdempotencyKey != "" {
+		key = res.IdempotencyKey
+	}
+	_, err = q.ExecContext(ctx, `INSERT INTO reservations (id, user_id, day, tee_time, slot, crossover_slot, player_count, idempotency_key, cancelled, data)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET user_id = excluded.user_id, day = excluded.day, tee_time = excluded.tee_time,
+			slot = excluded.slot, crossover_slot = excluded.crossover_slot, player_count = excluded.player_count,
+			idempotency_key = excluded.idempotency_key, cancelled = excluded.cancelled, data = excluded.data`,
+		res.ID, userID, sqlDay(res.TeeTime), res.TeeTime.UnixNano(), res.Slot, res.CrossoverSlot, res.PlayerCount, key, res.Cancelled, data)
+	return err
+}
+
+// queryReservations decodes rows selected with reservationColumns, filling
+// each booking user without their password, or with just the id when the
+// user is not stored
+func queryReservations(ctx context.Context, q sqlRunner, query string, args ...any) ([]Reservation, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var found []Reservation
+	for rows.Next() {
+		var userID, data, user string
+		if err := rows.Scan(&userID, &data, &user); err != nil {
+			return nil, err
+		}
+		var res Reservation
+		if err := fromJSON(data, &res); err != nil {
+			return nil, err
+		}
+		if user != "" {
+			res.BookingUser = &account.User{}
+			if err := fromJSON(user, res.BookingUser); err != nil {
+				return nil, err
+			}
+			res.BookingUser.Password = ""
+		} else if userID != "" {
+			res.BookingUser = &account.User{ID: userID}
+		}
+		found = append(found, res)
+	}
+	return found, rows.Err()
+}
@@ -0,0 +1,293 @@
+package teetimes
+
+import (
+	"bigfoot/golf/common/models/account"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBookTeeTimeConcurrentSlot(t *testing.T) {
+	ctx := context.Background()
+	teeTime := time.Date(2025, time.June, 14, 8, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		bookings    int
+		players     int
+		wantBooked  int
+		wantPlayers int
+	}{
+		{name: "foursomes race for one slot", bookings: 25, players: 4, wantBooked: 1, wantPlayers: 4},
+		{name: "singles fill the slot", bookings: 25, players: 1, wantBooked: 4, wantPlayers: 4},
+		{name: "threesomes leave one spot", bookings: 25, players: 3, wantBooked: 1, wantPlayers: 3},
+		{name: "pairs fill the slot", bookings: 25, players: 2, wantBooked: 2, wantPlayers: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stores := useMemoryStores(t)
+			store := stores.bookings
+
+			var wg sync.WaitGroup
+			var mu sync.Mutex
+			booked, rejected := 0, 0
+			start := make(chan struct{})
+
+			for i := 0; i < tt.bookings; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					user := account.User{ID: "user", LastName: "Booker"}
+					res := Reservation{TeeTime: teeTime, Slot: 7, BookingUser: &user, Players: []account.User{user}}
+					for p := 1; p < tt.players; p++ {
+						res.Players = append(res.Players, account.User{LastName: "Guest"})
+					}
+					<-start
+					err := stores.course.BookTeeTime(ctx, &res)
+
+					mu.Lock()
+					defer mu.Unlock()
+					switch {
+					case err == nil:
+						booked++
+					case errors.Is(err, ErrSlotUnavailable):
+						rejected++
+					default:
+						t.Errorf("unexpected error: %v", err)
+					}
+				}(i)
+			}
+			close(start)
+			wg.Wait()
+
+			if booked != tt.wantBooked {
+				t.Errorf("expected %d bookings to succeed, got %d", tt.wantBooked, booked)
+			}
+			if booked+rejected != tt.bookings {
+				t.Errorf("expected %d results, got %d", tt.bookings, booked+rejected)
+			}
+
+			players := 0
+			for _, res := range store.SlotReservations(teeTime, 7) {
+				players += int(res.PlayerCount)
+			}
+			if players != tt.wantPlayers {
+				t.Errorf("expected %d players in slot, got %d", tt.wantPlayers, players)
+			}
+		})
+	}
+}
+
+func TestBookTeeTimeSeparateSlots(t *testing.T) {
+	ctx := context.Background()
+	c := useMemoryStores(t).course
+
+	user := account.User{ID: "user"}
+	day := time.Date(2025, time.June, 14, 8, 30, 0, 0, time.UTC)
+	for _, tm := range []time.Time{day, day.AddDate(0, 0, 1)} {
+		res := Reservation{TeeTime: tm, Slot: 3, BookingUser: &user, Players: make([]account.User, MaxPlayersPerSlot)}
+		if err := c.BookTeeTime(ctx, &res); err != nil {
+			t.Fatalf("expected booking on %s to succeed: %v", tm.Format(time.DateOnly), err)
+		}
+	}
+
+	res := Reservation{TeeTime: day, Slot: 3, BookingUser: &user}
+	err := c.BookTeeTime(ctx, &res)
+	var slotErr *SlotUnavailableError
+	if !errors.As(err, &slotErr) {
+		t.Fatalf("expected SlotUnavailableError, got %v", err)
+	}
+	if slotErr.Remaining != 0 || slotErr.Requested != 1 {
+		t.Errorf("unexpected error details: %+v", slotErr)
+	}
+}
+
+func TestSlotHolds(t *testing.T) {
+	ctx := context.Background()
+	c := useMemoryStores(t).course
+
+	first := seasonAround(t, c)
+	teeTime := first.Add(10 * time.Minute)
+	holder := account.User{ID: "holder"}
+	other := account.User{ID: "other"}
+
+	tests := []struct {
+		name    string
+		hold    SlotHold
+		wantErr error
+	}{
+		{"refuses a hold without players", SlotHold{TeeTime: teeTime, Slot: 2}, ErrHoldPlayers},
+		{"refuses more players than a slot holds", SlotHold{TeeTime: teeTime, Slot: 2, Players: MaxPlayersPerSlot + 1}, ErrHoldPlayers},
+		{"refuses a slot the tee sheet does not lay out", SlotHold{TeeTime: teeTime, Slot: 999, Players: 1}, ErrSlotUnavailable},
+		{"refuses a slot laid out at another time", SlotHold{TeeTime: teeTime, Slot: 5, Players: 1}, ErrSlotUnavailable},
+		{"refuses a tee time already gone", SlotHold{TeeTime: first.AddDate(0, 0, -8), Slot: 2, Players: 1}, ErrSlotUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.hold.UserID = holder.ID
+			if err := c.PlaceHold(ctx, &tt.hold); !errors.Is(err, tt.wantErr) {
+				t.Errorf("expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	// a golfer holds one slot at a time, so holding another gives the first back
+	if err := c.PlaceHold(ctx, &SlotHold{UserID: holder.ID, TeeTime: first, Slot: 1, Players: MaxPlayersPerSlot}); err != nil {
+		t.Fatalf("expected hold to succeed: %v", err)
+	}
+	hold := SlotHold{UserID: holder.ID, TeeTime: teeTime, Slot: 2, Players: MaxPlayersPerSlot}
+	if err := c.PlaceHold(ctx, &hold); err != nil {
+		t.Fatalf("expected hold to succeed: %v", err)
+	}
+	if holds, _ := c.DayHolds(ctx, teeTime); len(holds) != 1 || holds[0].Slot != 2 {
+		t.Fatalf("expected only the latest hold kept, got %+v", holds)
+	}
+
+	res := Reservation{TeeTime: teeTime, Slot: 2, BookingUser: &other}
+	if err := c.BookTeeTime(ctx, &res); !errors.Is(err, ErrSlotUnavailable) {
+		t.Fatalf("expected held slot to reject other golfers, got %v", err)
+	}
+	if err := c.PlaceHold(ctx, &SlotHold{UserID: other.ID, TeeTime: teeTime, Slot: 2, Players: 1}); !errors.Is(err, ErrSlotUnavailable) {
+		t.Fatalf("expected second hold to be rejected, got %v", err)
+	}
+
+	holds, _ := c.DayHolds(ctx, teeTime)
+	day := ReservedDay{Times: []Reservation{{Slot: 1}, {Slot: 2}}}
+	day.MarkHeld(holds)
+	if day.Times[0].Held || !day.Times[1].Held {
+		t.Errorf("expected only slot 2 to be marked held: %+v", day.Times)
+	}
+
+	res = Reservation{TeeTime: teeTime, Slot: 2, BookingUser: &holder, Players: []account.User{holder, {LastName: "Guest"}}}
+	if err := c.BookTeeTime(ctx, &res); err != nil {
+		t.Fatalf("expected holder to book their held slot: %v", err)
+	}
+	if holds, _ := c.DayHolds(ctx, teeTime); len(holds) != 0 {
+		t.Errorf("expected booking to consume the hold, %d remain", len(holds))
+	}
+
+	res = Reservation{TeeTime: teeTime, Slot: 2, BookingUser: &other, Players: []account.User{other, {LastName: "Guest"}}}
+	if err := c.BookTeeTime(ctx, &res); err != nil {
+		t.Fatalf("expected remaining spots to open after booking: %v", err)
+	}
+}
+
+func TestReleaseExpiredHolds(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryBookingStore()
+	teeTime := time.Date(2025, time.June, 14, 9, 0, 0, 0, time.UTC)
+	now := time.Now()
+
+	expired := SlotHold{UserID: "a", TeeTime: teeTime, Slot: 1, CreatedAt: now.Add(-2 * HoldTTL), ExpiresAt: now.Add(-HoldTTL)}
+	active := SlotHold{UserID: "b", TeeTime: teeTime, Slot: 2, CreatedAt: now, ExpiresAt: now.Add(HoldTTL)}
+	for _, hold := range []*SlotHold{&expired, &active} {
+		if err := store.PlaceHold(ctx, hold); err != nil {
+			t.Fatalf("unexpected hold error: %v", err)
+		}
+	}
+
+	released, err := store.ReleaseExpiredHolds(ctx, now)
+	if err != nil || released != 1 {
+		t.Fatalf("expected 1 expired hold released, got %d (%v)", released, err)
+	}
+	if err := store.ReleaseHold(ctx, "a", active.ID); !errors.Is(err, ErrHoldNotFound) {
+		t.Errorf("expected another user's hold to be protected, got %v", err)
+	}
+	if err := store.ReleaseHold(ctx, "b", active.ID); err != nil {
+		t.Errorf("expected owner to release hold: %v", err)
+	}
+}
+
+func TestBookTeeTimeIgnoresClientState(t *testing.T) {
+	teeTime := time.Date(2025, time.June, 14, 9, 0, 0, 0, time.UTC)
+	user := account.User{ID: "user"}
+	tests := []struct {
+		name string
+		use  func(t *testing.T) *Course
+	}{
+		{"memory", func(t *testing.T) *Course { return useMemoryStores(t).course }},
+		{"sqlite", func(t *testing.T) *Course { c, _ := useSQLiteStores(t); return c }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := tt.use(t)
+			ctx := context.Background()
+
+			// a booking posted as cancelled still takes its spots
+			cancelledAt := time.Now()
+			res := Reservation{ID: "forged", Cancelled: true, CancelledAt: &cancelledAt, TeeTime: teeTime, Slot: 1,
+				BookingUser: &user, Players: make([]account.User, MaxPlayersPerSlot)}
+			if err := c.BookTeeTime(ctx, &res); err != nil {
+				t.Fatal(err)
+			}
+			if res.ID == "forged" || res.Cancelled || res.CancelledAt != nil {
+				t.Errorf("expected the store to assign a fresh, active reservation, got %+v", res)
+			}
+			next := Reservation{TeeTime: teeTime, Slot: 1, BookingUser: &user}
+			if err := c.BookTeeTime(ctx, &next); !errors.Is(err, ErrSlotUnavailable) {
+				t.Errorf("expected the slot full, got %v", err)
+			}
+		})
+	}
+}
+
+func TestReslot(t *testing.T) {
+	day := time.Date(2026, time.June, 10, 0, 0, 0, 0, time.UTC)
+	now := day.Add(6 * time.Hour)
+	last := day.Add(9 * time.Hour)
+	booking := func(id string, slot int64, players int64) Reservation {
+		return Reservation{ID: id, TeeTime: day.Add(7*time.Hour + time.Duration(slot-1)*10*time.Minute), Slot: slot,
+			PlayerCount: players, BookingUser: &account.User{ID: id}}
+	}
+	hold := func(userID string, slot int64, players int64) SlotHold {
+		return SlotHold{UserID: userID, Slot: slot, Players: players, ExpiresAt: now.Add(time.Minute)}
+	}
+	turning := booking("turning", 1, 2)
+	turning.CrossoverSlot = 10003
+	tenth := booking("tenth", 10003, 2)
+	tenth.TeeTime = day.Add(7*time.Hour + 20*time.Minute)
+
+	tests := []struct {
+		name         string
+		bookings     []Reservation
+		holds        []SlotHold
+		wantMoved    []string
+		wantStranded []string
+	}{
+		{"moves bookings into free slots", []Reservation{booking("a", 1, 4), booking("b", 2, 2)}, nil, []string{"a", "b"}, nil},
+		{"strands a booking without room beside a hold", []Reservation{booking("a", 1, 3)}, []SlotHold{hold("walkin", 3, 2)}, nil, []string{"a"}},
+		{"ignores the golfer's own hold", []Reservation{booking("a", 1, 3)}, []SlotHold{hold("a", 3, 2)}, []string{"a"}, nil},
+		{"ignores a lapsed hold", []Reservation{booking("a", 1, 3)}, []SlotHold{{UserID: "walkin", Slot: 3, Players: 2, ExpiresAt: now}}, []string{"a"}, nil},
+		{"strands a booking pushed past the last tee time", []Reservation{booking("a", 1, 2), booking("late", 12, 2)}, nil, []string{"a"}, []string{"late"}},
+		{"strands a booking whose crossover slot is held", []Reservation{turning}, []SlotHold{hold("walkin", 10005, 1)}, nil, []string{"turning"}},
+		{"strands a tenth tee booking a group turns into", []Reservation{turning, tenth}, nil, []string{"turning"}, []string{"tenth"}},
+		{"moves an older booking counted by its players", []Reservation{{ID: "old", TeeTime: day.Add(7 * time.Hour), Slot: 1, Players: make([]account.User, 2)}}, nil, []string{"old"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			moved, stranded := reslot(tt.bookings, tt.holds, 20*time.Minute, 2, last, now)
+			ids := func(reservations []Reservation) []string {
+				var found []string
+				for _, res := range reservations {
+					found = append(found, res.ID)
+				}
+				return found
+			}
+			if got := ids(moved); fmt.Sprint(got) != fmt.Sprint(tt.wantMoved) {
+				t.Errorf("expected %v moved, got %v", tt.wantMoved, got)
+			}
+			if got := ids(stranded); fmt.Sprint(got) != fmt.Sprint(tt.wantStranded) {
+				t.Errorf("expected %v stranded, got %v", tt.wantStranded, got)
+			}
+			for _, res := range moved {
+				if res.Slot < 3 || res.TeeTime.Before(day.Add(7*time.Hour+20*time.Minute)) {
+					t.Errorf("expected %s two slots later, got slot %d at %s", res.ID, res.Slot, res.TeeTime.Format("15:04"))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package teetimes
+
+import (
+	"bigfoot/golf/common/models/db"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// sqlRunner is satisfied by both *sql.DB and *sql.Tx so the SQLite stores can
+// share queries between plain reads and their transactions
+type sqlRunner interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// sqlDay is the day column for a time, read in the time's own zone
+func sqlDay(t time.Time) string {
+	return t.Format(time.DateOnly)
+}
+
+// withTx runs fn in a transaction bounded by the query timeout, committing
+// when it returns nil. fn gets the bounded context for its statements.
+func withTx(ctx context.Context, conn *sql.DB, fn func(ctx context.Context, tx *sql.Tx) error) error {
+	return db.Timed(ctx, func(ctx context.Context) error {
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		if err := fn(ctx, tx); err != nil {
+			return err
+		}
+		return tx.Commit()
+	})
+}
+
+// queryJSON decodes the json data column returned by each row. The rows are
+// read and closed before it returns, which the single connection pool needs
+// before the next query can run.
+func queryJSON[T any](ctx context.Context, q sqlRunner, query string, args ...any) ([]T, error) {
+	var found []T
+	err := db.Timed(ctx, func(ctx context.Context) error {
+		rows, err := q.QueryContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var data string
+			if err := rows.Scan(&data); err != nil {
+				return err
+			}
+			var v T
+			if err := json.Unmarshal([]byte(data), &v); err != nil {
+				return err
+			}
+			found = append(found, v)
+		}
+		return rows.Err()
+	})
+	return found, err
+}
+
+// firstJSON returns the first decoded row, or nil when there are none
+func firstJSON[T any](ctx context.Context, q sqlRunner, query string, args ...any) (*T, error) {
+	found, err := queryJSON[T](ctx, q, query, args...)
+	if err != nil || len(found) == 0 {
+		return nil, err
+	}
+	return &found[0], nil
+}
+
+// execCount runs a write and returns the rows it changed
+func execCount(ctx context.Context, q sqlRunner, query string, args ...any) (int, error) {
+	var changed int64
+	err := db.Timed(ctx, func(ctx context.Context) error {
+		result, err := q.ExecContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		changed, err = result.RowsAffected()
+		return err
+	})
+	return int(changed), err
+}
+
+func toJSON(v any) (string, error) {
+	data, err := json.Marshal(v)
+	return string(data), err
+}
+
+func fromJSON(data string, v any) error {
+	return json.Unmarshal([]byte(data), v)
+}
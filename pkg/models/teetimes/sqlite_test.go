@@ -0,0 +1,314 @@
+package teetimes
+
+import (
+	"bigfoot/golf/common/models/account"
+	"bigfoot/golf/common/models/audit"
+	"bigfoot/golf/common/models/db"
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// useSQLiteStores builds a course over stores backed by a fresh SQLite file,
+// recording audit entries there until the test ends
+func useSQLiteStores(t *testing.T) (*Course, *sql.DB) {
+	t.Helper()
+	conn, err := db.OpenSQLite(context.Background(), filepath.Join(t.TempDir(), "golf.db"))
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	audit.SetStore(audit.NewSQLiteStore(conn))
+	t.Cleanup(func() {
+		audit.SetStore(audit.NewMemoryStore())
+		conn.Close()
+	})
+	return NewCourse(Stores{
+		Seasons:   NewSQLiteSeasonStore(conn),
+		Bookings:  NewSQLiteBookingStore(conn),
+		Waitlist:  NewSQLiteWaitlistStore(conn),
+		Outings:   NewSQLiteOutingStore(conn),
+		Overrides: NewSQLiteOverrideStore(conn),
+		Standing:  NewSQLiteStandingStore(conn),
+	}), conn
+}
+
+func TestSQLiteBookTeeTimeConcurrentSlot(t *testing.T) {
+	ctx := context.Background()
+	teeTime := time.Date(2025, time.June, 14, 8, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		players     int
+		wantBooked  int
+		wantPlayers int
+	}{
+		{name: "foursomes race for one slot", players: 4, wantBooked: 1, wantPlayers: 4},
+		{name: "singles fill the slot", players: 1, wantBooked: 4, wantPlayers: 4},
+		{name: "pairs fill the slot", players: 2, wantBooked: 2, wantPlayers: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := useSQLiteStores(t)
+
+			var wg sync.WaitGroup
+			var mu sync.Mutex
+			booked := 0
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					user := account.User{ID: "user"}
+					res := Reservation{TeeTime: teeTime, Slot: 7, BookingUser: &user, Players: make([]account.User, tt.players)}
+					err := c.BookTeeTime(ctx, &res)
+
+					mu.Lock()
+					defer mu.Unlock()
+					switch {
+					case err == nil:
+						booked++
+					case !errors.Is(err, ErrSlotUnavailable):
+						t.Errorf("unexpected error: %v", err)
+					}
+				}()
+			}
+			wg.Wait()
+
+			if booked != tt.wantBooked {
+				t.Errorf("expected %d bookings to succeed, got %d", tt.wantBooked, booked)
+			}
+			day, err := c.stores.Bookings.DayReservations(ctx, teeTime)
+			if err != nil {
+				t.Fatal(err)
+			}
+			players := 0
+			for _, res := range day {
+				players += int(res.PlayerCount)
+			}
+			if players != tt.wantPlayers {
+				t.Errorf("expected %d players in slot, got %d", tt.wantPlayers, players)
+			}
+		})
+	}
+}
+
+func TestSQLiteBookingStore(t *testing.T) {
+	ctx := context.Background()
+	c, conn := useSQLiteStores(t)
+	users := account.NewSQLiteUserStore(conn)
+	holder := account.User{Email: "holder@example.com", FirstName: "Hal", Password: "secret"}
+	if err := users.Save(ctx, &holder); err != nil {
+		t.Fatal(err)
+	}
+	other := account.User{ID: "other"}
+	teeTime := seasonAround(t, c).Add(10 * time.Minute)
+
+	earlier := SlotHold{UserID: holder.ID, TeeTime: teeTime.Add(-10 * time.Minute), Slot: 1, Players: 1}
+	if err := c.PlaceHold(ctx, &earlier); err != nil {
+		t.Fatalf("expected hold to succeed: %v", err)
+	}
+	hold := SlotHold{UserID: holder.ID, TeeTime: teeTime, Slot: 2, Players: MaxPlayersPerSlot}
+	if err := c.PlaceHold(ctx, &hold); err != nil {
+		t.Fatalf("expected hold to succeed: %v", err)
+	}
+	if holds, _ := c.DayHolds(ctx, teeTime); len(holds) != 1 || holds[0].ID != hold.ID {
+		t.Fatalf("expected the new hold to replace the holder's earlier one, got %+v", holds)
+	}
+	res := Reservation{TeeTime: teeTime, Slot: 2, BookingUser: &other}
+	if err := c.BookTeeTime(ctx, &res); !errors.Is(err, ErrSlotUnavailable) {
+		t.Fatalf("expected held slot to reject other golfers, got %v", err)
+	}
+
+	res = Reservation{TeeTime: teeTime, Slot: 2, BookingUser: &holder, Players: []account.User{holder, {LastName: "Guest"}}}
+	if err := c.BookTeeTime(ctx, &res); err != nil {
+		t.Fatalf("expected holder to book their held slot: %v", err)
+	}
+	if holds, _ := c.DayHolds(ctx, teeTime); len(holds) != 0 {
+		t.Errorf("expected booking to consume the hold, %d remain", len(holds))
+	}
+
+	day, err := c.stores.Bookings.DayReservations(ctx, teeTime)
+	if err != nil || len(day) != 1 {
+		t.Fatalf("expected one reservation on the day, got %d: %v", len(day), err)
+	}
+	if u := day[0].BookingUser; u == nil || u.ID != holder.ID || u.FirstName != holder.FirstName || u.Email != "" || u.Password != "" {
+		t.Errorf("expected the booking user's name without their email or password, got %+v", day[0].BookingUser)
+	}
+
+	moved, stranded, err := c.stores.Bookings.ShiftReservations(ctx, teeTime, 30*time.Minute, 3, teeTime.Add(time.Hour))
+	if err != nil || len(moved) != 1 || len(stranded) != 0 {
+		t.Fatalf("expected one reservation moved, got %d and %d stranded: %v", len(moved), len(stranded), err)
+	}
+	mine, err := c.stores.Bookings.UserReservations(ctx, holder.ID, true, teeTime)
+	if err != nil || len(mine) != 1 {
+		t.Fatalf("expected the holder's reservation, got %d: %v", len(mine), err)
+	}
+	if mine[0].Slot != 5 || !mine[0].TeeTime.Equal(teeTime.Add(30*time.Minute)) {
+		t.Errorf("expected the reservation in slot 5 at 7:40, got slot %d at %s", mine[0].Slot, mine[0].TeeTime)
+	}
+
+	if err := c.cancelReservation(ctx, &mine[0]); err != nil {
+		t.Fatal(err)
+	}
+	if mine, err := c.stores.Bookings.UserReservations(ctx, holder.ID, true, teeTime); err != nil || len(mine) != 0 {
+		t.Errorf("expected the cancelled reservation left out of the holder's, got %d: %v", len(mine), err)
+	}
+	if day, err := c.stores.Bookings.DayReservations(ctx, teeTime); err != nil || len(day) != 0 {
+		t.Errorf("expected the cancelled reservation left out of the day, got %d: %v", len(day), err)
+	}
+	res = Reservation{TeeTime: mine[0].TeeTime, Slot: 5, BookingUser: &other, Players: make([]account.User, MaxPlayersPerSlot)}
+	if err := c.BookTeeTime(ctx, &res); err != nil {
+		t.Errorf("expected the cancelled reservation to free its slot: %v", err)
+	}
+}
+
+func TestSQLiteSeasonStore(t *testing.T) {
+	ctx := context.Background()
+	c, _ := useSQLiteStores(t)
+
+	season := Season{
+		Name:      "Summer",
+		BeginDate: time.Date(2025, time.May, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2025, time.September, 30, 0, 0, 0, 0, time.UTC),
+		DefaultSettings: []DetailedBlockSettings{
+			{Name: "Weekday Morning", Price: 40},
+			{Name: "Weekday Afternoon", Price: 30},
+		},
+	}
+	if err := c.stores.Seasons.Save(ctx, &season); err != nil {
+		t.Fatal(err)
+	}
+	override := DetailedBlockSettings{Name: "Member Day", Price: 20}
+	if err := c.stores.Seasons.AddOverride(ctx, season.ID, &override); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.stores.Seasons.AddOverride(ctx, "missing", &DetailedBlockSettings{}); err == nil {
+		t.Error("expected an override on a missing season to fail")
+	}
+	if err := c.stores.Seasons.SetOpen(ctx, season.ID, true, "admin"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		day   time.Time
+		found bool
+	}{
+		{name: "first day", day: season.BeginDate, found: true},
+		{name: "mid season", day: time.Date(2025, time.July, 4, 15, 0, 0, 0, time.UTC), found: true},
+		{name: "last day", day: season.EndDate, found: true},
+		{name: "before", day: season.BeginDate.AddDate(0, 0, -1)},
+		{name: "after", day: season.EndDate.AddDate(0, 0, 1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.stores.Seasons.SeasonOn(ctx, tt.day)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (got != nil) != tt.found {
+				t.Fatalf("expected found %v, got %+v", tt.found, got)
+			}
+			if got == nil {
+				return
+			}
+			if !got.IsOpen {
+				t.Error("expected the season to be open")
+			}
+			if len(got.DefaultSettings) != 2 || got.DefaultSettings[0].Name != "Weekday Morning" || got.DefaultSettings[1].ID == "" {
+				t.Errorf("expected the default settings in order, got %+v", got.DefaultSettings)
+			}
+			if len(got.OverideSettings) != 1 || got.OverideSettings[0].ID != override.ID {
+				t.Errorf("expected the override, got %+v", got.OverideSettings)
+			}
+		})
+	}
+
+	holiday := HolidayDate{Name: "Independence Day", Date: time.Date(2025, time.July, 4, 0, 0, 0, 0, time.UTC)}
+	if err := c.stores.Seasons.SaveHoliday(ctx, season.ID, &holiday); err != nil {
+		t.Fatal(err)
+	}
+	if holidays, _ := c.stores.Seasons.Holidays(ctx, season.ID); len(holidays) != 1 || holidays[0].Name != holiday.Name {
+		t.Errorf("expected the saved holiday, got %+v", holidays)
+	}
+	if err := c.stores.Seasons.DeleteHoliday(ctx, holiday.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.stores.Seasons.DeleteHoliday(ctx, holiday.ID); !errors.Is(err, ErrHolidayNotFound) {
+		t.Errorf("expected ErrHolidayNotFound, got %v", err)
+	}
+}
+
+func TestSQLiteStandingClaimOnce(t *testing.T) {
+	ctx := context.Background()
+	c, _ := useSQLiteStores(t)
+
+	standing := StandingReservation{UserID: "user", Weekday: time.Saturday, Time: "08:00", Players: 4}
+	if err := c.stores.Standing.Create(ctx, &standing); err != nil {
+		t.Fatal(err)
+	}
+	claims := 0
+	for i := 0; i < 3; i++ {
+		ok, err := c.stores.Standing.ClaimOccurrence(ctx, &StandingOccurrence{StandingID: standing.ID, Date: "2025-06-14", Status: OccurrencePending})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok {
+			claims++
+		}
+	}
+	if claims != 1 {
+		t.Errorf("expected the date to be claimed once, got %d", claims)
+	}
+	if err := c.stores.Standing.Delete(ctx, standing.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := c.stores.Standing.Occurrences(ctx, standing.ID, time.Time{}); len(got) != 0 {
+		t.Errorf("expected the pending occurrence removed with its standing reservation, got %+v", got)
+	}
+}
+
+func TestSQLiteBookingIdempotencyKey(t *testing.T) {
+	ctx := context.Background()
+	c, _ := useSQLiteStores(t)
+	teeTime := time.Date(2025, time.June, 14, 10, 0, 0, 0, time.UTC)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	ids := make(map[string]bool)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			user := account.User{ID: "user"}
+			res := Reservation{TeeTime: teeTime, Slot: 3, BookingUser: &user, IdempotencyKey: "retry"}
+			err := c.BookTeeTime(ctx, &res)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				t.Errorf("expected every retry to succeed: %v", err)
+			}
+			ids[res.ID] = true
+		}()
+	}
+	wg.Wait()
+
+	if len(ids) != 1 {
+		t.Errorf("expected the retries to share one reservation, got %d", len(ids))
+	}
+	day, err := c.stores.Bookings.DayReservations(ctx, teeTime)
+	if err != nil || len(day) != 1 || day[0].IdempotencyKey != "retry" {
+		t.Fatalf("expected one reservation keeping its key, got %+v: %v", day, err)
+	}
+
+	other := account.User{ID: "other"}
+	res := Reservation{TeeTime: teeTime, Slot: 3, BookingUser: &other, IdempotencyKey: "retry"}
+	if err := c.BookTeeTime(ctx, &res); err != nil || ids[res.ID] {
+		t.Errorf("expected another golfer's key to book separately, got %s: %v", res.ID, err)
+	}
+}
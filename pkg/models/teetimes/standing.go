@@ -0,0 +1,381 @@
+package teetimes
+
+import (
+	"bigfoot/golf/common/models/account"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+)
+
+// DefaultStandingLeadDays is how far ahead standing tee times are booked
+// when the standing reservation does not set its own lead
+const DefaultStandingLeadDays = 14
+
+// MaxStandingLeadDays caps how far ahead a standing reservation may book
+const MaxStandingLeadDays = 60
+
+// Standing occurrence statuses
+const (
+	OccurrencePending  = "pending"  // claimed, booking in progress
+	OccurrenceBooked   = "booked"   // a reservation was made
+	OccurrenceConflict = "conflict" // the tee time could not be booked, the owner was told why
+)
+
+var (
+	ErrStandingNotFound = errors.New("standing reservation not found")
+	ErrNotStandingOwner = errors.New("only the owner can change a standing reservation")
+)
+
+// StandingReservation books the same tee time every week for a regular group
+type StandingReservation struct {
+	ID        string       `json:"id,omitempty"`
+	UserID    string       `json:"userId"`
+	Name      string       `json:"name"`
+	Weekday   time.Weekday `json:"weekday"`
+	Time      string       `json:"time"` // 15:04 in the course's time zone
+	Tee       int          `json:"tee,omitempty"`
+	Holes     int          `json:"holes,omitempty"`
+	Players   int64        `json:"players"`
+	StartDate time.Time    `json:"startDate"`
+	EndDate   time.Time    `json:"endDate,omitempty" neo4j:",omitempty"` // zero runs until removed
+	SkipDates []string     `json:"skipDates,omitempty"`                  // 2006-01-02
+	LeadDays  int          `json:"leadDays,omitempty"`
+	CreatedAt time.Time    `json:"createdAt"`
+	UpdatedAt time.Time    `json:"updatedAt"`
+	// Upcoming lists what happened to the dates already booked ahead
+	Upcoming []StandingOccurrence `json:"upcoming,omitempty"`
+}
+
+// StandingOccurrence records the outcome of one date of a standing reservation
+// so each date is only attempted once
+type StandingOccurrence struct {
+	ID            string    `json:"id,omitempty"`
+	StandingID    string    `json:"standingId"`
+	UserID        string    `json:"userId"`
+	Date          string    `json:"date"` // 2006-01-02
+	TeeTime       time.Time `json:"teeTime"`
+	Status        string    `json:"status"`
+	ReservationID string    `json:"reservationId,omitempty"`
+	Reason        string    `json:"reason,omitempty"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+// StandingStore persists standing reservations and their occurrences.
+// ClaimOccurrence must be atomic so a date is never booked twice.
+type StandingStore interface {
+	Create(ctx context.Context, standing *StandingReservation) error
+	Update(ctx context.Context, standing *StandingReservation) error
+	Get(ctx context.Context, id string) (*StandingReservation, error)
+	Delete(ctx context.Context, id string) error
+	UserStanding(ctx context.Context, userID string) ([]StandingReservation, error)
+	// Active returns the standing reservations that have not ended by the day
+	Active(ctx context.Context, day time.Time) ([]StandingReservation, error)
+	// ClaimOccurrence records a pending occurrence, returning false when the date was already claimed
+	ClaimOccurrence(ctx context.Context, occurrence *StandingOccurrence) (bool, error)
+	UpdateOccurrence(ctx context.Context, occurrence *StandingOccurrence) error
+	// Occurrences returns the standing reservation's occurrences on or after the day in date order
+	Occurrences(ctx context.Context, standingID string, from time.Time) ([]StandingOccurrence, error)
+}
+
+var standingNotifier = func(ctx context.Context, standing StandingReservation, occurrence StandingOccurrence) {
+	log.Printf("Standing reservation %s could not book %s: %s", standing.ID, occurrence.Date, occurrence.Reason)
+}
+
+// SetStandingNotifier sets the function used to tell an owner a standing tee time could not be booked
+func SetStandingNotifier(notify func(ctx context.Context, standing StandingReservation, occurrence StandingOccurrence)) {
+	standingNotifier = notify
+}
+
+func (s *StandingReservation) validate() error {
+	if s.UserID == "" {
+		return fmt.Errorf("no user found")
+	}
+	if s.Weekday < time.Sunday || s.Weekday > time.Saturday {
+		return fmt.Errorf("weekday must be 0 (Sunday) through 6 (Saturday)")
+	}
+	if _, err := time.Parse("15:04", s.Time); err != nil {
+		return fmt.Errorf("time %q must be HH:MM", s.Time)
+	}
+	if s.Players < 1 || s.Players > MaxPlayersPerSlot {
+		return fmt.Errorf("standing tee times need between 1 and %d players", MaxPlayersPerSlot)
+	}
+	if s.Holes != 0 && s.Holes != NineHoles && s.Holes != EighteenHoles {
+		return fmt.Errorf("rounds are %d or %d holes", NineHoles, EighteenHoles)
+	}
+	if s.Tee != 0 && s.Tee != FirstTee && s.Tee != TenthTee {
+		return fmt.Errorf("starting tee must be %d or %d", FirstTee, TenthTee)
+	}
+	if s.StartDate.IsZero() {
+		return fmt.Errorf("standing tee times need a start date")
+	}
+	if !s.EndDate.IsZero() && s.EndDate.Before(s.StartDate) {
+		return fmt.Errorf("standing tee times must end after they start")
+	}
+	if s.LeadDays < 0 || s.LeadDays > MaxStandingLeadDays {
+		return fmt.Errorf("standing tee times book at most %d days ahead", MaxStandingLeadDays)
+	}
+	for _, skip := range s.SkipDates {
+		if _, err := time.Parse(time.DateOnly, skip); err != nil {
+			return fmt.Errorf("skip date %q must be YYYY-MM-DD", skip)
+		}
+	}
+	return nil
+}
+
+// Lead is how many days ahead the standing reservation books
+func (s *StandingReservation) Lead() int {
+	if s.LeadDays == 0 {
+		return DefaultStandingLeadDays
+	}
+	return s.LeadDays
+}
+
+// Skips reports whether the owner skipped the day
+func (s *StandingReservation) Skips(day time.Time) bool {
+	return slices.Contains(s.SkipDates, day.Format(time.DateOnly))
+}
+
+// Dates returns the days from through to, inclusive, the rule books a tee time on
+func (s *StandingReservation) Dates(from, to time.Time) []time.Time {
+	start := dayStart(from)
+	if begin := dayStart(s.StartDate); start.Before(begin) {
+		start = begin
+	}
+	start = start.AddDate(0, 0, (int(s.Weekday)-int(start.Weekday())+7)%7)
+
+	var dates []time.Time
+	for day := start; !day.After(to); day = day.AddDate(0, 0, 7) {
+		if !s.EndDate.IsZero() && day.After(s.EndDate) {
+			break
+		}
+		if !s.Skips(day) {
+			dates = append(dates, day)
+		}
+	}
+	return dates
+}
+
+// TeeTimeOn puts the standing tee time on the day in the course's time zone
+func (s *StandingReservation) TeeTimeOn(day time.Time) time.Time {
+	clock, _ := time.Parse("15:04", s.Time)
+	return time.Date(day.Year(), day.Month(), day.Day(), clock.Hour(), clock.Minute(), 0, 0, courseLocation())
+}
+
+// CreateStanding saves a new standing reservation and books its first weeks
+func (c *Course) CreateStanding(ctx context.Context, standing *StandingReservation, now time.Time) error {
+	standing.StartDate = dayStart(standing.StartDate)
+	if err := standing.validate(); err != nil {
+		return err
+	}
+	standing.CreatedAt = now
+	standing.UpdatedAt = now
+	if err := c.stores.Standing.Create(ctx, standing); err != nil {
+		return err
+	}
+	c.materializeStanding(ctx, *standing, now)
+	return nil
+}
+
+// UserStanding lists the user's standing reservations with their upcoming dates
+func (c *Course) UserStanding(ctx context.Context, userID string, now time.Time) ([]StandingReservation, error) {
+	standing, err := c.stores.Standing.UserStanding(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range standing {
+		standing[i].Upcoming, err = c.stores.Standing.Occurrences(ctx, standing[i].ID, dayStart(now))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return standing, nil
+}
+
+// SkipStandingDate stops the standing reservation booking the date. A tee
+// time already booked for the date stays on the bookings page to cancel.
+func (c *Course) SkipStandingDate(ctx context.Context, userID, standingID string, day time.Time) (*StandingReservation, error) {
+	standing, err := c.ownedStanding(ctx, userID, standingID)
+	if err != nil {
+		return nil, err
+	}
+	if !standing.Skips(day) {
+		standing.SkipDates = append(standing.SkipDates, day.Format(time.DateOnly))
+	}
+	standing.UpdatedAt = time.Now()
+	return standing, c.stores.Standing.Update(ctx, standing)
+}
+
+// DeleteStanding stops a standing reservation. Tee times it already booked are kept.
+func (c *Course) DeleteStanding(ctx context.Context, userID, standingID string) error {
+	if _, err := c.ownedStanding(ctx, userID, standingID); err != nil {
+		return err
+	}
+	return c.stores.Standing.Delete(ctx, standingID)
+}
+
+func (c *Course) ownedStanding(ctx context.Context, userID, standingID string) (*StandingReservation, error) {
+	standing, err := c.stores.Standing.Get(ctx, standingID)
+	if err != nil {
+		return nil, err
+	}
+	if standing == nil {
+		return nil, ErrStandingNotFound
+	}
+	if standing.UserID != userID {
+		return nil, ErrNotStandingOwner
+	}
+	return standing, nil
+}
+
+// MaterializeStanding books every active standing reservation's dates inside
+// its lead window that have not been attempted yet
+func (c *Course) MaterializeStanding(ctx context.Context, now time.Time) ([]StandingOccurrence, error) {
+	active, err := c.stores.Standing.Active(ctx, dayStart(now))
+	if err != nil {
+		return nil, err
+	}
+	var occurrences []StandingOccurrence
+	for _, standing := range active {
+		occurrences = append(occurrences, c.materializeStanding(ctx, standing, now)...)
+	}
+	return occurrences, nil
+}
+
+func (c *Course) materializeStanding(ctx context.Context, standing StandingReservation, now time.Time) []StandingOccurrence {
+	var occurrences []StandingOccurrence
+	for _, day := range standing.Dates(now, dayStart(now).AddDate(0, 0, standing.Lead())) {
+		teeTime := standing.TeeTimeOn(day)
+		if !teeTime.After(now) {
+			continue
+		}
+		occurrence := StandingOccurrence{
+			StandingID: standing.ID,
+			UserID:     standing.UserID,
+			Date:       day.Format(time.DateOnly),
+			TeeTime:    teeTime,
+			Status:     OccurrencePending,
+			CreatedAt:  now,
+		}
+		claimed, err := c.stores.Standing.ClaimOccurrence(ctx, &occurrence)
+		if err != nil {
+			log.Printf("Error claiming standing reservation %s on %s: %v", standing.ID, occurrence.Date, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		season, days, err := c.loadSheet(ctx, teeTime)
+		if err != nil {
+			log.Printf("Error loading the tee sheet for %s: %v", occurrence.Date, err)
+			occurrence.Status = OccurrenceConflict
+			occurrence.Reason = "the tee sheet could not be loaded"
+		} else {
+			c.bookOccurrence(ctx, standing, &occurrence, season, days, now)
+		}
+		if err := c.stores.Standing.UpdateOccurrence(ctx, &occurrence); err != nil {
+			log.Printf("Error saving standing reservation %s on %s: %v", standing.ID, occurrence.Date, err)
+		}
+		if occurrence.Status == OccurrenceConflict {
+			standingNotifier(ctx, standing, occurrence)
+		}
+		occurrences = append(occurrences, occurrence)
+	}
+	return occurrences
+}
+
+// loadSheet loads the season and tee sheet for the day
+func (c *Course) loadSheet(ctx context.Context, day time.Time) (*Season, []ReservedDay, error) {
+	days, err := c.DayTeeTimes(ctx, day)
+	if err != nil {
+		return nil, nil, err
+	}
+	season, err := c.Season(ctx, day)
+	if err != nil {
+		return nil, nil, err
+	}
+	return season, days, nil
+}
+
+// bookOccurrence books the standing tee time on a loaded tee sheet, recording
+// why on the occurrence when it cannot
+func (c *Course) bookOccurrence(ctx context.Context, standing StandingReservation, occurrence *StandingOccurrence, season *Season, days []ReservedDay, now time.Time) {
+	conflict := func(reason string) {
+		occurrence.Status = OccurrenceConflict
+		occurrence.Reason = reason
+	}
+	if season == nil || len(days) == 0 {
+		conflict("there is no season on that day")
+		return
+	}
+	if !season.IsOpen {
+		conflict(ErrCourseClosed.Error())
+		return
+	}
+
+	tee := standing.Tee
+	if tee == 0 {
+		tee = FirstTee
+	}
+	var slot *Reservation
+	for i := range days[0].Times {
+		res := &days[0].Times[i]
+		if res.TeeTime.Equal(occurrence.TeeTime) && res.StartingTee() == tee {
+			slot = res
+			break
+		}
+	}
+	if slot == nil {
+		conflict(fmt.Sprintf("the %s tee time is closed, blocked or outside course hours", occurrence.TeeTime.Format("3:04 PM")))
+		return
+	}
+
+	owner := account.User{ID: standing.UserID}
+	res := Reservation{
+		TeeTime:     occurrence.TeeTime,
+		Slot:        slot.Slot,
+		Holes:       standing.Holes,
+		BookingUser: &owner,
+		Players:     []account.User{owner},
+	}
+	for i := int64(1); i < standing.Players; i++ {
+		res.Players = append(res.Players, account.User{LastName: fmt.Sprintf("Guest %d", i)})
+	}
+	if _, err := priceOnDay(&res, *season, days[0], now); err != nil {
+		conflict(err.Error())
+		return
+	}
+	if err := c.BookTeeTime(ctx, &res); err != nil {
+		conflict(err.Error())
+		return
+	}
+	occurrence.Status = OccurrenceBooked
+	occurrence.ReservationID = res.ID
+	occurrence.Reason = ""
+}
+
+// StartStandingBooker books standing tee times as they come inside their lead
+// window, once at start and then every interval until ctx is done
+func (c *Course) StartStandingBooker(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		now := time.Now()
+		for {
+			occurrences, err := c.MaterializeStanding(ctx, now)
+			if err != nil {
+				log.Printf("Error booking standing tee times: %v", err)
+			} else if len(occurrences) > 0 {
+				log.Printf("Attempted %d standing tee times", len(occurrences))
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case now = <-ticker.C:
+			}
+		}
+	}()
+}
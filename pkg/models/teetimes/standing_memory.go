@@ -0,0 +1,138 @@
+package teetimes
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryStandingStore is an in-process StandingStore for tests and local runs
+type MemoryStandingStore struct {
+	mu          sync.Mutex
+	nextID      int
+	standing    []StandingReservation
+	occurrences map[string]StandingOccurrence // keyed by standing id and date
+}
+
+func NewMemoryStandingStore() *MemoryStandingStore {
+	return &MemoryStandingStore{occurrences: make(map[string]StandingOccurrence)}
+}
+
+func (m *MemoryStandingStore) Create(ctx context.Context, standing *StandingReservation) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	standing.ID = m.newID("standing")
+	m.standing = append(m.standing, *standing)
+	return nil
+}
+
+func (m *MemoryStandingStore) Update(ctx context.Context, standing *StandingReservation) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.standing {
+		if m.standing[i].ID == standing.ID {
+			m.standing[i] = *standing
+			return nil
+		}
+	}
+	return ErrStandingNotFound
+}
+
+func (m *MemoryStandingStore) Get(ctx context.Context, id string) (*StandingReservation, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, standing := range m.standing {
+		if standing.ID == id {
+			return &standing, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *MemoryStandingStore) Delete(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, standing := range m.standing {
+		if standing.ID == id {
+			m.standing = append(m.standing[:i], m.standing[i+1:]...)
+			return nil
+		}
+	}
+	return ErrStandingNotFound
+}
+
+func (m *MemoryStandingStore) UserStanding(ctx context.Context, userID string) ([]StandingReservation, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var standing []StandingReservation
+	for _, s := range m.standing {
+		if s.UserID == userID {
+			standing = append(standing, s)
+		}
+	}
+	return standing, nil
+}
+
+func (m *MemoryStandingStore) Active(ctx context.Context, day time.Time) ([]StandingReservation, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var active []StandingReservation
+	for _, s := range m.standing {
+		if s.EndDate.IsZero() || !s.EndDate.Before(day) {
+			active = append(active, s)
+		}
+	}
+	return active, nil
+}
+
+func (m *MemoryStandingStore) ClaimOccurrence(ctx context.Context, occurrence *StandingOccurrence) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := occurrence.StandingID + "#" + occurrence.Date
+	if _, ok := m.occurrences[key]; ok {
+		return false, nil
+	}
+	occurrence.ID = m.newID("occurrence")
+	m.occurrences[key] = *occurrence
+	return true, nil
+}
+
+func (m *MemoryStandingStore) UpdateOccurrence(ctx context.Context, occurrence *StandingOccurrence) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := occurrence.StandingID + "#" + occurrence.Date
+	if _, ok := m.occurrences[key]; !ok {
+		return fmt.Errorf("occurrence %s not found", key)
+	}
+	m.occurrences[key] = *occurrence
+	return nil
+}
+
+func (m *MemoryStandingStore) Occurrences(ctx context.Context, standingID string, from time.Time) ([]StandingOccurrence, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var occurrences []StandingOccurrence
+	for _, o := range m.occurrences {
+		if o.StandingID == standingID && o.Date >= from.Format(time.DateOnly) {
+			occurrences = append(occurrences, o)
+		}
+	}
+	sort.Slice(occurrences, func(i, j int) bool { return occurrences[i].Date < occurrences[j].Date })
+	return occurrences, nil
+}
+
+func (m *MemoryStandingStore) newID(prefix string) string {
+	m.nextID++
+	return fmt.Sprintf("%s-%d", prefix, m.nextID)
+}
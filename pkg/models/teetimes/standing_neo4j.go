@@ -0,0 +1,139 @@
+package teetimes
+
+import (
+	"bigfoot/golf/common/models/db"
+	"context"
+	"time"
+)
+
+// neo4jStandingStore keeps StandingReservation nodes linked to their owner and
+// a StandingOccurrence node per date, unique on the standing id and date
+type neo4jStandingStore struct {
+	conn *db.Database
+}
+
+// NewNeo4jStandingStore returns a StandingStore for standing reservations backed by the connection
+func NewNeo4jStandingStore(conn *db.Database) StandingStore {
+	return neo4jStandingStore{conn: conn}
+}
+
+func (s neo4jStandingStore) Create(ctx context.Context, standing *StandingReservation) error {
+	standing.ID = db.NewID()
+	props, err := db.Encode(standing)
+	if err != nil {
+		standing.ID = ""
+		return err
+	}
+	created, err := runWriteCount(ctx, s.conn, `MATCH (u:User {id: $userID})
+		CREATE (u)-[:HAS_STANDING]->(s:StandingReservation $props)
+		RETURN count(s)`, map[string]any{"userID": standing.UserID, "props": props})
+	if err == nil && created == 0 {
+		err = ErrStandingNotFound
+	}
+	if err != nil {
+		standing.ID = ""
+	}
+	return err
+}
+
+func (s neo4jStandingStore) Update(ctx context.Context, standing *StandingReservation) error {
+	props, err := db.Encode(standing)
+	if err != nil {
+		return err
+	}
+	updated, err := runWriteCount(ctx, s.conn, `MATCH (s:StandingReservation {id: $id})
+		SET s += $props
+		RETURN count(s)`, map[string]any{"id": standing.ID, "props": props})
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return ErrStandingNotFound
+	}
+	return nil
+}
+
+func (s neo4jStandingStore) Get(ctx context.Context, id string) (*StandingReservation, error) {
+	standing, err := queryStanding(ctx, s.conn, `MATCH (s:StandingReservation {id: $id}) RETURN s{.*} as data`,
+		map[string]any{"id": id})
+	if err != nil || len(standing) == 0 {
+		return nil, err
+	}
+	return &standing[0], nil
+}
+
+func (s neo4jStandingStore) Delete(ctx context.Context, id string) error {
+	deleted, err := runWriteCount(ctx, s.conn, `MATCH (s:StandingReservation {id: $id})
+		OPTIONAL MATCH (s)-[:OCCURRED]->(o:StandingOccurrence)
+		WHERE o.status <> $booked
+		DETACH DELETE o, s
+		RETURN count(DISTINCT s)`, map[string]any{"id": id, "booked": OccurrenceBooked})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrStandingNotFound
+	}
+	return nil
+}
+
+func (s neo4jStandingStore) UserStanding(ctx context.Context, userID string) ([]StandingReservation, error) {
+	return queryStanding(ctx, s.conn, `MATCH (s:StandingReservation {userId: $userID})
+		RETURN s{.*} as data
+		ORDER BY s.weekday, s.time`, map[string]any{"userID": userID})
+}
+
+func (s neo4jStandingStore) Active(ctx context.Context, day time.Time) ([]StandingReservation, error) {
+	return queryStanding(ctx, s.conn, `MATCH (s:StandingReservation)
+		WHERE s.endDate IS NULL OR date(s.endDate) >= date($day)
+		RETURN s{.*} as data
+		ORDER BY s.createdAt`, map[string]any{"day": day})
+}
+
+func (s neo4jStandingStore) ClaimOccurrence(ctx context.Context, occurrence *StandingOccurrence) (bool, error) {
+	occurrence.ID = db.NewID()
+	props, err := db.Encode(occurrence)
+	if err != nil {
+		occurrence.ID = ""
+		return false, err
+	}
+	claimed, err := runWriteCount(ctx, s.conn, `MATCH (s:StandingReservation {id: $standingID})
+		MERGE (o:StandingOccurrence {standingId: $standingID, date: $date})
+		ON CREATE SET o += $props
+		MERGE (s)-[:OCCURRED]->(o)
+		RETURN CASE WHEN o.id = $props.id THEN 1 ELSE 0 END`, map[string]any{
+		"standingID": occurrence.StandingID,
+		"date":       occurrence.Date,
+		"props":      props,
+	})
+	if err != nil || claimed == 0 {
+		occurrence.ID = ""
+	}
+	return claimed == 1, err
+}
+
+func (s neo4jStandingStore) UpdateOccurrence(ctx context.Context, occurrence *StandingOccurrence) error {
+	props, err := db.Encode(occurrence)
+	if err != nil {
+		return err
+	}
+	_, err = runWriteCount(ctx, s.conn, `MATCH (o:StandingOccurrence {standingId: $standingID, date: $date})
+		SET o += $props
+		RETURN count(o)`, map[string]any{
+		"standingID": occurrence.StandingID,
+		"date":       occurrence.Date,
+		"props":      props,
+	})
+	return err
+}
+
+func (s neo4jStandingStore) Occurrences(ctx context.Context, standingID string, from time.Time) ([]StandingOccurrence, error) {
+	return db.QueryAs[StandingOccurrence](ctx, s.conn, `MATCH (o:StandingOccurrence {standingId: $standingID})
+		WHERE o.date >= $from
+		RETURN o{.*} as data
+		ORDER BY o.date`, map[string]any{"standingID": standingID, "from": from.Format(time.DateOnly)})
+}
+
+func queryStanding(ctx context.Context, conn *db.Database, query string, params map[string]any) ([]StandingReservation, error) {
+	return db.QueryAs[StandingReservation](ctx, conn, query, params)
+}
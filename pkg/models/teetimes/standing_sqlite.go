@@ -0,0 +1,121 @@
+package teetimes
+
+import (
+	"bigfoot/golf/common/models/db"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// sqliteStandingStore keeps standing reservations and their occurrences, the
+// occurrences unique by standing reservation and date
+type sqliteStandingStore struct {
+	conn *sql.DB
+}
+
+// NewSQLiteStandingStore returns a StandingStore backed by the SQLite database
+func NewSQLiteStandingStore(conn *sql.DB) StandingStore {
+	return sqliteStandingStore{conn: conn}
+}
+
+func (s sqliteStandingStore) Create(ctx context.Context, standing *StandingReservation) error {
+	standing.ID = db.NewID()
+	data, err := toJSON(standing)
+	if err == nil {
+		_, err = execCount(ctx, s.conn, `INSERT INTO standing (id, user_id, end_day, data) VALUES (?, ?, ?, ?)`,
+			standing.ID, standing.UserID, standingEndDay(standing), data)
+	}
+	if err != nil {
+		standing.ID = ""
+	}
+	return err
+}
+
+func (s sqliteStandingStore) Update(ctx context.Context, standing *StandingReservation) error {
+	data, err := toJSON(standing)
+	if err != nil {
+		return err
+	}
+	updated, err := execCount(ctx, s.conn, `UPDATE standing SET end_day = ?, data = ? WHERE id = ?`, standingEndDay(standing), data, standing.ID)
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return ErrStandingNotFound
+	}
+	return nil
+}
+
+func (s sqliteStandingStore) Get(ctx context.Context, id string) (*StandingReservation, error) {
+	return firstJSON[StandingReservation](ctx, s.conn, `SELECT data FROM standing WHERE id = ?`, id)
+}
+
+// Delete removes the standing reservation with the occurrences that did not book
+func (s sqliteStandingStore) Delete(ctx context.Context, id string) error {
+	return withTx(ctx, s.conn, func(ctx context.Context, tx *sql.Tx) error {
+		deleted, err := execCount(ctx, tx, `DELETE FROM standing WHERE id = ?`, id)
+		if err != nil {
+			return err
+		}
+		if deleted == 0 {
+			return ErrStandingNotFound
+		}
+		_, err = tx.ExecContext(ctx, `DELETE FROM standing_occurrences WHERE standing_id = ? AND json_extract(data, '$.status') <> ?`, id, OccurrenceBooked)
+		return err
+	})
+}
+
+func (s sqliteStandingStore) UserStanding(ctx context.Context, userID string) ([]StandingReservation, error) {
+	return queryJSON[StandingReservation](ctx, s.conn, `SELECT data FROM standing WHERE user_id = ?
+		ORDER BY json_extract(data, '$.weekday'), json_extract(data, '$.time')`, userID)
+}
+
+func (s sqliteStandingStore) Active(ctx context.Context, day time.Time) ([]StandingReservation, error) {
+	return queryJSON[StandingReservation](ctx, s.conn, `SELECT data FROM standing WHERE end_day = '' OR end_day >= ?
+		ORDER BY json_extract(data, '$.createdAt')`, sqlDay(day))
+}
+
+func (s sqliteStandingStore) ClaimOccurrence(ctx context.Context, occurrence *StandingOccurrence) (bool, error) {
+	occurrence.ID = db.NewID()
+	data, err := toJSON(occurrence)
+	if err != nil {
+		return false, err
+	}
+	claimed, err := execCount(ctx, s.conn, `INSERT INTO standing_occurrences (id, standing_id, day, data) VALUES (?, ?, ?, ?)
+		ON CONFLICT (standing_id, day) DO NOTHING`, occurrence.ID, occurrence.StandingID, occurrence.Date, data)
+	if err != nil || claimed == 0 {
+		occurrence.ID = ""
+		return false, err
+	}
+	return true, nil
+}
+
+func (s sqliteStandingStore) UpdateOccurrence(ctx context.Context, occurrence *StandingOccurrence) error {
+	data, err := toJSON(occurrence)
+	if err != nil {
+		return err
+	}
+	updated, err := execCount(ctx, s.conn, `UPDATE standing_occurrences SET data = ? WHERE standing_id = ? AND day = ?`,
+		data, occurrence.StandingID, occurrence.Date)
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return fmt.Errorf("occurrence %s#%s not found", occurrence.StandingID, occurrence.Date)
+	}
+	return nil
+}
+
+func (s sqliteStandingStore) Occurrences(ctx context.Context, standingID string, from time.Time) ([]StandingOccurrence, error) {
+	return queryJSON[StandingOccurrence](ctx, s.conn, `SELECT data FROM standing_occurrences WHERE standing_id = ? AND day >= ? ORDER BY day`,
+		standingID, sqlDay(from))
+}
+
+// standingEndDay is the end_day column, empty while the standing reservation runs until removed
+func standingEndDay(standing *StandingReservation) string {
+	if standing.EndDate.IsZero() {
+		return ""
+	}
+	return sqlDay(standing.EndDate)
+}
@@ -0,0 +1,227 @@
+package teetimes
+
+import (
+	"bigfoot/golf/common/models/account"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStandingDates(t *testing.T) {
+	//June 10 2026 is a Wednesday
+	start := time.Date(2026, time.June, 10, 0, 0, 0, 0, time.UTC)
+	from := time.Date(2026, time.June, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, time.June, 30, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		standing StandingReservation
+		want     []string
+	}{
+		{"weekly from the start date", StandingReservation{Weekday: time.Wednesday, StartDate: start}, []string{"2026-06-10", "2026-06-17", "2026-06-24"}},
+		{"first weekday after the start date", StandingReservation{Weekday: time.Saturday, StartDate: start}, []string{"2026-06-13", "2026-06-20", "2026-06-27"}},
+		{"stops at the end date", StandingReservation{Weekday: time.Wednesday, StartDate: start, EndDate: start.AddDate(0, 0, 7)}, []string{"2026-06-10", "2026-06-17"}},
+		{"skips skip dates", StandingReservation{Weekday: time.Wednesday, StartDate: start, SkipDates: []string{"2026-06-17"}}, []string{"2026-06-10", "2026-06-24"}},
+		{"ended before the window", StandingReservation{Weekday: time.Wednesday, StartDate: from.AddDate(0, -1, 0), EndDate: from.AddDate(0, 0, -1)}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, day := range tt.standing.Dates(from, to) {
+				got = append(got, day.Format(time.DateOnly))
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Dates() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStandingValidate(t *testing.T) {
+	valid := func() StandingReservation {
+		return StandingReservation{UserID: "golfer", Weekday: time.Wednesday, Time: "08:00", Players: 2, StartDate: time.Date(2026, time.June, 10, 0, 0, 0, 0, time.UTC)}
+	}
+	tests := []struct {
+		name    string
+		change  func(s *StandingReservation)
+		wantErr bool
+	}{
+		{"valid", func(s *StandingReservation) {}, false},
+		{"needs an owner", func(s *StandingReservation) { s.UserID = "" }, true},
+		{"bad weekday", func(s *StandingReservation) { s.Weekday = 7 }, true},
+		{"bad time", func(s *StandingReservation) { s.Time = "8am" }, true},
+		{"too many players", func(s *StandingReservation) { s.Players = MaxPlayersPerSlot + 1 }, true},
+		{"bad round length", func(s *StandingReservation) { s.Holes = 12 }, true},
+		{"bad tee", func(s *StandingReservation) { s.Tee = 5 }, true},
+		{"ends before it starts", func(s *StandingReservation) { s.EndDate = s.StartDate.AddDate(0, 0, -1) }, true},
+		{"lead too long", func(s *StandingReservation) { s.LeadDays = MaxStandingLeadDays + 1 }, true},
+		{"bad skip date", func(s *StandingReservation) { s.SkipDates = []string{"06/17/2026"} }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := valid()
+			tt.change(&s)
+			if err := s.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBookOccurrence(t *testing.T) {
+	ctx := context.Background()
+	day := time.Date(2026, time.June, 10, 0, 0, 0, 0, time.UTC)
+	now := day.AddDate(0, 0, -7)
+	teeTime := day.Add(8 * time.Hour)
+
+	tests := []struct {
+		name       string
+		closed     bool
+		full       bool
+		blocked    bool
+		teeTime    time.Time
+		wantStatus string
+		wantReason string
+	}{
+		{name: "books the tee time", teeTime: teeTime, wantStatus: OccurrenceBooked},
+		{name: "full slot is a conflict", teeTime: teeTime, full: true, wantStatus: OccurrenceConflict, wantReason: "already taken"},
+		{name: "closed season is a conflict", teeTime: teeTime, closed: true, wantStatus: OccurrenceConflict, wantReason: ErrCourseClosed.Error()},
+		{name: "blocked window is a conflict", teeTime: teeTime, blocked: true, wantStatus: OccurrenceConflict, wantReason: "blocked"},
+		{name: "outside course hours is a conflict", teeTime: day.Add(18 * time.Hour), wantStatus: OccurrenceConflict, wantReason: "outside course hours"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stores := useMemoryStores(t)
+			season := overrideSeason()
+			season.IsOpen = !tt.closed
+
+			var reserved []Reservation
+			if tt.full {
+				full := Reservation{TeeTime: teeTime, Slot: 7, PlayerCount: MaxPlayersPerSlot, BookingUser: &account.User{ID: "other"}}
+				if err := stores.bookings.BookSlot(ctx, &full); err != nil {
+					t.Fatalf("unexpected booking error: %v", err)
+				}
+				reserved = append(reserved, full)
+			}
+			var blockers []SlotBlocker
+			if tt.blocked {
+				blockers = append(blockers, &DayOverride{Day: day, Kind: OverrideBlocked, Start: teeTime, End: teeTime.Add(time.Hour)})
+			}
+			sheet := NewReservedDay(day, season, reserved, blockers...)
+
+			standing := StandingReservation{ID: "standing", UserID: "golfer", Weekday: time.Wednesday, Time: tt.teeTime.Format("15:04"), Players: 3}
+			occurrence := StandingOccurrence{StandingID: standing.ID, Date: day.Format(time.DateOnly), TeeTime: tt.teeTime, Status: OccurrencePending}
+			stores.course.bookOccurrence(ctx, standing, &occurrence, &season, []ReservedDay{sheet}, now)
+
+			if occurrence.Status != tt.wantStatus {
+				t.Fatalf("expected status %s, got %s (%s)", tt.wantStatus, occurrence.Status, occurrence.Reason)
+			}
+			if !strings.Contains(occurrence.Reason, tt.wantReason) {
+				t.Errorf("expected reason containing %q, got %q", tt.wantReason, occurrence.Reason)
+			}
+			if tt.wantStatus != OccurrenceBooked {
+				return
+			}
+			booked := stores.bookings.SlotReservations(teeTime, 7)
+			if len(booked) != 1 || booked[0].PlayerCount != 3 || booked[0].Price == 0 {
+				t.Errorf("expected a priced booking for three in slot 7, got %+v", booked)
+			}
+			if occurrence.ReservationID == "" || occurrence.ReservationID != booked[0].ID {
+				t.Errorf("expected the occurrence to point at the booking, got %q", occurrence.ReservationID)
+			}
+		})
+	}
+}
+
+func TestClaimOccurrenceOnce(t *testing.T) {
+	ctx := context.Background()
+	stores := useMemoryStores(t)
+	occurrence := StandingOccurrence{StandingID: "standing", Date: "2026-06-10", Status: OccurrencePending}
+
+	claimed, err := stores.standing.ClaimOccurrence(ctx, &occurrence)
+	if err != nil || !claimed {
+		t.Fatalf("expected the first claim to win, got %v %v", claimed, err)
+	}
+	again := occurrence
+	if claimed, err := stores.standing.ClaimOccurrence(ctx, &again); err != nil || claimed {
+		t.Errorf("expected the second claim to lose, got %v %v", claimed, err)
+	}
+	next := StandingOccurrence{StandingID: "standing", Date: "2026-06-17", Status: OccurrencePending}
+	if claimed, _ := stores.standing.ClaimOccurrence(ctx, &next); !claimed {
+		t.Errorf("expected the next week to be claimable")
+	}
+}
+
+func TestStandingOwnership(t *testing.T) {
+	ctx := context.Background()
+	c := useMemoryStores(t).course
+	standing := StandingReservation{UserID: "golfer", Weekday: time.Wednesday, Time: "08:00", Players: 2, StartDate: time.Date(2026, time.June, 10, 0, 0, 0, 0, time.UTC)}
+	if err := c.stores.Standing.Create(ctx, &standing); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := c.SkipStandingDate(ctx, "someone-else", standing.ID, standing.StartDate); err != ErrNotStandingOwner {
+		t.Errorf("expected ErrNotStandingOwner, got %v", err)
+	}
+	skipped, err := c.SkipStandingDate(ctx, "golfer", standing.ID, standing.StartDate)
+	if err != nil || !skipped.Skips(standing.StartDate) {
+		t.Fatalf("expected the start date skipped, got %+v %v", skipped, err)
+	}
+	if err := c.DeleteStanding(ctx, "someone-else", standing.ID); err != ErrNotStandingOwner {
+		t.Errorf("expected ErrNotStandingOwner, got %v", err)
+	}
+	if err := c.DeleteStanding(ctx, "golfer", standing.ID); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := c.DeleteStanding(ctx, "golfer", standing.ID); err != ErrStandingNotFound {
+		t.Errorf("expected ErrStandingNotFound, got %v", err)
+	}
+}
+
+func TestMaterializeStanding(t *testing.T) {
+	ctx := context.Background()
+	stores := useMemoryStores(t)
+	var conflicts []StandingOccurrence
+	defer SetStandingNotifier(standingNotifier)
+	SetStandingNotifier(func(_ context.Context, _ StandingReservation, occurrence StandingOccurrence) {
+		conflicts = append(conflicts, occurrence)
+	})
+
+	season := overrideSeason()
+	season.BeginDate = time.Date(2026, time.May, 1, 0, 0, 0, 0, time.UTC)
+	season.EndDate = time.Date(2026, time.September, 30, 0, 0, 0, 0, time.UTC)
+	if err := stores.seasons.Save(ctx, &season); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	closed := time.Date(2026, time.June, 17, 0, 0, 0, 0, time.UTC)
+	if err := stores.overrides.Save(ctx, &DayOverride{Day: closed, Kind: OverrideClosed}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	now := time.Date(2026, time.June, 8, 6, 0, 0, 0, time.UTC)
+	standing := StandingReservation{UserID: "golfer", Weekday: time.Wednesday, Time: "08:00", Players: 2, StartDate: now}
+	if err := stores.course.CreateStanding(ctx, &standing, now); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	listed, err := stores.course.UserStanding(ctx, "golfer", now)
+	if err != nil || len(listed) != 1 {
+		t.Fatalf("expected one standing tee time, got %+v %v", listed, err)
+	}
+	upcoming := listed[0].Upcoming
+	if len(upcoming) != 2 || upcoming[0].Status != OccurrenceBooked || upcoming[1].Status != OccurrenceConflict {
+		t.Fatalf("expected June 10 booked and the closed June 17 in conflict, got %+v", upcoming)
+	}
+	if booked := stores.bookings.SlotReservations(upcoming[0].TeeTime, 7); len(booked) != 1 || booked[0].ID != upcoming[0].ReservationID {
+		t.Errorf("expected the June 10 booking in slot 7, got %+v", booked)
+	}
+	if len(conflicts) != 1 || conflicts[0].Date != "2026-06-17" {
+		t.Errorf("expected the owner told about June 17, got %+v", conflicts)
+	}
+
+	again, err := stores.course.MaterializeStanding(ctx, now.Add(time.Hour))
+	if err != nil || len(again) != 0 {
+		t.Errorf("expected no dates attempted twice, got %+v %v", again, err)
+	}
+}
@@ -0,0 +1,74 @@
+package teetimes
+
+import (
+	"bigfoot/golf/common/models/account"
+	"bigfoot/golf/common/models/audit"
+	"context"
+	"testing"
+	"time"
+)
+
+// memoryStores are the in-memory stores a test runs against
+type memoryStores struct {
+	bookings  *MemoryBookingStore
+	waitlist  *MemoryWaitlistStore
+	outings   *MemoryOutingStore
+	overrides *MemoryOverrideStore
+	standing  *MemoryStandingStore
+	seasons   *MemorySeasonStore
+	audit     *audit.MemoryStore
+	users     *account.MemoryUserStore
+	// course books against the stores above
+	course *Course
+}
+
+// useMemoryStores builds a course over in-memory stores and swaps the audit
+// and user stores for in-memory ones until the test ends
+func useMemoryStores(t *testing.T) *memoryStores {
+	t.Helper()
+	s := &memoryStores{
+		bookings:  NewMemoryBookingStore(),
+		waitlist:  NewMemoryWaitlistStore(),
+		outings:   NewMemoryOutingStore(),
+		overrides: NewMemoryOverrideStore(),
+		standing:  NewMemoryStandingStore(),
+		seasons:   NewMemorySeasonStore(),
+		audit:     audit.NewMemoryStore(),
+		users:     account.NewMemoryUserStore(),
+	}
+	s.course = NewCourse(Stores{
+		Seasons:   s.seasons,
+		Bookings:  s.bookings,
+		Waitlist:  s.waitlist,
+		Outings:   s.outings,
+		Overrides: s.overrides,
+		Standing:  s.standing,
+	})
+	audit.SetStore(s.audit)
+	account.SetUserStore(s.users)
+	t.Cleanup(func() {
+		audit.SetStore(audit.NewMemoryStore())
+	})
+	return s
+}
+
+// seasonAround saves an open season on the course for a day a week out,
+// laying out a tee time every ten minutes from 7:00 to 14:00, and returns the
+// first of them
+func seasonAround(t *testing.T, c *Course) time.Time {
+	t.Helper()
+	now := time.Now().UTC()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, 7)
+	season := overrideSeason()
+	season.BeginDate, season.EndDate = day.AddDate(0, 0, -1), day.AddDate(0, 0, 1)
+	season.FirstTeeTime = day.Add(7 * time.Hour)
+	season.LastTeeTime = day.Add(14 * time.Hour)
+	season.DefaultSettings = []DetailedBlockSettings{
+		{Type: int(WeekdayMorning), Name: "Morning", BeginOverride: season.FirstTeeTime, EndOverride: season.LastTeeTime, Price: 50, IsAvail: true},
+		{Type: int(WeekendMorning), Name: "Morning", BeginOverride: season.FirstTeeTime, EndOverride: season.LastTeeTime, Price: 60, IsAvail: true},
+	}
+	if err := c.SaveSeason(context.Background(), &season); err != nil {
+		t.Fatalf("unexpected error saving season: %v", err)
+	}
+	return season.FirstTeeTime
+}
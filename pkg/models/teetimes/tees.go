@@ -0,0 +1,159 @@
+package teetimes
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Starting tees and round lengths
+const (
+	FirstTee      = 1
+	TenthTee      = 10
+	NineHoles     = 9
+	EighteenHoles = 18
+)
+
+// TeeSlotStride keeps slot numbers unique across starting tees so a slot
+// number names one tee time on one tee for the whole day. The first tee keeps
+// slots 1, 2, 3 and the tenth tee numbers the same tee times 10001, 10002, 10003.
+const TeeSlotStride = 1000
+
+// DefaultTurn is how long a group takes to play a nine and reach the other starting tee
+const DefaultTurn = 2 * time.Hour
+
+// NineHoleShare prices a nine-hole round from the eighteen-hole price when a
+// setting has no nine-hole price of its own
+const NineHoleShare = 0.6
+
+// TeeSlot numbers the nth tee time of the day on a starting tee
+func TeeSlot(tee int, n int64) int64 {
+	if tee == FirstTee {
+		return n
+	}
+	return int64(tee)*TeeSlotStride + n
+}
+
+// SlotTee returns the starting tee a slot number belongs to
+func SlotTee(slot int64) int {
+	if slot < TeeSlotStride {
+		return FirstTee
+	}
+	return int(slot / TeeSlotStride)
+}
+
+// slotIndex is the slot's position in the day, the same on every tee
+func slotIndex(slot int64) int64 {
+	if slot < TeeSlotStride {
+		return slot
+	}
+	return slot % TeeSlotStride
+}
+
+// crossTee is the tee a group starting on the given tee reaches at the turn
+func crossTee(tee int) int {
+	if tee == TenthTee {
+		return FirstTee
+	}
+	return TenthTee
+}
+
+// StartingTee is the tee the reservation goes off, the first tee unless set
+func (r *Reservation) StartingTee() int {
+	if r.Tee == 0 {
+		return SlotTee(r.Slot)
+	}
+	return r.Tee
+}
+
+// RoundHoles is the length of the booked round, eighteen unless set
+func (r *Reservation) RoundHoles() int {
+	if r.Holes == 0 {
+		return EighteenHoles
+	}
+	return r.Holes
+}
+
+// StartingTees are the tees the season sends groups off, the first tee unless set
+func (s *Season) StartingTees() []int {
+	if len(s.Tees) == 0 {
+		return []int{FirstTee}
+	}
+	return s.Tees
+}
+
+// TurnSlots is the number of tee times a group plays through before it reaches the other tee
+func (s *Season) TurnSlots() int64 {
+	turn := s.Turn
+	if turn <= 0 {
+		turn = DefaultTurn
+	}
+	if s.Gap <= 0 {
+		return 0
+	}
+	return int64((turn + s.Gap - 1) / s.Gap)
+}
+
+// CrossoverSlot is the slot on the other starting tee the reservation takes
+// when it makes the turn, or 0 when it does not cross. Groups off the first
+// tee hold the tenth tee at the turn whether they play nine or eighteen, since
+// nine-hole golfers come off beside the tenth tee and may play on. Nine-hole
+// groups off the tenth tee finish at the eighteenth green.
+func (s *Season) CrossoverSlot(res *Reservation) int64 {
+	tee := res.StartingTee()
+	other := crossTee(tee)
+	if !slices.Contains(s.StartingTees(), other) {
+		return 0
+	}
+	if tee == TenthTee && res.RoundHoles() == NineHoles {
+		return 0
+	}
+	return TeeSlot(other, slotIndex(res.Slot)+s.TurnSlots())
+}
+
+// prepareRound checks the reservation's tee and round length against the
+// season and sets the crossover slot it will hold
+func (s *Season) prepareRound(res *Reservation) error {
+	res.Tee = SlotTee(res.Slot)
+	if !slices.Contains(s.StartingTees(), res.Tee) {
+		return fmt.Errorf("groups do not start from tee %d this season", res.Tee)
+	}
+	res.Holes = res.RoundHoles()
+	if res.Holes != NineHoles && res.Holes != EighteenHoles {
+		return fmt.Errorf("rounds are %d or %d holes", NineHoles, EighteenHoles)
+	}
+	res.CrossoverSlot = s.CrossoverSlot(res)
+	return nil
+}
+
+// PriceFor is the setting's price for the round length. Nine-hole rounds
+// without their own price pay NineHoleShare of the eighteen-hole price.
+func (d *DetailedBlockSettings) PriceFor(holes int) float32 {
+	if holes != NineHoles {
+		return d.Price
+	}
+	if d.NinePrice > 0 {
+		return d.NinePrice
+	}
+	return roundCents(d.Price * NineHoleShare)
+}
+
+// heldCrossovers returns the slots booked groups will take at the turn
+func heldCrossovers(reserved []Reservation) map[int64]bool {
+	held := make(map[int64]bool)
+	for _, res := range reserved {
+		if res.CrossoverSlot > 0 {
+			held[res.CrossoverSlot] = true
+		}
+	}
+	return held
+}
+
+// checkCrossover rejects a booking when a group making the turn holds its
+// slot or its own crossover slot already has golfers in it
+func checkCrossover(res *Reservation, conflicts int) error {
+	if conflicts > 0 {
+		return &SlotUnavailableError{TeeTime: res.TeeTime, Slot: res.Slot, Requested: int(res.PlayerCount)}
+	}
+	return nil
+}
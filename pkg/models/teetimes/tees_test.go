@@ -0,0 +1,165 @@
+package teetimes
+
+import (
+	"bigfoot/golf/common/models/account"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func twoTeeSeason() Season {
+	season := overrideSeason()
+	season.Tees = []int{FirstTee, TenthTee}
+	season.Turn = 25 * time.Minute
+	season.LastTeeTime = season.FirstTeeTime.Add(time.Hour)
+	return season
+}
+
+func TestCrossoverSlot(t *testing.T) {
+	tests := []struct {
+		name   string
+		tees   []int
+		slot   int64
+		holes  int
+		want   int64
+		wantOK bool
+	}{
+		{"eighteen off the first tee crosses to the tenth", []int{1, 10}, 2, 18, 10005, true},
+		{"nine off the first tee holds the tenth", []int{1, 10}, 2, 9, 10005, true},
+		{"eighteen off the tenth tee crosses to the first", []int{1, 10}, 10002, 18, 5, true},
+		{"nine off the tenth tee finishes on eighteen", []int{1, 10}, 10002, 9, 0, true},
+		{"one starting tee never crosses", nil, 2, 18, 0, true},
+		{"tenth tee is not a starting tee", nil, 10002, 18, 0, false},
+		{"rounds are nine or eighteen", []int{1, 10}, 2, 12, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			season := twoTeeSeason()
+			season.Tees = tt.tees
+			res := Reservation{Slot: tt.slot, Holes: tt.holes}
+			err := season.prepareRound(&res)
+			if (err == nil) != tt.wantOK {
+				t.Fatalf("prepareRound error = %v, want ok %v", err, tt.wantOK)
+			}
+			if err == nil && res.CrossoverSlot != tt.want {
+				t.Errorf("crossover slot = %d, want %d", res.CrossoverSlot, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewReservedDayTwoTees(t *testing.T) {
+	season := twoTeeSeason()
+	day := time.Date(2026, time.June, 10, 0, 0, 0, 0, time.UTC)
+	nine := Reservation{ID: "nine", TeeTime: day.Add(7 * time.Hour), Slot: 1, Holes: NineHoles, PlayerCount: MaxPlayersPerSlot}
+	if err := season.prepareRound(&nine); err != nil {
+		t.Fatal(err)
+	}
+
+	resDay := NewReservedDay(day, season, []Reservation{nine})
+	//7 tee times on two tees, less the tenth tee slot the nine-hole group holds at 7:30
+	if len(resDay.Times) != 13 {
+		t.Fatalf("expected 13 slots, got %d", len(resDay.Times))
+	}
+	if resDay.GetBySlot(nine.CrossoverSlot) != nil {
+		t.Errorf("slot %d should be held for the group making the turn", nine.CrossoverSlot)
+	}
+	tenth := resDay.GetBySlot(TeeSlot(TenthTee, 1))
+	if tenth == nil || tenth.Tee != TenthTee || !tenth.TeeTime.Equal(nine.TeeTime) {
+		t.Errorf("expected the tenth tee open at 7:00, got %+v", tenth)
+	}
+}
+
+func TestNewReservedDaySharedSlot(t *testing.T) {
+	season := twoTeeSeason()
+	season.Tees = nil
+	day := time.Date(2026, time.June, 10, 0, 0, 0, 0, time.UTC)
+	pair := func(id string, slot int64) Reservation {
+		return Reservation{ID: id, TeeTime: day.Add(7*time.Hour + time.Duration(slot-1)*10*time.Minute), Slot: slot, PlayerCount: 2}
+	}
+
+	tests := []struct {
+		name     string
+		reserved []Reservation
+		wantOpen int64
+	}{
+		{"an empty slot has every spot open", nil, MaxPlayersPerSlot},
+		{"a pair leaves two spots", []Reservation{pair("a", 2)}, 2},
+		{"two pairs fill the slot", []Reservation{pair("a", 2), pair("b", 2)}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resDay := NewReservedDay(day, season, tt.reserved)
+			var booked []string
+			var open int64
+			for _, res := range resDay.Times {
+				if res.Slot != 2 {
+					continue
+				}
+				if res.ID != "" {
+					booked = append(booked, res.ID)
+				}
+				open += res.Open
+			}
+			if len(booked) != len(tt.reserved) || open != tt.wantOpen {
+				t.Errorf("expected %d bookings and %d open spots in slot 2, got %v and %d", len(tt.reserved), tt.wantOpen, booked, open)
+			}
+		})
+	}
+}
+
+func TestBookTeeTimeCrossover(t *testing.T) {
+	ctx := context.Background()
+	c := useMemoryStores(t).course
+	season := twoTeeSeason()
+	day := time.Date(2026, time.June, 10, 0, 0, 0, 0, time.UTC)
+	book := func(userID string, slot int64, holes int) error {
+		n := slotIndex(slot) - 1
+		res := Reservation{TeeTime: day.Add(7*time.Hour + time.Duration(n)*season.Gap), Slot: slot, Holes: holes, BookingUser: &account.User{ID: userID}}
+		if err := season.prepareRound(&res); err != nil {
+			return err
+		}
+		return c.BookTeeTime(ctx, &res)
+	}
+
+	if err := book("front", 1, NineHoles); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := book("back", 10004, EighteenHoles); !errors.Is(err, ErrSlotUnavailable) {
+		t.Fatalf("expected the crossover slot to be taken, got %v", err)
+	}
+	if err := book("back", 10003, NineHoles); err != nil {
+		t.Fatalf("expected the slot before the crossover to book, got %v", err)
+	}
+	if err := book("back", 10006, NineHoles); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	//a first tee group that would turn into the booked 7:50 tenth tee slot cannot go out
+	if err := book("late", 3, EighteenHoles); !errors.Is(err, ErrSlotUnavailable) {
+		t.Fatalf("expected a booked crossover slot to reject the group, got %v", err)
+	}
+	if err := book("late", 4, EighteenHoles); err != nil {
+		t.Fatalf("expected the next first tee slot to book, got %v", err)
+	}
+}
+
+func TestPriceForHoles(t *testing.T) {
+	setting := DetailedBlockSettings{Name: "Weekday Morning", Price: 50}
+	if got := setting.PriceFor(NineHoles); got != 30 {
+		t.Errorf("expected nine holes at %.0f%% of $50, got %.2f", NineHoleShare*100, got)
+	}
+	setting.NinePrice = 28
+	if got := setting.PriceFor(NineHoles); got != 28 {
+		t.Errorf("expected the nine-hole price, got %.2f", got)
+	}
+	if got := setting.PriceFor(EighteenHoles); got != 50 {
+		t.Errorf("expected the eighteen-hole price, got %.2f", got)
+	}
+
+	engine := NewPricingEngine(BaseRateRule{Rates: DefaultRates})
+	quote := engine.Quote(PriceInput{Setting: setting, Holes: NineHoles})
+	if quote.Price != 28 || quote.Applied[0].Detail != "Weekday Morning nine-hole rate" {
+		t.Errorf("unexpected nine-hole quote %+v", quote)
+	}
+}
@@ -0,0 +1,281 @@
+package teetimes
+
+import (
+	"bigfoot/golf/common/models/weather"
+	_ "embed"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed seasons.yaml
+var defaultSeasonConfig []byte
+
+// sunTimes looks up the solar times a season's tee times are offset from
+var sunTimes = weather.GetSunriseAndSunset
+
+// SeasonTemplate describes a season independent of the year it is built for
+type SeasonTemplate struct {
+	Name           string        `yaml:"name" json:"name"`
+	Begin          string        `yaml:"begin" json:"begin"` // MM-DD
+	End            string        `yaml:"end" json:"end"`     // MM-DD
+	Gap            time.Duration `yaml:"gap" json:"gap"`
+	FirstTeeOffset time.Duration `yaml:"firstTeeOffset" json:"firstTeeOffset"` // from sunrise
+	LastTeeOffset  time.Duration `yaml:"lastTeeOffset" json:"lastTeeOffset"`   // from sunset
+	Tees           []int         `yaml:"tees" json:"tees"`                     // starting tees, 1 and optionally 10
+	Turn           time.Duration `yaml:"turn" json:"turn"`                     // time to play a nine
+	Tiers          []PriceTier   `yaml:"tiers" json:"tiers"`
+}
+
+// PriceTier prices a run of slots for one setting type
+type PriceTier struct {
+	Setting   string  `yaml:"setting" json:"setting"`
+	Name      string  `yaml:"name" json:"name"`
+	FromSlot  int     `yaml:"fromSlot" json:"fromSlot"`
+	ToSlot    int     `yaml:"toSlot" json:"toSlot"` // exclusive, 0 runs through the last tee time
+	Price     float32 `yaml:"price" json:"price"`
+	NinePrice float32 `yaml:"ninePrice" json:"ninePrice"` // 0 prices nine holes at NineHoleShare
+}
+
+var settingTypeNames = map[string]SettingType{
+	"weekdayMorning":   WeekdayMorning,
+	"weekdayMidday":    WeekdayMidday,
+	"weekdayAfternoon": WeekdayAfternoon,
+	"weekendMorning":   WeekendMorning,
+	"weekendMidday":    WeekendMidday,
+	"weekendAfternoon": WeekendAfternoon,
+	"holiday":          Holiday,
+}
+
+// LoadAppConfig reads the season templates from the file named by
+// SEASON_CONFIG, falling back to the templates built into the binary
+func LoadAppConfig() (*AppConfigFile, error) {
+	data := defaultSeasonConfig
+	if path := os.Getenv("SEASON_CONFIG"); path != "" {
+		var err error
+		data, err = os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading season config: %w", err)
+		}
+	}
+	return ParseAppConfig(data)
+}
+
+// ParseAppConfig decodes and validates a season config
+func ParseAppConfig(data []byte) (*AppConfigFile, error) {
+	var cfg AppConfigFile
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parsing season config: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// Validate checks every template and that no two seasons overlap
+func (c *AppConfigFile) Validate() error {
+	if len(c.Seasons) == 0 {
+		return fmt.Errorf("season config has no seasons")
+	}
+	if c.Latitude < -90 || c.Latitude > 90 || c.Longitude < -180 || c.Longitude > 180 {
+		return fmt.Errorf("course location %v, %v is out of range", c.Latitude, c.Longitude)
+	}
+	for _, t := range c.Seasons {
+		if err := t.validate(); err != nil {
+			return fmt.Errorf("season %q: %w", t.Name, err)
+		}
+	}
+	//a leap year catches seasons that only overlap on February 29th
+	for i, a := range c.Seasons {
+		aBegin, aEnd, _ := a.dates(2028)
+		for _, b := range c.Seasons[i+1:] {
+			bBegin, bEnd, _ := b.dates(2028)
+			if overlaps(aBegin, aEnd, bBegin, bEnd) {
+				return fmt.Errorf("seasons %q and %q overlap", a.Name, b.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// overlaps compares the two date ranges across the year boundary as well
+func overlaps(aBegin, aEnd, bBegin, bEnd time.Time) bool {
+	for _, shift := range []int{-1, 0, 1} {
+		b0, b1 := bBegin.AddDate(shift, 0, 0), bEnd.AddDate(shift, 0, 0)
+		if !aBegin.After(b1) && !b0.After(aEnd) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t SeasonTemplate) validate() error {
+	if t.Name == "" {
+		return fmt.Errorf("season needs a name")
+	}
+	if _, _, err := t.dates(2028); err != nil {
+		return err
+	}
+	if t.Gap < time.Minute || t.Gap%time.Minute != 0 || (24*time.Hour)%t.Gap != 0 {
+		return fmt.Errorf("gap %s must be whole minutes that divide the day", t.Gap)
+	}
+	for i, tee := range t.Tees {
+		if tee != FirstTee && tee != TenthTee {
+			return fmt.Errorf("starting tee %d must be %d or %d", tee, FirstTee, TenthTee)
+		}
+		if slices.Contains(t.Tees[:i], tee) {
+			return fmt.Errorf("starting tee %d is listed twice", tee)
+		}
+	}
+	if t.Turn < 0 {
+		return fmt.Errorf("turn %s cannot be negative", t.Turn)
+	}
+	if len(t.Tiers) == 0 {
+		return fmt.Errorf("season has no price tiers")
+	}
+	for i, tier := range t.Tiers {
+		settingType, ok := settingTypeNames[tier.Setting]
+		if !ok {
+			return fmt.Errorf("tier %d has unknown setting %q", i, tier.Setting)
+		}
+		if tier.Price <= 0 {
+			return fmt.Errorf("tier %d needs a price", i)
+		}
+		if tier.NinePrice < 0 {
+			return fmt.Errorf("tier %d nine-hole price cannot be negative", i)
+		}
+		if tier.FromSlot < 0 || (tier.ToSlot != 0 && tier.ToSlot <= tier.FromSlot) {
+			return fmt.Errorf("tier %d slots %d to %d are out of order", i, tier.FromSlot, tier.ToSlot)
+		}
+		for j, other := range t.Tiers[:i] {
+			if settingTypeNames[other.Setting] != settingType && !sameDayKind(settingTypeNames[other.Setting], settingType) {
+				continue
+			}
+			if tierSlotsOverlap(tier, other) {
+				return fmt.Errorf("tiers %d and %d overlap", j, i)
+			}
+		}
+	}
+	return nil
+}
+
+// sameDayKind reports whether the setting types price the same kind of day
+func sameDayKind(a, b SettingType) bool {
+	weekday := func(s SettingType) bool { return s >= WeekdayMorning && s <= WeekdayAfternoon }
+	weekend := func(s SettingType) bool { return s >= WeekendMorning && s <= WeekendAfternoon }
+	return (weekday(a) && weekday(b)) || (weekend(a) && weekend(b))
+}
+
+func tierSlotsOverlap(a, b PriceTier) bool {
+	aEnd, bEnd := a.ToSlot, b.ToSlot
+	if aEnd == 0 {
+		aEnd = int(^uint(0) >> 1)
+	}
+	if bEnd == 0 {
+		bEnd = int(^uint(0) >> 1)
+	}
+	return a.FromSlot < bEnd && b.FromSlot < aEnd
+}
+
+// dates resolves the template's begin and end for the year the season starts in
+func (t SeasonTemplate) dates(year int) (time.Time, time.Time, error) {
+	begin, err := monthDay(t.Begin, year)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	end, err := monthDay(t.End, year)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if end.Before(begin) {
+		end, _ = monthDay(t.End, year+1)
+	}
+	return begin, end.Add(24*time.Hour - time.Second), nil
+}
+
+// monthDay parses MM-DD, clamping a day past the end of the month to its last day
+func monthDay(value string, year int) (time.Time, error) {
+	var month, day int
+	if _, err := fmt.Sscanf(strings.TrimSpace(value), "%d-%d", &month, &day); err != nil || month < 1 || month > 12 || day < 1 || day > 31 {
+		return time.Time{}, fmt.Errorf("date %q must be MM-DD", value)
+	}
+	last := time.Date(year, time.Month(month)+1, 0, 0, 0, 0, 0, time.Local).Day()
+	if day > last {
+		day = last
+	}
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.Local), nil
+}
+
+// BuildSeasons materializes every template for the year with its settings
+func (c *AppConfigFile) BuildSeasons(year int) ([]Season, error) {
+	var seasons []Season
+	for _, t := range c.Seasons {
+		seas, err := t.Build(year, c.Latitude, c.Longitude)
+		if err != nil {
+			return nil, fmt.Errorf("season %q: %w", t.Name, err)
+		}
+		seasons = append(seasons, seas)
+	}
+	return seasons, nil
+}
+
+// Build lays out the season's tee times from the solar times on its middle day
+func (t SeasonTemplate) Build(year int, lat, lon float32) (Season, error) {
+	var seas Season
+	begin, end, err := t.dates(year)
+	if err != nil {
+		return seas, err
+	}
+	seas.Year = year
+	seas.Name = t.Name
+	seas.BeginDate = begin
+	seas.EndDate = end
+	seas.SolarTimes, err = sunTimes(begin.Add(end.Sub(begin)/2), lat, lon)
+	if err != nil {
+		return seas, err
+	}
+
+	seas.Gap = t.Gap
+	seas.Tees = t.Tees
+	seas.Turn = t.Turn
+	seas.FirstTeeTime = roundUpToGap(seas.SolarTimes.Sunrise.Add(t.FirstTeeOffset), t.Gap)
+	seas.LastTeeTime = seas.SolarTimes.Sunset.Add(t.LastTeeOffset)
+	if !seas.LastTeeTime.After(seas.FirstTeeTime) {
+		return seas, fmt.Errorf("last tee time %s is not after the first %s",
+			seas.LastTeeTime.Format(time.Kitchen), seas.FirstTeeTime.Format(time.Kitchen))
+	}
+	seas.IsOpen = true
+
+	for _, tier := range t.Tiers {
+		setting := DetailedBlockSettings{
+			Type:          int(settingTypeNames[tier.Setting]),
+			Name:          tier.Name,
+			BeginOverride: seas.FirstTeeTime.Add(seas.Gap * time.Duration(tier.FromSlot)),
+			EndOverride:   seas.LastTeeTime.Add(time.Minute),
+			Price:         tier.Price,
+			NinePrice:     tier.NinePrice,
+			IsAvail:       true,
+		}
+		if tier.ToSlot > 0 {
+			setting.EndOverride = seas.FirstTeeTime.Add(seas.Gap*time.Duration(tier.ToSlot) - time.Minute)
+		}
+		seas.DefaultSettings = append(seas.DefaultSettings, setting)
+	}
+	seas.Holidays = seas.BuildCalendar(nil)
+	return seas, nil
+}
+
+// roundUpToGap moves the time to the next tee time boundary in its day
+func roundUpToGap(t time.Time, gap time.Duration) time.Time {
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	since := t.Sub(midnight)
+	if rem := since % gap; rem != 0 {
+		since += gap - rem
+	}
+	return midnight.Add(since)
+}
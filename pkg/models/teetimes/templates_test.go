@@ -0,0 +1,128 @@
+package teetimes
+
+import (
+	"bigfoot/golf/common/models/weather"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func fakeSunTimes(dt time.Time, lat, lon float32) (*weather.ParsedSolarResults, error) {
+	return &weather.ParsedSolarResults{
+		Sunrise: time.Date(dt.Year(), dt.Month(), dt.Day(), 5, 53, 0, 0, time.Local),
+		Sunset:  time.Date(dt.Year(), dt.Month(), dt.Day(), 20, 47, 0, 0, time.Local),
+	}, nil
+}
+
+func TestDefaultSeasonConfig(t *testing.T) {
+	sunTimes = fakeSunTimes
+	defer func() { sunTimes = weather.GetSunriseAndSunset }()
+
+	cfg, err := ParseAppConfig(defaultSeasonConfig)
+	if err != nil {
+		t.Fatalf("default config is invalid: %v", err)
+	}
+	seasons, err := cfg.BuildSeasons(2027)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(seasons) != 4 {
+		t.Fatalf("expected 4 seasons, got %d", len(seasons))
+	}
+
+	summer := seasons[1]
+	if summer.FirstTeeTime.Format("15:04") != "06:00" {
+		t.Errorf("first tee time %s should round up to the gap", summer.FirstTeeTime.Format("15:04"))
+	}
+	if len(summer.DefaultSettings) != 7 {
+		t.Fatalf("expected 7 settings, got %d", len(summer.DefaultSettings))
+	}
+	morning := summer.DefaultSettings[0]
+	if morning.EndOverride.Format("15:04") != "07:19" || morning.Price != 50 {
+		t.Errorf("unexpected weekday morning %s to %s at %.2f",
+			morning.BeginOverride.Format("15:04"), morning.EndOverride.Format("15:04"), morning.Price)
+	}
+
+	if len(summer.StartingTees()) != 2 || summer.TurnSlots() != 12 {
+		t.Errorf("summer should start off two tees with a 12 slot turn, got %v and %d", summer.StartingTees(), summer.TurnSlots())
+	}
+	if morning.NinePrice != 30 {
+		t.Errorf("expected a $30 nine-hole weekday morning, got %.2f", morning.NinePrice)
+	}
+
+	winter := seasons[3]
+	if winter.EndDate.Format(time.DateOnly) != "2028-02-29" {
+		t.Errorf("winter should end on the last day of February, got %s", winter.EndDate.Format(time.DateOnly))
+	}
+}
+
+func TestSeasonConfigValidation(t *testing.T) {
+	season := func(name, begin, end, gap string) string {
+		return fmt.Sprintf(`
+  - name: %s
+    begin: "%s"
+    end: "%s"
+    gap: %s
+    tiers:
+      - {setting: weekdayMidday, name: Weekday, fromSlot: 0, price: 60}`, name, begin, end, gap)
+	}
+	const course = "latitude: 40.7\nlongitude: -79.6\nseasons:"
+
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr string
+	}{
+		{"overlapping seasons", course + season("summer", "06-01", "08-31", "10m") + season("late summer", "08-15", "09-30", "10m"), "overlap"},
+		{"overlap across new year", course + season("winter", "12-01", "02-28", "10m") + season("january", "01-15", "01-31", "10m"), "overlap"},
+		{"gap does not divide the day", course + season("summer", "06-01", "08-31", "7m"), "divide the day"},
+		{"bad date", course + season("summer", "June 1", "08-31", "10m"), "MM-DD"},
+		{"bad location", "latitude: 140.7\nlongitude: -79.6\nseasons:" + season("summer", "06-01", "08-31", "10m"), "out of range"},
+		{"unknown setting", course + `
+  - name: summer
+    begin: "06-01"
+    end: "08-31"
+    gap: 10m
+    tiers:
+      - {setting: brunch, name: Brunch, fromSlot: 0, price: 60}`, "unknown setting"},
+		{"overlapping tiers", course + `
+  - name: summer
+    begin: "06-01"
+    end: "08-31"
+    gap: 10m
+    tiers:
+      - {setting: weekdayMorning, name: Morning, fromSlot: 0, toSlot: 10, price: 50}
+      - {setting: weekdayMidday, name: Midday, fromSlot: 8, price: 60}`, "tiers 0 and 1 overlap"},
+		{"unknown starting tee", course + `
+  - name: summer
+    begin: "06-01"
+    end: "08-31"
+    gap: 10m
+    tees: [1, 4]
+    tiers:
+      - {setting: weekdayMidday, name: Weekday, fromSlot: 0, price: 60}`, "starting tee 4"},
+		{"negative nine-hole price", course + `
+  - name: summer
+    begin: "06-01"
+    end: "08-31"
+    gap: 10m
+    tiers:
+      - {setting: weekdayMidday, name: Weekday, fromSlot: 0, price: 60, ninePrice: -5}`, "nine-hole price"},
+		{"valid", course + season("spring", "03-01", "05-31", "12m") + season("summer", "06-01", "08-31", "10m"), ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseAppConfig([]byte(tc.yaml))
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("err = %v, want %q", err, tc.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,251 @@
+package teetimes
+
+import (
+	"bigfoot/golf/common/models/account"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// WaitlistOfferTTL is how long a waitlisted golfer has to accept an open spot
+// before it is offered to the next golfer in line
+const WaitlistOfferTTL = 30 * time.Minute
+
+// Waitlist entry statuses
+const (
+	WaitlistWaiting  = "waiting"
+	WaitlistOffered  = "offered"
+	WaitlistAccepted = "accepted"
+	WaitlistLapsed   = "lapsed"
+)
+
+var (
+	ErrWaitlistNotFound = errors.New("waitlist entry not found")
+	ErrNoWaitlistOffer  = errors.New("no open offer for this waitlist entry")
+)
+
+// WaitlistEntry queues a golfer for any slot in a time window on one day
+type WaitlistEntry struct {
+	ID       string    `json:"id,omitempty"`
+	UserID   string    `json:"userId"`
+	Earliest time.Time `json:"earliest"`
+	Latest   time.Time `json:"latest"`
+	Players  int64     `json:"players"`
+	Status   string    `json:"status"`
+	//offer details, set while a freed slot is held for this golfer
+	HoldID         string    `json:"holdId,omitempty"`
+	OfferTeeTime   time.Time `json:"offerTeeTime,omitempty" neo4j:",omitempty"`
+	OfferSlot      int64     `json:"offerSlot,omitempty"`
+	OfferPrice     float32   `json:"offerPrice,omitempty"`
+	OfferType      int       `json:"offerType,omitempty"`
+	OfferGroup     string    `json:"offerGroup,omitempty"`
+	OfferExpiresAt time.Time `json:"offerExpiresAt,omitempty" neo4j:",omitempty"`
+	ReservationID  string    `json:"reservationId,omitempty"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+// Matches reports whether a tee time falls in the entry's window
+func (w *WaitlistEntry) Matches(teeTime time.Time) bool {
+	return !teeTime.Before(w.Earliest) && !teeTime.After(w.Latest)
+}
+
+// WaitlistStore persists waitlist entries
+type WaitlistStore interface {
+	Join(ctx context.Context, entry *WaitlistEntry) error
+	Leave(ctx context.Context, userID, entryID string) error
+	Get(ctx context.Context, entryID string) (*WaitlistEntry, error)
+	Update(ctx context.Context, entry *WaitlistEntry) error
+	UserEntries(ctx context.Context, userID string) ([]WaitlistEntry, error)
+	// Waiting returns the waiting entries whose window covers the tee time, oldest first
+	Waiting(ctx context.Context, teeTime time.Time) ([]WaitlistEntry, error)
+	// LapsedOffers returns the offered entries whose accept deadline has passed
+	LapsedOffers(ctx context.Context, now time.Time) ([]WaitlistEntry, error)
+}
+
+var waitlistNotifier = func(ctx context.Context, entry WaitlistEntry) {
+	log.Printf("Waitlist entry %s offered %s", entry.ID, entry.OfferTeeTime.Format(time.RFC3339))
+}
+
+// SetWaitlistNotifier sets the function used to tell a golfer a spot opened up
+func SetWaitlistNotifier(notify func(ctx context.Context, entry WaitlistEntry)) {
+	waitlistNotifier = notify
+}
+
+// JoinWaitlist queues the user for the first spot that opens in the entry's window
+func (c *Course) JoinWaitlist(ctx context.Context, entry *WaitlistEntry) error {
+	if entry.UserID == "" {
+		return fmt.Errorf("no user found")
+	}
+	if entry.Players <= 0 || entry.Players > MaxPlayersPerSlot {
+		return fmt.Errorf("players must be between 1 and %d", MaxPlayersPerSlot)
+	}
+	if entry.Latest.Before(entry.Earliest) || entry.Earliest.Format(time.DateOnly) != entry.Latest.Format(time.DateOnly) {
+		return fmt.Errorf("waitlist window must be a time range on a single day")
+	}
+	entry.Status = WaitlistWaiting
+	entry.CreatedAt = time.Now()
+	entry.UpdatedAt = entry.CreatedAt
+	return c.stores.Waitlist.Join(ctx, entry)
+}
+
+// LeaveWaitlist removes the user's entry, giving back any spot offered to them
+func (c *Course) LeaveWaitlist(ctx context.Context, userID, entryID string) error {
+	entry, err := c.stores.Waitlist.Get(ctx, entryID)
+	if err != nil {
+		return err
+	}
+	if entry == nil || entry.UserID != userID {
+		return ErrWaitlistNotFound
+	}
+	if err := c.stores.Waitlist.Leave(ctx, userID, entryID); err != nil {
+		return err
+	}
+	if entry.Status == WaitlistOffered {
+		c.passOffer(ctx, *entry)
+	}
+	return nil
+}
+
+// UserWaitlist returns the user's waitlist entries
+func (c *Course) UserWaitlist(ctx context.Context, userID string) ([]WaitlistEntry, error) {
+	return c.stores.Waitlist.UserEntries(ctx, userID)
+}
+
+// AcceptWaitlistOffer books the spot being held for the user's waitlist entry
+func (c *Course) AcceptWaitlistOffer(ctx context.Context, userID, entryID string) (*Reservation, error) {
+	entry, err := c.stores.Waitlist.Get(ctx, entryID)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil || entry.UserID != userID {
+		return nil, ErrWaitlistNotFound
+	}
+	if entry.Status != WaitlistOffered || !entry.OfferExpiresAt.After(time.Now()) {
+		return nil, ErrNoWaitlistOffer
+	}
+
+	// the stored user, so pricing sees their birth date
+	booker, err := account.QueryUser(ctx, map[string]interface{}{"id": userID})
+	if err != nil {
+		return nil, err
+	}
+	if booker == nil {
+		return nil, ErrWaitlistNotFound
+	}
+	booker.Password, booker.TempStr = "", ""
+	res := Reservation{
+		TeeTime:     entry.OfferTeeTime,
+		Slot:        entry.OfferSlot,
+		BookingUser: booker,
+		Players:     []account.User{*booker},
+	}
+	for i := int64(1); i < entry.Players; i++ {
+		res.Players = append(res.Players, account.User{LastName: fmt.Sprintf("Guest %d", i)})
+	}
+	// the offer carries the freed reservation's price, which may have been
+	// another golfer's junior or surge rate, so price the booking afresh
+	if _, err := c.PriceReservation(ctx, &res, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := c.BookTeeTime(ctx, &res); err != nil {
+		return nil, err
+	}
+
+	entry.Status = WaitlistAccepted
+	entry.ReservationID = res.ID
+	entry.UpdatedAt = time.Now()
+	if err := c.stores.Waitlist.Update(ctx, entry); err != nil {
+		return &res, err
+	}
+	return &res, nil
+}
+
+// OfferOpenSpot offers the spots freed in a reservation's slot to the
+// waitlisted golfers whose window and party size fit, oldest first, until the
+// freed spots are used up, holding each party's spots until WaitlistOfferTTL
+// passes. It returns the entries offered.
+func (c *Course) OfferOpenSpot(ctx context.Context, freed Reservation) ([]WaitlistEntry, error) {
+	entries, err := c.stores.Waitlist.Waiting(ctx, freed.TeeTime)
+	if err != nil {
+		return nil, err
+	}
+	remaining := freed.bookedPlayers()
+	var offered []WaitlistEntry
+	holding := make(map[string]bool)
+	for _, entry := range entries {
+		if remaining <= 0 {
+			break
+		}
+		// a golfer holds one slot at a time, so each gets one offer
+		if entry.Players > remaining || holding[entry.UserID] {
+			continue
+		}
+		hold := SlotHold{UserID: entry.UserID, TeeTime: freed.TeeTime, Slot: freed.Slot, Players: entry.Players}
+		err := c.placeHold(ctx, &hold, WaitlistOfferTTL)
+		if errors.Is(err, ErrSlotUnavailable) {
+			//party too large for what opened up, try the next golfer
+			continue
+		}
+		if err != nil {
+			return offered, err
+		}
+
+		entry.Status = WaitlistOffered
+		entry.HoldID = hold.ID
+		entry.OfferTeeTime = freed.TeeTime
+		entry.OfferSlot = freed.Slot
+		entry.OfferPrice = freed.Price
+		entry.OfferType = freed.SettingType
+		entry.OfferGroup = freed.Group
+		entry.OfferExpiresAt = hold.ExpiresAt
+		entry.UpdatedAt = time.Now()
+		if err := c.stores.Waitlist.Update(ctx, &entry); err != nil {
+			c.stores.Bookings.ReleaseHold(ctx, hold.UserID, hold.ID)
+			return offered, err
+		}
+		waitlistNotifier(ctx, entry)
+		offered = append(offered, entry)
+		holding[entry.UserID] = true
+		remaining -= entry.Players
+	}
+	return offered, nil
+}
+
+// ExpireWaitlistOffers lapses offers that were not accepted in time and moves
+// each spot on to the next golfer in line
+func (c *Course) ExpireWaitlistOffers(ctx context.Context, now time.Time) (int, error) {
+	lapsed, err := c.stores.Waitlist.LapsedOffers(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+	for _, entry := range lapsed {
+		entry.Status = WaitlistLapsed
+		entry.UpdatedAt = now
+		if err := c.stores.Waitlist.Update(ctx, &entry); err != nil {
+			return 0, err
+		}
+		c.passOffer(ctx, entry)
+	}
+	return len(lapsed), nil
+}
+
+// passOffer releases the spot held for an entry and offers it to the next golfer
+func (c *Course) passOffer(ctx context.Context, entry WaitlistEntry) {
+	if err := c.stores.Bookings.ReleaseHold(ctx, entry.UserID, entry.HoldID); err != nil && !errors.Is(err, ErrHoldNotFound) {
+		log.Printf("Error releasing waitlist hold %s: %v", entry.HoldID, err)
+	}
+	freed := Reservation{
+		TeeTime:     entry.OfferTeeTime,
+		Slot:        entry.OfferSlot,
+		PlayerCount: entry.Players,
+		Price:       entry.OfferPrice,
+		SettingType: entry.OfferType,
+		Group:       entry.OfferGroup,
+	}
+	if _, err := c.OfferOpenSpot(ctx, freed); err != nil {
+		log.Printf("Error offering slot %d to waitlist: %v", entry.OfferSlot, err)
+	}
+}
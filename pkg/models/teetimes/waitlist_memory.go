@@ -0,0 +1,99 @@
+package teetimes
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryWaitlistStore is an in-process WaitlistStore for tests and local runs
+type MemoryWaitlistStore struct {
+	mu      sync.Mutex
+	nextID  int
+	entries []WaitlistEntry
+}
+
+func NewMemoryWaitlistStore() *MemoryWaitlistStore {
+	return &MemoryWaitlistStore{}
+}
+
+func (m *MemoryWaitlistStore) Join(ctx context.Context, entry *WaitlistEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.nextID++
+	entry.ID = fmt.Sprintf("wait-%d", m.nextID)
+	m.entries = append(m.entries, *entry)
+	return nil
+}
+
+func (m *MemoryWaitlistStore) Leave(ctx context.Context, userID, entryID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, entry := range m.entries {
+		if entry.ID == entryID && entry.UserID == userID {
+			m.entries = append(m.entries[:i], m.entries[i+1:]...)
+			return nil
+		}
+	}
+	return ErrWaitlistNotFound
+}
+
+func (m *MemoryWaitlistStore) Get(ctx context.Context, entryID string) (*WaitlistEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, entry := range m.entries {
+		if entry.ID == entryID {
+			return &entry, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *MemoryWaitlistStore) Update(ctx context.Context, entry *WaitlistEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.entries {
+		if m.entries[i].ID == entry.ID {
+			m.entries[i] = *entry
+			return nil
+		}
+	}
+	return ErrWaitlistNotFound
+}
+
+func (m *MemoryWaitlistStore) UserEntries(ctx context.Context, userID string) ([]WaitlistEntry, error) {
+	return m.filter(func(entry WaitlistEntry) bool { return entry.UserID == userID }), nil
+}
+
+func (m *MemoryWaitlistStore) Waiting(ctx context.Context, teeTime time.Time) ([]WaitlistEntry, error) {
+	entries := m.filter(func(entry WaitlistEntry) bool {
+		return entry.Status == WaitlistWaiting && entry.Matches(teeTime)
+	})
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].CreatedAt.Before(entries[j].CreatedAt) })
+	return entries, nil
+}
+
+func (m *MemoryWaitlistStore) LapsedOffers(ctx context.Context, now time.Time) ([]WaitlistEntry, error) {
+	return m.filter(func(entry WaitlistEntry) bool {
+		return entry.Status == WaitlistOffered && !entry.OfferExpiresAt.After(now)
+	}), nil
+}
+
+func (m *MemoryWaitlistStore) filter(match func(WaitlistEntry) bool) []WaitlistEntry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var entries []WaitlistEntry
+	for _, entry := range m.entries {
+		if match(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
@@ -0,0 +1,112 @@
+package teetimes
+
+import (
+	"bigfoot/golf/common/models/db"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// neo4jWaitlistStore keeps WaitlistEntry nodes linked to the waiting User
+type neo4jWaitlistStore struct {
+	conn *db.Database
+}
+
+// NewNeo4jWaitlistStore returns a WaitlistStore for waitlist entries backed by the connection
+func NewNeo4jWaitlistStore(conn *db.Database) WaitlistStore {
+	return neo4jWaitlistStore{conn: conn}
+}
+
+func (s neo4jWaitlistStore) Join(ctx context.Context, entry *WaitlistEntry) error {
+	entry.ID = db.NewID()
+	props, err := db.Encode(entry)
+	if err != nil {
+		entry.ID = ""
+		return err
+	}
+	_, err = s.conn.ExecuteWrite(ctx, func(ctx context.Context, tx neo4j.ManagedTransaction) (any, error) {
+		res, err := tx.Run(ctx, `MATCH (u:User {id: $userID})
+			CREATE (u)-[:WAITLISTED]->(w:WaitlistEntry $props)
+			RETURN w.id`, map[string]any{"userID": entry.UserID, "props": props})
+		if err != nil {
+			return nil, err
+		}
+		if !res.Next(ctx) {
+			if err := res.Err(); err != nil {
+				return nil, err
+			}
+			return nil, fmt.Errorf("no user found for id %s", entry.UserID)
+		}
+		return res.Record().Values[0], nil
+	})
+	if err != nil {
+		entry.ID = ""
+	}
+	return err
+}
+
+func (s neo4jWaitlistStore) Leave(ctx context.Context, userID, entryID string) error {
+	removed, err := runWriteCount(ctx, s.conn, `MATCH (w:WaitlistEntry {id: $id, userId: $userID})
+		DETACH DELETE w
+		RETURN count(w)`, map[string]any{"id": entryID, "userID": userID})
+	if err != nil {
+		return err
+	}
+	if removed == 0 {
+		return ErrWaitlistNotFound
+	}
+	return nil
+}
+
+func (s neo4jWaitlistStore) Get(ctx context.Context, entryID string) (*WaitlistEntry, error) {
+	entries, err := queryWaitlist(ctx, s.conn, `MATCH (w:WaitlistEntry {id: $id}) RETURN w{.*} as data`,
+		map[string]any{"id": entryID})
+	if err != nil || len(entries) == 0 {
+		return nil, err
+	}
+	return &entries[0], nil
+}
+
+func (s neo4jWaitlistStore) Update(ctx context.Context, entry *WaitlistEntry) error {
+	props, err := db.Encode(entry)
+	if err != nil {
+		return err
+	}
+	updated, err := runWriteCount(ctx, s.conn, `MATCH (w:WaitlistEntry {id: $id})
+		SET w += $props
+		RETURN count(w)`, map[string]any{"id": entry.ID, "props": props})
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return ErrWaitlistNotFound
+	}
+	return nil
+}
+
+func (s neo4jWaitlistStore) UserEntries(ctx context.Context, userID string) ([]WaitlistEntry, error) {
+	return queryWaitlist(ctx, s.conn, `MATCH (w:WaitlistEntry {userId: $userID})
+		WHERE date(w.latest) >= date()
+		RETURN w{.*} as data
+		ORDER BY w.earliest ASC`, map[string]any{"userID": userID})
+}
+
+func (s neo4jWaitlistStore) Waiting(ctx context.Context, teeTime time.Time) ([]WaitlistEntry, error) {
+	return queryWaitlist(ctx, s.conn, `MATCH (w:WaitlistEntry {status: $status})
+		WHERE w.earliest <= $teeTime AND w.latest >= $teeTime
+		RETURN w{.*} as data
+		ORDER BY w.createdAt ASC`, map[string]any{"status": WaitlistWaiting, "teeTime": teeTime})
+}
+
+func (s neo4jWaitlistStore) LapsedOffers(ctx context.Context, now time.Time) ([]WaitlistEntry, error) {
+	return queryWaitlist(ctx, s.conn, `MATCH (w:WaitlistEntry {status: $status})
+		WHERE w.offerExpiresAt <= $now
+		RETURN w{.*} as data
+		ORDER BY w.offerExpiresAt ASC`, map[string]any{"status": WaitlistOffered, "now": now})
+}
+
+func queryWaitlist(ctx context.Context, conn *db.Database, query string, params map[string]any) ([]WaitlistEntry, error) {
+	return db.QueryAs[WaitlistEntry](ctx, conn, query, params)
+}
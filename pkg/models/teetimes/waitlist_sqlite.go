@@ -0,0 +1,93 @@
+package teetimes
+
+import (
+	"bigfoot/golf/common/models/db"
+	"context"
+	"database/sql"
+	"time"
+)
+
+// sqliteWaitlistStore keeps waitlist entries indexed by user and status
+type sqliteWaitlistStore struct {
+	conn *sql.DB
+}
+
+// NewSQLiteWaitlistStore returns a WaitlistStore backed by the SQLite database
+func NewSQLiteWaitlistStore(conn *sql.DB) WaitlistStore {
+	return sqliteWaitlistStore{conn: conn}
+}
+
+func (s sqliteWaitlistStore) Join(ctx context.Context, entry *WaitlistEntry) error {
+	entry.ID = db.NewID()
+	data, err := toJSON(entry)
+	if err == nil {
+		_, err = execCount(ctx, s.conn, `INSERT INTO waitlist (id, user_id, status, created_at, data) VALUES (?, ?, ?, ?, ?)`,
+			entry.ID, entry.UserID, entry.Status, entry.CreatedAt.UnixNano(), data)
+	}
+	if err != nil {
+		entry.ID = ""
+	}
+	return err
+}
+
+func (s sqliteWaitlistStore) Leave(ctx context.Context, userID, entryID string) error {
+	removed, err := execCount(ctx, s.conn, `DELETE FROM waitlist WHERE id = ? AND user_id = ?`, entryID, userID)
+	if err != nil {
+		return err
+	}
+	if removed == 0 {
+		return ErrWaitlistNotFound
+	}
+	return nil
+}
+
+func (s sqliteWaitlistStore) Get(ctx context.Context, entryID string) (*WaitlistEntry, error) {
+	return firstJSON[WaitlistEntry](ctx, s.conn, `SELECT data FROM waitlist WHERE id = ?`, entryID)
+}
+
+func (s sqliteWaitlistStore) Update(ctx context.Context, entry *WaitlistEntry) error {
+	data, err := toJSON(entry)
+	if err != nil {
+		return err
+	}
+	updated, err := execCount(ctx, s.conn, `UPDATE waitlist SET status = ?, data = ? WHERE id = ?`, entry.Status, data, entry.ID)
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return ErrWaitlistNotFound
+	}
+	return nil
+}
+
+func (s sqliteWaitlistStore) UserEntries(ctx context.Context, userID string) ([]WaitlistEntry, error) {
+	return queryJSON[WaitlistEntry](ctx, s.conn, `SELECT data FROM waitlist WHERE user_id = ? ORDER BY created_at`, userID)
+}
+
+func (s sqliteWaitlistStore) Waiting(ctx context.Context, teeTime time.Time) ([]WaitlistEntry, error) {
+	entries, err := queryJSON[WaitlistEntry](ctx, s.conn, `SELECT data FROM waitlist WHERE status = ? ORDER BY created_at`, WaitlistWaiting)
+	if err != nil {
+		return nil, err
+	}
+	var waiting []WaitlistEntry
+	for _, entry := range entries {
+		if entry.Matches(teeTime) {
+			waiting = append(waiting, entry)
+		}
+	}
+	return waiting, nil
+}
+
+func (s sqliteWaitlistStore) LapsedOffers(ctx context.Context, now time.Time) ([]WaitlistEntry, error) {
+	entries, err := queryJSON[WaitlistEntry](ctx, s.conn, `SELECT data FROM waitlist WHERE status = ? ORDER BY created_at`, WaitlistOffered)
+	if err != nil {
+		return nil, err
+	}
+	var lapsed []WaitlistEntry
+	for _, entry := range entries {
+		if !entry.OfferExpiresAt.After(now) {
+			lapsed = append(lapsed, entry)
+		}
+	}
+	return lapsed, nil
+}
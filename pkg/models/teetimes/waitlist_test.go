@@ -0,0 +1,136 @@
+package teetimes
+
+import (
+	"bigfoot/golf/common/models/account"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWaitlistPromotion(t *testing.T) {
+	ctx := context.Background()
+	stores := useMemoryStores(t)
+
+	var offered []string
+	defer SetWaitlistNotifier(waitlistNotifier)
+	SetWaitlistNotifier(func(_ context.Context, entry WaitlistEntry) { offered = append(offered, entry.UserID) })
+
+	// a week out, so neither lead time nor a slow day moves the price
+	now := time.Now().UTC()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, 7)
+	season := overrideSeason()
+	season.BeginDate, season.EndDate = day.AddDate(0, 0, -1), day.AddDate(0, 0, 1)
+	season.FirstTeeTime = day.Add(7 * time.Hour)
+	season.LastTeeTime = day.Add(14 * time.Hour)
+	season.DefaultSettings = []DetailedBlockSettings{
+		{Type: int(WeekdayMidday), Name: "Midday", BeginOverride: season.FirstTeeTime, EndOverride: season.LastTeeTime, Price: 60, IsAvail: true},
+		{Type: int(WeekendMidday), Name: "Midday", BeginOverride: season.FirstTeeTime, EndOverride: season.LastTeeTime, Price: 60, IsAvail: true},
+	}
+	if err := stores.seasons.Save(ctx, &season); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	teeTime := day.Add(10 * time.Hour)
+	owner := account.User{ID: "owner"}
+	// the cancelled golfer paid a junior rate the next golfer must not inherit
+	full := Reservation{TeeTime: teeTime, Slot: 19, Price: 45, Group: "Midday", BookingUser: &owner, Players: make([]account.User, MaxPlayersPerSlot)}
+	if err := stores.course.BookTeeTime(ctx, &full); err != nil {
+		t.Fatalf("unexpected booking error: %v", err)
+	}
+
+	window := func(userID string, players int64, start, end int) *WaitlistEntry {
+		return &WaitlistEntry{
+			UserID:   userID,
+			Earliest: day.Add(time.Duration(start) * time.Hour),
+			Latest:   day.Add(time.Duration(end) * time.Hour),
+			Players:  players,
+		}
+	}
+	outside := window("outside", 2, 13, 15)
+	first := window("first", 2, 8, 11)
+	foursome := window("foursome", 3, 9, 12)
+	second := window("second", 2, 9, 12)
+	third := window("third", 2, 9, 12)
+	for _, entry := range []*WaitlistEntry{outside, first, foursome, second, third} {
+		if err := stores.course.JoinWaitlist(ctx, entry); err != nil {
+			t.Fatalf("unexpected waitlist error: %v", err)
+		}
+		user := account.User{ID: entry.UserID, Email: entry.UserID + "@example.com", FirstName: entry.UserID}
+		if err := stores.users.Save(ctx, &user); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	// the second golfer is a senior, priced from their stored profile
+	senior := account.User{ID: "second", Email: "second@example.com", FirstName: "second", DOB: "1950-01-01"}
+	if err := stores.users.Save(ctx, &senior); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// four freed spots go to both pairs that fit, passing the threesome over
+	if err := stores.course.Cancel(ctx, &full); err != nil {
+		t.Fatalf("unexpected cancel error: %v", err)
+	}
+	if len(offered) != 2 || offered[0] != "first" || offered[1] != "second" {
+		t.Fatalf("expected the freed spots offered to both pairs, got %v", offered)
+	}
+
+	walkIn := account.User{ID: "walkin"}
+	res := Reservation{TeeTime: teeTime, Slot: 19, BookingUser: &walkIn, Players: make([]account.User, 1)}
+	if err := stores.course.BookTeeTime(ctx, &res); !errors.Is(err, ErrSlotUnavailable) {
+		t.Fatalf("expected offered spots to be held, got %v", err)
+	}
+
+	booked, err := stores.course.AcceptWaitlistOffer(ctx, "second", second.ID)
+	if err != nil {
+		t.Fatalf("expected offer to be accepted: %v", err)
+	}
+	if booked.PlayerCount != 2 || booked.Players[0].FirstName != "second" || booked.Price != 51 || booked.Group != "Midday" || booked.Holes != EighteenHoles {
+		t.Errorf("unexpected reservation from offer: %+v", booked)
+	}
+	entries, _ := stores.course.UserWaitlist(ctx, "second")
+	if len(entries) != 1 || entries[0].Status != WaitlistAccepted || entries[0].ReservationID != booked.ID {
+		t.Errorf("expected entry to be accepted: %+v", entries)
+	}
+
+	// the lapsed pair's two spots pass the threesome over again
+	lapsed, err := stores.course.ExpireWaitlistOffers(ctx, time.Now().Add(WaitlistOfferTTL+time.Minute))
+	if err != nil || lapsed != 1 {
+		t.Fatalf("expected 1 lapsed offer, got %d (%v)", lapsed, err)
+	}
+	if len(offered) != 3 || offered[2] != "third" {
+		t.Fatalf("expected the spots to move to the next pair, got %v", offered)
+	}
+	if _, err := stores.course.AcceptWaitlistOffer(ctx, "first", first.ID); !errors.Is(err, ErrNoWaitlistOffer) {
+		t.Errorf("expected lapsed offer to be rejected, got %v", err)
+	}
+	if _, err := stores.course.AcceptWaitlistOffer(ctx, "third", third.ID); err != nil {
+		t.Errorf("expected offer to be accepted: %v", err)
+	}
+}
+
+func TestJoinWaitlistValidation(t *testing.T) {
+	ctx := context.Background()
+	c := useMemoryStores(t).course
+
+	day := time.Date(2025, time.June, 14, 8, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		entry   WaitlistEntry
+		wantErr bool
+	}{
+		{name: "valid window", entry: WaitlistEntry{UserID: "u", Earliest: day, Latest: day.Add(2 * time.Hour), Players: 2}},
+		{name: "no user", entry: WaitlistEntry{Earliest: day, Latest: day.Add(time.Hour), Players: 2}, wantErr: true},
+		{name: "too many players", entry: WaitlistEntry{UserID: "u", Earliest: day, Latest: day.Add(time.Hour), Players: 5}, wantErr: true},
+		{name: "reversed window", entry: WaitlistEntry{UserID: "u", Earliest: day, Latest: day.Add(-time.Hour), Players: 1}, wantErr: true},
+		{name: "spans days", entry: WaitlistEntry{UserID: "u", Earliest: day, Latest: day.AddDate(0, 0, 1), Players: 1}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := c.JoinWaitlist(ctx, &tt.entry)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -24,12 +24,19 @@ func (b *BError) FriendlyMsg() string {
 	case 401:
 		strOut = "You do not appear to be logged in, please login."
 		b.Redirect = "login"
+	case 403:
+		if strings.EqualFold(b.Request, "./api/userupdate") {
+			strOut = "Please confirm it is you before changing your email."
+		}
 	case 404:
 		strOut = "We need a mulligan. This is embarassing, this page or content does not exist."
 	case 409:
 		if strings.EqualFold(b.Request, "./auth/register") || strings.EqualFold(b.Request, "./api/userupdate") {
 			strOut = "The Email address is already in use, please select Login or Forgot Password."
 		}
+		if strings.HasSuffix(b.Request, "/api/bookTime") {
+			strOut = "Someone just beat you to that tee time, please pick another time."
+		}
 
 	}
 
@@ -102,7 +102,7 @@ func SendPostWithAuth(baseURL, payload string) ([]byte, models.BError) {
 	fullURL := fmt.Sprintf("./%s", baseURL)
 	_err := models.BError{Request: fullURL}
 	var byteOut []byte
-	resp, statusCd, err := sendBirdRequest(payload, accessToken, fullURL)
+	resp, statusCd, err := sendBirdRequest("POST", payload, accessToken, fullURL)
 	if err != nil {
 		_err.BError = err
 		return nil, _err
@@ -112,7 +112,7 @@ func SendPostWithAuth(baseURL, payload string) ([]byte, models.BError) {
 	byteOut = resp
 	if statusCd == 401 {
 		accessToken = stMgr.ForceRefresh()
-		resp, statusCd, err := sendBirdRequest(payload, accessToken, fullURL)
+		resp, statusCd, err := sendBirdRequest("POST", payload, accessToken, fullURL)
 		if err != nil {
 			_err.BError = err
 			return nil, _err
@@ -123,13 +123,77 @@ func SendPostWithAuth(baseURL, payload string) ([]byte, models.BError) {
 	return byteOut, _err
 }
 
-func sendBirdRequest(payload, accessToken, fullURL string) ([]byte, int, error) {
+// SendPost sends a POST request without authentication, for the public auth
+// endpoints, returning the status code in the BError
+func SendPost(baseURL, payload string) ([]byte, models.BError) {
+	fullURL := fmt.Sprintf("./%s", baseURL)
+	_err := models.BError{Request: fullURL}
+	resp, statusCd, err := sendBirdRequest("POST", payload, "", fullURL)
+	if err != nil {
+		_err.BError = err
+		return nil, _err
+	}
+	_err.Code = statusCd
+	return resp, _err
+}
+
+// SendGetWithAuth sends a GET request with authentication token and handles token refresh
+func SendGetWithAuth(baseURL string) ([]byte, models.BError) {
+
+	stMgr := state.GetAppState(nil)
+	accessToken := stMgr.TokenManager().GetAuth().Token
+	fullURL := fmt.Sprintf("./%s", baseURL)
+	_err := models.BError{Request: fullURL}
+	resp, statusCd, err := sendBirdRequest("GET", "", accessToken, fullURL)
+	if err != nil {
+		_err.BError = err
+		return nil, _err
+	}
+	_err.Code = statusCd
+	if statusCd == 401 {
+		accessToken = stMgr.ForceRefresh()
+		resp, statusCd, err = sendBirdRequest("GET", "", accessToken, fullURL)
+		if err != nil {
+			_err.BError = err
+			return nil, _err
+		}
+		_err.Code = statusCd
+	}
+	return resp, _err
+}
+
+// SendDeleteWithAuth sends a DELETE request with authentication token and handles token refresh
+func SendDeleteWithAuth(baseURL string) ([]byte, models.BError) {
+
+	stMgr := state.GetAppState(nil)
+	accessToken := stMgr.TokenManager().GetAuth().Token
+	fullURL := fmt.Sprintf("./%s", baseURL)
+	_err := models.BError{Request: fullURL}
+	resp, statusCd, err := sendBirdRequest("DELETE", "", accessToken, fullURL)
+	if err != nil {
+		_err.BError = err
+		return nil, _err
+	}
+	_err.Code = statusCd
+	if statusCd == 401 {
+		accessToken = stMgr.ForceRefresh()
+		resp, statusCd, err = sendBirdRequest("DELETE", "", accessToken, fullURL)
+		if err != nil {
+			_err.BError = err
+			return nil, _err
+		}
+		_err.Code = statusCd
+	}
+	return resp, _err
+}
+
+func sendBirdRequest(method, payload, accessToken, fullURL string) ([]byte, int, error) {
 	// Create context with timeout (important for WASM)
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
 	// Create request with context
-	req, err := http.NewRequestWithContext(ctx, "POST", fullURL, strings.NewReader(payload))
+	req, err := http.NewRequestWithContext(ctx, method, fullURL, strings.NewReader(payload))
 	if err != nil {
 		return nil, -1, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -137,7 +201,9 @@ func sendBirdRequest(payload, accessToken, fullURL string) ([]byte, int, error)
 	// Set headers
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
+	if accessToken != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
+	}
 
 	// CORS headers might be needed depending on your setup
 	req.Header.Set("Access-Control-Allow-Origin", "*")
@@ -93,7 +93,7 @@ func (h *HamburgerNav) Render() app.UI {
 							app.Range(navItems).Slice(func(i int) app.UI {
 								item := navItems[i]
 								if item.AuthPath != "" && h.authResp.AuthLevel > auth.NoAuthLevel {
-									if item.IsAdmin && !h.authResp.User.IsAdmin {
+									if item.IsAdmin && h.authResp.AuthLevel < auth.AdminLevel {
 										return nil
 									}
 									return app.Li().Body(
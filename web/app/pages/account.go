@@ -11,6 +11,8 @@ import (
 	"bigfoot/golf/web/app/state"
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"strings"
 
 	"github.com/maxence-charriere/go-app/v10/pkg/app"
 )
@@ -21,6 +23,20 @@ type MyAccount struct {
 	disabledMode bool
 	statusMsg    string
 	authResp     auth.AuthResponse
+	// signed in devices and any error loading or revoking them
+	sessions   []auth.RefreshSession
+	sessionMsg string
+	// needsStepUp offers to confirm it is the user after a change asked for it
+	needsStepUp bool
+	// the authenticator app, the setup while enrolling and the recovery
+	// codes, shown once after confirming. Enrolling and confirming send the
+	// current password, so a stolen token cannot add an authenticator.
+	totpEnrolled  bool
+	totpSetup     *auth.TOTPSetup
+	totpCode      string
+	totpPassword  string
+	recoveryCodes []string
+	totpMsg       string
 }
 
 func (h *MyAccount) OnMount(ctx app.Context) {
@@ -33,6 +49,8 @@ func (h *MyAccount) OnMount(ctx app.Context) {
 	h.user = h.authResp.User
 
 	//load profile component initially
+	h.loadSessions(ctx)
+	h.loadTOTP(ctx)
 
 }
 func (h *MyAccount) Render() app.UI {
@@ -65,10 +83,248 @@ func (h *MyAccount) Render() app.UI {
 						Text("Logout").
 						Hidden(!h.disabledMode).
 						OnClick(h.onLogout),
+					app.Button().
+						Class("action-btn primary").
+						Text("Confirm It's You").
+						Hidden(!h.needsStepUp).
+						OnClick(func(ctx app.Context, e app.Event) {
+							ctx.Navigate("/stepup?next=/account")
+						}),
 				),
+			h.renderTOTP(),
+			h.renderSessions(),
+		)
+}
+
+// renderSessions lists the devices signed in to the account, each with a revoke button
+func (h *MyAccount) renderSessions() app.UI {
+	return app.Div().
+		Class("sessions").
+		Hidden(!h.disabledMode).
+		Body(
+			app.H3().Text("Signed In Devices"),
+			app.If(h.sessionMsg != "", func() app.UI {
+				return app.P().Class("error").Text(h.sessionMsg)
+			}),
+			app.Range(h.sessions).Slice(func(i int) app.UI {
+				s := h.sessions[i]
+				device := s.Device
+				if device == "" {
+					device = "Unknown device"
+				}
+				if s.Current {
+					device += " (this device)"
+				}
+				return app.Div().
+					Class("booking-card").
+					Body(
+						app.P().Class("tee-time").Text(device),
+						app.P().Text(fmt.Sprintf("%s - last used %s, signed in %s",
+							s.IP, s.LastUsedAt.Local().Format("Jan 2 3:04 PM"), s.CreatedAt.Local().Format("Jan 2 2006"))),
+						app.Button().
+							Class("btn danger").
+							Text("Sign Out").
+							OnClick(func(ctx app.Context, e app.Event) {
+								h.revokeSession(ctx, s)
+							}),
+					)
+			}),
+			app.Button().
+				Class("action-btn secondary").
+				Text("Sign Out Everywhere").
+				Hidden(len(h.sessions) == 0).
+				OnClick(h.onSignOutEverywhere),
+		)
+}
+
+// renderTOTP shows whether an authenticator app is set up, walking the user
+// through adding one or removing it
+func (h *MyAccount) renderTOTP() app.UI {
+	return app.Div().
+		Class("sessions").
+		Hidden(!h.disabledMode).
+		Body(
+			app.H3().Text("Authenticator App"),
+			app.If(h.totpMsg != "", func() app.UI {
+				return app.P().Class("error").Text(h.totpMsg)
+			}),
+			app.If(len(h.recoveryCodes) > 0, func() app.UI {
+				return app.Div().Body(
+					app.P().Text("Save these recovery codes somewhere safe. Each one works once if you lose your phone, and they will not be shown again."),
+					app.Range(h.recoveryCodes).Slice(func(i int) app.UI {
+						return app.P().Class("tee-time").Text(h.recoveryCodes[i])
+					}),
+				)
+			}),
+			app.If(h.totpEnrolled, func() app.UI {
+				return app.Div().Body(
+					app.P().Text("An authenticator app is set up. You will be asked for a code from it before sensitive changes."),
+					app.Button().
+						Class("btn danger").
+						Text("Remove Authenticator").
+						OnClick(h.onRemoveTOTP),
+				)
+			}).ElseIf(h.totpSetup != nil, func() app.UI {
+				return app.Div().Body(
+					app.P().Text("Add Bigfoot Golf to your authenticator app with this key or the link below, then enter the six digit code it shows."),
+					app.P().Class("tee-time").Text(h.totpSetup.Secret),
+					app.A().Href(h.totpSetup.URI).Text("Open in authenticator app"),
+					app.Label().For("totpCode").Text("Code"),
+					app.Input().Type("text").ID("totpCode").
+						AutoComplete(false).
+						Attr("inputmode", "numeric").
+						OnChange(h.ValueTo(&h.totpCode)),
+					app.Button().
+						Class("action-btn primary").
+						Text("Confirm").
+						OnClick(h.onConfirmTOTP),
+				)
+			}).Else(func() app.UI {
+				return app.Div().Body(
+					app.Label().For("totpPassword").Text("Current Password"),
+					app.Input().Type("password").ID("totpPassword").
+						AutoComplete(false).
+						OnChange(h.ValueTo(&h.totpPassword)),
+					app.Button().
+						Class("action-btn secondary").
+						Text("Set Up Authenticator").
+						OnClick(h.onEnrollTOTP),
+				)
+			}),
 		)
 }
 
+func (h *MyAccount) loadTOTP(ctx app.Context) {
+	go func() {
+		body, err := clients.SendGetWithAuth("./auth/totp")
+		var status map[string]bool
+		if err.BError == nil && err.Code == 200 {
+			err.BError = json.Unmarshal(body, &status)
+		}
+		ctx.Dispatch(func(ctx app.Context) {
+			if err.BError != nil || err.Code != 200 {
+				h.totpMsg = "Unable to load your authenticator settings."
+				return
+			}
+			h.totpEnrolled = status["enrolled"]
+		})
+	}()
+}
+
+func (h *MyAccount) onEnrollTOTP(ctx app.Context, e app.Event) {
+	payload, _ := json.Marshal(auth.StepUpRequest{Password: h.totpPassword})
+	go func() {
+		body, err := clients.SendPostWithAuth("./auth/totp/enroll", string(payload))
+		var setup auth.TOTPSetup
+		if err.BError == nil && err.Code == 200 {
+			err.BError = json.Unmarshal(body, &setup)
+		}
+		ctx.Dispatch(func(ctx app.Context) {
+			switch {
+			case err.Code == http.StatusUnauthorized || err.Code == http.StatusForbidden:
+				h.totpMsg = "Enter your current password to set up an authenticator."
+				return
+			case err.BError != nil || err.Code != 200:
+				h.totpMsg = "Unable to set up an authenticator, please try again."
+				return
+			}
+			h.totpSetup = &setup
+			h.totpMsg = ""
+		})
+	}()
+}
+
+func (h *MyAccount) onConfirmTOTP(ctx app.Context, e app.Event) {
+	payload, _ := json.Marshal(auth.StepUpRequest{Code: strings.TrimSpace(h.totpCode), Password: h.totpPassword})
+	go func() {
+		body, err := clients.SendPostWithAuth("./auth/totp/confirm", string(payload))
+		var confirmed map[string][]string
+		if err.BError == nil && err.Code == 200 {
+			err.BError = json.Unmarshal(body, &confirmed)
+		}
+		ctx.Dispatch(func(ctx app.Context) {
+			if err.BError != nil || err.Code != 200 {
+				h.totpMsg = "That code was not right, please try the newest one."
+				return
+			}
+			h.totpEnrolled = true
+			h.totpSetup = nil
+			h.totpCode = ""
+			h.totpPassword = ""
+			h.recoveryCodes = confirmed["recoveryCodes"]
+			h.totpMsg = ""
+		})
+	}()
+}
+
+// onRemoveTOTP removes the authenticator, asking the user to confirm it is them first
+func (h *MyAccount) onRemoveTOTP(ctx app.Context, e app.Event) {
+	go func() {
+		_, err := clients.SendDeleteWithAuth("./auth/totp")
+		ctx.Dispatch(func(ctx app.Context) {
+			switch {
+			case err.Code == http.StatusForbidden:
+				ctx.Navigate("/stepup?next=/account")
+			case err.BError != nil || err.Code != http.StatusNoContent:
+				h.totpMsg = "Unable to remove the authenticator, please try again."
+			default:
+				h.totpEnrolled = false
+				h.recoveryCodes = nil
+				h.totpMsg = ""
+			}
+		})
+	}()
+}
+
+func (h *MyAccount) loadSessions(ctx app.Context) {
+	go func() {
+		body, err := clients.SendGetWithAuth("./auth/sessions")
+		var sessions []auth.RefreshSession
+		if err.BError == nil && err.Code == 200 {
+			err.BError = json.Unmarshal(body, &sessions)
+		}
+		ctx.Dispatch(func(ctx app.Context) {
+			if err.BError != nil || err.Code != 200 {
+				h.sessionMsg = "Unable to load your signed in devices."
+				return
+			}
+			h.sessions = sessions
+			h.sessionMsg = ""
+		})
+	}()
+}
+
+func (h *MyAccount) revokeSession(ctx app.Context, s auth.RefreshSession) {
+	if s.Current {
+		state.GetAppState(nil).Logout()
+		return
+	}
+	go func() {
+		_, err := clients.SendDeleteWithAuth("./auth/sessions/" + s.ID)
+		if err.BError != nil || err.Code != 204 {
+			ctx.Dispatch(func(ctx app.Context) {
+				h.sessionMsg = "Unable to sign that device out, please try again."
+			})
+			return
+		}
+		h.loadSessions(ctx)
+	}()
+}
+
+// onSignOutEverywhere revokes every session of the account, this one included, and logs out
+func (h *MyAccount) onSignOutEverywhere(ctx app.Context, e app.Event) {
+	go func() {
+		_, err := clients.SendDeleteWithAuth("./auth/sessions")
+		if err.BError != nil || err.Code != 200 {
+			ctx.Dispatch(func(ctx app.Context) {
+				h.sessionMsg = "Unable to sign out everywhere, please try again."
+			})
+			return
+		}
+		state.GetAppState(nil).Logout()
+	}()
+}
+
 func (h *MyAccount) onLogout(ctx app.Context, e app.Event) {
 
 	_state := state.GetAppState(nil)
@@ -94,10 +350,12 @@ func (h *MyAccount) onSaveProfileClick(_user account.User) {
 		appState := state.GetAppState(nil)
 		appState.UpdateUser(_user)
 		h.user = _user
+		h.needsStepUp = false
 		//h.statusMsg = "Profile Updated"
 	} else {
 		fmt.Println("Error Saving Profile", err)
 		h.statusMsg = err.FriendlyMsg()
+		h.needsStepUp = err.Code == http.StatusForbidden
 	}
 
 	h.disabledMode = true
@@ -20,6 +20,11 @@ type Bookings struct {
 	loading      bool
 	error        string
 	authResp     auth.AuthResponse
+	// standing tee times and the new standing time form
+	standing      []teetimes.StandingReservation
+	newStanding   teetimes.StandingReservation
+	standingStart string
+	standingError string
 }
 
 func (b *Bookings) OnMount(ctx app.Context) {
@@ -28,6 +33,7 @@ func (b *Bookings) OnMount(ctx app.Context) {
 		ctx.Dispatch(func(ctx app.Context) {
 			if b.authResp.AuthLevel > auth.NoAuthLevel {
 				b.loadReservations(ctx)
+				b.loadStanding(ctx)
 			}
 		})
 	})
@@ -35,6 +41,7 @@ func (b *Bookings) OnMount(ctx app.Context) {
 	// Load initial reservations if already authenticated
 	if b.authResp.AuthLevel > auth.NoAuthLevel {
 		b.loadReservations(ctx)
+		b.loadStanding(ctx)
 	}
 }
 
@@ -190,6 +197,8 @@ func (b *Bookings) Render() app.UI {
 			app.If(!b.loading && b.error == "", func() app.UI {
 				return b.renderReservations()
 			}),
+
+			b.renderStanding(),
 		)
 }
 
@@ -264,7 +273,11 @@ func (b *Bookings) renderReservationCard(reservation teetimes.Reservation, canCa
 				Body(
 					app.P().Text(fmt.Sprintf("Players: %d", len(reservation.Players)+1)),
 					app.P().Text(fmt.Sprintf("Price: $%.2f", reservation.Price)),
+					app.If(reservation.Total > 0, func() app.UI {
+						return app.P().Text(fmt.Sprintf("Total: $%.2f", reservation.Total))
+					}),
 					app.P().Text(fmt.Sprintf("Group: %s", reservation.Group)),
+					app.P().Text(fmt.Sprintf("%d holes off tee %d", reservation.RoundHoles(), reservation.StartingTee())),
 					app.If(len(reservation.Players) > 0, func() app.UI {
 						return app.Div().
 							Class("players-list").
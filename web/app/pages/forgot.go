@@ -0,0 +1,75 @@
+package pages
+
+import (
+	"bigfoot/golf/common/models/auth"
+	"bigfoot/golf/web/app/clients"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/maxence-charriere/go-app/v10/pkg/app"
+)
+
+// ForgotPage asks for the account's email and has the server email a link
+// to reset the password
+type ForgotPage struct {
+	app.Compo
+	email    string
+	sent     bool
+	errorMsg string
+}
+
+func (p *ForgotPage) Render() app.UI {
+	if p.sent {
+		return app.Div().
+			Body(
+				app.Div().Text("If an account uses that email, we have sent it a link to reset your password. The link works for 30 minutes."),
+				app.Div().
+					Class("quick-actions").
+					Body(
+						app.Button().Text("Back to Login").Class("action-btn secondary").
+							OnClick(func(ctx app.Context, e app.Event) {
+								ctx.Navigate("/login")
+							}),
+					),
+			)
+	}
+	return app.Div().
+		Body(
+			app.Div().Class("errMsg").Text(p.errorMsg),
+			app.Form().
+				OnSubmit(p.onSubmit).
+				Body(
+					app.Div().Text("Enter the email you sign in with and we will send you a link to choose a new password."),
+					app.Label().For("forgotEmail").Text("Email"),
+					app.Input().Type("email").ID("forgotEmail").AutoComplete(true).
+						Attr("inputmode", "email").
+						Required(true).
+						OnChange(p.ValueTo(&p.email)),
+					app.Div().
+						Class("quick-actions").
+						Body(
+							app.Button().Text("Email Me a Link").Class("action-btn primary").Type("submit"),
+						),
+				),
+		)
+}
+
+func (p *ForgotPage) onSubmit(ctx app.Context, e app.Event) {
+	e.PreventDefault()
+	body, _ := json.Marshal(auth.ForgotPasswordRequest{Email: strings.TrimSpace(p.email)})
+	go func() {
+		_, err := clients.SendPost("./auth/forgot", string(body))
+		ctx.Dispatch(func(ctx app.Context) {
+			switch {
+			case err.Code == http.StatusAccepted:
+				p.errorMsg = ""
+				p.sent = true
+			case err.Code == http.StatusTooManyRequests:
+				p.errorMsg = "Too many requests, please try again later."
+			default:
+				p.errorMsg = err.FriendlyMsg()
+			}
+		})
+	}()
+}
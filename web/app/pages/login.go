@@ -38,6 +38,10 @@ func (s *Login) Render() app.UI {
 						OnClick(func(ctx app.Context, e app.Event) {
 							ctx.Navigate("/register")
 						}),
+					app.Button().Text("Forgot Password").Class("action-btn secondary").
+						OnClick(func(ctx app.Context, e app.Event) {
+							ctx.Navigate("/forgot")
+						}),
 				),
 		)
 	return _obj
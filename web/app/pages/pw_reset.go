@@ -5,6 +5,8 @@ import (
 	"bigfoot/golf/web/app/clients"
 	"bigfoot/golf/web/app/components"
 	"encoding/json"
+	"net/http"
+	"strings"
 
 	"github.com/maxence-charriere/go-app/v10/pkg/app"
 )
@@ -67,7 +69,17 @@ func (p *PwResetPage) resetClick(ctx app.Context, opts app.Event) {
 
 	body, _ := json.Marshal(_mapData)
 
-	_, err := clients.SendPostWithAuth("./api/resetapw", string(body))
+	resp, err := clients.SendPostWithAuth("./api/resetapw", string(body))
+	if err.Code == http.StatusForbidden {
+		// Changing the password needs a step up first
+		ctx.Navigate("/stepup?next=/changepw")
+		return
+	}
+	if err.Code == http.StatusBadRequest {
+		// The password does not meet the policy; the server says why
+		p.errorMsg = strings.TrimSpace(string(resp))
+		return
+	}
 	if err.BError == nil {
 		//success update the state
 		ctx.Navigate("/")
@@ -0,0 +1,98 @@
+package pages
+
+import (
+	"bigfoot/golf/common/models/auth"
+	"bigfoot/golf/web/app/clients"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/maxence-charriere/go-app/v10/pkg/app"
+)
+
+// ResetPage sets a new password with the token from an emailed reset link
+type ResetPage struct {
+	app.Compo
+	token      string
+	newPW      string
+	validatePW string
+	done       bool
+	errorMsg   string
+}
+
+func (p *ResetPage) OnNav(ctx app.Context) {
+	p.token = ctx.Page().URL().Query().Get("token")
+	if p.token == "" {
+		p.errorMsg = "This reset link is incomplete, please request a new one."
+	}
+}
+
+func (p *ResetPage) Render() app.UI {
+	if p.done {
+		return app.Div().
+			Body(
+				app.Div().Text("Your password has been changed and you have been signed out everywhere. Please log in with the new password."),
+				app.Div().
+					Class("quick-actions").
+					Body(
+						app.Button().Text("Login").Class("action-btn primary").
+							OnClick(func(ctx app.Context, e app.Event) {
+								ctx.Navigate("/login")
+							}),
+					),
+			)
+	}
+	return app.Div().
+		Body(
+			app.Div().Class("errMsg").Text(p.errorMsg),
+			app.Form().
+				OnSubmit(p.onSubmit).
+				Body(
+					app.Div().Text("Choose a new password of at least 10 characters, mixing letters with numbers or symbols."),
+					app.Label().For("resetPassword").Text("New Password"),
+					app.Input().Type("password").ID("resetPassword").
+						AutoComplete(false).
+						Required(true).
+						OnChange(p.ValueTo(&p.newPW)),
+					app.Label().For("resetPassword2").Text("Repeat Password"),
+					app.Input().Type("password").ID("resetPassword2").
+						AutoComplete(false).
+						Required(true).
+						OnChange(p.ValueTo(&p.validatePW)),
+					app.Div().
+						Class("quick-actions").
+						Body(
+							app.Button().Text("Set Password").Class("action-btn primary").Type("submit"),
+							app.Button().Text("Request a New Link").Class("action-btn secondary").
+								OnClick(func(ctx app.Context, e app.Event) {
+									e.PreventDefault()
+									ctx.Navigate("/forgot")
+								}),
+						),
+				),
+		)
+}
+
+func (p *ResetPage) onSubmit(ctx app.Context, e app.Event) {
+	e.PreventDefault()
+	if p.newPW != p.validatePW {
+		p.errorMsg = "The passwords do not match"
+		return
+	}
+	body, _ := json.Marshal(auth.ResetPasswordRequest{Token: p.token, Password: p.newPW})
+	go func() {
+		resp, err := clients.SendPost("./auth/reset", string(body))
+		ctx.Dispatch(func(ctx app.Context) {
+			switch {
+			case err.Code == http.StatusNoContent:
+				p.newPW, p.validatePW, p.errorMsg = "", "", ""
+				p.done = true
+			case err.Code == http.StatusBadRequest, err.Code == http.StatusTooManyRequests:
+				// The server says what is wrong with the link or the password
+				p.errorMsg = strings.TrimSpace(string(resp))
+			default:
+				p.errorMsg = err.FriendlyMsg()
+			}
+		})
+	}()
+}
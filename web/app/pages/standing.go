@@ -0,0 +1,248 @@
+package pages
+
+import (
+	"bigfoot/golf/common/models/teetimes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/maxence-charriere/go-app/v10/pkg/app"
+)
+
+// standingRequest sends an authenticated request for the user's standing tee times
+func (b *Bookings) standingRequest(method, url string, payload any) ([]byte, error) {
+	var body io.Reader
+	if payload != nil {
+		jsonPayload, err := json.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+		body = strings.NewReader(string(jsonPayload))
+	}
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+b.authResp.Token)
+	req.Header.Set("X-User-ID", b.authResp.User.ID)
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	out, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, fmt.Errorf("%s", strings.TrimSpace(string(out)))
+	}
+	return out, nil
+}
+
+func (b *Bookings) loadStanding(ctx app.Context) {
+	go func() {
+		out, err := b.standingRequest("GET", "/api/standing", nil)
+		var standing []teetimes.StandingReservation
+		if err == nil {
+			err = json.Unmarshal(out, &standing)
+		}
+		ctx.Dispatch(func(ctx app.Context) {
+			if err != nil {
+				b.standingError = "Failed to load standing tee times"
+				return
+			}
+			b.standing = standing
+			b.standingError = ""
+		})
+	}()
+}
+
+// changeStanding sends a change to a standing tee time and reloads the list and reservations
+func (b *Bookings) changeStanding(ctx app.Context, method, url string, payload any) {
+	go func() {
+		_, err := b.standingRequest(method, url, payload)
+		ctx.Dispatch(func(ctx app.Context) {
+			if err != nil {
+				b.standingError = err.Error()
+				return
+			}
+			b.standingError = ""
+			b.loadStanding(ctx)
+			b.loadReservations(ctx)
+		})
+	}()
+}
+
+func (b *Bookings) createStanding(ctx app.Context, e app.Event) {
+	start, err := time.ParseInLocation(time.DateOnly, b.standingStart, time.Local)
+	if err != nil {
+		b.standingError = "Pick the first date for the standing tee time"
+		return
+	}
+	standing := b.newStanding
+	standing.StartDate = start
+	if standing.Players == 0 {
+		standing.Players = 4
+	}
+	if standing.Time == "" {
+		b.standingError = "Pick a tee time"
+		return
+	}
+	standing.Weekday = start.Weekday()
+	b.changeStanding(ctx, "POST", "/api/standing", standing)
+	b.newStanding = teetimes.StandingReservation{}
+	b.standingStart = ""
+}
+
+// nextStandingDate is the next date the standing tee time will be booked for
+func nextStandingDate(standing teetimes.StandingReservation) (time.Time, bool) {
+	now := time.Now()
+	dates := standing.Dates(now, now.AddDate(0, 0, 14))
+	if len(dates) == 0 {
+		return time.Time{}, false
+	}
+	return dates[0], true
+}
+
+func (b *Bookings) renderStanding() app.UI {
+	return app.Div().
+		Class("reservation-section standing-section").
+		Body(
+			app.H2().Text("Standing Tee Times"),
+			app.If(b.standingError != "", func() app.UI {
+				return app.Div().Class("error").Text(b.standingError)
+			}),
+			app.If(len(b.standing) == 0, func() app.UI {
+				return app.P().Text("Book the same tee time every week with a standing tee time.")
+			}),
+			app.Range(b.standing).Slice(func(i int) app.UI {
+				return b.renderStandingCard(b.standing[i])
+			}),
+			b.renderStandingForm(),
+		)
+}
+
+func (b *Bookings) renderStandingCard(standing teetimes.StandingReservation) app.UI {
+	clock, _ := time.Parse("15:04", standing.Time)
+	next, hasNext := nextStandingDate(standing)
+	return app.Div().
+		Class("booking-card").
+		Body(
+			app.Div().
+				Class("booking-header").
+				Body(
+					app.H3().Text(fmt.Sprintf("Every %s", standing.Weekday)),
+					app.P().Class("tee-time").Text(clock.Format("3:04 PM")),
+				),
+			app.Div().
+				Class("booking-details").
+				Body(
+					app.P().Text(fmt.Sprintf("Players: %d", standing.Players)),
+					app.If(standing.Holes == teetimes.NineHoles, func() app.UI {
+						return app.P().Text("9 holes")
+					}),
+					app.P().Text(fmt.Sprintf("Since %s", standing.StartDate.Format("January 2, 2006"))),
+					app.If(!standing.EndDate.IsZero(), func() app.UI {
+						return app.P().Text(fmt.Sprintf("Until %s", standing.EndDate.Format("January 2, 2006")))
+					}),
+					app.Range(standing.Upcoming).Slice(func(i int) app.UI {
+						occurrence := standing.Upcoming[i]
+						text := fmt.Sprintf("%s: %s", occurrence.TeeTime.Format("Mon Jan 2"), occurrence.Status)
+						if occurrence.Reason != "" {
+							text += " - " + occurrence.Reason
+						}
+						return app.P().Class("standing-" + occurrence.Status).Text(text)
+					}),
+				),
+			app.Div().
+				Class("booking-actions").
+				Body(
+					app.If(hasNext, func() app.UI {
+						return app.Button().
+							Class("btn secondary").
+							Text(fmt.Sprintf("Skip %s", next.Format("Jan 2"))).
+							OnClick(func(ctx app.Context, e app.Event) {
+								b.changeStanding(ctx, "POST", "/api/standing/"+standing.ID+"/skip",
+									map[string]string{"date": next.Format(time.DateOnly)})
+							})
+					}),
+					app.Button().
+						Class("btn danger").
+						Text("Remove").
+						OnClick(func(ctx app.Context, e app.Event) {
+							if app.Window().Call("confirm", "Stop this standing tee time? Tee times already booked are kept.").Bool() {
+								b.changeStanding(ctx, "DELETE", "/api/standing/"+standing.ID, nil)
+							}
+						}),
+				),
+		)
+}
+
+func (b *Bookings) renderStandingForm() app.UI {
+	return app.Div().
+		Class("search-form standing-form").
+		Body(
+			app.Div().
+				Class("form-group").
+				Body(
+					app.Label().Text("First Date"),
+					app.Input().
+						Type("date").
+						Class("form-input").
+						Value(b.standingStart).
+						OnChange(b.ValueTo(&b.standingStart)),
+				),
+			app.Div().
+				Class("form-group").
+				Body(
+					app.Label().Text("Tee Time"),
+					app.Input().
+						Type("time").
+						Class("form-input").
+						Value(b.newStanding.Time).
+						OnChange(b.ValueTo(&b.newStanding.Time)),
+				),
+			app.Div().
+				Class("form-group").
+				Body(
+					app.Label().Text("Players"),
+					app.Select().
+						Class("form-select").
+						Body(
+							app.Option().Value("4").Text("4 Players"),
+							app.Option().Value("3").Text("3 Players"),
+							app.Option().Value("2").Text("2 Players"),
+							app.Option().Value("1").Text("1 Player"),
+						).
+						OnChange(func(ctx app.Context, e app.Event) {
+							b.newStanding.Players, _ = strconv.ParseInt(ctx.JSSrc().Get("value").String(), 10, 64)
+						}),
+				),
+			app.Div().
+				Class("form-group").
+				Body(
+					app.Label().Text("Holes"),
+					app.Select().
+						Class("form-select").
+						Body(
+							app.Option().Value("18").Text("18 Holes"),
+							app.Option().Value("9").Text("9 Holes"),
+						).
+						OnChange(func(ctx app.Context, e app.Event) {
+							b.newStanding.Holes, _ = strconv.Atoi(ctx.JSSrc().Get("value").String())
+						}),
+				),
+			app.Button().
+				Class("btn primary").
+				Text("Add Standing Tee Time").
+				OnClick(b.createStanding),
+		)
+}
@@ -0,0 +1,67 @@
+package pages
+
+import (
+	"bigfoot/golf/common/models/auth"
+	"bigfoot/golf/web/app/state"
+	"strings"
+
+	"github.com/maxence-charriere/go-app/v10/pkg/app"
+)
+
+// StepUpPage asks the user to prove who they are again before a sensitive
+// change, then returns to the page in the next query parameter
+type StepUpPage struct {
+	app.Compo
+	request  auth.StepUpRequest
+	next     string
+	errorMsg string
+}
+
+func (p *StepUpPage) OnNav(ctx app.Context) {
+	p.next = ctx.Page().URL().Query().Get("next")
+	// Only return to a page of this app
+	if !strings.HasPrefix(p.next, "/") || strings.HasPrefix(p.next, "//") {
+		p.next = "/account"
+	}
+}
+
+func (p *StepUpPage) Render() app.UI {
+	return app.Div().
+		Body(
+			app.Div().Class("errMsg").Text(p.errorMsg),
+			app.Form().
+				OnSubmit(p.onSubmit).
+				Body(
+					app.Div().Text("This change needs you to confirm it is you. Enter the code from your authenticator app or a recovery code, or your password if you have not set up an authenticator."),
+					app.Label().For("stepUpCode").Text("Authenticator or Recovery Code"),
+					app.Input().Type("text").ID("stepUpCode").
+						AutoComplete(false).
+						Attr("inputmode", "numeric").
+						OnChange(p.ValueTo(&p.request.Code)),
+					app.Label().For("stepUpPassword").Text("Password"),
+					app.Input().Type("password").ID("stepUpPassword").
+						OnChange(p.ValueTo(&p.request.Password)),
+					app.Div().
+						Class("quick-actions").
+						Body(
+							app.Button().Text("Confirm").Class("action-btn primary").Type("submit"),
+						),
+				),
+		)
+}
+
+func (p *StepUpPage) onSubmit(ctx app.Context, e app.Event) {
+	e.PreventDefault()
+	req := auth.StepUpRequest{Code: strings.TrimSpace(p.request.Code), Password: p.request.Password}
+	go func() {
+		err := state.GetAppState(nil).StepUp(req)
+		ctx.Dispatch(func(ctx app.Context) {
+			if err != nil {
+				p.errorMsg = err.Error()
+				return
+			}
+			p.request = auth.StepUpRequest{}
+			ctx.Navigate(p.next)
+		})
+	}()
+}
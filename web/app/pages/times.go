@@ -13,6 +13,7 @@ import (
 	"time"
 	_ "time/tzdata"
 
+	"github.com/google/uuid"
 	"github.com/maxence-charriere/go-app/v10/pkg/app"
 )
 
@@ -23,7 +24,9 @@ type AvailTimes struct {
 	errorMsg       string
 	timeSlots      []teetimes.ReservedDay
 	reservSelected *teetimes.Reservation
+	hold           *teetimes.SlotHold
 	players        int
+	holes          int
 	showPopup      bool
 	authResp       *auth.AuthResponse
 }
@@ -34,6 +37,7 @@ func (p *AvailTimes) OnMount(ctx app.Context) {
 	//call the web service
 	p.getTeeTimes()
 	p.players = 4
+	p.holes = teetimes.EighteenHoles
 
 }
 
@@ -72,6 +76,9 @@ func (s *AvailTimes) Render() app.UI {
 	})
 	fmt.Println("days and slots ", len(s.timeSlots), " - ", len(s.timeSlots[0].Times))
 	_obj := app.Div().Body(
+		app.If(s.errorMsg != "", func() app.UI {
+			return app.Div().Class("errMsg").Text(s.errorMsg)
+		}),
 		app.Div().Class("fixedTeeHeader").Body(
 			app.If(s.selectedDate.After(time.Now().Truncate(24*time.Hour).Local().Add(time.Hour*24)), func() app.UI {
 				return app.Div().Class("fixedTeeBtn").Text("⬅️").OnClick(s.onDateBack)
@@ -84,16 +91,19 @@ func (s *AvailTimes) Render() app.UI {
 			Body(
 				app.Range(s.timeSlots[x].Times).Slice(func(i int) app.UI {
 					slot := s.timeSlots[x].Times[i]
-					_p := len(slot.Players)
-
-					if _p < 4 {
-						_open := strconv.Itoa(4-_p) + "👤"
+					if slot.ID == "" && slot.Open > 0 && !slot.Held {
+						_open := strconv.Itoa(int(slot.Open)) + "👤"
 						return app.Div().
 							Class("time-slot").
 							Body(
 								app.Div().
 									Class("slot-time").
 									Text(slot.TeeTime.Format("3:04 PM")),
+								app.If(len(s.timeSlots[x].Tees) > 1, func() app.UI {
+									return app.Div().
+										Class("slot-tee").
+										Text(fmt.Sprintf("Tee %d", slot.StartingTee()))
+								}),
 								app.Div().
 									Class("slot-spots").
 									Text(_open),
@@ -119,14 +129,42 @@ func (p *AvailTimes) onSelectReservation(time teetimes.Reservation, ctx app.Cont
 	//get logged in User
 	appState := state.GetAppState(nil)
 	p.authResp = appState.TokenManager().GetAuth()
-	if p.authResp == nil && p.authResp.AuthLevel < auth.LoginLevel {
+	if p.authResp == nil || p.authResp.AuthLevel < auth.LoginLevel {
 		ctx.Navigate("./login")
+		return
+	}
+
+	//hold the slot so no one else can book it while guests are added
+	_hold, _ := json.Marshal(teetimes.SlotHold{TeeTime: time.TeeTime, Slot: time.Slot, Players: time.Open})
+	resp, erb := clients.SendPostWithAuth("./api/holds", string(_hold))
+	var hold teetimes.SlotHold
+	if erb.Code >= 400 || erb.BError != nil || json.Unmarshal(resp, &hold) != nil {
+		fmt.Println("Error Holding Time: ", erb.Code, erb.BError)
+		ctx.Dispatch(func(ctx app.Context) {
+			p.errorMsg = "That tee time is no longer available, please pick another time."
+			p.getTeeTimes()
+		})
+		return
 	}
 	ctx.Dispatch(func(ctx app.Context) {
+		p.errorMsg = ""
+		p.hold = &hold
 		p.reservSelected = &time
 		p.showPopup = true
 	})
 }
+
+// releaseHold gives the selected slot back when the golfer backs out of booking
+func (p *AvailTimes) releaseHold() {
+	if p.hold == nil {
+		return
+	}
+	_, erb := clients.SendDeleteWithAuth("./api/holds/" + p.hold.ID)
+	if erb.BError != nil {
+		fmt.Println("Error Releasing Hold: ", erb.Code, erb.BError)
+	}
+	p.hold = nil
+}
 func (p *AvailTimes) onBookSlot(ctx app.Context, opts app.Event) {
 
 	time := p.reservSelected
@@ -134,10 +172,15 @@ func (p *AvailTimes) onBookSlot(ctx app.Context, opts app.Event) {
 	//add the user to the reservation
 	if p.authResp != nil && p.authResp.AuthLevel >= auth.LoginLevel {
 		time.BookingUser = &p.authResp.User
+		time.Holes = p.holes
 		time.Players = append(time.Players, *time.BookingUser)
 		for i := 1; i < p.players; i++ {
 			time.Players = append(time.Players, account.User{LastName: fmt.Sprintf("Guest %d", i)})
 		}
+		//keep the key across retries, the cached newRes carries it too
+		if time.IdempotencyKey == "" {
+			time.IdempotencyKey = uuid.NewString()
+		}
 		//book the time
 		_slot, _ := json.Marshal(time)
 		resp, erb := clients.SendPostWithAuth("./api/bookTime", string(_slot))
@@ -160,6 +203,7 @@ func (p *AvailTimes) onBookSlot(ctx app.Context, opts app.Event) {
 				p.reservSelected = nil
 				return
 			}
+			p.hold = nil
 			ctx.SetState("bookRes", time)
 			ctx.Navigate("/bookings")
 		}
@@ -200,21 +244,35 @@ func (s *AvailTimes) renderPopup() app.UI {
 				app.Span().Text("Tee Time"),
 				app.Span().Text(s.reservSelected.TeeTime.Format("3:04 PM")),
 			),
+			app.If(len(s.timeSlots) > 0 && len(s.timeSlots[0].Tees) > 1, func() app.UI {
+				return app.Div().Body(
+					app.Span().Text("Starting Tee"),
+					app.Span().Text(strconv.Itoa(s.reservSelected.StartingTee())),
+				)
+			}),
 			s.renderButtons(),
+			s.renderHoles(),
 			app.Div().Body(
 				app.Span().Text("Price per Person"),
-				app.Span().Text(fmt.Sprintf("$%.2f", s.reservSelected.Price)),
+				app.Span().Text(fmt.Sprintf("$%.2f", s.roundPrice())),
 			),
+			app.If(s.hold != nil, func() app.UI {
+				return app.Div().Body(
+					app.Span().Text("Held Until"),
+					app.Span().Text(s.hold.ExpiresAt.Local().Format("3:04 PM")),
+				)
+			}),
 		),
 		app.Div().Class("total-rows").Body(
 			app.Div().Body(
 				app.Span().Text("Grand Total"),
-				app.Span().Text(fmt.Sprintf("$%.2f", s.reservSelected.Price*float32(s.players))),
+				app.Span().Text(fmt.Sprintf("$%.2f", s.roundPrice()*float32(s.players))),
 			),
 			app.Div().Body(
 				app.Button().Text("Book").OnClick(s.onBookSlot),
 				app.Button().Text("Cancel").OnClick(func(ctx app.Context, e app.Event) {
 					ctx.Dispatch(func(ctx app.Context) {
+						s.releaseHold()
 						s.reservSelected = nil
 						s.showPopup = false
 					})
@@ -239,3 +297,27 @@ func (s *AvailTimes) renderButtons() app.UI {
 	)
 	return divOut
 }
+
+// renderHoles lets the golfer pick a nine or eighteen hole round
+func (s *AvailTimes) renderHoles() app.UI {
+	choices := []int{teetimes.NineHoles, teetimes.EighteenHoles}
+	return app.Div().Class("fixedTeeHeader").Body(
+		app.Div().Text("Holes"),
+		app.Range(choices).Slice(func(i int) app.UI {
+			holes := choices[i]
+			clsStr := []string{"fixedTeeBtn"}
+			if holes == s.holes {
+				clsStr = append(clsStr, "emoji-selected")
+			}
+			return app.Div().Class(clsStr...).Text(strconv.Itoa(holes)).OnClick(func(ctx app.Context, e app.Event) { ctx.Dispatch(func(ctx app.Context) { s.holes = holes }) })
+		}),
+	)
+}
+
+// roundPrice is the per-person price of the selected tee time for the chosen round length
+func (s *AvailTimes) roundPrice() float32 {
+	if s.holes == teetimes.NineHoles && s.reservSelected.NinePrice > 0 {
+		return s.reservSelected.NinePrice
+	}
+	return s.reservSelected.Price
+}
@@ -75,7 +75,9 @@ func (h *VerifyUI) verifyClick(ctx app.Context, opts app.Event) {
 		h.errorMsg = "Please enter a six digit code"
 		return
 	}
+	h.User.TempStr = strings.TrimSpace(_codeElm)
 	body, _ := json.Marshal(h.User)
+	h.User.TempStr = ""
 	_, err := clients.SendPostWithAuth("./api/verifyemailcode", string(body))
 	if err.BError != nil || err.Code > 200 {
 		h.errorMsg = "Incorrect Code, please try again."
@@ -24,6 +24,8 @@ func RegisterRoutes() {
 	app.Route("/chat", func() app.Composer { return &components.Layout{Page: &pages.ChatAgent{}} })
 	app.Route("/login", func() app.Composer { return &components.Layout{Page: &pages.Login{}} })
 	app.Route("/register", func() app.Composer { return &components.Layout{Page: &pages.Register{}} })
+	app.Route("/forgot", func() app.Composer { return &components.Layout{Page: &pages.ForgotPage{}} })
+	app.Route("/reset", func() app.Composer { return &components.Layout{Page: &pages.ResetPage{}} })
 	app.Route("/teetimes", func() app.Composer { return &components.Layout{Page: &pages.AvailTimes{}} })
 
 	// Authenticated routes
@@ -31,9 +33,10 @@ func RegisterRoutes() {
 	app.Route("/agent", func() app.Composer { return &components.Layout{Page: &pages.Agent{}, PageLevel: auth.LoginLevel} })
 	app.Route("/verify", func() app.Composer { return &components.Layout{Page: &pages.VerifyUI{}, PageLevel: auth.LoginLevel} })
 	app.Route("/changepw", func() app.Composer { return &components.Layout{Page: &pages.PwResetPage{}, PageLevel: auth.LoginLevel} })
+	app.Route("/stepup", func() app.Composer { return &components.Layout{Page: &pages.StepUpPage{}, PageLevel: auth.LoginLevel} })
 
 	// Admin routes
-	app.Route("/admin", func() app.Composer { return &components.Layout{Page: &admin.Administer{}, PageLevel: auth.LoginLevel} })
+	app.Route("/admin", func() app.Composer { return &components.Layout{Page: &admin.Administer{}, PageLevel: auth.AdminLevel} })
 	//RegisterProtectedRoutes()
 
 }
@@ -185,6 +185,50 @@ func (as *AppState) Login(authReq auth.LoginRequest) error {
 	return nil
 }
 
+// StepUp trades a second factor for a short-lived elevated token. The
+// session's refresh token is kept, and the next refresh ends the elevation.
+func (as *AppState) StepUp(req auth.StepUpRequest) error {
+	current := as.tokenManager.GetAuth()
+	if current == nil || current.Token == "" {
+		return fmt.Errorf("please log in again")
+	}
+	reqBody, _ := json.Marshal(req)
+	httpReq, err := http.NewRequest("POST", "./auth/stepup", bytes.NewBuffer(reqBody))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+current.Token)
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusTooManyRequests:
+		return fmt.Errorf("too many attempts, please wait a few minutes and try again")
+	case http.StatusForbidden:
+		return fmt.Errorf("please set up an authenticator app on your account first")
+	default:
+		return fmt.Errorf("that code or password was not right")
+	}
+
+	var authResp auth.AuthResponse
+	if err := json.NewDecoder(resp.Body).Decode(&authResp); err != nil {
+		return err
+	}
+	authResp.RefreshToken = current.RefreshToken
+	as.tokenManager.SetTokens(authResp)
+
+	as.notifySubscribers(StateEvent{
+		Type: "token_refreshed",
+		Data: authResp,
+	})
+	return nil
+}
+
 func (as *AppState) RegisterUser(_user account.User) models.BError {
 	url := "./auth/register"
 	_err := models.BError{Request: url}
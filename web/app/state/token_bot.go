@@ -17,7 +17,10 @@ const RefreshInterval int64 = 5
 
 // Token storage
 type TokenBot struct {
-	mu              sync.RWMutex
+	mu sync.RWMutex
+	// refreshMu lets one refresh run at a time: a refresh token is spent on
+	// use, and the server signs the session out when it sees one twice
+	refreshMu       sync.Mutex
 	authBody        *auth.AuthResponse
 	refreshInterval time.Duration
 	ctx             context.Context
@@ -90,6 +93,8 @@ func (tm *TokenBot) ForceRefresh() string {
 	return tm.checkAndRefreshToken(true)
 }
 func (tm *TokenBot) checkAndRefreshToken(force bool) string {
+	tm.refreshMu.Lock()
+	defer tm.refreshMu.Unlock()
 
 	tm.mu.RLock()
 	_authBody := tm.authBody
@@ -187,14 +192,29 @@ func (tm *TokenBot) GetAuth() *auth.AuthResponse {
 	return tm.authBody
 }
 
+// ClearTokens forgets the tokens and signs the session out on the server
 func (tm *TokenBot) ClearTokens() {
 	tm.mu.Lock()
 	defer tm.mu.Unlock()
 
+	if tm.authBody != nil && tm.authBody.RefreshToken != "" {
+		go logoutSession(tm.authBody.RefreshToken)
+	}
 	tm.authBody = nil
 
 }
 
+// logoutSession revokes the refresh token's session so it cannot be used again
+func logoutSession(refreshToken string) {
+	reqBody, _ := json.Marshal(map[string]string{"refresh_token": refreshToken})
+	resp, err := http.Post("/auth/logout", "application/json", bytes.NewBuffer(reqBody))
+	if err != nil {
+		fmt.Println("Error logging out", err)
+		return
+	}
+	resp.Body.Close()
+}
+
 func (tm *TokenBot) EventChannel() <-chan TokenEvent {
 	return tm.eventChan
 }
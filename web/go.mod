@@ -3,11 +3,11 @@ module bigfoot/golf/web
 go 1.23.4
 
 require (
+	github.com/google/uuid v1.6.0
 	github.com/gorilla/mux v1.8.1
 	github.com/maxence-charriere/go-app/v10 v10.1.5
 )
 
 require (
-	github.com/google/uuid v1.6.0 // indirect
 	github.com/stretchr/testify v1.9.0 // indirect
 )
@@ -6,8 +6,12 @@ package main
 import (
 	"bigfoot/golf/common/controllers"
 	"bigfoot/golf/common/handlers"
+	"bigfoot/golf/common/handlers/health"
 	"bigfoot/golf/common/handlers/sessionmgr"
+	"bigfoot/golf/common/models/anthropic"
 	"bigfoot/golf/common/models/db"
+	"bigfoot/golf/common/models/storage"
+	"bigfoot/golf/common/models/teetimes"
 	"bigfoot/golf/web/app/routes"
 	"context"
 	"fmt"
@@ -60,12 +64,32 @@ func main() {
 	db.TimeLocation = loc
 	fmt.Println("Application timezone set to:", time.Local.String())
 
-	//Initialize the Database
+	//Initialize the Database, retrying in the background until it answers
 	ctx := context.Background()
-	db.InitDB(ctx)
-	if db.Instance.Err != nil {
-		fmt.Println("The Database Failed To Intialize, display friendly message...", db.Instance.Err)
-	}
+	dbMonitor := storage.Start(ctx)
+	health.AddDependency("mcp", anthropic.CheckMCPServer)
+
+	// Answer the health checks right away and the app once the database is up,
+	// since registering the routes loads the auth config from it
+	server := health.NewServer()
+	go func() {
+		<-dbMonitor.Ready()
+		server.SetApp(newRouter(ctx, wasmHandler, storage.Course()))
+	}()
+
+	// Start server
+	port := ":8000"
+	fmt.Printf("Server starting on port %s\n", port)
+
+	log.Fatal(http.ListenAndServe(port, server))
+}
+
+// newRouter starts the course's background jobs and registers the app's routes
+func newRouter(ctx context.Context, wasmHandler *app.Handler, course *teetimes.Course) http.Handler {
+	// Release slot holds abandoned during checkout
+	course.StartHoldSweeper(ctx, time.Minute)
+	// Book standing tee times as they come inside their lead window
+	course.StartStandingBooker(ctx, time.Hour)
 	// Create a new router
 	r := mux.NewRouter()
 
@@ -73,16 +97,20 @@ func main() {
 	r.Use(loggingMiddleware)
 	// Create API subrouter
 	api := r.PathPrefix("/api").Subrouter()
-	handlers.RegisterAPIRoutes(api)
+	api.Use(health.RequireDB)
+	handlers.RegisterAPIRoutes(ctx, api, course)
 	// Create Public subrouter
 	papi := r.PathPrefix("/papi").Subrouter()
-	handlers.RegisterPublicRoutes(papi)
+	papi.Use(health.RequireDB)
+	handlers.RegisterPublicRoutes(papi, course)
 	// Create API subrouter
 	authRouter := r.PathPrefix("/auth").Subrouter()
-	handlers.RegisterAuthRouter(authRouter)
+	authRouter.Use(health.RequireDB)
+	handlers.RegisterAuthRouter(ctx, authRouter)
 	//Create Admin subrouter
 	adminRouter := r.PathPrefix("/admin").Subrouter()
-	handlers.RegisterAdminRoutes(adminRouter)
+	adminRouter.Use(health.RequireDB)
+	handlers.RegisterAdminRoutes(ctx, adminRouter, course)
 
 	//initialize session Manager
 	sessionmgr.NewSessionMgr()
@@ -94,13 +122,9 @@ func main() {
 	r.PathPrefix("/").Handler(wasmHandler)
 
 	if os.Getenv("MODE") == "dev" {
-		controllers.SetupDevEnvironment()
+		controllers.SetupDevEnvironment(ctx)
 	}
-	// Start server
-	port := ":8000"
-	fmt.Printf("Server starting on port %s\n", port)
-
-	log.Fatal(http.ListenAndServe(port, r))
+	return r
 }
 
 // Middleware for logging requests